@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -9,8 +10,10 @@ import (
 	"gorm.io/gorm/logger"
 	
 	"github.com/openwan/media-asset-management/internal/api"
+	"github.com/openwan/media-asset-management/internal/cache"
 	"github.com/openwan/media-asset-management/internal/config"
 	"github.com/openwan/media-asset-management/internal/database"
+	"github.com/openwan/media-asset-management/internal/fetch"
 	"github.com/openwan/media-asset-management/internal/queue"
 	"github.com/openwan/media-asset-management/internal/repository"
 	"github.com/openwan/media-asset-management/internal/service"
@@ -112,6 +115,14 @@ func main() {
 		fmt.Println("✓ Redis session store connected")
 	}
 
+	// Shared Redis cache for configuration lookups; services fall back to the database without it
+	var cacheService cache.CacheService
+	if redisCache, err := cache.NewRedisCache(redisAddr, "", 0); err != nil {
+		log.Printf("Warning: Failed to connect to Redis cache: %v", err)
+	} else {
+		cacheService = redisCache
+	}
+
 	// Initialize storage service
 	fmt.Println("Initializing storage service...")
 	// Load storage config from YAML config file or environment variables
@@ -127,6 +138,31 @@ func main() {
 			S3SecretKey:  os.Getenv("AWS_SECRET_ACCESS_KEY"), // From env for security
 			S3Prefix:     cfg.Storage.S3Prefix,
 			S3UseIAMRole: os.Getenv("S3_USE_IAM_ROLE") == "true",
+			S3Endpoint:             cfg.Storage.S3Endpoint,
+			S3UsePathStyle:         cfg.Storage.S3UsePathStyle,
+			S3InsecureSkipVerify:   cfg.Storage.S3InsecureSkipVerify,
+			S3CACertFile:           cfg.Storage.S3CACertFile,
+			S3ChecksumCalculation:  cfg.Storage.S3ChecksumCalculation,
+			S3ChecksumValidation:   cfg.Storage.S3ChecksumValidation,
+			S3ChecksumAlgorithm:    cfg.Storage.S3ChecksumAlgorithm,
+			S3ServerSideEncryption: cfg.Storage.S3ServerSideEncryption,
+			ReplicationMode:        cfg.Storage.ReplicationMode,
+			Layout:                 cfg.Storage.Layout,
+		}
+		for _, replica := range cfg.Storage.Replicas {
+			storageConfig.Replicas = append(storageConfig.Replicas, storage.ReplicaConfig{
+				Name: replica.Name,
+				Config: storage.Config{
+					Type:           replica.Type,
+					LocalPath:      replica.LocalPath,
+					S3Bucket:       replica.S3Bucket,
+					S3Region:       replica.S3Region,
+					S3Prefix:       replica.S3Prefix,
+					S3UseIAMRole:   os.Getenv("S3_USE_IAM_ROLE") == "true",
+					S3Endpoint:     replica.S3Endpoint,
+					S3UsePathStyle: replica.S3UsePathStyle,
+				},
+			})
 		}
 	} else {
 		// Fallback to environment variables
@@ -137,6 +173,9 @@ func main() {
 	if envType := os.Getenv("STORAGE_TYPE"); envType != "" {
 		storageConfig.Type = envType
 	}
+	if envLayout := os.Getenv("STORAGE_LAYOUT"); envLayout != "" {
+		storageConfig.Layout = envLayout
+	}
 	if envBucket := os.Getenv("S3_BUCKET"); envBucket != "" {
 		storageConfig.S3Bucket = envBucket
 	}
@@ -154,6 +193,9 @@ func main() {
 	} else {
 		fmt.Printf("  Local Path: %s\n", storageConfig.LocalPath)
 	}
+	for _, replica := range storageConfig.Replicas {
+		fmt.Printf("  Replica: %s (%s, %s)\n", replica.Name, replica.Config.Type, storageConfig.ReplicationMode)
+	}
 
 	// Initialize repositories
 	fmt.Println("Initializing repositories...")
@@ -177,6 +219,25 @@ func main() {
 	roleService := service.NewRoleService(mainRepo)
 	permissionService := service.NewPermissionService(mainRepo)
 	levelsService := service.NewLevelsService(levelsRepo)
+	quotaService := service.NewQuotaService(mainRepo)
+	sidecarService := service.NewSidecarService(mainRepo)
+	fileTypeRuleService := service.NewFileTypeRuleService(mainRepo, cacheService)
+	if err := fileTypeRuleService.EnsureDefaults(context.Background()); err != nil {
+		log.Printf("Warning: Failed to seed file type rules: %v", err)
+	}
+	workflowService := service.NewWorkflowService(mainRepo)
+	if err := workflowService.EnsureDefaults(context.Background()); err != nil {
+		log.Printf("Warning: Failed to seed default workflow: %v", err)
+	}
+	categoryService.SetWorkflowService(workflowService)
+	scanService := service.NewScanService(mainRepo, storageService, service.DefaultMalwareScanner())
+	if scanService.Enabled() {
+		fmt.Println("✓ Malware scanning enabled")
+	} else {
+		log.Println("Warning: CLAMD_ADDRESS not set, uploads are not scanned for malware")
+	}
+	duplicateService := service.NewDuplicateService(mainRepo, fileService, storageService)
+	fingerprintService := service.NewFingerprintService(mainRepo, storageService, nil)
 	fmt.Println("✓ Services initialized")
 
 	// Initialize queue service for transcoding
@@ -194,21 +255,85 @@ func main() {
 		fmt.Println("✓ Queue service initialized")
 	}
 
+	// Persist reference counts of deduplicated objects
+	if casStorage, ok := storageService.(*storage.ContentAddressedStorage); ok {
+		casStorage.SetIndex(service.NewBlobIndex(mainRepo))
+	}
+
+	// Wire replication tracking and async replication queue
+	if replicatedStorage, ok := storage.AsReplicated(storageService); ok {
+		replicatedStorage.SetRecorder(service.NewReplicationTracker(mainRepo))
+		if queueService != nil {
+			replicatedStorage.SetQueue(queueService)
+		}
+	}
+
+	// Fingerprint jobs for the near-duplicate backfill go to the workers
+	var jobQueue queue.QueueService
+	if queueService != nil {
+		jobQueue = queueService
+		fingerprintService.SetQueue(queueService)
+	}
+
+	// Server-side fetch from allowed hosts; jobs run on the workers, or in-process without a queue
+	fetcher, err := fetch.NewFetcherFromEnv()
+	if err != nil {
+		log.Printf("⚠ Warning: Server-side fetch disabled: %v", err)
+	} else if fetcher == nil {
+		log.Println("Warning: FETCH_ALLOWED_HOSTS not set, server-side fetch is disabled")
+	}
+	// Fetched files and new versions go through the same upload path as direct uploads
+	uploadService := service.NewUploadService(fileService, storageService, jobQueue)
+	uploadService.SetFileTypeRules(fileTypeRuleService)
+	fetchService := service.NewFetchService(mainRepo, uploadService, fetcher)
+	fetchService.SetQueue(jobQueue)
+	versionService := service.NewVersionService(mainRepo, uploadService, storageService)
+	reviewService := service.NewReviewService(mainRepo, fileService)
+	statsService := service.NewWorkflowStatsService(mainRepo, cacheService)
+	scheduleService := service.NewScheduleService(mainRepo, fileService)
+	trashService := service.NewTrashService(mainRepo, fileService, storageService)
+	legalHoldService := service.NewLegalHoldService(mainRepo)
+	bulkService := service.NewBulkService(mainRepo, fileService)
+	bulkService.SetQueue(jobQueue)
+	notificationService := service.NewNotificationService(mainRepo)
+	notificationService.SetQueue(jobQueue)
+	assignmentService := service.NewReviewAssignmentService(mainRepo, notificationService)
+	fileService.SetReviewAssignments(assignmentService)
+	commentService := service.NewCommentService(mainRepo)
+
 	// Setup router dependencies
 	deps := &api.RouterDependencies{
-		SessionStore:      sessionStore,
-		ACLService:        aclService,
-		UsersService:      usersService,
-		FileService:       fileService,
-		CategoryService:   categoryService,
-		CatalogService:    catalogService,
-		SearchService:     searchService,
-		GroupService:      groupService,
-		RoleService:       roleService,
-		PermissionService: permissionService,
-		LevelsService:     levelsService,
-		StorageService:    storageService,
-		QueueService:      queueService,
+		SessionStore:        sessionStore,
+		ACLService:          aclService,
+		UsersService:        usersService,
+		FileService:         fileService,
+		CategoryService:     categoryService,
+		CatalogService:      catalogService,
+		SearchService:       searchService,
+		GroupService:        groupService,
+		RoleService:         roleService,
+		PermissionService:   permissionService,
+		LevelsService:       levelsService,
+		QuotaService:        quotaService,
+		SidecarService:      sidecarService,
+		FileTypeRuleService: fileTypeRuleService,
+		ScanService:         scanService,
+		DuplicateService:    duplicateService,
+		FingerprintService:  fingerprintService,
+		FetchService:        fetchService,
+		VersionService:      versionService,
+		WorkflowService:     workflowService,
+		ReviewService:       reviewService,
+		StatsService:        statsService,
+		ScheduleService:     scheduleService,
+		TrashService:        trashService,
+		LegalHoldService:    legalHoldService,
+		BulkService:         bulkService,
+		AssignmentService:   assignmentService,
+		NotificationService: notificationService,
+		CommentService:      commentService,
+		StorageService:      storageService,
+		QueueService:        queueService,
 	}
 
 	// Setup router
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/database"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// MigrateConfig holds configuration for the layout migration
+type MigrateConfig struct {
+	BatchSize int
+	StartID   uint64
+	DryRun    bool
+	KeepOld   bool
+}
+
+func main() {
+	config := parseFlags()
+
+	storageConfig := storage.LoadConfigFromEnv()
+	storageConfig.Layout = storage.LayoutContentAddressed
+	storageService, err := storage.NewStorageFromConfig(storageConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize storage: %v\n", err)
+		os.Exit(1)
+	}
+	casStorage := storageService.(*storage.ContentAddressedStorage)
+
+	if err := database.Initialize(database.LoadConfigFromEnv()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize database: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	repo := repository.NewRepository(database.GetDB())
+	index := service.NewBlobIndex(repo)
+	casStorage.SetIndex(index)
+	if replicatedStorage, ok := storage.AsReplicated(casStorage); ok {
+		replicatedStorage.SetRecorder(service.NewReplicationTracker(repo))
+	}
+
+	ctx := context.Background()
+	if err := runMigration(ctx, config, repo, casStorage); err != nil {
+		fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	stats, err := index.GetStats(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load deduplication stats: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nContent-addressed storage: %d objects, %d references, %.2f MB stored, %.2f MB saved by deduplication\n",
+		stats.Blobs, stats.References, megabytes(stats.StoredBytes), megabytes(stats.LogicalBytes-stats.StoredBytes))
+}
+
+// parseFlags parses command-line flags
+func parseFlags() *MigrateConfig {
+	config := &MigrateConfig{}
+
+	flag.IntVar(&config.BatchSize, "batch", 200, "Number of files loaded per batch")
+	flag.Uint64Var(&config.StartID, "start-id", 0, "Resume after this file ID")
+	flag.BoolVar(&config.DryRun, "dry-run", false, "Only report files that would be migrated")
+	flag.BoolVar(&config.KeepOld, "keep-old", false, "Keep objects at their old paths after migrating")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nOpenWan Content-Addressed Storage Migration\n")
+		fmt.Fprintf(os.Stderr, "Moves existing originals and previews to SHA-256 keys, sharing one object between identical files\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	return config
+}
+
+// runMigration walks all files and moves objects that are not yet content-addressed
+func runMigration(ctx context.Context, config *MigrateConfig, repo repository.Repository, casStorage *storage.ContentAddressedStorage) error {
+	fmt.Printf("Starting migration at %s\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	// Objects of files under legal hold stay where they are
+	holds := service.NewLegalHoldService(repo)
+
+	var scanned, migrated, skipped, held, failed int
+	lastID := config.StartID
+	for {
+		files, err := repo.Files().FindAfterID(ctx, lastID, config.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load files after ID %d: %w", lastID, err)
+		}
+		if len(files) == 0 {
+			break
+		}
+
+		for _, file := range files {
+			lastID = file.ID
+			scanned++
+
+			if strings.HasPrefix(file.Path, storage.ContentAddressedPrefix) {
+				skipped++
+				continue
+			}
+			if err := holds.Check(ctx, file, service.HoldOperationMove, ""); err != nil {
+				fmt.Printf("  ⚠ file %d: %v\n", file.ID, err)
+				held++
+				continue
+			}
+			if config.DryRun {
+				fmt.Printf("  would migrate file %d: %s\n", file.ID, file.Path)
+				migrated++
+				continue
+			}
+			if err := migrateFile(ctx, config, repo, casStorage, file); err != nil {
+				fmt.Printf("  ✗ file %d: %v\n", file.ID, err)
+				failed++
+				continue
+			}
+			migrated++
+		}
+
+		fmt.Printf("Processed files up to ID %d (scanned %d, migrated %d, skipped %d, held %d, failed %d)\n",
+			lastID, scanned, migrated, skipped, held, failed)
+	}
+
+	fmt.Printf("Migration finished: scanned %d files, migrated %d, skipped %d, held %d, failed %d\n", scanned, migrated, skipped, held, failed)
+	return nil
+}
+
+// migrateFile copies one file (and its preview) to the content-addressed layout and repoints the database row
+func migrateFile(ctx context.Context, config *MigrateConfig, repo repository.Repository, casStorage *storage.ContentAddressedStorage, file *models.Files) error {
+	backend := casStorage.Backend()
+	oldPath := file.Path
+
+	reader, err := backend.Download(ctx, oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", oldPath, err)
+	}
+	newPath, err := casStorage.Upload(ctx, file.Name+file.Ext, reader, nil)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", oldPath, err)
+	}
+
+	// Move the preview next to the new object so PreviewFile finds it by naming convention
+	var oldPreview string
+	if file.Type == models.FileTypeVideo || file.Type == models.FileTypeAudio {
+		oldPreview = strings.TrimSuffix(oldPath, filepath.Ext(oldPath)) + "-preview.flv"
+		newPreview := strings.TrimSuffix(newPath, filepath.Ext(newPath)) + "-preview.flv"
+		if err := copyIfMissing(ctx, casStorage, oldPreview, newPreview); err != nil {
+			casStorage.Delete(ctx, newPath)
+			return err
+		}
+	}
+
+	if err := repo.Files().UpdateStoragePath(ctx, file.ID, newPath, storage.ContentHashFromPath(newPath)); err != nil {
+		casStorage.Delete(ctx, newPath)
+		return fmt.Errorf("failed to update file record: %w", err)
+	}
+
+	if !config.KeepOld {
+		if err := backend.Delete(ctx, oldPath); err != nil {
+			fmt.Printf("  ⚠ file %d: failed to delete old object %s: %v\n", file.ID, oldPath, err)
+		}
+		if oldPreview != "" {
+			if exists, err := backend.Exists(ctx, oldPreview); err == nil && exists {
+				backend.Delete(ctx, oldPreview)
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyIfMissing copies an existing object to a new content-addressed key unless the target already exists
+func copyIfMissing(ctx context.Context, casStorage *storage.ContentAddressedStorage, from, to string) error {
+	backend := casStorage.Backend()
+
+	exists, err := backend.Exists(ctx, from)
+	if err != nil || !exists {
+		return nil
+	}
+	if exists, err := backend.Exists(ctx, to); err == nil && exists {
+		return nil
+	}
+
+	reader, err := backend.Download(ctx, from)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", from, err)
+	}
+	defer reader.Close()
+
+	if _, err := casStorage.Upload(ctx, to, reader, map[string]string{storage.MetadataContentType: "video/x-flv"}); err != nil {
+		return fmt.Errorf("failed to store %s: %w", to, err)
+	}
+	return nil
+}
+
+// megabytes converts a byte count to MB
+func megabytes(bytes int64) float64 {
+	return float64(bytes) / (1024 * 1024)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/openwan/media-asset-management/internal/database"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+func main() {
+	recalculate := flag.Bool("recalculate", false, "Rebuild usage counters from the files table")
+	groupID := flag.Int("group", 0, "Only report this group ID (default: all groups)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nOpenWan Storage Quota Tool\n")
+		fmt.Fprintf(os.Stderr, "Reports per-group and per-user storage usage and repairs usage counters\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := database.Initialize(database.LoadConfigFromEnv()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize database: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	quotaService := service.NewQuotaService(repository.NewRepository(database.GetDB()))
+
+	if *recalculate {
+		fmt.Println("Recalculating storage usage...")
+		if err := quotaService.Recalculate(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Recalculation failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Storage usage recalculated")
+	}
+
+	report, err := quotaService.GetUsageReport(ctx, *groupID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load usage report: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, group := range report {
+		quota := "unlimited"
+		if group.QuotaMB > 0 {
+			quota = fmt.Sprintf("%d MB per user", group.QuotaMB)
+		}
+		fmt.Printf("\nGroup %d (%s): %.2f MB in %d files, quota %s\n",
+			group.GroupID, group.GroupName, megabytes(group.UsedBytes), group.FileCount, quota)
+		for _, user := range group.Users {
+			marker := ""
+			if user.Exceeded {
+				marker = "  ✗ over quota"
+			}
+			fmt.Printf("  %-20s %10.2f MB %6d files%s\n", user.Username, megabytes(user.UsedBytes), user.FileCount, marker)
+		}
+	}
+}
+
+// megabytes converts a byte count to MB
+func megabytes(bytes int64) float64 {
+	return float64(bytes) / (1024 * 1024)
+}
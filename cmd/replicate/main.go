@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/database"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// BackfillConfig holds configuration for the replication backfill
+type BackfillConfig struct {
+	Replica   string
+	BatchSize int
+	StartID   uint64
+	DryRun    bool
+	StatsOnly bool
+}
+
+func main() {
+	config := parseFlags()
+
+	storageConfig := storage.LoadConfigFromEnv()
+	storageService, err := storage.NewStorageFromConfig(storageConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize storage: %v\n", err)
+		os.Exit(1)
+	}
+	replicatedStorage, ok := storage.AsReplicated(storageService)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "No storage replicas configured (set REPLICA_STORAGE_TYPE)")
+		os.Exit(1)
+	}
+
+	if err := database.Initialize(database.LoadConfigFromEnv()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize database: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	repo := repository.NewRepository(database.GetDB())
+	tracker := service.NewReplicationTracker(repo)
+	replicatedStorage.SetRecorder(tracker)
+
+	ctx := context.Background()
+	if !config.StatsOnly {
+		if err := runBackfill(ctx, config, repo, tracker, replicatedStorage); err != nil {
+			fmt.Fprintf(os.Stderr, "Backfill failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := printStats(ctx, tracker); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load replication stats: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseFlags parses command-line flags
+func parseFlags() *BackfillConfig {
+	config := &BackfillConfig{}
+
+	flag.StringVar(&config.Replica, "replica", "", "Only backfill this replica (default: all replicas)")
+	flag.IntVar(&config.BatchSize, "batch", 500, "Number of files loaded per batch")
+	flag.Uint64Var(&config.StartID, "start-id", 0, "Resume after this file ID")
+	flag.BoolVar(&config.DryRun, "dry-run", false, "Only report objects that would be replicated")
+	flag.BoolVar(&config.StatsOnly, "stats", false, "Only print replication lag and failure statistics")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nOpenWan Storage Replication Backfill\n")
+		fmt.Fprintf(os.Stderr, "Copies existing originals and previews to the configured storage replicas\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	return config
+}
+
+// runBackfill walks all files and replicates objects not yet present on the replicas
+func runBackfill(ctx context.Context, config *BackfillConfig, repo repository.Repository, tracker *service.ReplicationTracker, replicatedStorage *storage.ReplicatedStorage) error {
+	var replicas []string
+	for _, replica := range replicatedStorage.Replicas() {
+		if config.Replica == "" || config.Replica == replica.Name {
+			replicas = append(replicas, replica.Name)
+		}
+	}
+	if len(replicas) == 0 {
+		return fmt.Errorf("unknown replica: %s", config.Replica)
+	}
+
+	fmt.Printf("Starting backfill at %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Replicas: %s\n", strings.Join(replicas, ", "))
+
+	var scanned, copied, skipped, failed int
+	lastID := config.StartID
+	for {
+		files, err := repo.Files().FindAfterID(ctx, lastID, config.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load files after ID %d: %w", lastID, err)
+		}
+		if len(files) == 0 {
+			break
+		}
+
+		for _, file := range files {
+			lastID = file.ID
+			scanned++
+
+			for _, path := range objectPaths(ctx, replicatedStorage, file) {
+				for _, replica := range replicas {
+					done, err := tracker.IsReplicated(ctx, path, replica)
+					if err != nil {
+						return fmt.Errorf("failed to check replication state: %w", err)
+					}
+					if done {
+						skipped++
+						continue
+					}
+					if config.DryRun {
+						fmt.Printf("  would replicate %s -> %s\n", path, replica)
+						copied++
+						continue
+					}
+					if err := replicatedStorage.ReplicateObject(ctx, path, replica, nil); err != nil {
+						fmt.Printf("  ✗ file %d: %v\n", file.ID, err)
+						failed++
+						continue
+					}
+					copied++
+				}
+			}
+		}
+
+		fmt.Printf("Processed files up to ID %d (scanned %d, copied %d, skipped %d, failed %d)\n",
+			lastID, scanned, copied, skipped, failed)
+	}
+
+	fmt.Printf("Backfill finished: scanned %d files, copied %d, skipped %d, failed %d\n", scanned, copied, skipped, failed)
+	return nil
+}
+
+// objectPaths returns the original path and any derivative paths present on the primary
+func objectPaths(ctx context.Context, replicatedStorage *storage.ReplicatedStorage, file *models.Files) []string {
+	paths := []string{file.Path}
+
+	if file.Type == models.FileTypeVideo || file.Type == models.FileTypeAudio {
+		previewPath := strings.TrimSuffix(file.Path, filepath.Ext(file.Path)) + "-preview.flv"
+		if exists, err := replicatedStorage.Primary().Exists(ctx, previewPath); err == nil && exists {
+			paths = append(paths, previewPath)
+		}
+	}
+
+	return paths
+}
+
+// printStats prints replication counts and the oldest outstanding item per replica
+func printStats(ctx context.Context, tracker *service.ReplicationTracker) error {
+	stats, err := tracker.GetStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nReplication status:")
+	now := time.Now()
+	for _, stat := range stats {
+		line := fmt.Sprintf("  %-16s %-12s %8d", stat.Replica, stat.Status, stat.Count)
+		if stat.Status != models.ReplicaStatusReplicated && stat.OldestQueuedAt != nil {
+			line += fmt.Sprintf("  oldest lag %s", now.Sub(*stat.OldestQueuedAt).Round(time.Second))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
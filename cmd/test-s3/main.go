@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -10,6 +14,10 @@ import (
 )
 
 func main() {
+	createBucket := flag.Bool("create-bucket", false, "Create the bucket if it does not exist (local MinIO/Ceph setups)")
+	multipartMB := flag.Int("multipart-mb", 0, "Also round-trip a random object of this many MB to exercise multipart upload")
+	flag.Parse()
+
 	// Load configuration from environment
 	cfg := storage.LoadConfigFromEnv()
 	
@@ -20,6 +28,14 @@ func main() {
 	fmt.Printf("Bucket: %s\n", cfg.S3Bucket)
 	fmt.Printf("Region: %s\n", cfg.S3Region)
 	fmt.Printf("Prefix: %s\n", cfg.S3Prefix)
+	if cfg.S3Endpoint != "" {
+		fmt.Printf("Endpoint: %s\n", cfg.S3Endpoint)
+		fmt.Printf("Path-style: %v\n", cfg.S3UsePathStyle)
+		fmt.Printf("TLS verify: %v\n", !cfg.S3InsecureSkipVerify)
+	}
+	if cfg.S3ChecksumAlgorithm != "" {
+		fmt.Printf("Checksum: %s\n", cfg.S3ChecksumAlgorithm)
+	}
 	fmt.Println("========================================")
 	
 	// Create storage service
@@ -30,8 +46,22 @@ func main() {
 	}
 	fmt.Println("✓ Storage service created")
 	
-	// Test upload
 	ctx := context.Background()
+	
+	if *createBucket {
+		s3Storage, ok := storageService.(*storage.S3Storage)
+		if !ok {
+			fmt.Println("❌ -create-bucket requires STORAGE_TYPE=s3")
+			os.Exit(1)
+		}
+		if err := s3Storage.EnsureBucket(ctx); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Bucket ready: %s\n", cfg.S3Bucket)
+	}
+	
+	// Test upload
 	testContent := "This is a test file for S3 storage validation."
 	reader := strings.NewReader(testContent)
 	
@@ -66,12 +96,15 @@ func main() {
 		fmt.Printf("❌ Download failed: %v\n", err)
 		os.Exit(1)
 	}
-	defer downloadReader.Close()
 	
 	// Read content
-	buf := make([]byte, 1024)
-	n, _ := downloadReader.Read(buf)
-	downloadedContent := string(buf[:n])
+	downloaded, err := io.ReadAll(downloadReader)
+	downloadReader.Close()
+	if err != nil {
+		fmt.Printf("❌ Download read failed: %v\n", err)
+		os.Exit(1)
+	}
+	downloadedContent := string(downloaded)
 	
 	if downloadedContent == testContent {
 		fmt.Printf("✓ Download successful and content matches\n")
@@ -80,6 +113,7 @@ func main() {
 		fmt.Printf("❌ Content mismatch\n")
 		fmt.Printf("  Expected: %s\n", testContent)
 		fmt.Printf("  Got: %s\n", downloadedContent)
+		os.Exit(1)
 	}
 	
 	// Test get URL
@@ -96,15 +130,62 @@ func main() {
 	err = storageService.Delete(ctx, path)
 	if err != nil {
 		fmt.Printf("❌ Delete failed: %v\n", err)
-	} else {
-		fmt.Printf("✓ Delete successful\n")
+		os.Exit(1)
 	}
+	fmt.Printf("✓ Delete successful\n")
 	
 	// Verify deleted
-	exists, _ = storageService.Exists(ctx, path)
+	exists, err = storageService.Exists(ctx, path)
+	if err != nil || exists {
+		fmt.Printf("❌ File still exists after delete (err: %v)\n", err)
+		os.Exit(1)
+	}
 	fmt.Printf("✓ File exists after delete: %v\n", exists)
 	
+	// Test multipart upload with a larger random object
+	if *multipartMB > 0 {
+		fmt.Printf("\n--- Testing Multipart Round-trip (%d MB) ---\n", *multipartMB)
+		if err := multipartRoundTrip(ctx, storageService, *multipartMB); err != nil {
+			fmt.Printf("❌ Multipart round-trip failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Multipart round-trip successful\n")
+	}
+	
 	fmt.Println("\n========================================")
 	fmt.Println("✅ All S3 storage tests passed!")
 	fmt.Println("========================================")
 }
+
+// multipartRoundTrip uploads, downloads, compares and deletes a random object of the given size
+func multipartRoundTrip(ctx context.Context, storageService storage.StorageService, sizeMB int) error {
+	data := make([]byte, sizeMB*1024*1024)
+	if _, err := rand.Read(data); err != nil {
+		return fmt.Errorf("failed to generate test data: %w", err)
+	}
+	
+	metadata := map[string]string{
+		"content-type": "application/octet-stream",
+		"test-by":      "s3-validation",
+	}
+	path, err := storageService.Upload(ctx, "test_multipart.bin", bytes.NewReader(data), metadata)
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	defer storageService.Delete(ctx, path)
+	
+	reader, err := storageService.Download(ctx, path)
+	if err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+	defer reader.Close()
+	
+	downloaded, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if !bytes.Equal(data, downloaded) {
+		return fmt.Errorf("content mismatch: uploaded %d bytes, downloaded %d bytes", len(data), len(downloaded))
+	}
+	return nil
+}
@@ -13,7 +13,11 @@ import (
 	"time"
 
 	"github.com/openwan/media-asset-management/internal/config"
+	"github.com/openwan/media-asset-management/internal/database"
+	"github.com/openwan/media-asset-management/internal/fetch"
 	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
 	"github.com/openwan/media-asset-management/internal/storage"
 	"github.com/openwan/media-asset-management/internal/transcoding"
 )
@@ -54,7 +58,34 @@ func main() {
 		S3Bucket:     cfg.Storage.S3Bucket,
 		S3Region:     cfg.Storage.S3Region,
 		S3Prefix:     cfg.Storage.S3Prefix,
-		S3UseIAMRole: true, // Use IAM role for EC2 instance
+		S3AccessKey:  os.Getenv("AWS_ACCESS_KEY_ID"),
+		S3SecretKey:  os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		S3UseIAMRole: os.Getenv("AWS_ACCESS_KEY_ID") == "", // Use IAM role for EC2 instance unless static keys are set (MinIO/Ceph)
+		S3Endpoint:             cfg.Storage.S3Endpoint,
+		S3UsePathStyle:         cfg.Storage.S3UsePathStyle,
+		S3InsecureSkipVerify:   cfg.Storage.S3InsecureSkipVerify,
+		S3CACertFile:           cfg.Storage.S3CACertFile,
+		S3ChecksumCalculation:  cfg.Storage.S3ChecksumCalculation,
+		S3ChecksumValidation:   cfg.Storage.S3ChecksumValidation,
+		S3ChecksumAlgorithm:    cfg.Storage.S3ChecksumAlgorithm,
+		S3ServerSideEncryption: cfg.Storage.S3ServerSideEncryption,
+		ReplicationMode:        cfg.Storage.ReplicationMode,
+		Layout:                 cfg.Storage.Layout,
+	}
+	for _, replica := range cfg.Storage.Replicas {
+		storageConfig.Replicas = append(storageConfig.Replicas, storage.ReplicaConfig{
+			Name: replica.Name,
+			Config: storage.Config{
+				Type:           replica.Type,
+				LocalPath:      replica.LocalPath,
+				S3Bucket:       replica.S3Bucket,
+				S3Region:       replica.S3Region,
+				S3Prefix:       replica.S3Prefix,
+				S3UseIAMRole:   storageConfig.S3UseIAMRole,
+				S3Endpoint:     replica.S3Endpoint,
+				S3UsePathStyle: replica.S3UsePathStyle,
+			},
+		})
 	}
 	storageService, err := storage.NewStorageFromConfig(storageConfig)
 	if err != nil {
@@ -62,6 +93,34 @@ func main() {
 	}
 	fmt.Println("✓ Storage service initialized")
 
+	// Replication tracking and derivative usage accounting need the database; without it
+	// jobs are still processed but not tracked
+	var repo repository.Repository
+	if err := database.Initialize(database.LoadConfigFromEnv()); err != nil {
+		log.Printf("⚠ Warning: Failed to connect to database, usage and replication tracking disabled: %v", err)
+	} else {
+		repo = repository.NewRepository(database.GetDB())
+		fmt.Println("✓ Connected to database")
+	}
+
+	var quotaService *service.QuotaService
+	if repo != nil {
+		quotaService = service.NewQuotaService(repo)
+	}
+
+	// Reference counts of deduplicated objects are shared with the API through the database;
+	// without it the content-addressed layout refuses to store or delete originals
+	if casStorage, ok := storageService.(*storage.ContentAddressedStorage); ok && repo != nil {
+		casStorage.SetIndex(service.NewBlobIndex(repo))
+		fmt.Println("✓ Blob reference index enabled")
+	}
+
+	replicatedStorage, isReplicated := storage.AsReplicated(storageService)
+	if isReplicated && repo != nil {
+		replicatedStorage.SetRecorder(service.NewReplicationTracker(repo))
+		fmt.Println("✓ Replication tracking enabled")
+	}
+
 	// Initialize FFmpeg service
 	ffmpegWrapper := transcoding.NewFFmpegWrapper(cfg.FFmpeg.BinaryPath, cfg.FFmpeg.Parameters)
 	fmt.Println("✓ FFmpeg service initialized")
@@ -90,7 +149,63 @@ func main() {
 	// Start workers
 	for i := 0; i < workerCount; i++ {
 		workerID := i + 1
-		go worker(ctx, workerID, queueService, ffmpegWrapper, storageService, cfg.FFmpeg.Parameters)
+		go worker(ctx, workerID, queueService, ffmpegWrapper, storageService, quotaService, cfg.FFmpeg.Parameters)
+	}
+
+	// Start replication consumer for async storage replication
+	if isReplicated {
+		go replicationWorker(ctx, queueService, replicatedStorage)
+	}
+
+	// Start fingerprint consumer for near-duplicate detection; fingerprints are stored in the database
+	if repo != nil {
+		fingerprintService := service.NewFingerprintService(repo, storageService, ffmpegWrapper)
+		go fingerprintWorker(ctx, queueService, fingerprintService)
+	}
+
+	// Start fetch consumer for server-side ingest; the worker needs the same FETCH_* settings as the API
+	if repo != nil {
+		fetcher, err := fetch.NewFetcherFromEnv()
+		if err != nil {
+			log.Printf("⚠ Warning: Server-side fetch disabled: %v", err)
+		} else if fetcher != nil && !hasBlobIndex(storageService) {
+			log.Printf("⚠ Warning: Server-side fetch disabled: content-addressed storage has no reference index")
+		} else if fetcher != nil {
+			uploadService := service.NewUploadService(service.NewFilesService(repo), storageService, queueService)
+			go fetchWorker(ctx, queueService, service.NewFetchService(repo, uploadService, fetcher))
+		}
+	}
+
+	// Start the bulk job consumer; it applies bulk file operations submitted through the API and
+	// requeues jobs abandoned by a stopped worker
+	if repo != nil {
+		bulkService := service.NewBulkService(repo, service.NewFilesService(repo))
+		bulkService.SetQueue(queueService)
+		go bulkWorker(ctx, queueService, bulkService)
+		go bulkRequeueWorker(ctx, bulkService, bulkRequeueInterval())
+	}
+
+	// Start the publication scheduler; it publishes and withdraws files at their scheduled times
+	if repo != nil {
+		scheduleService := service.NewScheduleService(repo, service.NewFilesService(repo))
+		go scheduleWorker(ctx, scheduleService, scheduleInterval())
+	}
+
+	// Start the trash purger; it removes deleted files once their category's retention has passed
+	if repo != nil && !hasBlobIndex(storageService) {
+		log.Printf("⚠ Warning: Trash purger disabled: content-addressed storage has no reference index")
+	} else if repo != nil {
+		trashService := service.NewTrashService(repo, service.NewFilesService(repo), storageService)
+		go trashPurgeWorker(ctx, trashService, trashPurgeInterval())
+	}
+
+	// Start the review escalation job; it notifies the escalation role of reviews past their SLA
+	if repo != nil {
+		notificationService := service.NewNotificationService(repo)
+		notificationService.SetQueue(queueService)
+		assignmentService := service.NewReviewAssignmentService(repo, notificationService)
+		assignmentService.SetEscalationRole(os.Getenv("REVIEW_ESCALATION_ROLE"))
+		go reviewEscalationWorker(ctx, assignmentService, reviewEscalationInterval())
 	}
 
 	fmt.Printf("✓ All workers started\n")
@@ -112,14 +227,14 @@ func main() {
 	fmt.Println("✓ Worker service stopped")
 }
 
-func worker(ctx context.Context, workerID int, queueService queue.QueueService, ffmpegWrapper *transcoding.FFmpegWrapper, storageService storage.StorageService, defaultParams string) {
+func worker(ctx context.Context, workerID int, queueService queue.QueueService, ffmpegWrapper *transcoding.FFmpegWrapper, storageService storage.StorageService, quotaService *service.QuotaService, defaultParams string) {
 	queueName := "openwan_transcoding_jobs"
 
 	fmt.Printf("[Worker %d] Started, subscribing to queue: %s\n", workerID, queueName)
 
 	// Subscribe to queue
 	err := queueService.Subscribe(ctx, queueName, func(message *queue.Message) error {
-		return handleTranscodeJob(workerID, message, ffmpegWrapper, storageService, defaultParams)
+		return handleTranscodeJob(workerID, message, ffmpegWrapper, storageService, quotaService, defaultParams)
 	})
 
 	if err != nil {
@@ -127,7 +242,159 @@ func worker(ctx context.Context, workerID int, queueService queue.QueueService,
 	}
 }
 
-func handleTranscodeJob(workerID int, message *queue.Message, ffmpegWrapper *transcoding.FFmpegWrapper, storageService storage.StorageService, defaultParams string) error {
+func replicationWorker(ctx context.Context, queueService queue.QueueService, replicatedStorage *storage.ReplicatedStorage) {
+	fmt.Printf("[Replication] Started, subscribing to queue: %s\n", storage.ReplicationQueueName)
+
+	err := queueService.Subscribe(ctx, storage.ReplicationQueueName, func(message *queue.Message) error {
+		return replicatedStorage.HandleReplicationJob(ctx, message)
+	})
+
+	if err != nil {
+		log.Printf("[Replication] Error: %v\n", err)
+	}
+}
+
+func fingerprintWorker(ctx context.Context, queueService queue.QueueService, fingerprintService *service.FingerprintService) {
+	fmt.Printf("[Fingerprint] Started, subscribing to queue: %s\n", service.FingerprintQueueName)
+
+	err := queueService.Subscribe(ctx, service.FingerprintQueueName, func(message *queue.Message) error {
+		return fingerprintService.HandleJob(ctx, message)
+	})
+
+	if err != nil {
+		log.Printf("[Fingerprint] Error: %v\n", err)
+	}
+}
+
+func fetchWorker(ctx context.Context, queueService queue.QueueService, fetchService *service.FetchService) {
+	fmt.Printf("[Fetch] Started, subscribing to queue: %s\n", service.FetchQueueName)
+
+	err := queueService.Subscribe(ctx, service.FetchQueueName, func(message *queue.Message) error {
+		return fetchService.HandleJob(ctx, message)
+	})
+
+	if err != nil {
+		log.Printf("[Fetch] Error: %v\n", err)
+	}
+}
+
+// hasBlobIndex reports whether storage can count references to shared objects; only the
+// content-addressed layout needs an index
+func hasBlobIndex(storageService storage.StorageService) bool {
+	casStorage, ok := storageService.(*storage.ContentAddressedStorage)
+	return !ok || casStorage.HasIndex()
+}
+
+func bulkWorker(ctx context.Context, queueService queue.QueueService, bulkService *service.BulkService) {
+	fmt.Printf("[Bulk] Started, subscribing to queue: %s\n", service.BulkQueueName)
+
+	err := queueService.Subscribe(ctx, service.BulkQueueName, func(message *queue.Message) error {
+		return bulkService.HandleJob(ctx, message)
+	})
+
+	if err != nil {
+		log.Printf("[Bulk] Error: %v\n", err)
+	}
+}
+
+func bulkRequeueWorker(ctx context.Context, bulkService *service.BulkService, interval time.Duration) {
+	fmt.Printf("[Bulk] Checking for stale jobs every %s\n", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		requeued, err := bulkService.RequeueStale(ctx)
+		if err != nil {
+			log.Printf("[Bulk] Error: %v\n", err)
+		} else if requeued > 0 {
+			fmt.Printf("[Bulk] Requeued %d stale jobs\n", requeued)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// bulkRequeueInterval returns how often stale bulk jobs are requeued, from BULK_REQUEUE_INTERVAL
+// (default 5m)
+func bulkRequeueInterval() time.Duration {
+	if value := os.Getenv("BULK_REQUEUE_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("⚠ Warning: Invalid BULK_REQUEUE_INTERVAL %q, using 5m", value)
+	}
+	return 5 * time.Minute
+}
+
+func scheduleWorker(ctx context.Context, scheduleService *service.ScheduleService, interval time.Duration) {
+	fmt.Printf("[Schedule] Started, checking every %s\n", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		published, unpublished, err := scheduleService.RunDue(ctx)
+		if err != nil {
+			log.Printf("[Schedule] Error: %v\n", err)
+		} else if published > 0 || unpublished > 0 {
+			fmt.Printf("[Schedule] Published %d, withdrew %d files\n", published, unpublished)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleInterval returns how often the scheduler runs, from SCHEDULE_INTERVAL (default 1m)
+func scheduleInterval() time.Duration {
+	if value := os.Getenv("SCHEDULE_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("⚠ Warning: Invalid SCHEDULE_INTERVAL %q, using 1m", value)
+	}
+	return time.Minute
+}
+
+func trashPurgeWorker(ctx context.Context, trashService *service.TrashService, interval time.Duration) {
+	fmt.Printf("[Trash] Started, purging every %s\n", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := trashService.PurgeExpired(ctx)
+		if err != nil {
+			log.Printf("[Trash] Error: %v\n", err)
+		} else if purged > 0 {
+			fmt.Printf("[Trash] Purged %d files\n", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashPurgeInterval returns how often the trash purger runs, from TRASH_PURGE_INTERVAL (default 1h)
+func trashPurgeInterval() time.Duration {
+	if value := os.Getenv("TRASH_PURGE_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("⚠ Warning: Invalid TRASH_PURGE_INTERVAL %q, using 1h", value)
+	}
+	return time.Hour
+}
+
+func handleTranscodeJob(workerID int, message *queue.Message, ffmpegWrapper *transcoding.FFmpegWrapper, storageService storage.StorageService, quotaService *service.QuotaService, defaultParams string) error {
 	// Parse job data
 	var job queue.TranscodeJob
 	if err := json.Unmarshal([]byte(message.Body), &job); err != nil {
@@ -221,6 +488,15 @@ func handleTranscodeJob(workerID int, message *queue.Message, ffmpegWrapper *tra
 	}
 
 	fmt.Printf("[Worker %d] ✓ Transcoding completed (%.2fs)\n", workerID, duration.Seconds())
+
+	// Count the preview against the owner's storage usage
+	if quotaService != nil {
+		if stat, err := os.Stat(outputFile); err == nil {
+			if err := quotaService.RecordDerivative(context.Background(), job.FileID, stat.Size()); err != nil {
+				log.Printf("[Worker %d] ⚠ Failed to record derivative size: %v\n", workerID, err)
+			}
+		}
+	}
 	
 	// For S3 storage, upload output file
 	if job.StorageType == "s3" {
@@ -256,3 +532,36 @@ func handleTranscodeJob(workerID int, message *queue.Message, ffmpegWrapper *tra
 
 	return nil
 }
+
+func reviewEscalationWorker(ctx context.Context, assignmentService *service.ReviewAssignmentService, interval time.Duration) {
+	fmt.Printf("[Escalation] Started, checking review SLAs every %s\n", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		escalated, err := assignmentService.EscalateOverdue(ctx)
+		if err != nil {
+			log.Printf("[Escalation] Error: %v\n", err)
+		} else if escalated > 0 {
+			fmt.Printf("[Escalation] Escalated %d overdue reviews\n", escalated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reviewEscalationInterval returns how often review SLAs are checked, from
+// REVIEW_ESCALATION_INTERVAL (default 15m)
+func reviewEscalationInterval() time.Duration {
+	if value := os.Getenv("REVIEW_ESCALATION_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("⚠ Warning: Invalid REVIEW_ESCALATION_INTERVAL %q, using 15m", value)
+	}
+	return 15 * time.Minute
+}
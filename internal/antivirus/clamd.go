@@ -0,0 +1,175 @@
+// Package antivirus scans content with a virus scanner speaking the clamd protocol
+package antivirus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of scanning one stream
+type Result struct {
+	Infected  bool
+	Signature string // Name of the detected malware, e.g. "Eicar-Test-Signature"
+}
+
+// ClamdScanner scans streams with clamd's INSTREAM command over TCP or a unix socket
+type ClamdScanner struct {
+	network   string
+	address   string
+	timeout   time.Duration
+	chunkSize int
+	dial      func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewClamdScanner creates a scanner for address, given as "tcp://host:3310", "unix:///path/clamd.sock",
+// "host:port" or an absolute socket path
+func NewClamdScanner(address string) (*ClamdScanner, error) {
+	network, addr := "tcp", address
+	switch {
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "/"):
+		network = "unix"
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("invalid clamd address: %q", address)
+	}
+
+	var dialer net.Dialer
+	return &ClamdScanner{
+		network:   network,
+		address:   addr,
+		timeout:   5 * time.Minute,
+		chunkSize: 64 * 1024,
+		dial:      dialer.DialContext,
+	}, nil
+}
+
+// NewClamdScannerFromEnv creates a scanner for CLAMD_ADDRESS, or returns nil if it is not set
+func NewClamdScannerFromEnv() (*ClamdScanner, error) {
+	address := os.Getenv("CLAMD_ADDRESS")
+	if address == "" {
+		return nil, nil
+	}
+	scanner, err := NewClamdScanner(address)
+	if err != nil {
+		return nil, err
+	}
+	if value := os.Getenv("CLAMD_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CLAMD_TIMEOUT: %w", err)
+		}
+		scanner.SetTimeout(timeout)
+	}
+	return scanner, nil
+}
+
+// SetTimeout sets the time allowed for one scan, including the upload of the stream
+func (s *ClamdScanner) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// Address returns the scanner address for logging
+func (s *ClamdScanner) Address() string {
+	return s.network + "://" + s.address
+}
+
+// Ping checks that clamd is reachable
+func (s *ClamdScanner) Ping(ctx context.Context) error {
+	reply, err := s.command(ctx, func(conn net.Conn) error {
+		_, err := conn.Write([]byte("zPING\x00"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+	return nil
+}
+
+// Scan streams content to clamd and returns the verdict. Errors reported by clamd, such as the
+// stream exceeding StreamMaxLength, are returned as errors rather than clean results.
+func (s *ClamdScanner) Scan(ctx context.Context, content io.Reader) (*Result, error) {
+	reply, err := s.command(ctx, func(conn net.Conn) error {
+		if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+			return err
+		}
+
+		buf := make([]byte, s.chunkSize)
+		size := make([]byte, 4)
+		for {
+			n, readErr := content.Read(buf)
+			if n > 0 {
+				binary.BigEndian.PutUint32(size, uint32(n))
+				if _, err := conn.Write(size); err != nil {
+					return err
+				}
+				if _, err := conn.Write(buf[:n]); err != nil {
+					return err
+				}
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				return fmt.Errorf("failed to read content: %w", readErr)
+			}
+		}
+
+		// A zero-length chunk ends the stream
+		_, err := conn.Write([]byte{0, 0, 0, 0})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Replies look like "stream: OK", "stream: Eicar-Test-Signature FOUND" or "... ERROR"
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
+
+// command connects to clamd, runs send and reads the NUL-terminated reply
+func (s *ClamdScanner) command(ctx context.Context, send func(conn net.Conn) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx, s.network, s.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd at %s: %w", s.Address(), err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := send(conn); err != nil {
+		return "", fmt.Errorf("failed to send to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && !(err == io.EOF && len(reply) > 0) {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return string(bytes.TrimSpace(bytes.TrimRight(reply, "\x00"))), nil
+}
@@ -0,0 +1,116 @@
+package antivirus
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeClamd answers one INSTREAM command on conn with reply and sends the received stream
+func fakeClamd(t *testing.T, conn net.Conn, reply string) <-chan []byte {
+	received := make(chan []byte, 1)
+	go func() {
+		defer conn.Close()
+		command := make([]byte, len("zINSTREAM\x00"))
+		if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+			t.Errorf("clamd received command %q, %v", command, err)
+			received <- nil
+			return
+		}
+
+		var stream bytes.Buffer
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(conn, size); err != nil {
+				t.Errorf("failed to read chunk size: %v", err)
+				received <- nil
+				return
+			}
+			n := binary.BigEndian.Uint32(size)
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&stream, conn, int64(n)); err != nil {
+				t.Errorf("failed to read chunk: %v", err)
+				received <- nil
+				return
+			}
+		}
+		conn.Write([]byte(reply + "\x00"))
+		received <- stream.Bytes()
+	}()
+	return received
+}
+
+func TestClamdScannerScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		want      *Result
+		wantError string
+	}{
+		{"clean", "stream: OK", &Result{}, ""},
+		{"infected", "stream: Eicar-Test-Signature FOUND", &Result{Infected: true, Signature: "Eicar-Test-Signature"}, ""},
+		{"scanner error", "INSTREAM size limit exceeded. ERROR", nil, "clamd scan failed: INSTREAM size limit exceeded. ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner, err := NewClamdScanner("tcp://clamd:3310")
+			if err != nil {
+				t.Fatalf("NewClamdScanner() error = %v", err)
+			}
+			scanner.chunkSize = 4 // Content spans several chunks
+			client, server := net.Pipe()
+			received := fakeClamd(t, server, tt.reply)
+			scanner.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+				if network != "tcp" || address != "clamd:3310" {
+					t.Errorf("dialled %s %s, want tcp clamd:3310", network, address)
+				}
+				return client, nil
+			}
+
+			content := "content streamed in chunks"
+			result, err := scanner.Scan(context.Background(), strings.NewReader(content))
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError {
+					t.Fatalf("Scan() error = %v, want %q", err, tt.wantError)
+				}
+			} else if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			} else if *result != *tt.want {
+				t.Errorf("Scan() = %+v, want %+v", result, tt.want)
+			}
+			if stream := <-received; string(stream) != content {
+				t.Errorf("clamd received %q, want %q", stream, content)
+			}
+		})
+	}
+}
+
+func TestNewClamdScannerAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"tcp://clamd:3310", "tcp://clamd:3310"},
+		{"clamd:3310", "tcp://clamd:3310"},
+		{"unix:///run/clamd.sock", "unix:///run/clamd.sock"},
+		{"/run/clamd.sock", "unix:///run/clamd.sock"},
+	}
+	for _, tt := range tests {
+		scanner, err := NewClamdScanner(tt.address)
+		if err != nil {
+			t.Fatalf("NewClamdScanner(%q) error = %v", tt.address, err)
+		}
+		if got := scanner.Address(); got != tt.want {
+			t.Errorf("NewClamdScanner(%q).Address() = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+	if _, err := NewClamdScanner("unix://"); err == nil {
+		t.Error("NewClamdScanner(\"unix://\") succeeded, want an error")
+	}
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// DuplicatesHandler handles the duplicate files report
+type DuplicatesHandler struct {
+	service *service.DuplicateService
+}
+
+// NewDuplicatesHandler creates a new duplicates handler
+func NewDuplicatesHandler(service *service.DuplicateService) *DuplicatesHandler {
+	return &DuplicatesHandler{
+		service: service,
+	}
+}
+
+// MergeDuplicatesRequest selects the canonical file and the duplicates merged into it
+type MergeDuplicatesRequest struct {
+	CanonicalID  uint64   `json:"canonical_id" binding:"required"`
+	DuplicateIDs []uint64 `json:"duplicate_ids"` // Empty merges every other file with the same content
+}
+
+// ListGroups returns groups of files with the same content, largest first
+func (h *DuplicatesHandler) ListGroups(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	groups, total, err := h.service.ListGroups(c.Request.Context(), pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve duplicate groups",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      groups,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetGroup returns the files sharing one content MD5
+func (h *DuplicatesHandler) GetGroup(c *gin.Context) {
+	group, err := h.service.GetGroup(c.Request.Context(), c.Param("md5"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Duplicate group not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    group,
+	})
+}
+
+// Merge folds the duplicates' metadata into the canonical file and removes the duplicates
+func (h *DuplicatesHandler) Merge(c *gin.Context) {
+	var req MergeDuplicatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	username := c.GetString("username")
+	result, err := h.service.Merge(c.Request.Context(), req.CanonicalID, req.DuplicateIDs, username)
+	if err != nil {
+		var holdErr *service.LegalHoldError
+		if errors.As(err, &holdErr) {
+			c.JSON(http.StatusLocked, gin.H{
+				"success": false,
+				"message": "File is under legal hold",
+				"error":   err.Error(),
+				"code":    "LEGAL_HOLD",
+			})
+			return
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidMerge) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "Failed to merge duplicates",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Duplicates merged successfully",
+		"data":    result,
+	})
+}
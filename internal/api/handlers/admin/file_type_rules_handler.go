@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// FileTypeRulesHandler handles upload file type rule endpoints
+type FileTypeRulesHandler struct {
+	service *service.FileTypeRuleService
+}
+
+// NewFileTypeRulesHandler creates a new file type rules handler
+func NewFileTypeRulesHandler(service *service.FileTypeRuleService) *FileTypeRulesHandler {
+	return &FileTypeRulesHandler{
+		service: service,
+	}
+}
+
+// FileTypeRuleRequest is the request body for creating or updating a file type rule
+type FileTypeRuleRequest struct {
+	CategoryID int    `json:"category_id"` // 0 = all categories
+	Ext        string `json:"ext" binding:"required"`
+	MimeTypes  string `json:"mime_types"` // Comma-separated; empty accepts any content type
+	Type       int    `json:"type" binding:"required"`
+	MaxSize    int64  `json:"max_size"` // Bytes, 0 = unlimited
+	Enabled    *bool  `json:"enabled"`
+}
+
+// ListRules returns all stored file type rules, including category overrides
+func (h *FileTypeRulesHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve file type rules",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+		"total":   len(rules),
+	})
+}
+
+// GetRule returns a single file type rule by ID
+func (h *FileTypeRulesHandler) GetRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// CreateRule creates a new file type rule
+func (h *FileTypeRulesHandler) CreateRule(c *gin.Context) {
+	var req FileTypeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rule := &models.FileTypeRule{Enabled: true}
+	applyRuleRequest(rule, &req)
+
+	if err := h.service.CreateRule(c.Request.Context(), rule); err != nil {
+		respondRuleError(c, "Failed to create file type rule", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "File type rule created successfully",
+		"data":    rule,
+	})
+}
+
+// UpdateRule updates an existing file type rule
+func (h *FileTypeRulesHandler) UpdateRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	var req FileTypeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+	applyRuleRequest(rule, &req)
+
+	if err := h.service.UpdateRule(c.Request.Context(), rule); err != nil {
+		respondRuleError(c, "Failed to update file type rule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "File type rule updated successfully",
+		"data":    rule,
+	})
+}
+
+// DeleteRule deletes a file type rule
+func (h *FileTypeRulesHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid rule ID",
+		})
+		return
+	}
+
+	if err := h.service.DeleteRule(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete file type rule",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "File type rule deleted successfully",
+	})
+}
+
+// GetUploadRules returns the enabled rules that apply to uploads into a category (?category_id=),
+// so clients can check files before uploading them
+func (h *FileTypeRulesHandler) GetUploadRules(c *gin.Context) {
+	categoryID := 0
+	if value := c.Query("category_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid category ID",
+			})
+			return
+		}
+		categoryID = id
+	}
+
+	rules, err := h.service.EffectiveRules(c.Request.Context(), categoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve upload rules",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+		"total":   len(rules),
+	})
+}
+
+// loadRule loads the rule named by the :id parameter, writing the error response on failure
+func (h *FileTypeRulesHandler) loadRule(c *gin.Context) (*models.FileTypeRule, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid rule ID",
+		})
+		return nil, false
+	}
+
+	rule, err := h.service.GetRule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File type rule not found",
+		})
+		return nil, false
+	}
+	return rule, true
+}
+
+// applyRuleRequest copies the request fields onto the rule
+func applyRuleRequest(rule *models.FileTypeRule, req *FileTypeRuleRequest) {
+	rule.CategoryID = req.CategoryID
+	rule.Ext = req.Ext
+	rule.MimeTypes = req.MimeTypes
+	rule.Type = req.Type
+	rule.MaxSize = req.MaxSize
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+}
+
+// respondRuleError maps rule validation errors to 400 and anything else to 500
+func respondRuleError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, service.ErrInvalidFileTypeRule) {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
+	"gorm.io/gorm"
+)
+
+// LegalHoldsHandler handles legal holds and their log
+type LegalHoldsHandler struct {
+	service *service.LegalHoldService
+}
+
+// NewLegalHoldsHandler creates a new legal holds handler
+func NewLegalHoldsHandler(service *service.LegalHoldService) *LegalHoldsHandler {
+	return &LegalHoldsHandler{
+		service: service,
+	}
+}
+
+// ReleaseLegalHoldRequest is the request body for releasing a hold
+type ReleaseLegalHoldRequest struct {
+	Reason string `json:"reason" binding:"required,max=1024"`
+}
+
+// List returns legal holds, newest first, filtered by ?active=true&case_reference=&file_id=&category_id=
+func (h *LegalHoldsHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	active, _ := strconv.ParseBool(c.Query("active"))
+	fileID, _ := strconv.ParseUint(c.Query("file_id"), 10, 64)
+	categoryID, _ := strconv.Atoi(c.Query("category_id"))
+	filter := repository.LegalHoldFilter{
+		ActiveOnly:    active,
+		CaseReference: c.Query("case_reference"),
+		FileID:        fileID,
+		CategoryID:    categoryID,
+	}
+
+	holds, total, err := h.service.List(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve legal holds",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      holds,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Get returns a legal hold
+func (h *LegalHoldsHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid legal hold ID",
+		})
+		return
+	}
+
+	hold, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Legal hold not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hold,
+	})
+}
+
+// Apply places a legal hold on a file or on a category and its subcategories
+func (h *LegalHoldsHandler) Apply(c *gin.Context) {
+	var req service.LegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	hold, err := h.service.Apply(c.Request.Context(), &req, c.GetString("username"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidLegalHold):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrLegalHoldTarget):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "Failed to apply legal hold",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Legal hold applied successfully",
+		"data":    hold,
+	})
+}
+
+// Release ends a legal hold
+func (h *LegalHoldsHandler) Release(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid legal hold ID",
+		})
+		return
+	}
+
+	var req ReleaseLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	hold, err := h.service.Release(c.Request.Context(), id, c.GetString("username"), req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrLegalHoldReleased):
+			status = http.StatusConflict
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "Failed to release legal hold",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Legal hold released successfully",
+		"data":    hold,
+	})
+}
+
+// ListEvents returns the legal hold log, newest first, filtered by ?hold_id=&file_id=&action=
+func (h *LegalHoldsHandler) ListEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	holdID, _ := strconv.ParseUint(c.Query("hold_id"), 10, 64)
+	fileID, _ := strconv.ParseUint(c.Query("file_id"), 10, 64)
+	filter := repository.LegalHoldEventFilter{
+		HoldID: holdID,
+		FileID: fileID,
+		Action: c.Query("action"),
+	}
+
+	events, total, err := h.service.Events(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve legal hold log",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      events,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
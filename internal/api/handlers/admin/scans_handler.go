@@ -0,0 +1,143 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// ScansHandler handles malware scan review endpoints
+type ScansHandler struct {
+	service *service.ScanService
+}
+
+// NewScansHandler creates a new scans handler
+func NewScansHandler(service *service.ScanService) *ScansHandler {
+	return &ScansHandler{
+		service: service,
+	}
+}
+
+// ListFiles returns files by scan status (?status=infected|pending, default infected)
+func (h *ScansHandler) ListFiles(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ScanStatusInfected)
+	switch status {
+	case models.ScanStatusPending, models.ScanStatusClean, models.ScanStatusInfected, models.ScanStatusSkipped:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid scan status",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	files, total, err := h.service.ListFiles(c.Request.Context(), status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      files,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"scanning":  h.service.Enabled(),
+	})
+}
+
+// RescanFile scans a stored file again; infected files are quarantined
+func (h *ScansHandler) RescanFile(c *gin.Context) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid file ID",
+		})
+		return
+	}
+
+	if !h.service.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Malware scanning is not configured",
+		})
+		return
+	}
+
+	file, err := h.service.Rescan(c.Request.Context(), fileID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if file != nil {
+			status = http.StatusBadGateway // The scanner failed; the file stays pending
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "Failed to scan file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"id":          file.ID,
+			"status":      file.Status,
+			"scan_status": file.ScanStatus,
+			"scan_result": file.ScanResult,
+			"scanned_at":  file.ScannedAt,
+		},
+	})
+}
+
+// RescanPending scans files whose earlier scan did not complete (?limit=, default 100)
+func (h *ScansHandler) RescanPending(c *gin.Context) {
+	if !h.service.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Malware scanning is not configured",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	scanned, infected, err := h.service.RescanPending(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to rescan pending files",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"scanned":  scanned,
+			"infected": infected,
+		},
+	})
+}
@@ -0,0 +1,267 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// SidecarTemplatesHandler handles sidecar mapping template endpoints
+type SidecarTemplatesHandler struct {
+	service *service.SidecarService
+}
+
+// NewSidecarTemplatesHandler creates a new sidecar templates handler
+func NewSidecarTemplatesHandler(service *service.SidecarService) *SidecarTemplatesHandler {
+	return &SidecarTemplatesHandler{
+		service: service,
+	}
+}
+
+// SidecarTemplateRequest is the request body for creating or updating a sidecar template
+type SidecarTemplateRequest struct {
+	Name        string                  `json:"name" binding:"required"`
+	Format      string                  `json:"format" binding:"required"` // xml, json, csv
+	Description string                  `json:"description"`
+	Mappings    []models.SidecarMapping `json:"mappings" binding:"required"`
+	Enabled     *bool                   `json:"enabled"`
+}
+
+// ListTemplates returns all sidecar templates
+func (h *SidecarTemplatesHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve sidecar templates",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+		"total":   len(templates),
+	})
+}
+
+// GetTemplate returns a single sidecar template by ID
+func (h *SidecarTemplatesHandler) GetTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// CreateTemplate creates a new sidecar template
+func (h *SidecarTemplatesHandler) CreateTemplate(c *gin.Context) {
+	var req SidecarTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	template := &models.SidecarTemplate{Enabled: true}
+	if err := applyTemplateRequest(template, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid mappings",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.CreateTemplate(c.Request.Context(), template); err != nil {
+		respondTemplateError(c, "Failed to create sidecar template", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Sidecar template created successfully",
+		"data":    template,
+	})
+}
+
+// UpdateTemplate updates an existing sidecar template
+func (h *SidecarTemplatesHandler) UpdateTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req SidecarTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := applyTemplateRequest(template, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid mappings",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.UpdateTemplate(c.Request.Context(), template); err != nil {
+		respondTemplateError(c, "Failed to update sidecar template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sidecar template updated successfully",
+		"data":    template,
+	})
+}
+
+// DeleteTemplate deletes a sidecar template
+func (h *SidecarTemplatesHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid template ID",
+		})
+		return
+	}
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete sidecar template",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sidecar template deleted successfully",
+	})
+}
+
+// TestTemplate applies a template to an uploaded "sidecar" part and returns the mapped values
+// or the validation errors, without creating a file
+func (h *SidecarTemplatesHandler) TestTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("sidecar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "No sidecar uploaded",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Failed to read sidecar",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.ApplyTemplate(c.Request.Context(), template, header.Filename, content)
+	if err != nil {
+		var validationErr *service.SidecarValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"message": "Sidecar metadata is invalid",
+				"code":    "INVALID_SIDECAR",
+				"data": gin.H{
+					"sidecar": validationErr.Sidecar,
+					"errors":  validationErr.Errors,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to apply sidecar template",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// loadTemplate loads the template named by the :id parameter, writing the error response on failure
+func (h *SidecarTemplatesHandler) loadTemplate(c *gin.Context) (*models.SidecarTemplate, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid template ID",
+		})
+		return nil, false
+	}
+
+	template, err := h.service.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Sidecar template not found",
+		})
+		return nil, false
+	}
+	return template, true
+}
+
+// applyTemplateRequest copies the request fields onto the template
+func applyTemplateRequest(template *models.SidecarTemplate, req *SidecarTemplateRequest) error {
+	template.Name = req.Name
+	template.Format = req.Format
+	template.Description = req.Description
+	if req.Enabled != nil {
+		template.Enabled = *req.Enabled
+	}
+	return template.SetMappings(req.Mappings)
+}
+
+// respondTemplateError maps template validation errors to 400 and anything else to 500
+func respondTemplateError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, service.ErrInvalidSidecarTemplate) {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// SimilarityHandler handles the near-duplicate report
+type SimilarityHandler struct {
+	service *service.FingerprintService
+}
+
+// NewSimilarityHandler creates a new similarity handler
+func NewSimilarityHandler(service *service.FingerprintService) *SimilarityHandler {
+	return &SimilarityHandler{
+		service: service,
+	}
+}
+
+// Report lists pairs of likely near-duplicates, closest first (?max_distance=0-32, default 10)
+func (h *SimilarityHandler) Report(c *gin.Context) {
+	maxDistance, err := strconv.Atoi(c.DefaultQuery("max_distance", strconv.Itoa(service.DefaultSimilarityDistance)))
+	if err != nil || maxDistance < 0 || maxDistance > service.MaxSimilarityDistance {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "max_distance must be between 0 and " + strconv.Itoa(service.MaxSimilarityDistance),
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	pairs, total, err := h.service.Report(c.Request.Context(), maxDistance, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to build near-duplicate report",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"data":         pairs,
+		"total":        total,
+		"page":         page,
+		"page_size":    pageSize,
+		"max_distance": maxDistance,
+	})
+}
+
+// Backfill queues fingerprint jobs for images and videos without one (?limit=, default 1000)
+func (h *SimilarityHandler) Backfill(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if limit < 1 || limit > 100000 {
+		limit = 1000
+	}
+
+	queued, err := h.service.Backfill(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to queue fingerprint jobs",
+			"error":   err.Error(),
+			"data": gin.H{
+				"queued": queued,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"queued": queued,
+		},
+	})
+}
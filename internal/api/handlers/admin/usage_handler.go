@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// UsageHandler handles storage usage and quota endpoints
+type UsageHandler struct {
+	service *service.QuotaService
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(service *service.QuotaService) *UsageHandler {
+	return &UsageHandler{
+		service: service,
+	}
+}
+
+// GetStorageUsage returns storage usage per group and user (?group_id= filters to one group)
+func (h *UsageHandler) GetStorageUsage(c *gin.Context) {
+	groupID := 0
+	if value := c.Query("group_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid group ID",
+			})
+			return
+		}
+		groupID = id
+	}
+
+	report, err := h.service.GetUsageReport(c.Request.Context(), groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve storage usage",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"total":   len(report),
+	})
+}
+
+// GetMyQuota returns the current user's storage usage and quota
+func (h *UsageHandler) GetMyQuota(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Not authenticated",
+		})
+		return
+	}
+
+	quota, err := h.service.GetUserUsage(c.Request.Context(), int(userID.(uint)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve storage quota",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    quota,
+	})
+}
+
+// RecalculateUsage rebuilds the usage counters from the files table
+func (h *UsageHandler) RecalculateUsage(c *gin.Context) {
+	if err := h.service.Recalculate(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to recalculate storage usage",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Storage usage recalculated",
+	})
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// WorkflowsHandler handles workflow definition endpoints
+type WorkflowsHandler struct {
+	service *service.WorkflowService
+}
+
+// NewWorkflowsHandler creates a new workflows handler
+func NewWorkflowsHandler(service *service.WorkflowService) *WorkflowsHandler {
+	return &WorkflowsHandler{
+		service: service,
+	}
+}
+
+// WorkflowRequest is the request body for creating or updating a workflow
+type WorkflowRequest struct {
+	Name         string                       `json:"name" binding:"required"`
+	Description  string                       `json:"description"`
+	States       []models.WorkflowState       `json:"states" binding:"required"`
+	Transitions  []models.WorkflowTransition  `json:"transitions"`
+	ReviewStages []models.WorkflowReviewStage `json:"review_stages"` // Approvals required before publishing
+	IsDefault    bool                         `json:"is_default"`    // Used by categories without a workflow
+}
+
+// ListWorkflows returns all workflows
+func (h *WorkflowsHandler) ListWorkflows(c *gin.Context) {
+	workflows, err := h.service.ListWorkflows(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve workflows",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    workflows,
+		"total":   len(workflows),
+	})
+}
+
+// GetWorkflow returns a single workflow by ID
+func (h *WorkflowsHandler) GetWorkflow(c *gin.Context) {
+	workflow, ok := h.loadWorkflow(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    workflow,
+	})
+}
+
+// CreateWorkflow creates a new workflow
+func (h *WorkflowsHandler) CreateWorkflow(c *gin.Context) {
+	var req WorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	workflow := &models.Workflow{}
+	if err := applyWorkflowRequest(workflow, &req); err != nil {
+		respondWorkflowError(c, "Invalid workflow", err)
+		return
+	}
+
+	if err := h.service.CreateWorkflow(c.Request.Context(), workflow); err != nil {
+		respondWorkflowError(c, "Failed to create workflow", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Workflow created successfully",
+		"data":    workflow,
+	})
+}
+
+// UpdateWorkflow updates an existing workflow
+func (h *WorkflowsHandler) UpdateWorkflow(c *gin.Context) {
+	workflow, ok := h.loadWorkflow(c)
+	if !ok {
+		return
+	}
+
+	var req WorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := applyWorkflowRequest(workflow, &req); err != nil {
+		respondWorkflowError(c, "Invalid workflow", err)
+		return
+	}
+
+	if err := h.service.UpdateWorkflow(c.Request.Context(), workflow); err != nil {
+		respondWorkflowError(c, "Failed to update workflow", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Workflow updated successfully",
+		"data":    workflow,
+	})
+}
+
+// DeleteWorkflow deletes a workflow that is neither the default nor assigned to a category
+func (h *WorkflowsHandler) DeleteWorkflow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid workflow ID",
+		})
+		return
+	}
+
+	if err := h.service.DeleteWorkflow(c.Request.Context(), id); err != nil {
+		respondWorkflowError(c, "Failed to delete workflow", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Workflow deleted successfully",
+	})
+}
+
+// loadWorkflow loads the workflow named by the :id parameter, writing the error response on failure
+func (h *WorkflowsHandler) loadWorkflow(c *gin.Context) (*models.Workflow, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid workflow ID",
+		})
+		return nil, false
+	}
+
+	workflow, err := h.service.GetWorkflow(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Workflow not found",
+		})
+		return nil, false
+	}
+	return workflow, true
+}
+
+// applyWorkflowRequest copies the request fields onto the workflow
+func applyWorkflowRequest(workflow *models.Workflow, req *WorkflowRequest) error {
+	workflow.Name = req.Name
+	workflow.Description = req.Description
+	workflow.IsDefault = req.IsDefault
+	if err := workflow.SetStates(req.States); err != nil {
+		return err
+	}
+	if err := workflow.SetTransitions(req.Transitions); err != nil {
+		return err
+	}
+	return workflow.SetReviewStages(req.ReviewStages)
+}
+
+// respondWorkflowError maps workflow validation errors to 400, deleting a workflow in use to 409
+// and anything else to 500
+func respondWorkflowError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidWorkflow):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrWorkflowInUse):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
+	"gorm.io/gorm"
+)
+
+// AssignmentHandler handles review assignments, their due dates and the current user's
+// notifications
+type AssignmentHandler struct {
+	assignmentService   *service.ReviewAssignmentService
+	notificationService *service.NotificationService
+	fileService         *service.FileService
+}
+
+// NewAssignmentHandler creates a new assignment handler
+func NewAssignmentHandler(assignmentService *service.ReviewAssignmentService, notificationService *service.NotificationService, fileService *service.FileService) *AssignmentHandler {
+	return &AssignmentHandler{
+		assignmentService:   assignmentService,
+		notificationService: notificationService,
+		fileService:         fileService,
+	}
+}
+
+// AssignRequest names the reviewer of a pending file, or asks for the next member of its
+// category's review group
+type AssignRequest struct {
+	ReviewerID int  `json:"reviewer_id"`
+	RoundRobin bool `json:"round_robin"`
+}
+
+// AssignReviewer makes a user the reviewer of a pending file, replacing its current assignment
+func (h *AssignmentHandler) AssignReviewer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid file ID",
+			})
+			return
+		}
+
+		var req AssignRequest
+		if err := c.ShouldBindJSON(&req); err != nil || (req.ReviewerID > 0) == req.RoundRobin {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Give either reviewer_id or round_robin",
+			})
+			return
+		}
+
+		username, _ := fetchRequester(c)
+		var assignment *models.ReviewAssignment
+		if req.RoundRobin {
+			assignment, err = h.assignmentService.AssignInTurn(c.Request.Context(), fileID, username)
+		} else {
+			assignment, err = h.assignmentService.Assign(c.Request.Context(), fileID, req.ReviewerID, username)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{
+					"success": false,
+					"message": "File not found",
+				})
+			case errors.Is(err, service.ErrInvalidReviewer), errors.Is(err, service.ErrNoReviewGroup):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			case errors.Is(err, service.ErrFileNotPending), errors.Is(err, service.ErrAssignmentConflict):
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Failed to assign reviewer",
+					"error":   err.Error(),
+				})
+			}
+			return
+		}
+
+		fmt.Printf("✓ Reviewer assigned to file %d by %s\n", fileID, username)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Reviewer assigned",
+			"data":    assignment,
+		})
+	}
+}
+
+// GetFileAssignments lists a file's review assignments, oldest first
+func (h *AssignmentHandler) GetFileAssignments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid file ID",
+			})
+			return
+		}
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		_, admin := fetchRequester(c)
+		if !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "File not found",
+			})
+			return
+		}
+
+		assignments, err := h.assignmentService.FileAssignments(c.Request.Context(), fileID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve review assignments",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    assignments,
+		})
+	}
+}
+
+// MyAssignments lists the open review assignments of the current user, newest first
+func (h *AssignmentHandler) MyAssignments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize := assignmentPage(c)
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+
+		filter := repository.ReviewAssignmentFilter{ReviewerID: int(uid), OpenOnly: true}
+		assignments, total, err := h.assignmentService.List(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve review assignments",
+				"error":   err.Error(),
+			})
+			return
+		}
+		respondAssignments(c, assignments, total, page, pageSize)
+	}
+}
+
+// ListOverdue lists the open review assignments past their due date, most overdue first.
+// Filters: ?reviewer_id and ?category_id.
+func (h *AssignmentHandler) ListOverdue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize := assignmentPage(c)
+
+		var filter repository.ReviewAssignmentFilter
+		for param, target := range map[string]*int{"reviewer_id": &filter.ReviewerID, "category_id": &filter.CategoryID} {
+			value := c.Query(param)
+			if value == "" {
+				continue
+			}
+			id, err := strconv.Atoi(value)
+			if err != nil || id < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid " + param,
+				})
+				return
+			}
+			*target = id
+		}
+
+		assignments, total, err := h.assignmentService.Overdue(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve overdue reviews",
+				"error":   err.Error(),
+			})
+			return
+		}
+		respondAssignments(c, assignments, total, page, pageSize)
+	}
+}
+
+// ListNotifications lists the current user's notifications, newest first; ?unread=true for
+// unread ones only
+func (h *AssignmentHandler) ListNotifications() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize := assignmentPage(c)
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+
+		notifications, total, err := h.notificationService.List(c.Request.Context(), int(uid), c.Query("unread") == "true", pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve notifications",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    notifications,
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read
+func (h *AssignmentHandler) MarkNotificationRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid notification ID",
+			})
+			return
+		}
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+
+		found, err := h.notificationService.MarkRead(c.Request.Context(), id, int(uid))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to update notification",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Notification not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Notification marked as read",
+		})
+	}
+}
+
+// assignmentPage parses ?page and ?page_size
+func assignmentPage(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// respondAssignments writes a page of review assignments
+func respondAssignments(c *gin.Context, assignments []*models.ReviewAssignment, total int64, page, pageSize int) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    assignments,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// BulkHandler handles bulk operations on files
+type BulkHandler struct {
+	bulkService *service.BulkService
+}
+
+// NewBulkHandler creates a new bulk handler
+func NewBulkHandler(bulkService *service.BulkService) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+// BulkRequest represents a bulk action on files given by ID or selected by a query
+type BulkRequest struct {
+	Action  string             `json:"action" binding:"required"` // submit, publish, reject, move, access or catalog
+	FileIDs []uint64           `json:"file_ids"`
+	Query   *service.BulkQuery `json:"query"` // Used when file_ids is empty
+	Params  service.BulkParams `json:"params"`
+}
+
+// SubmitBulk queues a bulk action. Each file is checked and changed on its own when the job runs;
+// the results are available from the job and its report.
+func (h *BulkHandler) SubmitBulk() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BulkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request parameters",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		username, admin := fetchRequester(c)
+
+		job, err := h.bulkService.Submit(c.Request.Context(), &service.BulkRequest{
+			Action:   req.Action,
+			FileIDs:  req.FileIDs,
+			Query:    req.Query,
+			Params:   req.Params,
+			UserID:   int(uid),
+			Username: username,
+			IsAdmin:  admin,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrBulkPermission):
+				c.JSON(http.StatusForbidden, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			case errors.Is(err, service.ErrInvalidBulkAction),
+				errors.Is(err, service.ErrEmptyBulkSelection),
+				errors.Is(err, service.ErrBulkTooLarge):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Failed to queue bulk job",
+					"error":   err.Error(),
+				})
+			}
+			return
+		}
+
+		fmt.Printf("✓ Bulk job %d (%s, %d files) queued by %s\n", job.ID, job.Action, job.Total, username)
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "Bulk job queued",
+			"data":    job,
+		})
+	}
+}
+
+// GetBulkJob returns a bulk job with a page of its per-file results, filtered by ?status;
+// users see only their own jobs
+func (h *BulkHandler) GetBulkJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := h.requestedJob(c)
+		if !ok {
+			return
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 500 {
+			pageSize = 50
+		}
+
+		status := c.Query("status")
+		if status != "" && status != models.BulkItemPending && status != models.BulkItemSucceeded && status != models.BulkItemFailed {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid status, use pending, succeeded or failed",
+			})
+			return
+		}
+
+		items, total, err := h.bulkService.ListItems(c.Request.Context(), job.ID, status, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve bulk job results",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"job":   job,
+				"items": items,
+			},
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// ListBulkJobs lists the current user's bulk jobs, or everyone's for administrators (?all=true)
+func (h *BulkHandler) ListBulkJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		username, admin := fetchRequester(c)
+		if admin && c.Query("all") == "true" {
+			username = ""
+		}
+
+		jobs, total, err := h.bulkService.ListJobs(c.Request.Context(), username, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve bulk jobs",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    jobs,
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// DownloadBulkReport downloads a bulk job's per-file results as CSV
+func (h *BulkHandler) DownloadBulkReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := h.requestedJob(c)
+		if !ok {
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"bulk-job-%d.csv\"", job.ID))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := h.bulkService.WriteReport(c.Request.Context(), job, c.Writer); err != nil {
+			fmt.Printf("Failed to write report of bulk job %d: %v\n", job.ID, err)
+		}
+	}
+}
+
+// requestedJob loads the job in the :id parameter if the user may see it, writing the error
+// response otherwise
+func (h *BulkHandler) requestedJob(c *gin.Context) (*models.BulkJob, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid job ID",
+		})
+		return nil, false
+	}
+
+	job, err := h.bulkService.GetJob(c.Request.Context(), id)
+	username, admin := fetchRequester(c)
+	if err != nil || (!admin && job.Username != username) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Bulk job not found",
+		})
+		return nil, false
+	}
+	return job, true
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -113,6 +114,14 @@ func (h *CategoryHandler) CreateCategory() gin.HandlerFunc {
 		}
 
 		if err := h.categoryService.CreateCategory(c.Request.Context(), &category); err != nil {
+			if errors.Is(err, service.ErrInvalidDuplicatePolicy) || errors.Is(err, service.ErrUnknownWorkflow) ||
+				errors.Is(err, service.ErrInvalidTrashRetention) || errors.Is(err, service.ErrInvalidReviewSettings) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Failed to create category",
@@ -152,6 +161,14 @@ func (h *CategoryHandler) UpdateCategory() gin.HandlerFunc {
 		}
 
 		if err := h.categoryService.UpdateCategory(c.Request.Context(), uint(categoryID), updates); err != nil {
+			if errors.Is(err, service.ErrInvalidDuplicatePolicy) || errors.Is(err, service.ErrUnknownWorkflow) ||
+				errors.Is(err, service.ErrInvalidTrashRetention) || errors.Is(err, service.ErrInvalidReviewSettings) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Failed to update category",
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// CommentHandler handles review comments and annotations on files
+type CommentHandler struct {
+	commentService *service.CommentService
+	fileService    *service.FileService
+}
+
+// NewCommentHandler creates a new comment handler
+func NewCommentHandler(commentService *service.CommentService, fileService *service.FileService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		fileService:    fileService,
+	}
+}
+
+// EditCommentRequest represents a comment's new text
+type EditCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// ListComments lists a file's comment threads with their replies, in timecode order;
+// ?unresolved=true for open threads only
+func (h *CommentHandler) ListComments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.commentFile(c)
+		if !ok {
+			return
+		}
+
+		threads, err := h.commentService.List(c.Request.Context(), file.ID, c.Query("unresolved") == "true")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve comments",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    threads,
+		})
+	}
+}
+
+// AddComment comments on a file, optionally at a timecode range and a region of the frame, or
+// replies to a thread (parent_id)
+func (h *CommentHandler) AddComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.commentFile(c)
+		if !ok {
+			return
+		}
+
+		var req service.CommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		username, _ := fetchRequester(c)
+		comment, err := h.commentService.Add(c.Request.Context(), file.ID, &req, int(uid), username)
+		if err != nil {
+			respondCommentError(c, "Failed to add comment", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"message": "Comment added",
+			"data":    comment,
+		})
+	}
+}
+
+// EditComment changes the text of one of the current user's comments
+func (h *CommentHandler) EditComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.commentFile(c)
+		if !ok {
+			return
+		}
+		commentID, ok := commentIDParam(c)
+		if !ok {
+			return
+		}
+
+		var req EditCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		comment, err := h.commentService.Edit(c.Request.Context(), file.ID, commentID, req.Body, int(uid))
+		if err != nil {
+			respondCommentError(c, "Failed to edit comment", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Comment updated",
+			"data":    comment,
+		})
+	}
+}
+
+// DeleteComment deletes a comment, with its replies for a thread
+func (h *CommentHandler) DeleteComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.commentFile(c)
+		if !ok {
+			return
+		}
+		commentID, ok := commentIDParam(c)
+		if !ok {
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		_, admin := fetchRequester(c)
+		if err := h.commentService.Delete(c.Request.Context(), file.ID, commentID, int(uid), admin); err != nil {
+			respondCommentError(c, "Failed to delete comment", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Comment deleted",
+		})
+	}
+}
+
+// ResolveComment resolves a comment thread
+func (h *CommentHandler) ResolveComment() gin.HandlerFunc {
+	return h.setResolved(true)
+}
+
+// ReopenComment reopens a resolved comment thread
+func (h *CommentHandler) ReopenComment() gin.HandlerFunc {
+	return h.setResolved(false)
+}
+
+func (h *CommentHandler) setResolved(resolved bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.commentFile(c)
+		if !ok {
+			return
+		}
+		commentID, ok := commentIDParam(c)
+		if !ok {
+			return
+		}
+
+		username, _ := fetchRequester(c)
+		comment, err := h.commentService.Resolve(c.Request.Context(), file.ID, commentID, resolved, username)
+		if err != nil {
+			respondCommentError(c, "Failed to update comment", err)
+			return
+		}
+
+		message := "Comment reopened"
+		if resolved {
+			message = "Comment resolved"
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": message,
+			"data":    comment,
+		})
+	}
+}
+
+// ExportComments downloads a file's comment threads as a marker list for editors:
+// ?format=csv (default) or edl, with timecodes at ?fps (default 25)
+func (h *CommentHandler) ExportComments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.commentFile(c)
+		if !ok {
+			return
+		}
+
+		fps := service.DefaultMarkerFPS
+		if value := c.Query("fps"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 120 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid fps, use a whole frame rate from 1 to 120",
+				})
+				return
+			}
+			fps = parsed
+		}
+
+		format := c.DefaultQuery("format", "csv")
+		var write func() error
+		switch format {
+		case "csv":
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			write = func() error { return h.commentService.WriteCSV(c.Request.Context(), file, fps, c.Writer) }
+		case "edl":
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			write = func() error { return h.commentService.WriteEDL(c.Request.Context(), file, fps, c.Writer) }
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid format, use csv or edl",
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"file-%d-comments.%s\"", file.ID, format))
+		c.Status(http.StatusOK)
+		if err := write(); err != nil {
+			fmt.Printf("Failed to export comments of file %d: %v\n", file.ID, err)
+		}
+	}
+}
+
+// commentFile loads the file in the :id parameter if the user may see it, writing the error
+// response otherwise
+func (h *CommentHandler) commentFile(c *gin.Context) (*models.Files, bool) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid file ID",
+		})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	_, admin := fetchRequester(c)
+	file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
+	if err != nil || !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File not found",
+		})
+		return nil, false
+	}
+	return file, true
+}
+
+// commentIDParam parses the :comment_id parameter, writing the error response if it is invalid
+func commentIDParam(c *gin.Context) (uint64, bool) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid comment ID",
+		})
+		return 0, false
+	}
+	return commentID, true
+}
+
+// respondCommentError writes the response for a failed comment operation
+func respondCommentError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidComment):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+	case errors.Is(err, service.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Comment not found",
+		})
+	case errors.Is(err, service.ErrCommentNotAuthor):
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+	case errors.Is(err, service.ErrCommentReply), errors.Is(err, service.ErrCommentState):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": message,
+			"error":   err.Error(),
+		})
+	}
+}
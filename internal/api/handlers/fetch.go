@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// FetchHandler handles server-side ingest from URLs
+type FetchHandler struct {
+	fetchService *service.FetchService
+}
+
+// NewFetchHandler creates a new fetch handler
+func NewFetchHandler(fetchService *service.FetchService) *FetchHandler {
+	return &FetchHandler{
+		fetchService: fetchService,
+	}
+}
+
+// FetchRequest represents a URL to ingest with its upload metadata
+type FetchRequest struct {
+	URL         string `json:"url" binding:"required"`
+	Checksum    string `json:"checksum"` // Optional, e.g. sha256:<hex>
+	CategoryID  uint   `json:"category_id" binding:"required"`
+	Title       string `json:"title"` // Defaults to the source filename
+	Type        int    `json:"type"`  // 1=video, 2=audio, 3=image, 4=rich; 0 = detect
+	Level       int    `json:"level"`
+	Groups      string `json:"groups"`
+	CatalogInfo string `json:"catalog_info"`
+}
+
+// Fetch queues a server-side download of a remote file
+func (h *FetchHandler) Fetch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req FetchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request parameters",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		username := ""
+		if value, exists := c.Get("username"); exists {
+			username, _ = value.(string)
+		}
+
+		job, err := h.fetchService.Submit(c.Request.Context(), &service.FetchRequest{
+			URL:         req.URL,
+			Checksum:    req.Checksum,
+			CategoryID:  int(req.CategoryID),
+			Title:       req.Title,
+			Type:        req.Type,
+			Level:       req.Level,
+			Groups:      req.Groups,
+			CatalogInfo: req.CatalogInfo,
+			Username:    username,
+		})
+		if err != nil {
+			var validationErr *service.UploadValidationError
+			switch {
+			case errors.Is(err, service.ErrFetchDisabled):
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"success": false,
+					"message": "Server-side fetch is not enabled",
+				})
+			case errors.As(err, &validationErr):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": validationErr.Message,
+				})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Failed to queue fetch",
+					"error":   err.Error(),
+				})
+			}
+			return
+		}
+
+		fmt.Printf("✓ Fetch job %d queued by %s\n", job.ID, username)
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "Fetch queued",
+			"data":    job,
+		})
+	}
+}
+
+// GetFetchJob returns the status of a fetch job; users see only their own jobs
+func (h *FetchHandler) GetFetchJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid job ID",
+			})
+			return
+		}
+
+		job, err := h.fetchService.GetJob(c.Request.Context(), id)
+		username, admin := fetchRequester(c)
+		if err != nil || (!admin && job.Username != username) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Fetch job not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    job,
+		})
+	}
+}
+
+// ListFetchJobs lists the current user's fetch jobs, or everyone's for administrators (?all=true)
+func (h *FetchHandler) ListFetchJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		username, admin := fetchRequester(c)
+		if admin && c.Query("all") == "true" {
+			username = ""
+		}
+
+		jobs, total, err := h.fetchService.ListJobs(c.Request.Context(), username, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve fetch jobs",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    jobs,
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// fetchRequester returns the current username and whether the user is an administrator
+func fetchRequester(c *gin.Context) (string, bool) {
+	username, _ := c.Get("username")
+	isAdmin, _ := c.Get("is_admin")
+	name, _ := username.(string)
+	admin, _ := isAdmin.(bool)
+	return name, admin
+}
@@ -2,11 +2,11 @@ package handlers
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"io"
+	"mime"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -26,44 +26,41 @@ type FileHandler struct {
 	fileService    *service.FileService
 	storageService storage.StorageService
 	queueService   queue.QueueService
-	allowedTypes   map[string][]string
-	maxFileSize    int64
+	uploadService  *service.UploadService
 }
 
-// NewFileHandler creates a new file handler
-func NewFileHandler(fileService *service.FileService, storageService storage.StorageService, queueService queue.QueueService) *FileHandler {
-	// Define allowed file types per category
-	allowedTypes := map[string][]string{
-		"video": {".mp4", ".avi", ".mov", ".wmv", ".flv", ".mkv", ".mpg", ".mpeg"},
-		"audio": {".mp3", ".wav", ".wma", ".aac", ".flac", ".ogg", ".m4a"},
-		"image": {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".webp"},
-		"rich":  {".swf", ".pdf", ".doc", ".docx", ".ppt", ".pptx"},
-	}
-
-	return &FileHandler{
+// NewFileHandler creates a new file handler; size and type limits come from the file type rules
+func NewFileHandler(fileService *service.FileService, storageService storage.StorageService, queueService queue.QueueService, fileTypeRules *service.FileTypeRuleService) *FileHandler {
+	h := &FileHandler{
 		fileService:    fileService,
 		storageService: storageService,
 		queueService:   queueService,
-		allowedTypes:   allowedTypes,
-		maxFileSize:    500 * 1024 * 1024, // 500MB default
+		uploadService:  service.NewUploadService(fileService, storageService, queueService),
+	}
+	h.uploadService.SetTranscodeFallback(h.syncTranscodeVideo)
+	if fileTypeRules != nil {
+		h.uploadService.SetFileTypeRules(fileTypeRules)
 	}
+
+	return h
 }
 
 // UploadRequest represents file upload metadata
 type UploadRequest struct {
-	CategoryID uint   `form:"category_id" binding:"required"`
-	Title      string `form:"title" binding:"required"`
-	Type       int    `form:"type"` // 1=video, 2=audio, 3=image, 4=rich
+	CategoryID      uint   `form:"category_id" binding:"required"`
+	Title           string `form:"title"`            // Required unless mapped from the sidecar
+	Type            int    `form:"type"`             // 1=video, 2=audio, 3=image, 4=rich
+	SidecarTemplate string `form:"sidecar_template"` // Mapping template for the optional "sidecar" part
 }
 
 // Upload handles file upload
 func (h *FileHandler) Upload() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Parse multipart form
-		if err := c.Request.ParseMultipartForm(h.maxFileSize); err != nil {
+		// Parse multipart form; parts beyond 32MB are buffered on disk
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
-				"message": "File too large or invalid form data",
+				"message": "Invalid form data",
 				"error":   err.Error(),
 			})
 			return
@@ -92,205 +89,93 @@ func (h *FileHandler) Upload() gin.HandlerFunc {
 			return
 		}
 
-		// Validate file size
-		if header.Size > h.maxFileSize {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"message": fmt.Sprintf("File size exceeds maximum allowed size of %d MB", h.maxFileSize/(1024*1024)),
-			})
-			return
-		}
-
-		// Get file extension
-		ext := strings.ToLower(filepath.Ext(header.Filename))
-		if ext == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"message": "File must have an extension",
-			})
-			return
-		}
-
-		// Determine file type if not provided
-		fileType := req.Type
-		if fileType == 0 {
-			fileType = h.determineFileType(ext)
-			if fileType == 0 {
+		// Optional metadata sidecar (XML/JSON/CSV) delivered with the file
+		var sidecar *service.SidecarInput
+		if sidecarFile, sidecarHeader, err := c.Request.FormFile("sidecar"); err == nil {
+			content, err := io.ReadAll(sidecarFile)
+			sidecarFile.Close()
+			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"success": false,
-					"message": fmt.Sprintf("Unsupported file type: %s", ext),
+					"message": "Failed to read sidecar",
+					"error":   err.Error(),
 				})
 				return
 			}
-		}
-
-		// Validate file type
-		if !h.validateFileType(fileType, ext) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"message": fmt.Sprintf("File extension %s not allowed for type %d", ext, fileType),
-			})
-			return
-		}
-
-		// Calculate MD5 hash of file content
-		hash := md5.New()
-		if _, err := io.Copy(hash, file); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to process file",
-				"error":   err.Error(),
-			})
-			return
-		}
-		md5Hash := fmt.Sprintf("%x", hash.Sum(nil))
-
-		// Reset file pointer
-		file.Seek(0, 0)
-
-		// Generate storage path (MD5-based directory structure)
-		timestamp := time.Now().Unix()
-		dirHash := md5.Sum([]byte(fmt.Sprintf("%s_%d", header.Filename, timestamp)))
-		dirName := fmt.Sprintf("%x", dirHash)
-		fileName := fmt.Sprintf("%s%s", md5Hash, ext)
-		storagePath := filepath.Join(dirName, fileName)
-
-		// Prepare metadata for storage
-		metadata := map[string]string{
-			"original-filename": header.Filename,
-			"content-type":      header.Header.Get("Content-Type"),
-			"title":             req.Title,
-		}
-
-		// Upload file to storage
-		uploadedPath, err := h.storageService.Upload(c.Request.Context(), storagePath, file, metadata)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to upload file to storage",
-				"error":   err.Error(),
-			})
-			return
+			sidecar = &service.SidecarInput{
+				Filename: sidecarHeader.Filename,
+				Content:  content,
+				Template: req.SidecarTemplate,
+			}
 		}
 
 		// Get current user
-		userID, _ := c.Get("user_id")
-		username, _ := c.Get("username")
-		
-		// Get username string safely
-		var usernameStr string
-		if username != nil {
-			usernameStr = username.(string)
-		} else {
-			usernameStr = "anonymous" // Default username if not authenticated
-		}
-
-		// Create file record
-		fileRecord := &models.Files{
-			CategoryID:     int(req.CategoryID),
-			Type:           fileType,
-			Title:          req.Title,
-			Name:           md5Hash,
-			Ext:            ext,
-			Size:           header.Size,
-			Path:           uploadedPath,
-			Status:         0, // New
-			Level:          1, // Default level
-			UploadUsername: usernameStr,
-			UploadAt:       int(time.Now().Unix()),
-			Groups:         "all", // Default to all groups
-		}
-
-		if userID != nil {
-			// Get user's group for access control
-			// TODO: Implement group lookup
-			fileRecord.Groups = "all" // Default to all groups for now
-		}
-
-		// Save file record to database
-		if err := h.fileService.CreateFile(c.Request.Context(), fileRecord); err != nil {
-			// Check if it's a duplicate file error
-			if dupErr, ok := err.(*service.DuplicateFileError); ok {
-				// Return conflict status with existing file info
-				c.JSON(http.StatusConflict, gin.H{
+		username := ""
+		if value, exists := c.Get("username"); exists {
+			username, _ = value.(string)
+		}
+
+		// Store the file and create its record through the shared upload path
+		fileRecord, err := h.uploadService.Upload(c.Request.Context(), &service.UploadRequest{
+			Filename:    header.Filename,
+			Content:     file,
+			Size:        header.Size,
+			ContentType: header.Header.Get("Content-Type"),
+			CategoryID:  int(req.CategoryID),
+			Title:       req.Title,
+			Type:        req.Type,
+			Username:    username,
+			Sidecar:     sidecar,
+		})
+		if err != nil {
+			switch uploadErr := err.(type) {
+			case *service.UploadValidationError:
+				c.JSON(http.StatusBadRequest, gin.H{
 					"success": false,
-					"message": dupErr.Message,
-					"code":    "DUPLICATE_FILE",
+					"message": uploadErr.Message,
+				})
+			case *service.SidecarValidationError:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"success": false,
+					"message": "Sidecar metadata is invalid",
+					"code":    "INVALID_SIDECAR",
 					"data": gin.H{
-						"existing_file_id":    dupErr.ExistingFile.ID,
-						"existing_file_title": dupErr.ExistingFile.Title,
-						"existing_file_name":  dupErr.ExistingFile.Name + dupErr.ExistingFile.Ext,
-						"uploaded_by":         dupErr.ExistingFile.UploadUsername,
-						"uploaded_at":         dupErr.ExistingFile.UploadAt,
-						"category_name":       dupErr.ExistingFile.CategoryName,
+						"sidecar": uploadErr.Sidecar,
+						"errors":  uploadErr.Errors,
 					},
 				})
-				return
-			}
-			
-			// Other errors: rollback by deleting uploaded file
-			h.storageService.Delete(c.Request.Context(), uploadedPath)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to save file record",
-				"error":   err.Error(),
-			})
-			return
-		}
-
-		// Trigger transcoding for video and audio files
-		if h.queueService != nil && (fileRecord.Type == 1 || fileRecord.Type == 2) {
-			// Determine storage type
-			storageType := "local"
-			if h.storageService != nil {
-				// Check if S3 storage by checking type
-				storageType = "s3" // TODO: get from config
-			}
-
-			// Create transcode job
-			transcodeJob := queue.TranscodeJob{
-				FileID:      uint64(fileRecord.ID),
-				InputPath:   uploadedPath,
-				OutputPath:  strings.TrimSuffix(uploadedPath, filepath.Ext(uploadedPath)) + "-preview.flv",
-				Parameters:  "-y -ab 56 -ar 22050 -r 15 -b 500 -s 320x240",
-				StorageType: storageType,
-			}
-
-			// Marshal to JSON
-			jobData, err := json.Marshal(transcodeJob)
-			if err == nil {
-				// Create message for queue
-				message := &queue.Message{
-					ID:        fmt.Sprintf("transcode-%d-%d", fileRecord.ID, time.Now().Unix()),
-					Body:      string(jobData),
-					Timestamp: time.Now(),
-					Attributes: map[string]string{
-						"file_id":   strconv.FormatUint(uint64(fileRecord.ID), 10),
-						"file_type": strconv.Itoa(fileRecord.Type),
+			case *service.QuotaExceededError:
+				respondQuotaExceeded(c, uploadErr)
+			case *service.MalwareDetectedError:
+				fmt.Printf("⚠ Upload %s quarantined as file %d: %s\n", header.Filename, uploadErr.File.ID, uploadErr.Signature)
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"success": false,
+					"message": "Malware detected, the file has been quarantined",
+					"code":    "MALWARE_DETECTED",
+					"data": gin.H{
+						"file_id":   uploadErr.File.ID,
+						"signature": uploadErr.Signature,
 					},
-				}
-				
-				// Try to publish to queue (non-blocking)
-				go func() {
-					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-					defer cancel()
-					if err := h.queueService.Publish(ctx, "openwan_transcoding_jobs", message); err != nil {
-						fmt.Printf("⚠ Queue unavailable for file %d, using sync transcode: %v\n", fileRecord.ID, err)
-						// Queue is not available, trigger sync transcode
-						h.syncTranscodeVideo(fileRecord, uploadedPath, storageType)
-					} else {
-						fmt.Printf("✓ Transcode job published for file %d (type %d)\n", fileRecord.ID, fileRecord.Type)
-					}
-				}()
+				})
+			case *service.DuplicateFileError:
+				// Return conflict status pointing at the existing file
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": uploadErr.Message,
+					"code":    "DUPLICATE_FILE",
+					"data":    duplicateDetails(c, h.fileService, uploadErr.ExistingFile),
+				})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Failed to upload file",
+					"error":   err.Error(),
+				})
 			}
-		} else if fileRecord.Type == 1 || fileRecord.Type == 2 {
-			// No queue service, do sync transcode for video/audio
-			storageType := "s3" // Assuming S3 for now
-			fmt.Printf("⚠ No queue service available, using sync transcode for file %d\n", fileRecord.ID)
-			go h.syncTranscodeVideo(fileRecord, uploadedPath, storageType)
+			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		response := gin.H{
 			"success": true,
 			"message": "File uploaded successfully",
 			"file": gin.H{
@@ -303,58 +188,18 @@ func (h *FileHandler) Upload() gin.HandlerFunc {
 				"status":   fileRecord.Status,
 				"path":     fileRecord.Path,
 				"uploaded": fileRecord.UploadAt,
+				"scan":     fileRecord.ScanStatus,
 			},
-		})
-	}
-}
-
-// determineFileType determines file type based on extension
-func (h *FileHandler) determineFileType(ext string) int {
-	for _, videoExt := range h.allowedTypes["video"] {
-		if ext == videoExt {
-			return 1 // Video
-		}
-	}
-	for _, audioExt := range h.allowedTypes["audio"] {
-		if ext == audioExt {
-			return 2 // Audio
 		}
-	}
-	for _, imageExt := range h.allowedTypes["image"] {
-		if ext == imageExt {
-			return 3 // Image
-		}
-	}
-	for _, richExt := range h.allowedTypes["rich"] {
-		if ext == richExt {
-			return 4 // Rich media
-		}
-	}
-	return 0 // Unknown
-}
-
-// validateFileType validates if extension is allowed for file type
-func (h *FileHandler) validateFileType(fileType int, ext string) bool {
-	var allowedExts []string
-	switch fileType {
-	case 1:
-		allowedExts = h.allowedTypes["video"]
-	case 2:
-		allowedExts = h.allowedTypes["audio"]
-	case 3:
-		allowedExts = h.allowedTypes["image"]
-	case 4:
-		allowedExts = h.allowedTypes["rich"]
-	default:
-		return false
-	}
-
-	for _, allowed := range allowedExts {
-		if ext == allowed {
-			return true
+		if fileRecord.DuplicateOf != 0 {
+			// Accepted under the category's warn policy and linked to the existing file
+			if existing, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileRecord.DuplicateOf)); err == nil {
+				response["warning"] = "DUPLICATE_FILE"
+				response["duplicate_of"] = duplicateDetails(c, h.fileService, existing)
+			}
 		}
+		c.JSON(http.StatusOK, response)
 	}
-	return false
 }
 
 // ListFiles returns paginated list of files
@@ -431,8 +276,9 @@ func (h *FileHandler) GetFile() gin.HandlerFunc {
 			return
 		}
 
+		// Embargoed files are only shown to reviewers
 		file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
-		if err != nil {
+		if err != nil || (file.Embargoed(time.Now().Unix()) && !canSeeEmbargoed(c, h.fileService, file)) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
 				"message": "File not found",
@@ -459,9 +305,9 @@ func (h *FileHandler) DownloadFile() gin.HandlerFunc {
 			return
 		}
 
-		// Get file record
+		// Get file record; embargoed files are only downloaded by reviewers
 		file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
-		if err != nil {
+		if err != nil || (file.Embargoed(time.Now().Unix()) && !canSeeEmbargoed(c, h.fileService, file)) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
 				"message": "File not found",
@@ -469,6 +315,18 @@ func (h *FileHandler) DownloadFile() gin.HandlerFunc {
 			return
 		}
 
+		// The current version is served unless an earlier one is requested
+		file, ok := h.fileAtVersion(c, file)
+		if !ok {
+			return
+		}
+
+		// Unscanned and infected content is never served
+		if !file.Downloadable() {
+			respondScanBlocked(c, file)
+			return
+		}
+
 		// Check download permission
 		if !file.IsDownload {
 			c.JSON(http.StatusForbidden, gin.H{
@@ -521,7 +379,7 @@ func (h *FileHandler) DownloadFile() gin.HandlerFunc {
 		c.Header("Content-Description", "File Transfer")
 		c.Header("Content-Transfer-Encoding", "binary")
 		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-		c.Header("Content-Type", getContentType(file.Ext))
+		c.Header("Content-Type", contentTypeOf(file))
 		c.Header("Content-Length", fmt.Sprintf("%d", fileSize))
 
 		// Stream file to client
@@ -541,9 +399,9 @@ func (h *FileHandler) PreviewFile() gin.HandlerFunc {
 			return
 		}
 
-		// Get file record
+		// Get file record; embargoed files are only previewed by reviewers
 		file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
-		if err != nil {
+		if err != nil || (file.Embargoed(time.Now().Unix()) && !canSeeEmbargoed(c, h.fileService, file)) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
 				"message": "File not found",
@@ -551,6 +409,18 @@ func (h *FileHandler) PreviewFile() gin.HandlerFunc {
 			return
 		}
 
+		// The current version is served unless an earlier one is requested
+		file, ok := h.fileAtVersion(c, file)
+		if !ok {
+			return
+		}
+
+		// Unscanned and infected content is never served
+		if !file.Downloadable() {
+			respondScanBlocked(c, file)
+			return
+		}
+
 		// Determine preview file path and content type
 		var contentType string
 		var reader io.ReadCloser
@@ -574,28 +444,7 @@ func (h *FileHandler) PreviewFile() gin.HandlerFunc {
 					})
 					return
 				}
-				// Set content type based on original file extension
-				ext := strings.ToLower(file.Ext)
-				switch ext {
-				case ".mp4":
-					contentType = "video/mp4"
-				case ".avi":
-					contentType = "video/x-msvideo"
-				case ".mov":
-					contentType = "video/quicktime"
-				case ".mkv":
-					contentType = "video/x-matroska"
-				case ".flv":
-					contentType = "video/x-flv"
-				case ".mp3":
-					contentType = "audio/mpeg"
-				case ".wav":
-					contentType = "audio/wav"
-				case ".aac":
-					contentType = "audio/aac"
-				default:
-					contentType = "video/mp4"
-				}
+				contentType = contentTypeOf(file)
 			} else {
 				// Preview file exists
 				contentType = "video/x-flv"
@@ -611,22 +460,7 @@ func (h *FileHandler) PreviewFile() gin.HandlerFunc {
 				})
 				return
 			}
-			// Determine image content type based on extension
-			ext := strings.ToLower(file.Ext)
-			switch ext {
-			case ".jpg", ".jpeg":
-				contentType = "image/jpeg"
-			case ".png":
-				contentType = "image/png"
-			case ".gif":
-				contentType = "image/gif"
-			case ".bmp":
-				contentType = "image/bmp"
-			case ".webp":
-				contentType = "image/webp"
-			default:
-				contentType = "image/jpeg"
-			}
+			contentType = contentTypeOf(file)
 		} else {
 			// Other file types don't support preview
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -670,7 +504,10 @@ func (h *FileHandler) UpdateFile() gin.HandlerFunc {
 			return
 		}
 
-		if err := h.fileService.UpdateFile(c.Request.Context(), uint(fileID), updates); err != nil {
+		if err := h.fileService.UpdateFile(c.Request.Context(), uint(fileID), updates, c.GetString("username")); err != nil {
+			if respondLegalHold(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Failed to update file",
@@ -686,7 +523,7 @@ func (h *FileHandler) UpdateFile() gin.HandlerFunc {
 	}
 }
 
-// DeleteFile deletes a file (soft delete by setting status to 4)
+// DeleteFile moves a file to the trash (status 4); it is removed by a purge
 func (h *FileHandler) DeleteFile() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -707,9 +544,30 @@ func (h *FileHandler) DeleteFile() gin.HandlerFunc {
 				}
 			}
 		}
+		if username == "" {
+			username = c.GetString("username")
+		}
+
+		file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "File not found",
+			})
+			return
+		}
 
-		// Hard delete - permanently remove from database
-		if err := h.fileService.DeleteFile(c.Request.Context(), uint64(fileID), username); err != nil {
+		if err := h.fileService.DeleteFile(c.Request.Context(), file.ID, username); err != nil {
+			if respondLegalHold(c, err) {
+				return
+			}
+			if errors.Is(err, service.ErrFileInTrash) || errors.Is(err, service.ErrStatusConflict) {
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Failed to delete file",
@@ -720,83 +578,75 @@ func (h *FileHandler) DeleteFile() gin.HandlerFunc {
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"message": "File deleted successfully",
+			"message": "File moved to trash",
 		})
 	}
 }
 
 
-// getContentType returns the appropriate MIME type based on file extension
-func getContentType(ext string) string {
-	ext = strings.ToLower(ext)
-	
-	// Video formats
-	videoTypes := map[string]string{
-		".mp4":  "video/mp4",
-		".avi":  "video/x-msvideo",
-		".mov":  "video/quicktime",
-		".wmv":  "video/x-ms-wmv",
-		".flv":  "video/x-flv",
-		".mkv":  "video/x-matroska",
-		".mpg":  "video/mpeg",
-		".mpeg": "video/mpeg",
-		".webm": "video/webm",
-	}
-	
-	// Audio formats
-	audioTypes := map[string]string{
-		".mp3":  "audio/mpeg",
-		".wav":  "audio/wav",
-		".wma":  "audio/x-ms-wma",
-		".aac":  "audio/aac",
-		".flac": "audio/flac",
-		".ogg":  "audio/ogg",
-		".m4a":  "audio/mp4",
-	}
-	
-	// Image formats
-	imageTypes := map[string]string{
-		".jpg":  "image/jpeg",
-		".jpeg": "image/jpeg",
-		".png":  "image/png",
-		".gif":  "image/gif",
-		".bmp":  "image/bmp",
-		".tiff": "image/tiff",
-		".webp": "image/webp",
-		".svg":  "image/svg+xml",
+// duplicateDetails describes the existing copy of an uploaded file; callers who cannot see it
+// only learn that it exists
+func duplicateDetails(c *gin.Context, fileService *service.FileService, existing *models.Files) gin.H {
+	userID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	uid, _ := userID.(uint)
+	admin, _ := isAdmin.(bool)
+
+	if !fileService.CanViewFile(c.Request.Context(), int(uid), admin, existing.ID) {
+		return gin.H{
+			"existing_file_id": existing.ID,
+			"can_view":         false,
+		}
 	}
-	
-	// Document formats
-	docTypes := map[string]string{
-		".pdf":  "application/pdf",
-		".doc":  "application/msword",
-		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-		".xls":  "application/vnd.ms-excel",
-		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
-		".ppt":  "application/vnd.ms-powerpoint",
-		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
-		".txt":  "text/plain",
-		".swf":  "application/x-shockwave-flash",
+	return gin.H{
+		"existing_file_id":    existing.ID,
+		"existing_file_title": existing.Title,
+		"existing_file_name":  existing.Name + existing.Ext,
+		"uploaded_by":         existing.UploadUsername,
+		"uploaded_at":         existing.UploadAt,
+		"category_name":       existing.CategoryName,
+		"can_view":            true,
 	}
-	
-	// Check each type map
-	if contentType, ok := videoTypes[ext]; ok {
-		return contentType
-	}
-	if contentType, ok := audioTypes[ext]; ok {
-		return contentType
+}
+
+// respondScanBlocked rejects access to a file whose malware scan is pending or found an infection
+func respondScanBlocked(c *gin.Context, file *models.Files) {
+	message := "File is waiting for the malware scan"
+	if file.ScanStatus == models.ScanStatusInfected {
+		message = "File is quarantined because malware was detected"
 	}
-	if contentType, ok := imageTypes[ext]; ok {
-		return contentType
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"message": message,
+		"code":    "SCAN_BLOCKED",
+		"data": gin.H{
+			"scan_status": file.ScanStatus,
+			"scan_result": file.ScanResult,
+		},
+	})
+}
+
+// contentTypeOf returns the MIME type detected at upload, falling back to the extension for
+// files uploaded before content detection
+func contentTypeOf(file *models.Files) string {
+	if file.MimeType != "" {
+		return file.MimeType
 	}
-	if contentType, ok := docTypes[ext]; ok {
+	if contentType := mime.TypeByExtension(strings.ToLower(file.Ext)); contentType != "" {
 		return contentType
 	}
-	
-	// Default to octet-stream for unknown types
 	return "application/octet-stream"
 }
 
+// canSeeEmbargoed reports whether the current user may see files outside their publication window
+func canSeeEmbargoed(c *gin.Context, fileService *service.FileService, file *models.Files) bool {
+	userID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	uid, _ := userID.(uint)
+	admin, _ := isAdmin.(bool)
+	return fileService.CanSeeEmbargoed(c.Request.Context(), int(uid), admin, file)
+}
+
 // GetStats returns file statistics
 func (h *FileHandler) GetStats() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -850,7 +700,8 @@ func (h *FileHandler) GetRecentFiles() gin.HandlerFunc {
 
 // syncTranscodeVideo performs synchronous video transcoding
 // This is a fallback when RabbitMQ queue is not available
-func (h *FileHandler) syncTranscodeVideo(fileRecord *models.Files, inputPath, storageType string) {
+func (h *FileHandler) syncTranscodeVideo(fileRecord *models.Files, storageType string) {
+	inputPath := fileRecord.Path
 	fmt.Printf("🎬 Starting sync transcode for file %d (%s)\n", fileRecord.ID, inputPath)
 	
 	// Create FFmpeg wrapper
@@ -949,3 +800,43 @@ func (h *FileHandler) syncTranscodeVideo(fileRecord *models.Files, inputPath, st
 	
 	fmt.Printf("✅ Transcode completed for file %d\n", fileRecord.ID)
 }
+
+// fileAtVersion resolves the ?version= query parameter to that version of the file, writing an
+// error response if it is invalid
+func (h *FileHandler) fileAtVersion(c *gin.Context, file *models.Files) (*models.Files, bool) {
+	value := c.Query("version")
+	if value == "" {
+		return file, true
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid version",
+		})
+		return nil, false
+	}
+	at, err := h.fileService.FileAtVersion(c.Request.Context(), file, version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Version not found",
+		})
+		return nil, false
+	}
+	return at, true
+}
+
+// respondQuotaExceeded writes a 413 response describing the exceeded storage quota
+func respondQuotaExceeded(c *gin.Context, err *service.QuotaExceededError) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"success": false,
+		"message": fmt.Sprintf("Storage quota exceeded: %d MB of %d MB used", err.UsedBytes/(1024*1024), err.QuotaBytes/(1024*1024)),
+		"code":    "QUOTA_EXCEEDED",
+		"data": gin.H{
+			"quota_bytes":     err.QuotaBytes,
+			"used_bytes":      err.UsedBytes,
+			"requested_bytes": err.Requested,
+		},
+	})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// ReviewHandler handles review tasks and votes
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+	fileService   *service.FileService
+}
+
+// NewReviewHandler creates a new review handler
+func NewReviewHandler(reviewService *service.ReviewService, fileService *service.FileService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		fileService:   fileService,
+	}
+}
+
+// VoteRequest represents a reviewer's decision
+type VoteRequest struct {
+	Decision string `json:"decision" binding:"required"` // approve or reject
+	Comment  string `json:"comment"`
+}
+
+// GetFileReviews lists a file's review tasks with their votes
+func (h *ReviewHandler) GetFileReviews() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid file ID",
+			})
+			return
+		}
+		userID, _ := c.Get("user_id")
+		isAdmin, _ := c.Get("is_admin")
+		uid, _ := userID.(uint)
+		admin, _ := isAdmin.(bool)
+		if !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "File not found",
+			})
+			return
+		}
+
+		tasks, err := h.reviewService.FileReviews(c.Request.Context(), fileID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve reviews",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    tasks,
+		})
+	}
+}
+
+// MyReviewQueue lists the open review tasks the current user can vote on, oldest first
+func (h *ReviewHandler) MyReviewQueue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		tasks, total, err := h.reviewService.Queue(c.Request.Context(), int(uid), pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve review queue",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    tasks,
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// Vote approves or rejects a review task. The stage advances when its quorum is met; the last
+// stage publishes the file and a rejection rejects it.
+func (h *ReviewHandler) Vote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid review task ID",
+			})
+			return
+		}
+
+		var req VoteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		username, _ := c.Get("username")
+		isAdmin, _ := c.Get("is_admin")
+		uid, _ := userID.(uint)
+		name, _ := username.(string)
+		admin, _ := isAdmin.(bool)
+
+		task, err := h.reviewService.Vote(c.Request.Context(), taskID, int(uid), name, admin, req.Decision, req.Comment)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidReviewDecision):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			case errors.Is(err, service.ErrReviewNotFound):
+				c.JSON(http.StatusNotFound, gin.H{
+					"success": false,
+					"message": "Review task not found",
+				})
+			case errors.Is(err, service.ErrReviewNotAssigned):
+				c.JSON(http.StatusForbidden, gin.H{
+					"success": false,
+					"message": "You are not a reviewer of this task",
+				})
+			case errors.Is(err, service.ErrReviewClosed), errors.Is(err, service.ErrReviewAlreadyVoted), errors.Is(err, service.ErrReviewConflict):
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			default:
+				respondTransitionError(c, "Failed to record vote", err)
+			}
+			return
+		}
+
+		fmt.Printf("✓ Review task %d: %s by %s\n", taskID, req.Decision, name)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Vote recorded",
+			"data":    task,
+		})
+	}
+}
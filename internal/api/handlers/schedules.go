@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// ScheduleHandler handles publication windows of files
+type ScheduleHandler struct {
+	scheduleService *service.ScheduleService
+	fileService     *service.FileService
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(scheduleService *service.ScheduleService, fileService *service.FileService) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService: scheduleService,
+		fileService:     fileService,
+	}
+}
+
+// ScheduleRequest is the request body for setting a publication window. Times are Unix
+// timestamps; 0 clears either end.
+type ScheduleRequest struct {
+	PublishAt   int `json:"publish_at" binding:"min=0"`   // Embargoed until, then published if pending
+	UnpublishAt int `json:"unpublish_at" binding:"min=0"` // Withdrawn at
+}
+
+// GetSchedule returns a file's publication window
+func (h *ScheduleHandler) GetSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.loadFile(c)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    scheduleOf(file),
+		})
+	}
+}
+
+// SetSchedule sets a file's publication window
+func (h *ScheduleHandler) SetSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.loadFile(c)
+		if !ok {
+			return
+		}
+
+		var req ScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		h.updateSchedule(c, file.ID, req.PublishAt, req.UnpublishAt)
+	}
+}
+
+// ClearSchedule removes a file's publication window
+func (h *ScheduleHandler) ClearSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, ok := h.loadFile(c)
+		if !ok {
+			return
+		}
+
+		h.updateSchedule(c, file.ID, 0, 0)
+	}
+}
+
+// ListScheduled lists files with a publication or withdrawal still to come, soonest first
+func (h *ScheduleHandler) ListScheduled() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		files, total, err := h.scheduleService.ListScheduled(c.Request.Context(), pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve scheduled files",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    files,
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// updateSchedule stores the window and writes the response
+func (h *ScheduleHandler) updateSchedule(c *gin.Context, fileID uint64, publishAt, unpublishAt int) {
+	username, _ := c.Get("username")
+	usernameStr, _ := username.(string)
+
+	file, err := h.scheduleService.SetSchedule(c.Request.Context(), fileID, publishAt, unpublishAt, usernameStr)
+	if respondLegalHold(c, err) {
+		return
+	}
+	if errors.Is(err, service.ErrInvalidSchedule) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid schedule",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update schedule",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule updated successfully",
+		"data":    scheduleOf(file),
+	})
+}
+
+// loadFile loads the file named by the :id parameter if the user may view it, writing the error
+// response otherwise
+func (h *ScheduleHandler) loadFile(c *gin.Context) (*models.Files, bool) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid file ID",
+		})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	uid, _ := userID.(uint)
+	admin, _ := isAdmin.(bool)
+	file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
+	if err != nil || !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File not found",
+		})
+		return nil, false
+	}
+	return file, true
+}
+
+// scheduleOf returns the publication window of a file as a response body
+func scheduleOf(file *models.Files) gin.H {
+	return gin.H{
+		"file_id":      file.ID,
+		"status":       file.Status,
+		"publish_at":   file.PublishAt,
+		"unpublish_at": file.UnpublishAt,
+		"embargoed":    file.Embargoed(time.Now().Unix()),
+	}
+}
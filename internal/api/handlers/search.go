@@ -10,12 +10,14 @@ import (
 // SearchHandler handles search operations
 type SearchHandler struct {
 	searchService *service.SearchService
+	fileService   *service.FileService
 }
 
 // NewSearchHandler creates a new search handler
-func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+func NewSearchHandler(searchService *service.SearchService, fileService *service.FileService) *SearchHandler {
 	return &SearchHandler{
 		searchService: searchService,
+		fileService:   fileService,
 	}
 }
 
@@ -88,6 +90,9 @@ func (h *SearchHandler) Search() gin.HandlerFunc {
 			}
 		}
 
+		// Embargoed files are only found by reviewers
+		params.IncludeEmbargoed = canSeeEmbargoed(c, h.fileService, nil)
+
 		// Perform search
 		results, total, facets, err := h.searchService.Search(
 			c.Request.Context(),
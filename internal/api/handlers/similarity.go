@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// SimilarityHandler finds visually similar files
+type SimilarityHandler struct {
+	fingerprintService *service.FingerprintService
+	fileService        *service.FileService
+}
+
+// NewSimilarityHandler creates a new similarity handler
+func NewSimilarityHandler(fingerprintService *service.FingerprintService, fileService *service.FileService) *SimilarityHandler {
+	return &SimilarityHandler{
+		fingerprintService: fingerprintService,
+		fileService:        fileService,
+	}
+}
+
+// GetSimilarFiles lists files that look like the given one (?max_distance=0-32, default 10; ?limit=)
+func (h *SimilarityHandler) GetSimilarFiles() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid file ID",
+			})
+			return
+		}
+
+		maxDistance, err := strconv.Atoi(c.DefaultQuery("max_distance", strconv.Itoa(service.DefaultSimilarityDistance)))
+		if err != nil || maxDistance < 0 || maxDistance > service.MaxSimilarityDistance {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "max_distance must be between 0 and " + strconv.Itoa(service.MaxSimilarityDistance),
+			})
+			return
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		userID, _ := c.Get("user_id")
+		isAdmin, _ := c.Get("is_admin")
+		uid, _ := userID.(uint)
+		admin, _ := isAdmin.(bool)
+
+		if !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "File not found",
+			})
+			return
+		}
+
+		similar, err := h.fingerprintService.Similar(c.Request.Context(), fileID, maxDistance, limit)
+		if err != nil {
+			if errors.Is(err, service.ErrFingerprintNotFound) {
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": "File has not been fingerprinted yet",
+					"code":    "FINGERPRINT_PENDING",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to find similar files",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		// Only list files the caller may see
+		visible := make([]*service.SimilarFile, 0, len(similar))
+		for _, match := range similar {
+			if h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, match.File.ID) {
+				visible = append(visible, match)
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"data":         visible,
+			"max_distance": maxDistance,
+		})
+	}
+}
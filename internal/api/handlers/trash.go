@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/service"
+	"gorm.io/gorm"
+)
+
+// TrashHandler handles deleted files in the trash
+type TrashHandler struct {
+	trashService *service.TrashService
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler(trashService *service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// ListTrash lists the files in the trash with the time each is purged, most recently deleted
+// first. Filters: ?category_id and ?deleted_by.
+func (h *TrashHandler) ListTrash() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		filter := repository.TrashFilter{DeletedBy: c.Query("deleted_by")}
+		if value := c.Query("category_id"); value != "" {
+			categoryID, err := strconv.Atoi(value)
+			if err != nil || categoryID < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid category ID",
+				})
+				return
+			}
+			filter.CategoryID = categoryID
+		}
+
+		files, total, err := h.trashService.List(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve trash",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    files,
+			"pagination": gin.H{
+				"page":        page,
+				"page_size":   pageSize,
+				"total":       total,
+				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		})
+	}
+}
+
+// RestoreFile takes a file out of the trash back into pending review
+func (h *TrashHandler) RestoreFile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, ok := trashFileID(c)
+		if !ok {
+			return
+		}
+		username, _ := c.Get("username")
+		usernameStr, _ := username.(string)
+
+		file, err := h.trashService.Restore(c.Request.Context(), fileID, usernameStr)
+		if err != nil {
+			if !respondTrashError(c, err) {
+				respondTransitionError(c, "Failed to restore file", err)
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "File restored successfully",
+			"data":    file,
+		})
+	}
+}
+
+// PurgeFile permanently removes a file in the trash with its stored objects
+func (h *TrashHandler) PurgeFile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, ok := trashFileID(c)
+		if !ok {
+			return
+		}
+		username, _ := c.Get("username")
+		usernameStr, _ := username.(string)
+
+		if err := h.trashService.Purge(c.Request.Context(), fileID, usernameStr); err != nil {
+			if !respondTrashError(c, err) && !respondLegalHold(c, err) {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Failed to purge file",
+					"error":   err.Error(),
+				})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "File purged successfully",
+		})
+	}
+}
+
+// trashFileID parses the :id parameter, writing the error response if it is invalid
+func trashFileID(c *gin.Context) (uint64, bool) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid file ID",
+		})
+		return 0, false
+	}
+	return fileID, true
+}
+
+// respondTrashError writes the response for errors about the file's presence in the trash and
+// reports whether it did
+func respondTrashError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File not found",
+		})
+	case errors.Is(err, service.ErrFileNotInTrash):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    "FILE_NOT_IN_TRASH",
+		})
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// VersionHandler handles revisions of existing files
+type VersionHandler struct {
+	versionService *service.VersionService
+	fileService    *service.FileService
+}
+
+// NewVersionHandler creates a new version handler
+func NewVersionHandler(versionService *service.VersionService, fileService *service.FileService) *VersionHandler {
+	return &VersionHandler{
+		versionService: versionService,
+		fileService:    fileService,
+	}
+}
+
+// RestoreVersionRequest represents a version restore
+type RestoreVersionRequest struct {
+	Comment       string `json:"comment"`
+	ResetWorkflow bool   `json:"reset_workflow"` // Send the file back to pending review
+}
+
+// AddVersion uploads new content for a file (multipart "file", optional "comment" and
+// "reset_workflow"); the file keeps its ID, metadata and history
+func (h *VersionHandler) AddVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, ok := h.visibleFileID(c)
+		if !ok {
+			return
+		}
+
+		// Parse multipart form; parts beyond 32MB are buffered on disk
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid form data",
+				"error":   err.Error(),
+			})
+			return
+		}
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "No file uploaded",
+				"error":   err.Error(),
+			})
+			return
+		}
+		defer file.Close()
+
+		username := ""
+		if value, exists := c.Get("username"); exists {
+			username, _ = value.(string)
+		}
+		resetWorkflow, _ := strconv.ParseBool(c.PostForm("reset_workflow"))
+
+		version, fileRecord, err := h.versionService.AddVersion(c.Request.Context(), fileID, &service.VersionRequest{
+			Filename:      header.Filename,
+			Content:       file,
+			Size:          header.Size,
+			ContentType:   header.Header.Get("Content-Type"),
+			Comment:       c.PostForm("comment"),
+			Username:      username,
+			ResetWorkflow: resetWorkflow,
+		})
+		if err != nil {
+			var validationErr *service.UploadValidationError
+			var quotaErr *service.QuotaExceededError
+			var malwareErr *service.MalwareDetectedError
+			var duplicateErr *service.DuplicateFileError
+			switch {
+			case errors.As(err, &validationErr):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": validationErr.Message,
+				})
+			case errors.Is(err, service.ErrVersionUnchanged):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Content is identical to the current version",
+					"code":    "VERSION_UNCHANGED",
+				})
+			case errors.Is(err, service.ErrVersionConflict):
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			case errors.As(err, &quotaErr):
+				respondQuotaExceeded(c, quotaErr)
+			case errors.As(err, &malwareErr):
+				fmt.Printf("⚠ Version %d of file %d quarantined: %s\n", version.Version, fileID, malwareErr.Signature)
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"success": false,
+					"message": "Malware detected, the new version has been quarantined",
+					"code":    "MALWARE_DETECTED",
+					"data": gin.H{
+						"file_id":   fileID,
+						"version":   version.Version,
+						"signature": malwareErr.Signature,
+					},
+				})
+			case errors.As(err, &duplicateErr):
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": duplicateErr.Message,
+					"code":    "DUPLICATE_FILE",
+					"data":    duplicateDetails(c, h.fileService, duplicateErr.ExistingFile),
+				})
+			default:
+				// Resetting the workflow may be refused by the file's workflow
+				respondTransitionError(c, "Failed to store new version", err)
+			}
+			return
+		}
+
+		fmt.Printf("✓ File %d revised to version %d\n", fileID, version.Version)
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"message": "New version stored",
+			"data": gin.H{
+				"version": version,
+				"file":    fileRecord,
+			},
+		})
+	}
+}
+
+// ListVersions lists a file's versions, newest first
+func (h *VersionHandler) ListVersions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, ok := h.visibleFileID(c)
+		if !ok {
+			return
+		}
+
+		versions, err := h.versionService.ListVersions(c.Request.Context(), fileID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve versions",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    versions,
+		})
+	}
+}
+
+// DiffVersions compares two versions (?from=&to=, defaulting to the previous and current versions)
+func (h *VersionHandler) DiffVersions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, ok := h.visibleFileID(c)
+		if !ok {
+			return
+		}
+
+		versions, err := h.versionService.ListVersions(c.Request.Context(), fileID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve versions",
+				"error":   err.Error(),
+			})
+			return
+		}
+		to, err := strconv.Atoi(c.DefaultQuery("to", strconv.Itoa(versions[0].Version)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid to version",
+			})
+			return
+		}
+		from, err := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(to-1)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid from version",
+			})
+			return
+		}
+
+		diff, err := h.versionService.Diff(c.Request.Context(), fileID, from, to)
+		if err != nil {
+			if errors.Is(err, service.ErrVersionNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{
+					"success": false,
+					"message": "Version not found",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to compare versions",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    diff,
+		})
+	}
+}
+
+// RestoreVersion makes an earlier version current again
+func (h *VersionHandler) RestoreVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, ok := h.visibleFileID(c)
+		if !ok {
+			return
+		}
+		number, err := strconv.Atoi(c.Param("version"))
+		if err != nil || number < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid version",
+			})
+			return
+		}
+
+		var req RestoreVersionRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid request body",
+					"error":   err.Error(),
+				})
+				return
+			}
+		}
+
+		username := ""
+		if value, exists := c.Get("username"); exists {
+			username, _ = value.(string)
+		}
+
+		version, fileRecord, err := h.versionService.Restore(c.Request.Context(), fileID, number, username, req.Comment, req.ResetWorkflow)
+		if err != nil {
+			var validationErr *service.UploadValidationError
+			var quotaErr *service.QuotaExceededError
+			switch {
+			case errors.As(err, &validationErr):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": validationErr.Message,
+				})
+			case errors.As(err, &quotaErr):
+				respondQuotaExceeded(c, quotaErr)
+			case errors.Is(err, service.ErrVersionNotFound):
+				c.JSON(http.StatusNotFound, gin.H{
+					"success": false,
+					"message": "Version not found",
+				})
+			case errors.Is(err, service.ErrFileQuarantined):
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": "Quarantined versions cannot be restored",
+					"code":    "FILE_QUARANTINED",
+				})
+			case errors.Is(err, service.ErrVersionConflict):
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": err.Error(),
+				})
+			default:
+				// Resetting the workflow may be refused by the file's workflow
+				respondTransitionError(c, "Failed to restore version", err)
+			}
+			return
+		}
+
+		fmt.Printf("✓ File %d restored to version %d as version %d\n", fileID, number, version.Version)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": fmt.Sprintf("Version %d restored", number),
+			"data": gin.H{
+				"version": version,
+				"file":    fileRecord,
+			},
+		})
+	}
+}
+
+// visibleFileID parses the file ID and checks that the user can see the file, writing an error
+// response otherwise
+func (h *VersionHandler) visibleFileID(c *gin.Context) (uint64, bool) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid file ID",
+		})
+		return 0, false
+	}
+
+	userID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	uid, _ := userID.(uint)
+	admin, _ := isAdmin.(bool)
+	if !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "File not found",
+		})
+		return 0, false
+	}
+	return fileID, true
+}
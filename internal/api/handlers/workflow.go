@@ -1,26 +1,30 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openwan/media-asset-management/internal/models"
 	"github.com/openwan/media-asset-management/internal/service"
 )
 
 // WorkflowHandler handles file workflow operations
 type WorkflowHandler struct {
-	fileService *service.FileService
+	fileService  *service.FileService
+	statsService *service.WorkflowStatsService
 }
 
 // NewWorkflowHandler creates a new workflow handler
-func NewWorkflowHandler(fileService *service.FileService) *WorkflowHandler {
+func NewWorkflowHandler(fileService *service.FileService, statsService *service.WorkflowStatsService) *WorkflowHandler {
 	return &WorkflowHandler{
-		fileService: fileService,
+		fileService:  fileService,
+		statsService: statsService,
 	}
 }
 
-// SubmitForReview submits a file for review (status: 0 -> 1)
+// SubmitForReview submits a file for review (status -> 1)
 func (h *WorkflowHandler) SubmitForReview() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -39,11 +43,7 @@ func (h *WorkflowHandler) SubmitForReview() gin.HandlerFunc {
 		}
 
 		if err := h.fileService.SubmitForReview(c.Request.Context(), fileID, usernameStr); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to submit file for review",
-				"error":   err.Error(),
-			})
+			respondTransitionError(c, "Failed to submit file for review", err)
 			return
 		}
 
@@ -54,7 +54,7 @@ func (h *WorkflowHandler) SubmitForReview() gin.HandlerFunc {
 	}
 }
 
-// PublishFile publishes a file (status: 1 -> 2)
+// PublishFile publishes a file (status -> 2)
 func (h *WorkflowHandler) PublishFile() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -73,11 +73,7 @@ func (h *WorkflowHandler) PublishFile() gin.HandlerFunc {
 		}
 
 		if err := h.fileService.PublishFile(c.Request.Context(), fileID, usernameStr); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to publish file",
-				"error":   err.Error(),
-			})
+			respondTransitionError(c, "Failed to publish file", err)
 			return
 		}
 
@@ -88,7 +84,7 @@ func (h *WorkflowHandler) PublishFile() gin.HandlerFunc {
 	}
 }
 
-// RejectFile rejects a file (status: 1 -> 3)
+// RejectFile rejects a file (status -> 3)
 func (h *WorkflowHandler) RejectFile() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -113,12 +109,8 @@ func (h *WorkflowHandler) RejectFile() gin.HandlerFunc {
 			usernameStr = username.(string)
 		}
 
-		if err := h.fileService.RejectFile(c.Request.Context(), fileID, usernameStr); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to reject file",
-				"error":   err.Error(),
-			})
+		if err := h.fileService.RejectFile(c.Request.Context(), fileID, usernameStr, req.Reason); err != nil {
+			respondTransitionError(c, "Failed to reject file", err)
 			return
 		}
 
@@ -129,7 +121,7 @@ func (h *WorkflowHandler) RejectFile() gin.HandlerFunc {
 	}
 }
 
-// UpdateFileStatus updates file status with validation
+// UpdateFileStatus moves a file to any status its category's workflow allows
 func (h *WorkflowHandler) UpdateFileStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -142,7 +134,7 @@ func (h *WorkflowHandler) UpdateFileStatus() gin.HandlerFunc {
 		}
 
 		type StatusUpdateRequest struct {
-			Status int    `json:"status" binding:"required,min=0,max=4"`
+			Status *int   `json:"status" binding:"required,min=0"`
 			Reason string `json:"reason"`
 		}
 
@@ -162,9 +154,36 @@ func (h *WorkflowHandler) UpdateFileStatus() gin.HandlerFunc {
 			usernameStr = username.(string)
 		}
 
-		// Get current file
-		file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
+		if err := h.fileService.ChangeStatus(c.Request.Context(), fileID, *req.Status, usernameStr, req.Reason); err != nil {
+			respondTransitionError(c, "Failed to update file status", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "File status updated successfully",
+		})
+	}
+}
+
+// GetFileWorkflow returns the file's workflow and the transitions from its current status
+func (h *WorkflowHandler) GetFileWorkflow() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid file ID",
+			})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		isAdmin, _ := c.Get("is_admin")
+		uid, _ := userID.(uint)
+		admin, _ := isAdmin.(bool)
+		file, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID))
+		if err != nil || !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
 				"message": "File not found",
@@ -172,87 +191,289 @@ func (h *WorkflowHandler) UpdateFileStatus() gin.HandlerFunc {
 			return
 		}
 
-		// Validate status transition
-		if !h.isValidStatusTransition(file.Status, req.Status) {
-			c.JSON(http.StatusBadRequest, gin.H{
+		username, _ := c.Get("username")
+		usernameStr, _ := username.(string)
+		workflow, transitions, err := h.fileService.AvailableTransitions(c.Request.Context(), file, usernameStr)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "Invalid status transition",
-				"from":    file.Status,
-				"to":      req.Status,
+				"message": "Failed to resolve workflow",
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		// Update status based on target
-		var updateErr error
-		switch req.Status {
-		case 1: // Pending
-			updateErr = h.fileService.SubmitForReview(c.Request.Context(), fileID, usernameStr)
-		case 2: // Published
-			updateErr = h.fileService.PublishFile(c.Request.Context(), fileID, usernameStr)
-		case 3: // Rejected
-			updateErr = h.fileService.RejectFile(c.Request.Context(), fileID, usernameStr)
-		case 4: // Deleted
-			updateErr = h.fileService.DeleteFile(c.Request.Context(), fileID, usernameStr)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"workflow":    workflow,
+				"status":      file.Status,
+				"transitions": transitions,
+			},
+		})
+	}
+}
+
+// GetFileHistory returns the file's workflow transitions, newest first, filtered by
+// ?username=&date_from=&date_to=
+func (h *WorkflowHandler) GetFileHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid file ID",
+			})
+			return
 		}
 
-		if updateErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+		userID, _ := c.Get("user_id")
+		isAdmin, _ := c.Get("is_admin")
+		uid, _ := userID.(uint)
+		admin, _ := isAdmin.(bool)
+		if _, err := h.fileService.GetFileByID(c.Request.Context(), uint(fileID)); err != nil || !h.fileService.CanViewFile(c.Request.Context(), int(uid), admin, fileID) {
+			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
-				"message": "Failed to update file status",
-				"error":   updateErr.Error(),
+				"message": "File not found",
 			})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "File status updated successfully",
+		respondHistory(c, h.fileService, service.HistoryParams{
+			FileID:   fileID,
+			Username: c.Query("username"),
+			DateFrom: c.Query("date_from"),
+			DateTo:   c.Query("date_to"),
 		})
 	}
 }
 
-// isValidStatusTransition validates if status transition is allowed
-func (h *WorkflowHandler) isValidStatusTransition(from, to int) bool {
-	// Status: 0=new, 1=pending, 2=published, 3=rejected, 4=deleted
-	validTransitions := map[int][]int{
-		0: {1, 4},       // New -> Pending, Deleted
-		1: {2, 3, 4},    // Pending -> Published, Rejected, Deleted
-		2: {1, 4},       // Published -> Pending (for re-review), Deleted
-		3: {1, 4},       // Rejected -> Pending (resubmit), Deleted
-		4: {1},          // Deleted -> Pending (restore)
+// GetWorkflowHistory returns workflow transitions of all files for audits, including deleted
+// files, filtered by ?file_id=&username=&date_from=&date_to=
+func (h *WorkflowHandler) GetWorkflowHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params := service.HistoryParams{
+			Username: c.Query("username"),
+			DateFrom: c.Query("date_from"),
+			DateTo:   c.Query("date_to"),
+		}
+		if fileID := c.Query("file_id"); fileID != "" {
+			id, err := strconv.ParseUint(fileID, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid file ID",
+				})
+				return
+			}
+			params.FileID = id
+		}
+
+		respondHistory(c, h.fileService, params)
 	}
+}
 
-	allowedTargets, exists := validTransitions[from]
-	if !exists {
-		return false
+// respondHistory writes a page of workflow history
+func respondHistory(c *gin.Context, fileService *service.FileService, params service.HistoryParams) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
 	}
 
-	for _, allowed := range allowedTargets {
-		if allowed == to {
-			return true
-		}
+	events, total, err := fileService.WorkflowHistory(c.Request.Context(), params, pageSize, (page-1)*pageSize)
+	if errors.Is(err, service.ErrInvalidHistoryDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid date filter",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve workflow history",
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	return false
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    events,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// respondTransitionError maps workflow errors of a status change to their HTTP status
+func respondTransitionError(c *gin.Context, message string, err error) {
+	var transitionErr *service.WorkflowTransitionError
+	var guardErr *service.WorkflowGuardError
+	switch {
+	case errors.As(err, &transitionErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid status transition",
+			"error":   err.Error(),
+			"from":    transitionErr.From,
+			"to":      transitionErr.To,
+		})
+	case errors.Is(err, service.ErrWorkflowPermission):
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "Permission denied",
+			"error":   err.Error(),
+		})
+	case errors.As(err, &guardErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": "Required metadata is missing",
+			"error":   err.Error(),
+			"code":    "WORKFLOW_GUARD_FAILED",
+			"data": gin.H{
+				"transition":     guardErr.Transition,
+				"missing_fields": guardErr.Missing,
+			},
+		})
+	case errors.Is(err, service.ErrReviewPending):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "File is published by its review stages",
+			"error":   err.Error(),
+			"code":    "REVIEW_PENDING",
+		})
+	case errors.Is(err, service.ErrUnresolvedComments):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Resolve the file's review comments before publishing it",
+			"error":   err.Error(),
+			"code":    "UNRESOLVED_COMMENTS",
+		})
+	case errors.Is(err, service.ErrFileQuarantined):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "File is quarantined",
+			"code":    "FILE_QUARANTINED",
+		})
+	case errors.Is(err, service.ErrStatusConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "File status was changed by another request",
+			"error":   err.Error(),
+			"code":    "STATUS_CONFLICT",
+		})
+	case errors.As(err, new(*service.LegalHoldError)):
+		respondLegalHold(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": message,
+			"error":   err.Error(),
+		})
+	}
 }
 
-// GetWorkflowStats returns workflow statistics
+// GetWorkflowStats returns file counts per status, type, category and uploader, submissions and
+// publications per day and time in review, filtered by ?category_id=&date_from=&date_to=
 func (h *WorkflowHandler) GetWorkflowStats() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Implement actual statistics query
-		// This is a placeholder implementation
-		
+		params := service.WorkflowStatsParams{
+			DateFrom: c.Query("date_from"),
+			DateTo:   c.Query("date_to"),
+		}
+		if categoryID := c.Query("category_id"); categoryID != "" {
+			id, err := strconv.Atoi(categoryID)
+			if err != nil || id < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid category ID",
+				})
+				return
+			}
+			params.CategoryID = id
+		}
+
+		stats, err := h.statsService.Stats(c.Request.Context(), params)
+		if errors.Is(err, service.ErrInvalidStatsRange) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid date range",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to retrieve workflow statistics",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		// stats keeps the per-status summary earlier clients read
+		summary := gin.H{
+			"new":       int64(0),
+			"pending":   int64(0),
+			"published": int64(0),
+			"rejected":  int64(0),
+			"deleted":   int64(0),
+			"total":     stats.Files.Total,
+		}
+		names := map[int]string{
+			models.FileStatusNew:       "new",
+			models.FileStatusPending:   "pending",
+			models.FileStatusPublished: "published",
+			models.FileStatusRejected:  "rejected",
+			models.FileStatusDeleted:   "deleted",
+		}
+		for _, row := range stats.Files.ByStatus {
+			if name, ok := names[row.Status]; ok {
+				summary[name] = row.Count
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"stats": gin.H{
-				"new":       0,
-				"pending":   0,
-				"published": 0,
-				"rejected":  0,
-				"deleted":   0,
-				"total":     0,
-			},
+			"stats":   summary,
+			"data":    stats,
+		})
+	}
+}
+
+// respondLegalHold writes the response for operations blocked by a legal hold and reports whether
+// it did
+func respondLegalHold(c *gin.Context, err error) bool {
+	var holdErr *service.LegalHoldError
+	if !errors.As(err, &holdErr) {
+		return false
+	}
+	cases := make([]gin.H, 0, len(holdErr.Holds))
+	for _, hold := range holdErr.Holds {
+		cases = append(cases, gin.H{
+			"id":             hold.ID,
+			"case_reference": hold.CaseReference,
 		})
 	}
+	c.JSON(http.StatusLocked, gin.H{
+		"success": false,
+		"message": "File is under legal hold",
+		"error":   err.Error(),
+		"code":    "LEGAL_HOLD",
+		"data": gin.H{
+			"file_id":   holdErr.FileID,
+			"operation": holdErr.Operation,
+			"holds":     cases,
+		},
+	})
+	return true
 }
@@ -13,19 +13,37 @@ import (
 
 // RouterDependencies holds all dependencies needed for router setup
 type RouterDependencies struct {
-	SessionStore      session.Store
-	ACLService        *service.ACLService
-	UsersService      *service.UsersService
-	FileService       *service.FileService
-	CategoryService   *service.CategoryService
-	CatalogService    *service.CatalogService
-	SearchService     *service.SearchService
-	GroupService      *service.GroupService
-	RoleService       *service.RoleService
-	PermissionService *service.PermissionService
-	LevelsService     *service.LevelsService
-	StorageService    storage.StorageService
-	QueueService      queue.QueueService
+	SessionStore        session.Store
+	ACLService          *service.ACLService
+	UsersService        *service.UsersService
+	FileService         *service.FileService
+	CategoryService     *service.CategoryService
+	CatalogService      *service.CatalogService
+	SearchService       *service.SearchService
+	GroupService        *service.GroupService
+	RoleService         *service.RoleService
+	PermissionService   *service.PermissionService
+	LevelsService       *service.LevelsService
+	QuotaService        *service.QuotaService
+	SidecarService      *service.SidecarService
+	FileTypeRuleService *service.FileTypeRuleService
+	ScanService         *service.ScanService
+	DuplicateService    *service.DuplicateService
+	FingerprintService  *service.FingerprintService
+	FetchService        *service.FetchService
+	VersionService      *service.VersionService
+	WorkflowService     *service.WorkflowService
+	ReviewService       *service.ReviewService
+	StatsService        *service.WorkflowStatsService
+	ScheduleService     *service.ScheduleService
+	TrashService        *service.TrashService
+	LegalHoldService    *service.LegalHoldService
+	BulkService         *service.BulkService
+	AssignmentService   *service.ReviewAssignmentService
+	NotificationService *service.NotificationService
+	CommentService      *service.CommentService
+	StorageService      storage.StorageService
+	QueueService        queue.QueueService
 }
 
 // SetupRouter creates and configures the Gin router
@@ -51,18 +69,35 @@ func SetupRouter(allowedOrigins []string, deps *RouterDependencies) *gin.Engine
 	
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(deps.ACLService, deps.SessionStore)
-	fileHandler := handlers.NewFileHandler(deps.FileService, deps.StorageService, deps.QueueService)
+	fileHandler := handlers.NewFileHandler(deps.FileService, deps.StorageService, deps.QueueService, deps.FileTypeRuleService)
 	categoryHandler := handlers.NewCategoryHandler(deps.CategoryService)
 	catalogHandler := handlers.NewCatalogHandler(deps.CatalogService)
-	searchHandler := handlers.NewSearchHandler(deps.SearchService)
-	workflowHandler := handlers.NewWorkflowHandler(deps.FileService)
+	searchHandler := handlers.NewSearchHandler(deps.SearchService, deps.FileService)
+	workflowHandler := handlers.NewWorkflowHandler(deps.FileService, deps.StatsService)
+	scheduleHandler := handlers.NewScheduleHandler(deps.ScheduleService, deps.FileService)
+	trashHandler := handlers.NewTrashHandler(deps.TrashService)
+	bulkHandler := handlers.NewBulkHandler(deps.BulkService)
 	groupHandler := handlers.NewGroupHandler(deps.GroupService)
 	roleHandler := handlers.NewRoleHandler(deps.RoleService)
 	permissionHandler := handlers.NewPermissionHandler(deps.PermissionService)
+	similarityHandler := handlers.NewSimilarityHandler(deps.FingerprintService, deps.FileService)
+	fetchHandler := handlers.NewFetchHandler(deps.FetchService)
+	versionHandler := handlers.NewVersionHandler(deps.VersionService, deps.FileService)
+	reviewHandler := handlers.NewReviewHandler(deps.ReviewService, deps.FileService)
+	assignmentHandler := handlers.NewAssignmentHandler(deps.AssignmentService, deps.NotificationService, deps.FileService)
+	commentHandler := handlers.NewCommentHandler(deps.CommentService, deps.FileService)
 	
 	// New admin handlers
 	usersHandler := admin.NewUsersHandler(deps.UsersService)
 	levelsHandler := admin.NewLevelsHandler(deps.LevelsService)
+	usageHandler := admin.NewUsageHandler(deps.QuotaService)
+	sidecarTemplatesHandler := admin.NewSidecarTemplatesHandler(deps.SidecarService)
+	fileTypeRulesHandler := admin.NewFileTypeRulesHandler(deps.FileTypeRuleService)
+	workflowsHandler := admin.NewWorkflowsHandler(deps.WorkflowService)
+	scansHandler := admin.NewScansHandler(deps.ScanService)
+	duplicatesHandler := admin.NewDuplicatesHandler(deps.DuplicateService)
+	similarityReportHandler := admin.NewSimilarityHandler(deps.FingerprintService)
+	legalHoldsHandler := admin.NewLegalHoldsHandler(deps.LegalHoldService)
 	
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -90,19 +125,51 @@ func SetupRouter(allowedOrigins []string, deps *RouterDependencies) *gin.Engine
 			files.GET("", middleware.RequirePermission("files.list.view"), fileHandler.ListFiles())
 			files.GET("/stats", middleware.RequirePermission("files.stats.view"), fileHandler.GetStats()) // Stats endpoint - must be before /:id
 			files.GET("/recent", middleware.RequirePermission("files.list.view"), fileHandler.GetRecentFiles()) // Recent files endpoint - must be before /:id
+			files.GET("/quota", middleware.RequirePermission("files.list.view"), usageHandler.GetMyQuota) // Current user's storage quota - must be before /:id
+			files.GET("/upload-rules", middleware.RequirePermission("files.upload.create"), fileTypeRulesHandler.GetUploadRules) // ?category_id= - must be before /:id
+			files.POST("/fetch", middleware.RequirePermission("files.upload.create"), fetchHandler.Fetch()) // Server-side ingest from an HTTP/FTP URL
+			files.GET("/fetch", middleware.RequirePermission("files.upload.create"), fetchHandler.ListFetchJobs()) // ?all=true for admins - must be before /:id
+			files.GET("/fetch/:id", middleware.RequirePermission("files.upload.create"), fetchHandler.GetFetchJob())
+			files.GET("/trash", middleware.RequirePermission("files.edit.delete"), trashHandler.ListTrash()) // ?category_id=&deleted_by= - must be before /:id
+			files.POST("/bulk", middleware.RequirePermission("files.list.view"), bulkHandler.SubmitBulk()) // The action's permission is checked per job and each file per item
+			files.GET("/bulk", middleware.RequirePermission("files.list.view"), bulkHandler.ListBulkJobs()) // ?all=true for admins - must be before /:id
+			files.GET("/bulk/:id", middleware.RequirePermission("files.list.view"), bulkHandler.GetBulkJob()) // ?status=pending|succeeded|failed
+			files.GET("/bulk/:id/report", middleware.RequirePermission("files.list.view"), bulkHandler.DownloadBulkReport()) // CSV
 			files.GET("/:id", middleware.RequirePermission("files.detail.view"), fileHandler.GetFile())
 			files.POST("", middleware.RequirePermission("files.upload.create"), fileHandler.Upload())
 			files.PUT("/:id", middleware.RequirePermission("files.edit.update"), fileHandler.UpdateFile())
-			files.DELETE("/:id", middleware.RequirePermission("files.edit.delete"), fileHandler.DeleteFile())
-			files.GET("/:id/download", middleware.RequirePermission("files.download.execute"), fileHandler.DownloadFile())
+			files.DELETE("/:id", middleware.RequirePermission("files.edit.delete"), fileHandler.DeleteFile()) // Moves the file to the trash
+			files.POST("/:id/restore", middleware.RequirePermission("files.edit.delete"), trashHandler.RestoreFile())
+			files.DELETE("/:id/purge", middleware.RequirePermission("files.trash.purge"), trashHandler.PurgeFile()) // Permanent
+			files.GET("/:id/download", middleware.RequirePermission("files.download.execute"), fileHandler.DownloadFile()) // ?version= for an earlier version
 			 files.GET("/:id/preview", middleware.RequirePermission("files.preview.view"), fileHandler.PreviewFile())
 			files.HEAD("/:id/preview", middleware.RequirePermission("files.preview.view"), fileHandler.PreviewFile()) // HEAD support for video players
+			files.GET("/:id/similar", middleware.RequirePermission("files.detail.view"), similarityHandler.GetSimilarFiles()) // ?max_distance=&limit=
+			files.POST("/:id/versions", middleware.RequirePermission("files.edit.update"), versionHandler.AddVersion())
+			files.GET("/:id/versions", middleware.RequirePermission("files.detail.view"), versionHandler.ListVersions())
+			files.GET("/:id/versions/diff", middleware.RequirePermission("files.detail.view"), versionHandler.DiffVersions()) // ?from=&to=
+			files.POST("/:id/versions/:version/restore", middleware.RequirePermission("files.edit.update"), versionHandler.RestoreVersion())
 			
 			// Workflow routes
 			files.POST("/:id/submit", middleware.RequirePermission("files.workflow.submit"), workflowHandler.SubmitForReview())
 			files.POST("/:id/publish", middleware.RequirePermission("files.workflow.publish"), workflowHandler.PublishFile())
 			files.POST("/:id/reject", middleware.RequirePermission("files.workflow.reject"), workflowHandler.RejectFile())
 			files.PUT("/:id/status", middleware.RequirePermission("files.workflow.manage"), workflowHandler.UpdateFileStatus())
+			files.GET("/:id/workflow", middleware.RequirePermission("files.detail.view"), workflowHandler.GetFileWorkflow()) // Available transitions
+			files.GET("/:id/history", middleware.RequirePermission("files.detail.view"), workflowHandler.GetFileHistory())   // ?username=&date_from=&date_to=
+			files.GET("/:id/reviews", middleware.RequirePermission("files.detail.view"), reviewHandler.GetFileReviews())
+			files.POST("/:id/assign", middleware.RequirePermission("files.workflow.assign"), assignmentHandler.AssignReviewer()) // {"reviewer_id"} or {"round_robin": true}
+			files.GET("/:id/assignments", middleware.RequirePermission("files.detail.view"), assignmentHandler.GetFileAssignments())
+			files.GET("/:id/comments", middleware.RequirePermission("files.detail.view"), commentHandler.ListComments()) // ?unresolved=true
+			files.GET("/:id/comments/export", middleware.RequirePermission("files.detail.view"), commentHandler.ExportComments()) // ?format=csv|edl&fps=25
+			files.POST("/:id/comments", middleware.RequirePermission("files.review.comment"), commentHandler.AddComment()) // Unresolved threads block publishing
+			files.PUT("/:id/comments/:comment_id", middleware.RequirePermission("files.review.comment"), commentHandler.EditComment())
+			files.DELETE("/:id/comments/:comment_id", middleware.RequirePermission("files.review.comment"), commentHandler.DeleteComment())
+			files.POST("/:id/comments/:comment_id/resolve", middleware.RequirePermission("files.review.comment"), commentHandler.ResolveComment())
+			files.POST("/:id/comments/:comment_id/reopen", middleware.RequirePermission("files.review.comment"), commentHandler.ReopenComment())
+			files.GET("/:id/schedule", middleware.RequirePermission("files.detail.view"), scheduleHandler.GetSchedule())
+			files.PUT("/:id/schedule", middleware.RequirePermission("files.workflow.publish"), scheduleHandler.SetSchedule()) // Embargo and withdrawal times
+			files.DELETE("/:id/schedule", middleware.RequirePermission("files.workflow.publish"), scheduleHandler.ClearSchedule())
 		}
 		
 		// Category routes
@@ -141,6 +208,24 @@ func SetupRouter(allowedOrigins []string, deps *RouterDependencies) *gin.Engine
 			search.GET("/status", middleware.RequirePermission("search.admin.status"), searchHandler.GetIndexStatus())
 		}
 		
+		// Review routes - reviewers are assigned by the workflow's review stages
+		reviews := v1.Group("/reviews")
+		reviews.Use(middleware.RequireAuth())
+		{
+			reviews.GET("/queue", reviewHandler.MyReviewQueue()) // ?page=&page_size=
+			reviews.GET("/assignments", assignmentHandler.MyAssignments()) // Files the current user is responsible for
+			reviews.GET("/overdue", middleware.RequirePermission("files.workflow.assign"), assignmentHandler.ListOverdue()) // ?reviewer_id=&category_id=
+			reviews.POST("/:id/vote", reviewHandler.Vote())
+		}
+		
+		// Notifications of the current user
+		notifications := v1.Group("/notifications")
+		notifications.Use(middleware.RequireAuth())
+		{
+			notifications.GET("", assignmentHandler.ListNotifications()) // ?unread=true
+			notifications.POST("/:id/read", assignmentHandler.MarkNotificationRead())
+		}
+		
 		// Admin routes
 		adminGroup := v1.Group("/admin")
 		adminGroup.Use(middleware.RequireAuth())
@@ -205,8 +290,87 @@ func SetupRouter(allowedOrigins []string, deps *RouterDependencies) *gin.Engine
 				levels.DELETE("/:id", middleware.RequirePermission("levels.manage.delete"), levelsHandler.DeleteLevel)
 			}
 			
+			// Storage usage and quotas
+			adminGroup.GET("/storage/usage", middleware.RequirePermission("groups.manage.view"), usageHandler.GetStorageUsage)
+			adminGroup.POST("/storage/usage/recalculate", middleware.RequirePermission("groups.manage.update"), usageHandler.RecalculateUsage)
+			
+			// Sidecar metadata mapping templates (map into catalog fields, so catalog permissions apply)
+			sidecarTemplates := adminGroup.Group("/sidecar-templates")
+			sidecarTemplates.Use(middleware.RequirePermission("catalog.config.view"))
+			{
+				sidecarTemplates.GET("", sidecarTemplatesHandler.ListTemplates)
+				sidecarTemplates.GET("/:id", sidecarTemplatesHandler.GetTemplate)
+				sidecarTemplates.POST("", middleware.RequirePermission("catalog.config.create"), sidecarTemplatesHandler.CreateTemplate)
+				sidecarTemplates.PUT("/:id", middleware.RequirePermission("catalog.config.update"), sidecarTemplatesHandler.UpdateTemplate)
+				sidecarTemplates.DELETE("/:id", middleware.RequirePermission("catalog.config.delete"), sidecarTemplatesHandler.DeleteTemplate)
+				sidecarTemplates.POST("/:id/test", sidecarTemplatesHandler.TestTemplate)
+			}
+			
+			// Upload file type rules
+			fileTypeRules := adminGroup.Group("/file-type-rules")
+			fileTypeRules.Use(middleware.RequirePermission("system.config.view"))
+			{
+				fileTypeRules.GET("", fileTypeRulesHandler.ListRules)
+				fileTypeRules.GET("/:id", fileTypeRulesHandler.GetRule)
+				fileTypeRules.POST("", middleware.RequirePermission("system.config.update"), fileTypeRulesHandler.CreateRule)
+				fileTypeRules.PUT("/:id", middleware.RequirePermission("system.config.update"), fileTypeRulesHandler.UpdateRule)
+				fileTypeRules.DELETE("/:id", middleware.RequirePermission("system.config.update"), fileTypeRulesHandler.DeleteRule)
+			}
+			
+			// Workflow definitions, assigned to categories
+			workflows := adminGroup.Group("/workflows")
+			workflows.Use(middleware.RequirePermission("system.config.view"))
+			{
+				workflows.GET("", workflowsHandler.ListWorkflows)
+				workflows.GET("/:id", workflowsHandler.GetWorkflow)
+				workflows.POST("", middleware.RequirePermission("system.config.update"), workflowsHandler.CreateWorkflow)
+				workflows.PUT("/:id", middleware.RequirePermission("system.config.update"), workflowsHandler.UpdateWorkflow)
+				workflows.DELETE("/:id", middleware.RequirePermission("system.config.update"), workflowsHandler.DeleteWorkflow)
+			}
+			
+			// Malware scan review
+			scans := adminGroup.Group("/scans")
+			scans.Use(middleware.RequirePermission("system.config.view"))
+			{
+				scans.GET("/files", scansHandler.ListFiles) // ?status=infected|pending
+				scans.POST("/files/:id/rescan", middleware.RequirePermission("system.config.update"), scansHandler.RescanFile)
+				scans.POST("/rescan-pending", middleware.RequirePermission("system.config.update"), scansHandler.RescanPending)
+			}
+			
+			// Duplicate files report and merge
+			duplicates := adminGroup.Group("/duplicates")
+			duplicates.Use(middleware.RequirePermission("files.edit.update"))
+			{
+				duplicates.GET("", duplicatesHandler.ListGroups)
+				duplicates.GET("/:md5", duplicatesHandler.GetGroup)
+				duplicates.POST("/merge", middleware.RequirePermission("files.edit.delete"), duplicatesHandler.Merge)
+			}
+			
+			// Near-duplicate report from perceptual hashes
+			similar := adminGroup.Group("/similar")
+			similar.Use(middleware.RequirePermission("files.edit.update"))
+			{
+				similar.GET("", similarityReportHandler.Report) // ?max_distance=&page=&page_size=
+				similar.POST("/backfill", middleware.RequirePermission("system.config.update"), similarityReportHandler.Backfill)
+			}
+			
 			// Workflow statistics
 			adminGroup.GET("/workflow/stats", middleware.RequirePermission("workflow.stats.view"), workflowHandler.GetWorkflowStats())
+			adminGroup.GET("/workflow/history", middleware.RequirePermission("workflow.history.view"), workflowHandler.GetWorkflowHistory()) // Audit: ?file_id=&username=&date_from=&date_to=
+			
+			// Upcoming scheduled publications and withdrawals
+			adminGroup.GET("/schedules", middleware.RequirePermission("files.workflow.publish"), scheduleHandler.ListScheduled())
+			
+			// Legal holds: files and category subtrees that must not be deleted or altered
+			legalHolds := adminGroup.Group("/legal-holds")
+			legalHolds.Use(middleware.RequirePermission("legal.holds.view"))
+			{
+				legalHolds.GET("", legalHoldsHandler.List)              // ?active=true&case_reference=&file_id=&category_id=
+				legalHolds.GET("/events", legalHoldsHandler.ListEvents) // Hold log: ?hold_id=&file_id=&action=
+				legalHolds.GET("/:id", legalHoldsHandler.Get)
+				legalHolds.POST("", middleware.RequirePermission("legal.holds.manage"), legalHoldsHandler.Apply)
+				legalHolds.POST("/:id/release", middleware.RequirePermission("legal.holds.manage"), legalHoldsHandler.Release)
+			}
 		}
 	}
 	
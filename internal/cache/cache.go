@@ -22,6 +22,8 @@ const (
 	KeyTypeCategoryTree    CacheKeyType = "category:tree"
 	KeyTypeCatalogConfig   CacheKeyType = "catalog:config"
 	KeyTypeFileMetadata    CacheKeyType = "file:metadata"
+	KeyTypeFileTypeRules   CacheKeyType = "file:type_rules"
+	KeyTypeWorkflowStats   CacheKeyType = "workflow:stats"
 )
 
 // TTL values for different cache types
@@ -30,4 +32,6 @@ const (
 	TTLCategories  = 1 * time.Hour
 	TTLCatalog     = 1 * time.Hour
 	TTLFileMetadata = 30 * time.Minute
+	TTLFileTypeRules = 10 * time.Minute
+	TTLWorkflowStats = 1 * time.Minute
 )
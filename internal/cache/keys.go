@@ -19,6 +19,10 @@ func GenerateKey(keyType CacheKeyType, params ...interface{}) string {
 		if len(params) > 0 {
 			return fmt.Sprintf("file:metadata:%v", params[0])
 		}
+	case KeyTypeWorkflowStats:
+		if len(params) > 0 {
+			return fmt.Sprintf("workflow:stats:%v", params[0])
+		}
 	}
 	return string(keyType)
 }
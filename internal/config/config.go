@@ -39,6 +39,31 @@ type StorageConfig struct {
 	S3Bucket  string `mapstructure:"s3_bucket"`
 	S3Region  string `mapstructure:"s3_region"`
 	S3Prefix  string `mapstructure:"s3_prefix"`
+
+	// S3-compatible endpoints (MinIO, Ceph RGW)
+	S3Endpoint             string `mapstructure:"s3_endpoint"`
+	S3UsePathStyle         bool   `mapstructure:"s3_use_path_style"`
+	S3InsecureSkipVerify   bool   `mapstructure:"s3_insecure_skip_verify"`
+	S3CACertFile           string `mapstructure:"s3_ca_cert_file"`
+	S3ChecksumCalculation  string `mapstructure:"s3_checksum_calculation"` // when_supported or when_required
+	S3ChecksumValidation   string `mapstructure:"s3_checksum_validation"`  // when_supported or when_required
+	S3ChecksumAlgorithm    string `mapstructure:"s3_checksum_algorithm"`   // CRC32, CRC32C, SHA1, SHA256
+	S3ServerSideEncryption string `mapstructure:"s3_server_side_encryption"`
+
+	ReplicationMode string                 `mapstructure:"replication_mode"` // sync or async
+	Layout          string                 `mapstructure:"layout"`           // default or cas (content-addressed)
+	Replicas        []StorageReplicaConfig `mapstructure:"replicas"`
+}
+
+type StorageReplicaConfig struct {
+	Name           string `mapstructure:"name"`
+	Type           string `mapstructure:"type"`
+	LocalPath      string `mapstructure:"local_path"`
+	S3Bucket       string `mapstructure:"s3_bucket"`
+	S3Region       string `mapstructure:"s3_region"`
+	S3Prefix       string `mapstructure:"s3_prefix"`
+	S3Endpoint     string `mapstructure:"s3_endpoint"`
+	S3UsePathStyle bool   `mapstructure:"s3_use_path_style"`
 }
 
 type FFmpegConfig struct {
@@ -3,6 +3,8 @@ package database
 import (
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -89,3 +91,34 @@ func Close() error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// LoadConfigFromEnv loads database configuration from environment variables
+func LoadConfigFromEnv() Config {
+	port := 3306
+	if value := os.Getenv("DB_PORT"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			port = parsed
+		}
+	}
+
+	return Config{
+		Host:            getEnv("DB_HOST", "127.0.0.1"),
+		Port:            port,
+		Database:        getEnv("DB_NAME", "openwan_db"),
+		Username:        getEnv("DB_USER", "openwan"),
+		Password:        getEnv("DB_PASSWORD", "openwan123"),
+		Prefix:          "ow_",
+		MaxOpenConns:    20,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: 10 * time.Minute,
+		LogLevel:        logger.Warn,
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
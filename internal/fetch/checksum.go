@@ -0,0 +1,71 @@
+package fetch
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Checksum is an expected digest of the fetched content, written as "sha256:<hex>"
+type Checksum struct {
+	Algorithm string
+	Digest    string // Lowercase hex
+}
+
+// ParseChecksum parses "algorithm:hex" for md5, sha1, sha256 or sha512; an empty value means no check
+func ParseChecksum(value string) (*Checksum, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	algorithm, digest, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("checksum must be written as algorithm:hex, e.g. sha256:9f86d0...")
+	}
+	checksum := &Checksum{
+		Algorithm: strings.ToLower(strings.TrimSpace(algorithm)),
+		Digest:    strings.ToLower(strings.TrimSpace(digest)),
+	}
+	h := checksum.New()
+	if h == nil {
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", checksum.Algorithm)
+	}
+	if decoded, err := hex.DecodeString(checksum.Digest); err != nil || len(decoded) != h.Size() {
+		return nil, fmt.Errorf("invalid %s checksum", checksum.Algorithm)
+	}
+	return checksum, nil
+}
+
+// New returns a hash for the algorithm, or nil if it is not supported
+func (c *Checksum) New() hash.Hash {
+	switch c.Algorithm {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
+
+// Verify compares a computed digest with the expected one
+func (c *Checksum) Verify(sum []byte) error {
+	if actual := hex.EncodeToString(sum); actual != c.Digest {
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s", c.Algorithm, c.Digest, actual)
+	}
+	return nil
+}
+
+// String returns the checksum in algorithm:hex form
+func (c *Checksum) String() string {
+	return c.Algorithm + ":" + c.Digest
+}
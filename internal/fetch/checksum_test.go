@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChecksum(t *testing.T) {
+	sha256Test := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tests := []struct {
+		value   string
+		want    string // String() of the parsed checksum, "" for none
+		wantErr bool
+	}{
+		{"", "", false},
+		{"   ", "", false},
+		{"sha256:" + sha256Test, "sha256:" + sha256Test, false},
+		{" SHA256 : " + strings.ToUpper(sha256Test) + " ", "sha256:" + sha256Test, false},
+		{"md5:098f6bcd4621d373cade4e832627b4f6", "md5:098f6bcd4621d373cade4e832627b4f6", false},
+		{"sha1:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", "sha1:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", false},
+		{sha256Test, "", true},                        // No algorithm
+		{"crc32:d87f7e0c", "", true},                  // Unsupported algorithm
+		{"sha256:" + sha256Test[:62], "", true},       // Too short
+		{"sha256:" + sha256Test + "00", "", true},     // Too long
+		{"sha256:" + sha256Test[:63] + "g", "", true}, // Not hex
+		{"md5:" + sha256Test, "", true},               // Length of another algorithm
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			checksum, err := ParseChecksum(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChecksum error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := ""
+			if checksum != nil {
+				got = checksum.String()
+			}
+			if got != tt.want {
+				t.Errorf("ParseChecksum = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumVerify(t *testing.T) {
+	for _, value := range []string{
+		"md5:098f6bcd4621d373cade4e832627b4f6",
+		"sha1:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3",
+		"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		"sha512:ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff",
+	} {
+		checksum, err := ParseChecksum(value)
+		if err != nil {
+			t.Fatalf("ParseChecksum(%q) returned error: %v", value, err)
+		}
+
+		h := checksum.New()
+		h.Write([]byte("test"))
+		if err := checksum.Verify(h.Sum(nil)); err != nil {
+			t.Errorf("%s: Verify of matching content returned error: %v", checksum.Algorithm, err)
+		}
+
+		h.Reset()
+		h.Write([]byte("tost"))
+		if err := checksum.Verify(h.Sum(nil)); err == nil {
+			t.Errorf("%s: Verify of different content returned no error", checksum.Algorithm)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package fetch downloads source files from HTTP(S) and FTP servers for server-side ingest.
+// Only hosts on the configured allow list can be fetched from.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrHostNotAllowed is returned for URLs whose host is not on the allow list
+	ErrHostNotAllowed = errors.New("host is not on the fetch allow list")
+	// ErrUnsupportedScheme is returned for URLs other than http, https and ftp
+	ErrUnsupportedScheme = errors.New("only http, https and ftp URLs can be fetched")
+	// ErrTooLarge is returned when the source exceeds the size limit
+	ErrTooLarge = errors.New("source exceeds the fetch size limit")
+)
+
+// Config configures a Fetcher
+type Config struct {
+	AllowedHosts []string      // Host names; "*.example.com" also matches subdomains
+	MaxSize      int64         // Bytes, 0 = unlimited
+	Timeout      time.Duration // Whole transfer, including connecting
+}
+
+// Source is an opened remote file
+type Source struct {
+	Body        io.ReadCloser
+	Size        int64 // -1 if the server did not report it
+	Filename    string
+	ContentType string // As reported by the server; uploads detect the real type from the content
+}
+
+// Fetcher opens remote files on allowed hosts
+type Fetcher struct {
+	allowed []string
+	maxSize int64
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewFetcher creates a fetcher for the configured hosts
+func NewFetcher(cfg Config) *Fetcher {
+	f := &Fetcher{
+		maxSize: cfg.MaxSize,
+		timeout: cfg.Timeout,
+	}
+	for _, host := range cfg.AllowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			f.allowed = append(f.allowed, host)
+		}
+	}
+	if f.timeout <= 0 {
+		f.timeout = 30 * time.Minute
+	}
+
+	f.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			// Redirects must stay on allowed hosts
+			_, err := f.Validate(req.URL.String())
+			return err
+		},
+	}
+	return f
+}
+
+// NewFetcherFromEnv creates a fetcher from FETCH_ALLOWED_HOSTS, FETCH_MAX_SIZE and FETCH_TIMEOUT,
+// or returns nil if no hosts are allowed
+func NewFetcherFromEnv() (*Fetcher, error) {
+	hosts := os.Getenv("FETCH_ALLOWED_HOSTS")
+	if strings.TrimSpace(hosts) == "" {
+		return nil, nil
+	}
+
+	cfg := Config{AllowedHosts: strings.Split(hosts, ",")}
+	if value := os.Getenv("FETCH_MAX_SIZE"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid FETCH_MAX_SIZE: %q", value)
+		}
+		cfg.MaxSize = size
+	}
+	if value := os.Getenv("FETCH_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FETCH_TIMEOUT: %w", err)
+		}
+		cfg.Timeout = timeout
+	}
+	return NewFetcher(cfg), nil
+}
+
+// MaxSize returns the size limit in bytes, 0 for unlimited
+func (f *Fetcher) MaxSize() int64 {
+	return f.maxSize
+}
+
+// Timeout returns the time allowed for one transfer
+func (f *Fetcher) Timeout() time.Duration {
+	return f.timeout
+}
+
+// Validate parses a source URL and checks its scheme and host
+func (f *Fetcher) Validate(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp":
+	default:
+		return nil, ErrUnsupportedScheme
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid URL: missing host")
+	}
+	if !f.HostAllowed(u.Hostname()) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, u.Hostname())
+	}
+	return u, nil
+}
+
+// HostAllowed reports whether host matches the allow list
+func (f *Fetcher) HostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range f.allowed {
+		if allowed == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open starts the transfer of a validated URL. The context bounds the whole transfer, so callers
+// should apply Timeout to it.
+func (f *Fetcher) Open(ctx context.Context, u *url.URL) (*Source, error) {
+	if strings.EqualFold(u.Scheme, "ftp") {
+		return f.openFTP(ctx, u)
+	}
+	return f.openHTTP(ctx, u)
+}
+
+// Copy writes the source to dst, failing with ErrTooLarge once it exceeds the size limit
+func (f *Fetcher) Copy(dst io.Writer, src *Source) (int64, error) {
+	if f.maxSize > 0 && src.Size > f.maxSize {
+		return 0, ErrTooLarge
+	}
+
+	reader := io.Reader(src.Body)
+	if f.maxSize > 0 {
+		reader = io.LimitReader(src.Body, f.maxSize+1)
+	}
+	written, err := io.Copy(dst, reader)
+	if err != nil {
+		return written, err
+	}
+	if f.maxSize > 0 && written > f.maxSize {
+		return written, ErrTooLarge
+	}
+	if src.Size >= 0 && written != src.Size {
+		return written, fmt.Errorf("transfer incomplete: received %d of %d bytes", written, src.Size)
+	}
+	return written, nil
+}
+
+// openHTTP sends a GET request and checks the response
+func (f *Fetcher) openHTTP(ctx context.Context, u *url.URL) (*Source, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	if f.maxSize > 0 && resp.ContentLength > f.maxSize {
+		resp.Body.Close()
+		return nil, ErrTooLarge
+	}
+
+	filename := path.Base(resp.Request.URL.Path)
+	if disposition := resp.Header.Get("Content-Disposition"); disposition != "" {
+		if _, params, err := mime.ParseMediaType(disposition); err == nil && params["filename"] != "" {
+			filename = path.Base(params["filename"])
+		}
+	}
+
+	return &Source{
+		Body:        resp.Body,
+		Size:        resp.ContentLength,
+		Filename:    filename,
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
@@ -0,0 +1,198 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ftpSource streams a RETR data connection and finishes the control session on Close
+type ftpSource struct {
+	data    net.Conn
+	control *textproto.Conn
+}
+
+func (s *ftpSource) Read(p []byte) (int, error) {
+	return s.data.Read(p)
+}
+
+func (s *ftpSource) Close() error {
+	s.data.Close()
+	// 226 confirms the transfer; the session is closed either way
+	_, _, err := s.control.ReadResponse(226)
+	s.control.Cmd("QUIT")
+	s.control.Close()
+	return err
+}
+
+// openFTP logs in, switches to binary passive mode and starts retrieving the file
+func (f *Fetcher) openFTP(ctx context.Context, u *url.URL) (*Source, error) {
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "21")
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	control := textproto.NewConn(conn)
+	fail := func(err error) (*Source, error) {
+		control.Close()
+		return nil, err
+	}
+
+	if _, _, err := control.ReadResponse(220); err != nil {
+		return fail(fmt.Errorf("ftp greeting: %w", err))
+	}
+
+	user, password := "anonymous", "anonymous@"
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+	}
+	code, _, err := ftpCommand(control, []int{230, 331}, "USER %s", user)
+	if err != nil {
+		return fail(fmt.Errorf("ftp login: %w", err))
+	}
+	if code == 331 {
+		if _, _, err := ftpCommand(control, []int{230, 202}, "PASS %s", password); err != nil {
+			return fail(fmt.Errorf("ftp login: %w", err))
+		}
+	}
+	if _, _, err := ftpCommand(control, []int{200}, "TYPE I"); err != nil {
+		return fail(fmt.Errorf("ftp binary mode: %w", err))
+	}
+
+	// URL paths are relative to the login directory
+	filePath := strings.TrimPrefix(u.Path, "/")
+	size := int64(-1)
+	if _, message, err := ftpCommand(control, []int{213}, "SIZE %s", filePath); err == nil {
+		if parsed, err := strconv.ParseInt(strings.TrimSpace(message), 10, 64); err == nil {
+			size = parsed
+		}
+	}
+	if f.maxSize > 0 && size > f.maxSize {
+		return fail(ErrTooLarge)
+	}
+
+	dataAddress, err := ftpPassiveAddress(control, u.Hostname())
+	if err != nil {
+		return fail(err)
+	}
+	data, err := dialer.DialContext(ctx, "tcp", dataAddress)
+	if err != nil {
+		return fail(fmt.Errorf("ftp data connection: %w", err))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		data.SetDeadline(deadline)
+	}
+	if _, _, err := ftpCommand(control, []int{125, 150}, "RETR %s", filePath); err != nil {
+		data.Close()
+		return fail(fmt.Errorf("ftp retrieve: %w", err))
+	}
+
+	return &Source{
+		Body:     &ftpSource{data: data, control: control},
+		Size:     size,
+		Filename: path.Base(u.Path),
+	}, nil
+}
+
+// ftpPassiveAddress asks for a passive data port, preferring EPSV. The data connection always
+// goes to the control host, never to an address the server names.
+func ftpPassiveAddress(control *textproto.Conn, host string) (string, error) {
+	if _, message, err := ftpCommand(control, []int{229}, "EPSV"); err == nil {
+		if port, err := parseEPSVPort(message); err == nil {
+			return net.JoinHostPort(host, strconv.Itoa(port)), nil
+		}
+	}
+
+	_, message, err := ftpCommand(control, []int{227}, "PASV")
+	if err != nil {
+		return "", fmt.Errorf("ftp passive mode: %w", err)
+	}
+	port, err := parsePASVPort(message)
+	if err != nil {
+		return "", fmt.Errorf("ftp passive mode: %w", err)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// parseEPSVPort reads the port from an EPSV reply, "Entering Extended Passive Mode (|||6446|)".
+// The server picks the delimiter; the protocol and address fields must be empty.
+func parseEPSVPort(message string) (int, error) {
+	start, end := strings.Index(message, "("), strings.LastIndex(message, ")")
+	if start < 0 || end < start+2 {
+		return 0, fmt.Errorf("unexpected reply %q", message)
+	}
+	fields := message[start+1 : end]
+	parts := strings.Split(fields, fields[:1])
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "" || parts[2] != "" || parts[4] != "" {
+		return 0, fmt.Errorf("unexpected reply %q", message)
+	}
+	return ftpPort(parts[3], message)
+}
+
+// parsePASVPort reads the port from a PASV reply, "Entering Passive Mode (h1,h2,h3,h4,p1,p2)".
+// The address is checked for form but not used.
+func parsePASVPort(message string) (int, error) {
+	start, end := strings.Index(message, "("), strings.Index(message, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("unexpected reply %q", message)
+	}
+	parts := strings.Split(message[start+1:end], ",")
+	if len(parts) != 6 {
+		return 0, fmt.Errorf("unexpected reply %q", message)
+	}
+	var values [6]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 0 || value > 255 {
+			return 0, fmt.Errorf("unexpected reply %q", message)
+		}
+		values[i] = value
+	}
+	return ftpPort(strconv.Itoa(values[4]*256+values[5]), message)
+}
+
+// ftpPort parses a data port named in a passive mode reply
+func ftpPort(value, message string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port in reply %q", message)
+	}
+	return port, nil
+}
+
+// ftpCommand sends a command and accepts any of the expected reply codes
+func ftpCommand(control *textproto.Conn, expected []int, format string, args ...interface{}) (int, string, error) {
+	id, err := control.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	control.StartResponse(id)
+	defer control.EndResponse(id)
+
+	code, message, err := control.ReadResponse(0)
+	if err != nil {
+		return code, message, err
+	}
+	for _, want := range expected {
+		if code == want {
+			return code, message, nil
+		}
+	}
+	return code, message, fmt.Errorf("unexpected reply %d %s", code, message)
+}
@@ -0,0 +1,145 @@
+package fetch
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestParseEPSVPort(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int
+		wantErr bool
+	}{
+		{"Entering Extended Passive Mode (|||6446|)", 6446, false},
+		{"Entering Extended Passive Mode (!!!1!)", 1, false},
+		{"Entering Extended Passive Mode (|||65535|)", 65535, false},
+		{"Entering Extended Passive Mode (|||65536|)", 0, true},
+		{"Entering Extended Passive Mode (|||0|)", 0, true},
+		{"Entering Extended Passive Mode (|||-1|)", 0, true},
+		{"Entering Extended Passive Mode (|||abc|)", 0, true},
+		{"Entering Extended Passive Mode (||||)", 0, true},
+		{"Entering Extended Passive Mode (|1|10.0.0.1|6446|)", 0, true}, // Must not name an address
+		{"Entering Extended Passive Mode (|||6446)", 0, true},
+		{"Entering Extended Passive Mode", 0, true},
+		{"Entering Extended Passive Mode ()", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			port, err := parseEPSVPort(tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEPSVPort error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if port != tt.want {
+				t.Errorf("parseEPSVPort = %d, want %d", port, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePASVPort(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int
+		wantErr bool
+	}{
+		{"Entering Passive Mode (192,168,1,2,25,46)", 25*256 + 46, false},
+		{"Entering Passive Mode (192, 168, 1, 2, 0, 21)", 21, false},
+		{"Entering Passive Mode (10,0,0,1,255,255)", 65535, false},
+		{"Entering Passive Mode (10,0,0,1,0,0)", 0, true},
+		{"Entering Passive Mode (10,0,0,1,256,0)", 0, true},
+		{"Entering Passive Mode (10,0,0,1,1,256)", 0, true},
+		{"Entering Passive Mode (10,0,0,1,-1,10)", 0, true},
+		{"Entering Passive Mode (10,0,0,300,1,10)", 0, true},
+		{"Entering Passive Mode (10,0,0,1,25)", 0, true},
+		{"Entering Passive Mode 10,0,0,1,25,46", 0, true},
+		{"Entering Passive Mode )10,0,0,1,25,46(", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			port, err := parsePASVPort(tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePASVPort error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if port != tt.want {
+				t.Errorf("parsePASVPort = %d, want %d", port, tt.want)
+			}
+		})
+	}
+}
+
+func TestFTPPassiveAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies map[string]string // Command -> reply
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "EPSV",
+			replies: map[string]string{"EPSV": "229 Entering Extended Passive Mode (|||6446|)"},
+			want:    "ftp.example.com:6446",
+		},
+		{
+			name: "PASV fallback",
+			replies: map[string]string{
+				"EPSV": "500 EPSV not understood",
+				"PASV": "227 Entering Passive Mode (10,0,0,99,25,46)",
+			},
+			want: "ftp.example.com:6446", // Never the address the server names
+		},
+		{
+			name: "malformed EPSV",
+			replies: map[string]string{
+				"EPSV": "229 Entering Extended Passive Mode (|||99999|)",
+				"PASV": "227 Entering Passive Mode (10,0,0,99,25,46)",
+			},
+			want: "ftp.example.com:6446",
+		},
+		{
+			name: "no passive mode",
+			replies: map[string]string{
+				"EPSV": "500 EPSV not understood",
+				"PASV": "500 PASV not understood",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			go serveFTP(server, tt.replies)
+
+			address, err := ftpPassiveAddress(textproto.NewConn(client), "ftp.example.com")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ftpPassiveAddress error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if address != tt.want {
+				t.Errorf("ftpPassiveAddress = %q, want %q", address, tt.want)
+			}
+		})
+	}
+}
+
+// serveFTP answers each command line with its reply until the connection is closed
+func serveFTP(conn net.Conn, replies map[string]string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		reply, ok := replies[strings.TrimSpace(line)]
+		if !ok {
+			reply = "502 Command not implemented"
+		}
+		if _, err := conn.Write([]byte(reply + "\r\n")); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package fingerprint computes perceptual hashes of images and video frames. Similar pictures get
+// hashes with a small Hamming distance even after resizing, re-encoding or light colour changes.
+package fingerprint
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GridSize is the side of the grayscale grid frames are reduced to before hashing
+const GridSize = 32
+
+// HashBits is the number of bits in a hash, and so the largest possible distance
+const HashBits = 64
+
+// Gray is a GridSize×GridSize grayscale frame in row-major order
+type Gray []byte
+
+// FromImage reduces an image to a grayscale grid by averaging the pixels that fall in each cell
+func FromImage(img image.Image) Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	gray := make(Gray, GridSize*GridSize)
+	if width == 0 || height == 0 {
+		return gray
+	}
+
+	var sums [GridSize * GridSize]float64
+	var counts [GridSize * GridSize]int
+	for y := 0; y < height; y++ {
+		row := y * GridSize / height
+		for x := 0; x < width; x++ {
+			col := x * GridSize / width
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// ITU-R BT.601 luma, from 16-bit channels
+			sums[row*GridSize+col] += (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			counts[row*GridSize+col]++
+		}
+	}
+	for i := range gray {
+		if counts[i] > 0 {
+			gray[i] = byte(math.Round(sums[i] / float64(counts[i])))
+		}
+	}
+	return gray
+}
+
+// PHash returns the DCT hash: one bit per low-frequency coefficient, set when it is above the median
+func PHash(gray Gray) (uint64, error) {
+	if len(gray) != GridSize*GridSize {
+		return 0, fmt.Errorf("frame has %d pixels, want %d", len(gray), GridSize*GridSize)
+	}
+
+	pixels := make([]float64, len(gray))
+	for i, value := range gray {
+		pixels[i] = float64(value)
+	}
+	coefficients := dct2D(pixels)
+
+	// The top-left 8x8 block holds the lowest frequencies; the DC term is left out of the median
+	// because it only reflects overall brightness
+	low := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			low = append(low, coefficients[y*GridSize+x])
+		}
+	}
+	sorted := append([]float64(nil), low[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, value := range low {
+		if value > median {
+			hash |= 1 << uint(63-i)
+		}
+	}
+	return hash, nil
+}
+
+// DHash returns the difference hash: one bit per horizontally adjacent pair of cells in a 9x8
+// grid, set when brightness increases to the right
+func DHash(gray Gray) (uint64, error) {
+	if len(gray) != GridSize*GridSize {
+		return 0, fmt.Errorf("frame has %d pixels, want %d", len(gray), GridSize*GridSize)
+	}
+
+	var cells [8][9]float64
+	var counts [8][9]int
+	for y := 0; y < GridSize; y++ {
+		row := y * 8 / GridSize
+		for x := 0; x < GridSize; x++ {
+			col := x * 9 / GridSize
+			cells[row][col] += float64(gray[y*GridSize+x])
+			counts[row][col]++
+		}
+	}
+
+	var hash uint64
+	bit := 63
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			left := cells[row][col] / float64(counts[row][col])
+			right := cells[row][col+1] / float64(counts[row][col+1])
+			if right > left {
+				hash |= 1 << uint(bit)
+			}
+			bit--
+		}
+	}
+	return hash, nil
+}
+
+// Distance returns the number of differing bits between two hashes
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similarity converts a distance into a score between 0 (unrelated) and 1 (identical)
+func Similarity(distance float64) float64 {
+	return 1 - distance/HashBits
+}
+
+// Combine returns the bitwise majority of the hashes, a single hash summarizing a video
+func Combine(hashes []uint64) uint64 {
+	var combined uint64
+	for bit := 0; bit < HashBits; bit++ {
+		set := 0
+		for _, hash := range hashes {
+			if hash&(1<<uint(bit)) != 0 {
+				set++
+			}
+		}
+		if set*2 > len(hashes) {
+			combined |= 1 << uint(bit)
+		}
+	}
+	return combined
+}
+
+// SequenceDistance compares two sequences of frame hashes sampled evenly across their videos,
+// pairing frames at the same relative position, and returns the mean distance
+func SequenceDistance(a, b []uint64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return HashBits
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	total := 0
+	for i, hash := range a {
+		total += Distance(hash, b[i*len(b)/len(a)])
+	}
+	return float64(total) / float64(len(a))
+}
+
+// FormatHashes encodes hashes as comma-separated hex for storage
+func FormatHashes(hashes []uint64) string {
+	parts := make([]string, len(hashes))
+	for i, hash := range hashes {
+		parts[i] = strconv.FormatUint(hash, 16)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseHashes decodes hashes written by FormatHashes, skipping malformed entries
+func ParseHashes(value string) []uint64 {
+	var hashes []uint64
+	for _, part := range strings.Split(value, ",") {
+		if hash, err := strconv.ParseUint(strings.TrimSpace(part), 16, 64); err == nil {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// dct2D applies a two-dimensional DCT-II to a GridSize×GridSize block
+func dct2D(pixels []float64) []float64 {
+	n := GridSize
+	cosines := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			cosines[k*n+i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+
+	rows := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			sum := 0.0
+			for x := 0; x < n; x++ {
+				sum += pixels[y*n+x] * cosines[k*n+x]
+			}
+			rows[y*n+k] = sum
+		}
+	}
+
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			sum := 0.0
+			for y := 0; y < n; y++ {
+				sum += rows[y*n+x] * cosines[k*n+y]
+			}
+			out[k*n+x] = sum
+		}
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package fingerprint_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/fingerprint"
+)
+
+// frame returns a grid whose cells are set by value(x, y), clamped to 0..255
+func frame(value func(x, y int) int) fingerprint.Gray {
+	gray := make(fingerprint.Gray, fingerprint.GridSize*fingerprint.GridSize)
+	for y := 0; y < fingerprint.GridSize; y++ {
+		for x := 0; x < fingerprint.GridSize; x++ {
+			v := value(x, y)
+			if v < 0 {
+				v = 0
+			}
+			if v > 255 {
+				v = 255
+			}
+			gray[y*fingerprint.GridSize+x] = byte(v)
+		}
+	}
+	return gray
+}
+
+// scene is a frame with some structure in both directions
+func scene(x, y int) int {
+	v := x*6 + (y%8)*10
+	if x > 12 && x < 20 && y > 8 && y < 24 {
+		v += 60
+	}
+	return v
+}
+
+func TestPHash(t *testing.T) {
+	base, err := fingerprint.PHash(frame(scene))
+	if err != nil {
+		t.Fatalf("PHash returned error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		frame       fingerprint.Gray
+		maxDistance int
+		minDistance int
+	}{
+		{"identical", frame(scene), 0, 0},
+		{"brighter", frame(func(x, y int) int { return scene(x, y) + 15 }), 4, 0},
+		{"lower contrast", frame(func(x, y int) int { return scene(x, y) * 9 / 10 }), 4, 0},
+		{"mirrored", frame(func(x, y int) int { return scene(fingerprint.GridSize-1-x, y) }), fingerprint.HashBits, 16},
+		{"transposed", frame(func(x, y int) int { return scene(y, x) }), fingerprint.HashBits, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := fingerprint.PHash(tt.frame)
+			if err != nil {
+				t.Fatalf("PHash returned error: %v", err)
+			}
+			distance := fingerprint.Distance(base, hash)
+			if distance > tt.maxDistance || distance < tt.minDistance {
+				t.Errorf("distance = %d, want %d..%d", distance, tt.minDistance, tt.maxDistance)
+			}
+		})
+	}
+}
+
+func TestHashesRejectWrongFrameSize(t *testing.T) {
+	for _, size := range []int{0, fingerprint.GridSize, fingerprint.GridSize*fingerprint.GridSize + 1} {
+		if _, err := fingerprint.PHash(make(fingerprint.Gray, size)); err == nil {
+			t.Errorf("PHash accepted a frame of %d pixels", size)
+		}
+		if _, err := fingerprint.DHash(make(fingerprint.Gray, size)); err == nil {
+			t.Errorf("DHash accepted a frame of %d pixels", size)
+		}
+	}
+}
+
+func TestDHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame fingerprint.Gray
+		want  uint64
+	}{
+		{"brighter to the right", frame(func(x, y int) int { return x * 8 }), ^uint64(0)},
+		{"darker to the right", frame(func(x, y int) int { return 255 - x*8 }), 0},
+		{"flat", frame(func(x, y int) int { return 128 }), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := fingerprint.DHash(tt.frame)
+			if err != nil {
+				t.Fatalf("DHash returned error: %v", err)
+			}
+			if hash != tt.want {
+				t.Errorf("DHash = %016x, want %016x", hash, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromImage(t *testing.T) {
+	// Left half black, right half white, at twice the grid resolution
+	img := image.NewGray(image.Rect(0, 0, fingerprint.GridSize*2, fingerprint.GridSize*2))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := fingerprint.GridSize; x < img.Bounds().Dx(); x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	gray := fingerprint.FromImage(img)
+	if len(gray) != fingerprint.GridSize*fingerprint.GridSize {
+		t.Fatalf("FromImage returned %d pixels", len(gray))
+	}
+	for _, x := range []int{0, fingerprint.GridSize/2 - 1, fingerprint.GridSize / 2, fingerprint.GridSize - 1} {
+		want := byte(0)
+		if x >= fingerprint.GridSize/2 {
+			want = 255
+		}
+		if got := gray[5*fingerprint.GridSize+x]; got != want {
+			t.Errorf("cell (%d, 5) = %d, want %d", x, got, want)
+		}
+	}
+
+	if empty := fingerprint.FromImage(image.NewGray(image.Rect(0, 0, 0, 0))); len(empty) != fingerprint.GridSize*fingerprint.GridSize {
+		t.Errorf("FromImage of an empty image returned %d pixels", len(empty))
+	}
+}
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		name   string
+		hashes []uint64
+		want   uint64
+	}{
+		{"none", nil, 0},
+		{"single", []uint64{0xF0F0}, 0xF0F0},
+		{"majority", []uint64{0b1100, 0b1010, 0b1001}, 0b1000},
+		{"tie is unset", []uint64{0b11, 0b01}, 0b01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint.Combine(tt.hashes); got != tt.want {
+				t.Errorf("Combine = %b, want %b", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequenceDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []uint64
+		want float64
+	}{
+		{"empty", nil, []uint64{1}, fingerprint.HashBits},
+		{"identical", []uint64{1, 2, 3}, []uint64{1, 2, 3}, 0},
+		{"one bit per frame", []uint64{0, 0}, []uint64{1, 2}, 1},
+		// The shorter sequence is paired with frames at the same relative position
+		{"different lengths", []uint64{0, 0xFF}, []uint64{0, 0xF, 0xFF, 0xF}, 0},
+		{"order does not matter", []uint64{0, 0xF, 0xFF, 0xF}, []uint64{0, 0xFF}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint.SequenceDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("SequenceDistance = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatParseHashes(t *testing.T) {
+	hashes := []uint64{0, 0xDEADBEEF, ^uint64(0)}
+	encoded := fingerprint.FormatHashes(hashes)
+	if encoded != "0,deadbeef,ffffffffffffffff" {
+		t.Errorf("FormatHashes = %q", encoded)
+	}
+
+	parsed := fingerprint.ParseHashes(encoded + ", zz ,12")
+	want := append(hashes, 0x12)
+	if len(parsed) != len(want) {
+		t.Fatalf("ParseHashes = %x, want %x", parsed, want)
+	}
+	for i := range want {
+		if parsed[i] != want[i] {
+			t.Errorf("ParseHashes[%d] = %x, want %x", i, parsed[i], want[i])
+		}
+	}
+	if parsed := fingerprint.ParseHashes(""); len(parsed) != 0 {
+		t.Errorf("ParseHashes(\"\") = %x, want none", parsed)
+	}
+}
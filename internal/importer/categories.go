@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// maxCategoryName is the length of ow_category.name
+const maxCategoryName = 64
+
+// CategoryResolver maps category paths below an import category to category records,
+// creating missing categories when enabled
+type CategoryResolver struct {
+	repo     repository.CategoryRepository
+	create   bool
+	dryRun   bool
+	byID     map[int]*models.Category
+	children map[int]map[string]*models.Category
+	created  int
+	nextFake int
+}
+
+// NewCategoryResolver loads the existing categories. With create, missing categories are created;
+// with dryRun, they are only reported.
+func NewCategoryResolver(ctx context.Context, repo repository.CategoryRepository, create, dryRun bool) (*CategoryResolver, error) {
+	categories, err := repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	r := &CategoryResolver{
+		repo:     repo,
+		create:   create,
+		dryRun:   dryRun,
+		byID:     make(map[int]*models.Category, len(categories)),
+		children: make(map[int]map[string]*models.Category),
+	}
+	for _, category := range categories {
+		r.add(category)
+	}
+	return r, nil
+}
+
+// Created returns the number of categories created (or that would be created in a dry run)
+func (r *CategoryResolver) Created() int {
+	return r.created
+}
+
+// Get returns a category by ID
+func (r *CategoryResolver) Get(ctx context.Context, id int) (*models.Category, error) {
+	if category, ok := r.byID[id]; ok {
+		return category, nil
+	}
+	category, err := r.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("category %d not found: %w", id, err)
+	}
+	r.add(category)
+	return category, nil
+}
+
+// Resolve returns the category at names below the category rootID. Without category creation
+// the root category is returned.
+func (r *CategoryResolver) Resolve(ctx context.Context, rootID int, names []string) (*models.Category, error) {
+	category, err := r.Get(ctx, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if !r.create {
+		return category, nil
+	}
+
+	for _, name := range names {
+		if len(name) > maxCategoryName {
+			name = name[:maxCategoryName]
+		}
+		child, ok := r.children[category.ID][name]
+		if !ok {
+			if child, err = r.createChild(ctx, category, name); err != nil {
+				return nil, err
+			}
+		}
+		category = child
+	}
+	return category, nil
+}
+
+// createChild creates a category below parent and maintains its hierarchical path
+func (r *CategoryResolver) createChild(ctx context.Context, parent *models.Category, name string) (*models.Category, error) {
+	now := int(time.Now().Unix())
+	child := &models.Category{
+		ParentID: parent.ID,
+		Name:     name,
+		Enabled:  true,
+		Created:  now,
+		Updated:  now,
+	}
+	r.created++
+
+	if r.dryRun {
+		// Placeholder IDs keep the tree consistent for the rest of the dry run
+		r.nextFake--
+		child.ID = r.nextFake
+		child.Path = parent.Path + strconv.Itoa(child.ID) + ","
+		fmt.Printf("  would create category %q under %q\n", name, parent.Name)
+		r.add(child)
+		return child, nil
+	}
+
+	if err := r.repo.Create(ctx, child); err != nil {
+		return nil, fmt.Errorf("failed to create category %q: %w", name, err)
+	}
+	child.Path = parent.Path + strconv.Itoa(child.ID) + ","
+	if err := r.repo.Update(ctx, child); err != nil {
+		return nil, fmt.Errorf("failed to set path of category %q: %w", name, err)
+	}
+	fmt.Printf("  created category %q (ID %d) under %q\n", name, child.ID, parent.Name)
+
+	r.add(child)
+	return child, nil
+}
+
+// add indexes a category by ID and by parent and name
+func (r *CategoryResolver) add(category *models.Category) {
+	r.byID[category.ID] = category
+	if r.children[category.ParentID] == nil {
+		r.children[category.ParentID] = make(map[string]*models.Category)
+	}
+	if _, exists := r.children[category.ParentID][category.Name]; !exists {
+		r.children[category.ParentID][category.Name] = category
+	}
+}
@@ -0,0 +1,411 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/service"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// Options control a bulk import
+type Options struct {
+	CategoryID       int     // Import category; parent of created categories
+	CreateCategories bool    // Create categories from item category paths (folder structure)
+	Username         string  // Uploader recorded on imported files
+	Type             int     // 0 = detect from extension
+	Level            int     // Default browse level
+	Groups           string  // Default groups
+	SidecarTemplate  string  // Mapping template for item sidecars
+	BatchSize        int     // Files rows created per transaction
+	TranscodeRate    float64 // Transcode jobs published per second; 0 disables publishing
+	DryRun           bool
+}
+
+// Stats summarizes an import run
+type Stats struct {
+	Total           int
+	AlreadyDone     int // Recorded in the journal by an earlier run
+	Imported        int
+	Duplicates      int
+	Skipped         int
+	Failed          int
+	Quarantined     int // Imported as flagged records because malware was detected
+	Bytes           int64
+	TranscodeQueued int
+	TranscodeFailed int
+}
+
+// pending is a stored object waiting for its file record to be created
+type pending struct {
+	item *Item
+	file *models.Files
+}
+
+// Importer uploads items directly through storage and creates their file records in batches
+type Importer struct {
+	uploads    *service.UploadService
+	files      *service.FilesService
+	storage    storage.StorageService
+	categories *CategoryResolver
+	journal    *Journal
+	opts       Options
+	stats      Stats
+	transcodes chan *models.Files
+}
+
+// NewImporter creates a new bulk importer
+func NewImporter(uploads *service.UploadService, files *service.FilesService, storageService storage.StorageService, categories *CategoryResolver, journal *Journal, opts Options) *Importer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	return &Importer{
+		uploads:    uploads,
+		files:      files,
+		storage:    storageService,
+		categories: categories,
+		journal:    journal,
+		opts:       opts,
+	}
+}
+
+// Run imports the items not yet recorded in the journal. It stops early when ctx is cancelled;
+// running it again with the same journal resumes where it stopped.
+func (imp *Importer) Run(ctx context.Context, items []*Item) (*Stats, error) {
+	imp.stats = Stats{Total: len(items)}
+
+	done := make(chan struct{})
+	if imp.opts.TranscodeRate > 0 && !imp.opts.DryRun {
+		imp.transcodes = make(chan *models.Files, imp.opts.BatchSize*2)
+		go imp.publishTranscodes(done)
+	} else {
+		close(done)
+	}
+
+	var batch []*pending
+	batchNames := make(map[string]*pending)
+	lastReport := time.Now()
+	var runErr error
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		if imp.journal.Done(item.Source) {
+			imp.stats.AlreadyDone++
+			continue
+		}
+
+		if imp.opts.DryRun {
+			imp.dryRun(ctx, item)
+			continue
+		}
+
+		p, err := imp.store(ctx, item)
+		if err != nil {
+			imp.recordError(item, err)
+			continue
+		}
+
+		// The same content twice in one batch is not yet visible to the duplicate check
+		if first, ok := batchNames[p.file.Name]; ok {
+			policy := imp.files.DuplicatePolicy(ctx, p.file.CategoryID)
+			if policy == models.DuplicatePolicyReject {
+				imp.storage.Delete(ctx, p.file.Path)
+				imp.record(&JournalEntry{Source: item.Source, Status: StatusDuplicate, Error: "same content as " + first.item.Source})
+				continue
+			}
+
+			// Create the pending records so the first copy has an ID to link to
+			if runErr = imp.flush(ctx, batch); runErr != nil {
+				imp.storage.Delete(ctx, p.file.Path)
+				break
+			}
+			batch = batch[:0]
+			batchNames = make(map[string]*pending)
+			if policy == models.DuplicatePolicyWarn && first.file.ID != 0 {
+				p.file.DuplicateOf = first.file.ID
+			}
+		}
+		batchNames[p.file.Name] = p
+		batch = append(batch, p)
+
+		if len(batch) >= imp.opts.BatchSize {
+			if runErr = imp.flush(ctx, batch); runErr != nil {
+				break
+			}
+			batch = batch[:0]
+			batchNames = make(map[string]*pending)
+		}
+
+		if time.Since(lastReport) > 10*time.Second {
+			imp.printProgress()
+			lastReport = time.Now()
+		}
+	}
+
+	if runErr == nil && len(batch) > 0 {
+		// Flush even when cancelled so stored objects get their records
+		runErr = imp.flush(context.WithoutCancel(ctx), batch)
+	}
+
+	if imp.transcodes != nil {
+		close(imp.transcodes)
+	}
+	<-done
+
+	if err := imp.journal.Sync(); err != nil && runErr == nil {
+		runErr = err
+	}
+	return &imp.stats, runErr
+}
+
+// store resolves the item's category and writes its content to storage
+func (imp *Importer) store(ctx context.Context, item *Item) (*pending, error) {
+	category, err := imp.resolveCategory(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	req, file, err := imp.uploadRequest(ctx, item, category)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	record, err := imp.uploads.Store(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	record.CategoryName = category.Name
+	return &pending{item: item, file: record}, nil
+}
+
+// uploadRequest builds the upload request for an item, opening its source file
+func (imp *Importer) uploadRequest(ctx context.Context, item *Item, category *models.Category) (*service.UploadRequest, *os.File, error) {
+	ext := strings.ToLower(filepath.Ext(item.Source))
+	fileType := firstNonZero(item.Type, imp.opts.Type)
+	if !imp.supported(ctx, category.ID, item.Source) {
+		return nil, nil, &skipError{reason: fmt.Sprintf("unsupported file type: %s", ext)}
+	}
+
+	var catalogInfo string
+	if len(item.CatalogInfo) > 0 {
+		data, err := json.Marshal(item.CatalogInfo)
+		if err != nil {
+			return nil, nil, err
+		}
+		catalogInfo = string(data)
+	}
+
+	var sidecar *service.SidecarInput
+	if item.Sidecar != "" {
+		if imp.opts.SidecarTemplate == "" {
+			return nil, nil, fmt.Errorf("item has a sidecar but no sidecar template is configured")
+		}
+		content, err := os.ReadFile(item.Sidecar)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read sidecar: %w", err)
+		}
+		sidecar = &service.SidecarInput{
+			Filename: filepath.Base(item.Sidecar),
+			Content:  content,
+			Template: imp.opts.SidecarTemplate,
+		}
+	}
+
+	file, err := os.Open(item.Source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open source: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to stat source: %w", err)
+	}
+
+	groups := item.Groups
+	if groups == "" {
+		groups = imp.opts.Groups
+	}
+
+	return &service.UploadRequest{
+		Filename:       filepath.Base(item.Source),
+		Content:        file,
+		Size:           info.Size(),
+		ContentType:    mime.TypeByExtension(ext),
+		CategoryID:     category.ID,
+		Title:          item.Title,
+		Type:           fileType,
+		Level:          firstNonZero(item.Level, imp.opts.Level),
+		Groups:         groups,
+		CatalogInfo:    catalogInfo,
+		Username:       imp.opts.Username,
+		Metadata:       map[string]string{"import-source": item.Source},
+		Sidecar:        sidecar,
+		SkipQuotaCheck: true,
+	}, file, nil
+}
+
+// resolveCategory returns the item's explicit category or its category path below the import category
+func (imp *Importer) resolveCategory(ctx context.Context, item *Item) (*models.Category, error) {
+	if item.CategoryID > 0 {
+		return imp.categories.Get(ctx, item.CategoryID)
+	}
+	return imp.categories.Resolve(ctx, imp.opts.CategoryID, item.CategoryNames())
+}
+
+// flush creates the batch's file records in one transaction. If the transaction fails, records are
+// created one by one so a single bad row only fails its own item.
+func (imp *Importer) flush(ctx context.Context, batch []*pending) error {
+	files := make([]*models.Files, len(batch))
+	for i, p := range batch {
+		files[i] = p.file
+	}
+
+	if err := imp.files.CreateFiles(ctx, files); err != nil {
+		fmt.Printf("⚠ Batch insert failed, creating %d records individually: %v\n", len(batch), err)
+		for _, p := range batch {
+			p.file.ID = 0
+			if err := imp.files.CreateFiles(ctx, []*models.Files{p.file}); err != nil {
+				imp.storage.Delete(ctx, p.file.Path)
+				imp.recordError(p.item, err)
+				continue
+			}
+			imp.imported(p)
+		}
+	} else {
+		for _, p := range batch {
+			imp.imported(p)
+		}
+	}
+
+	return imp.journal.Sync()
+}
+
+// imported records a created file and queues its transcode job
+func (imp *Importer) imported(p *pending) {
+	imp.stats.Imported++
+	imp.stats.Bytes += p.file.Size
+	imp.record(&JournalEntry{Source: p.item.Source, Status: StatusImported, FileID: p.file.ID})
+
+	if p.file.ScanStatus == models.ScanStatusInfected {
+		imp.stats.Quarantined++
+		fmt.Printf("  ⚠ %s: malware detected (%s), quarantined as file %d\n", p.item.Source, p.file.ScanResult, p.file.ID)
+		return
+	}
+
+	if imp.transcodes != nil && (p.file.Type == models.FileTypeVideo || p.file.Type == models.FileTypeAudio) {
+		imp.transcodes <- p.file
+	}
+}
+
+// publishTranscodes publishes queued transcode jobs at the configured rate
+func (imp *Importer) publishTranscodes(done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / imp.opts.TranscodeRate))
+	defer ticker.Stop()
+
+	for file := range imp.transcodes {
+		<-ticker.C
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := imp.uploads.PublishTranscode(ctx, file)
+		cancel()
+		if err != nil {
+			fmt.Printf("  ✗ transcode job for file %d not published: %v\n", file.ID, err)
+			imp.stats.TranscodeFailed++
+			continue
+		}
+		imp.stats.TranscodeQueued++
+	}
+}
+
+// dryRun reports what would be imported for an item without storing anything
+func (imp *Importer) dryRun(ctx context.Context, item *Item) {
+	category, err := imp.resolveCategory(ctx, item)
+	if err != nil {
+		imp.stats.Failed++
+		fmt.Printf("  ✗ %s: %v\n", item.Source, err)
+		return
+	}
+	if !imp.supported(ctx, category.ID, item.Source) {
+		imp.stats.Skipped++
+		return
+	}
+	imp.stats.Imported++
+	imp.stats.Bytes += item.Size
+	fmt.Printf("  would import %s -> %q (category %s)\n", item.Source, item.Title, category.Name)
+}
+
+// supported reports whether the category's file type rules allow the item's extension
+func (imp *Importer) supported(ctx context.Context, categoryID int, source string) bool {
+	if categoryID < 0 {
+		categoryID = imp.opts.CategoryID // Categories a dry run would create inherit the import category's rules
+	}
+	return imp.uploads.FileTypeRules().DetectType(ctx, categoryID, source) != 0
+}
+
+// recordError journals a failed, skipped or duplicate item
+func (imp *Importer) recordError(item *Item, err error) {
+	entry := &JournalEntry{Source: item.Source, Status: StatusFailed, Error: err.Error()}
+	switch e := err.(type) {
+	case *service.DuplicateFileError:
+		entry.Status = StatusDuplicate
+		entry.ExistingFileID = e.ExistingFile.ID
+	case *skipError:
+		entry.Status = StatusSkipped
+	default:
+		fmt.Printf("  ✗ %s: %v\n", item.Source, err)
+	}
+	imp.record(entry)
+}
+
+// record journals an entry and updates the counters
+func (imp *Importer) record(entry *JournalEntry) {
+	switch entry.Status {
+	case StatusDuplicate:
+		imp.stats.Duplicates++
+	case StatusSkipped:
+		imp.stats.Skipped++
+	case StatusFailed:
+		imp.stats.Failed++
+	}
+	if err := imp.journal.Record(entry); err != nil {
+		fmt.Printf("⚠ %v\n", err)
+	}
+}
+
+// printProgress prints the running counters
+func (imp *Importer) printProgress() {
+	s := &imp.stats // Transcode counters belong to the publisher goroutine
+	processed := s.AlreadyDone + s.Imported + s.Duplicates + s.Skipped + s.Failed
+	fmt.Printf("Progress: %d/%d (imported %d, duplicates %d, skipped %d, failed %d, %.1f MB)\n",
+		processed, s.Total, s.Imported, s.Duplicates, s.Skipped, s.Failed, float64(s.Bytes)/(1024*1024))
+}
+
+// skipError marks items that are not imported and not retried, such as unsupported file types
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string {
+	return e.reason
+}
+
+// firstNonZero returns the first non-zero value
+func firstNonZero(values ...int) int {
+	for _, v := range values {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
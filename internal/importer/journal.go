@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Journal entry statuses
+const (
+	StatusImported  = "imported"
+	StatusDuplicate = "duplicate"
+	StatusSkipped   = "skipped"
+	StatusFailed    = "failed"
+)
+
+// JournalEntry records the outcome for one source file
+type JournalEntry struct {
+	Source         string    `json:"source"`
+	Status         string    `json:"status"`
+	FileID         uint64    `json:"file_id,omitempty"`
+	ExistingFileID uint64    `json:"existing_file_id,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// Journal is an append-only JSON-lines progress log. An interrupted import is resumed by running
+// it again with the same journal: sources with a final status are skipped, failed ones retried.
+type Journal struct {
+	file    *os.File
+	writer  *bufio.Writer
+	entries map[string]*JournalEntry
+}
+
+// OpenJournal loads the journal at path, creating it if needed
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{entries: make(map[string]*JournalEntry)}
+
+	if existing, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry JournalEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue // A line cut short by a crash
+			}
+			j.entries[entry.Source] = &entry
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.file = file
+	j.writer = bufio.NewWriter(file)
+	return j, nil
+}
+
+// Done reports whether a source has already been imported, detected as duplicate or skipped
+func (j *Journal) Done(source string) bool {
+	entry, ok := j.entries[source]
+	return ok && entry.Status != StatusFailed
+}
+
+// Len returns the number of sources recorded in the journal
+func (j *Journal) Len() int {
+	return len(j.entries)
+}
+
+// Record appends an entry; call Sync to make recorded entries durable
+func (j *Journal) Record(entry *JournalEntry) error {
+	entry.Time = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.entries[entry.Source] = entry
+	if _, err := j.writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Sync flushes recorded entries to disk
+func (j *Journal) Sync() error {
+	if err := j.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return j.file.Sync()
+}
+
+// Close flushes and closes the journal
+func (j *Journal) Close() error {
+	if err := j.Sync(); err != nil {
+		j.file.Close()
+		return err
+	}
+	return j.file.Close()
+}
@@ -0,0 +1,234 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/openwan/media-asset-management/internal/ingest"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// Item is one file to import, from a directory scan or a manifest row
+type Item struct {
+	Source      string            `json:"path"`              // File path (manifest paths are relative to the manifest)
+	Title       string            `json:"title"`             // Default: filename without extension
+	CategoryID  int               `json:"category_id"`       // 0 = import category, or Category below it
+	Category    string            `json:"category"`          // Category path below the import category, "News/2019"
+	Type        int               `json:"type"`              // 0 = detect from extension
+	Level       int               `json:"level"`             // 0 = import default
+	Groups      string            `json:"groups"`            // "" = import default
+	CatalogInfo map[string]string `json:"catalog_info"`      // Catalog field values
+	Sidecar     string            `json:"sidecar,omitempty"` // Metadata sidecar path
+	Size        int64             `json:"-"`
+}
+
+// CategoryNames splits the item's category path into names
+func (i *Item) CategoryNames() []string {
+	var names []string
+	for _, name := range strings.Split(filepath.ToSlash(i.Category), "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ScanDirectory lists the files below root in lexical order. Subdirectories become the item's
+// category path; with pairSidecars, metadata sidecars are attached to their media file instead
+// of being imported themselves.
+func ScanDirectory(root string, pairSidecars bool) ([]*Item, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*Item
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && path != root {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+		if pairSidecars && service.IsSidecarFile(name) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			rel = ""
+		}
+
+		item := &Item{
+			Source:   path,
+			Title:    strings.TrimSuffix(name, filepath.Ext(name)),
+			Category: filepath.ToSlash(rel),
+			Size:     info.Size(),
+		}
+		if pairSidecars {
+			item.Sidecar = ingest.FindSidecar(path)
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return items, nil
+}
+
+// LoadManifest reads items from a CSV or JSON manifest. CSV manifests have a header row with the
+// columns path, title, category_id, category, type, level, groups and sidecar; any column named
+// catalog.<name> sets that catalog field. JSON manifests are an array of Item objects.
+func LoadManifest(path string) ([]*Item, error) {
+	var items []*Item
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		items, err = loadCSVManifest(path)
+	case ".json":
+		items, err = loadJSONManifest(path)
+	default:
+		return nil, fmt.Errorf("unsupported manifest format: %s (expected .csv or .json)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve relative paths against the manifest directory and fill in defaults
+	base := filepath.Dir(path)
+	for i, item := range items {
+		if item.Source == "" {
+			return nil, fmt.Errorf("manifest entry %d has no path", i+1)
+		}
+		item.Source = resolvePath(base, item.Source)
+		if item.Sidecar != "" {
+			item.Sidecar = resolvePath(base, item.Sidecar)
+		}
+		if item.Title == "" {
+			name := filepath.Base(item.Source)
+			item.Title = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		if info, err := os.Stat(item.Source); err == nil {
+			item.Size = info.Size()
+		}
+	}
+	return items, nil
+}
+
+// loadCSVManifest reads a CSV manifest with a header row
+func loadCSVManifest(path string) ([]*Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("CSV manifest has no entries")
+	}
+
+	header := records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	items := make([]*Item, 0, len(records)-1)
+	for row, record := range records[1:] {
+		item := &Item{}
+		for col, value := range record {
+			column := strings.TrimSpace(header[col])
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			switch column {
+			case "path":
+				item.Source = value
+			case "title":
+				item.Title = value
+			case "category":
+				item.Category = value
+			case "groups":
+				item.Groups = value
+			case "sidecar":
+				item.Sidecar = value
+			case "category_id", "type", "level":
+				number, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("manifest row %d: %s must be a number, got %q", row+2, column, value)
+				}
+				switch column {
+				case "category_id":
+					item.CategoryID = number
+				case "type":
+					item.Type = number
+				case "level":
+					item.Level = number
+				}
+			default:
+				if key := strings.TrimPrefix(column, service.CatalogTargetPrefix); key != column && key != "" {
+					if item.CatalogInfo == nil {
+						item.CatalogInfo = make(map[string]string)
+					}
+					item.CatalogInfo[key] = value
+				}
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// loadJSONManifest reads a JSON array of items
+func loadJSONManifest(path string) ([]*Item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var items []*Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid JSON manifest: %w", err)
+	}
+	return items, nil
+}
+
+// resolvePath makes path absolute relative to base
+func resolvePath(base, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,98 @@
+package ingest
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config holds the watch-folder ingest configuration
+type Config struct {
+	Username     string         `mapstructure:"username"`      // Uploader recorded on ingested files
+	PollInterval time.Duration  `mapstructure:"poll_interval"` // Full rescan interval (polling fallback)
+	StableFor    time.Duration  `mapstructure:"stable_for"`    // Size and mtime must be unchanged for this long
+	Folders      []FolderConfig `mapstructure:"folders"`
+}
+
+// FolderConfig maps one watched directory to the properties of the files ingested from it
+type FolderConfig struct {
+	Path       string            `mapstructure:"path"`
+	DoneDir    string            `mapstructure:"done_dir"`   // Default: <path>/done
+	FailedDir  string            `mapstructure:"failed_dir"` // Default: <path>/failed
+	CategoryID int               `mapstructure:"category_id"`
+	Type       int               `mapstructure:"type"`     // 0 = detect from extension
+	Level      int               `mapstructure:"level"`    // Default browse level
+	Groups     string            `mapstructure:"groups"`   // Comma-separated group IDs or "all"
+	Username   string            `mapstructure:"username"` // Overrides Config.Username
+	Title      string            `mapstructure:"title"`    // Title template, default "{{.Basename}}"
+	Metadata   map[string]string `mapstructure:"metadata"` // Catalog field templates (catalog_info)
+
+	// Metadata sidecars (clip.mov + clip.xml / clip.mov.xml) are mapped with this template
+	SidecarTemplate string `mapstructure:"sidecar_template"`
+	SidecarRequired bool   `mapstructure:"sidecar_required"` // Wait for a sidecar before ingesting
+}
+
+// LoadConfig reads the ingest configuration from a YAML or JSON file
+func LoadConfig(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetDefault("username", "ingest")
+	v.SetDefault("poll_interval", "10s")
+	v.SetDefault("stable_for", "30s")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read ingest config: %w", err)
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ingest config: %w", err)
+	}
+
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// normalize validates the configuration and fills in defaults
+func (c *Config) normalize() error {
+	if len(c.Folders) == 0 {
+		return fmt.Errorf("no ingest folders configured")
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 10 * time.Second
+	}
+	if c.StableFor <= 0 {
+		c.StableFor = 30 * time.Second
+	}
+
+	for i := range c.Folders {
+		folder := &c.Folders[i]
+		if folder.Path == "" {
+			return fmt.Errorf("ingest folder %d has no path", i+1)
+		}
+		if folder.CategoryID <= 0 {
+			return fmt.Errorf("ingest folder %s has no category_id", folder.Path)
+		}
+		folder.Path = filepath.Clean(folder.Path)
+		if folder.DoneDir == "" {
+			folder.DoneDir = filepath.Join(folder.Path, "done")
+		}
+		if folder.FailedDir == "" {
+			folder.FailedDir = filepath.Join(folder.Path, "failed")
+		}
+		if folder.Username == "" {
+			folder.Username = c.Username
+		}
+		if folder.SidecarRequired && folder.SidecarTemplate == "" {
+			return fmt.Errorf("ingest folder %s requires sidecars but has no sidecar_template", folder.Path)
+		}
+		if folder.Title == "" {
+			folder.Title = "{{.Basename}}"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// Report statuses written to the sidecar report
+const (
+	ReportStatusIngested    = "ingested"
+	ReportStatusDuplicate   = "duplicate"
+	ReportStatusQuarantined = "quarantined" // Malware detected; the flagged record points at the quarantined copy
+	ReportStatusFailed      = "failed"
+)
+
+// ReportSuffix is appended to the source filename for the sidecar report
+const ReportSuffix = ".report.json"
+
+// Report is the sidecar written next to each processed source file
+type Report struct {
+	Source         string    `json:"source"`
+	Status         string    `json:"status"`
+	FileID         uint64    `json:"file_id,omitempty"`
+	ExistingFileID uint64    `json:"existing_file_id,omitempty"` // Rejected duplicate, or the file a warned duplicate is linked to
+	Title          string    `json:"title,omitempty"`
+	CategoryID     int       `json:"category_id"`
+	Size           int64     `json:"size"`
+	StoragePath    string    `json:"storage_path,omitempty"`
+	Sidecar        string    `json:"sidecar,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+
+	// Per-field problems found in the sidecar
+	ValidationErrors []service.SidecarFieldError `json:"validation_errors,omitempty"`
+}
+
+// TemplateData is available to title and metadata templates
+type TemplateData struct {
+	Filename string    // clip_01.mov
+	Basename string    // clip_01
+	Ext      string    // .mov
+	Folder   string    // Watched folder path
+	Size     int64     // Bytes
+	ModTime  time.Time // Source modification time
+	Now      time.Time // Ingest time
+}
+
+// Processor ingests single files through the shared upload path
+type Processor struct {
+	uploads *service.UploadService
+}
+
+// NewProcessor creates a new ingest processor
+func NewProcessor(uploads *service.UploadService) *Processor {
+	return &Processor{uploads: uploads}
+}
+
+// Process ingests the file at path and moves it with its report to the folder's done or failed directory
+func (p *Processor) Process(ctx context.Context, folder *FolderConfig, path string) *Report {
+	report := &Report{
+		Source:     path,
+		CategoryID: folder.CategoryID,
+		StartedAt:  time.Now(),
+	}
+
+	if err := p.ingest(ctx, folder, path, report); err != nil {
+		report.Error = err.Error()
+		switch ingestErr := err.(type) {
+		case *service.DuplicateFileError:
+			report.Status = ReportStatusDuplicate
+			report.ExistingFileID = ingestErr.ExistingFile.ID
+		case *service.SidecarValidationError:
+			report.Status = ReportStatusFailed
+			report.ValidationErrors = ingestErr.Errors
+		case *service.MalwareDetectedError:
+			report.Status = ReportStatusQuarantined
+			report.FileID = ingestErr.File.ID
+			report.StoragePath = ingestErr.File.Path
+		default:
+			report.Status = ReportStatusFailed
+		}
+	} else {
+		report.Status = ReportStatusIngested
+	}
+	report.FinishedAt = time.Now()
+
+	targetDir := folder.DoneDir
+	if report.Status != ReportStatusIngested {
+		targetDir = folder.FailedDir
+	}
+
+	moved, err := moveFile(path, targetDir)
+	if err != nil {
+		// Leave the source in place; the report still records the outcome
+		report.Error = strings.TrimSpace(report.Error + "; failed to move source: " + err.Error())
+		moved = filepath.Join(targetDir, filepath.Base(path))
+	}
+	if report.Sidecar != "" {
+		if _, err := moveFile(report.Sidecar, targetDir); err != nil {
+			report.Error = strings.TrimSpace(report.Error + "; failed to move sidecar: " + err.Error())
+		}
+	}
+	if err := writeReport(moved+ReportSuffix, report); err != nil {
+		fmt.Printf("⚠ Failed to write ingest report for %s: %v\n", path, err)
+	}
+
+	return report
+}
+
+// ingest uploads one file, filling the report with the result
+func (p *Processor) ingest(ctx context.Context, folder *FolderConfig, path string, report *Report) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open source: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source: %w", err)
+	}
+	report.Size = info.Size()
+
+	filename := filepath.Base(path)
+	ext := filepath.Ext(filename)
+	data := TemplateData{
+		Filename: filename,
+		Basename: strings.TrimSuffix(filename, ext),
+		Ext:      ext,
+		Folder:   folder.Path,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Now:      time.Now(),
+	}
+
+	title, err := render("title", folder.Title, data)
+	if err != nil {
+		return err
+	}
+	report.Title = title
+
+	catalogInfo, err := renderMetadata(folder.Metadata, data)
+	if err != nil {
+		return err
+	}
+
+	var sidecar *service.SidecarInput
+	if folder.SidecarTemplate != "" {
+		if sidecarPath := FindSidecar(path); sidecarPath != "" {
+			report.Sidecar = sidecarPath
+			content, err := os.ReadFile(sidecarPath)
+			if err != nil {
+				return fmt.Errorf("failed to read sidecar: %w", err)
+			}
+			sidecar = &service.SidecarInput{
+				Filename: filepath.Base(sidecarPath),
+				Content:  content,
+				Template: folder.SidecarTemplate,
+			}
+		} else if folder.SidecarRequired {
+			return fmt.Errorf("no sidecar found for %s", filename)
+		}
+	}
+
+	created, err := p.uploads.Upload(ctx, &service.UploadRequest{
+		Filename:    filename,
+		Content:     file,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(strings.ToLower(ext)),
+		CategoryID:  folder.CategoryID,
+		Title:       title,
+		Type:        folder.Type,
+		Level:       folder.Level,
+		Groups:      folder.Groups,
+		CatalogInfo: catalogInfo,
+		Username:    folder.Username,
+		Metadata: map[string]string{
+			"ingest-source": path,
+		},
+		Sidecar: sidecar,
+	})
+	if err != nil {
+		return err
+	}
+
+	report.FileID = created.ID
+	report.ExistingFileID = created.DuplicateOf
+	report.StoragePath = created.Path
+	return nil
+}
+
+// FindSidecar returns the metadata sidecar delivered with a media file, or "" if there is none.
+// Both clip.xml and clip.mov.xml are recognised, for each sidecar format.
+func FindSidecar(path string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, prefix := range []string{path, base} {
+		for _, ext := range []string{".xml", ".json", ".csv"} {
+			candidate := prefix + ext
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// render executes a text template against the file data
+func render(name, text string, data TemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// renderMetadata renders the catalog field templates into catalog_info JSON
+func renderMetadata(templates map[string]string, data TemplateData) (string, error) {
+	if len(templates) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]string, len(templates))
+	for key, text := range templates {
+		value, err := render("metadata."+key, text, data)
+		if err != nil {
+			return "", err
+		}
+		values[key] = value
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode catalog metadata: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// moveFile moves path into dir, adding a timestamp if the name is taken, and returns the new path
+func moveFile(path, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(target)
+		target = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(target, ext), time.Now().Format("20060102150405"), ext)
+	}
+
+	if err := os.Rename(path, target); err == nil {
+		return target, nil
+	}
+
+	// Rename fails across filesystems; fall back to copy and remove
+	if err := copyFile(path, target); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// copyFile copies the contents of src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// writeReport writes the report as indented JSON
+func writeReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
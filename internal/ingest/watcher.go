@@ -0,0 +1,231 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+// candidate is a file seen in a watched folder that has not been ingested yet
+type candidate struct {
+	folder    *FolderConfig
+	size      int64
+	modTime   time.Time
+	changedAt time.Time
+}
+
+// Watcher watches the configured folders and hands files to the processor once they are stable.
+// Change notifications use inotify where available; a periodic rescan covers network shares and
+// platforms where notifications are not delivered.
+type Watcher struct {
+	cfg        *Config
+	processor  *Processor
+	candidates map[string]*candidate
+}
+
+// NewWatcher creates a new watch-folder watcher
+func NewWatcher(cfg *Config, processor *Processor) *Watcher {
+	return &Watcher{
+		cfg:        cfg,
+		processor:  processor,
+		candidates: make(map[string]*candidate),
+	}
+}
+
+// Run watches the folders until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	for i := range w.cfg.Folders {
+		folder := &w.cfg.Folders[i]
+		for _, dir := range []string{folder.Path, folder.DoneDir, folder.FailedDir} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+	}
+
+	var events <-chan fsnotify.Event
+	var notifyErrors <-chan error
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Printf("⚠ File notifications unavailable, polling every %s: %v\n", w.cfg.PollInterval, err)
+	} else {
+		defer notifier.Close()
+		for _, folder := range w.cfg.Folders {
+			if err := notifier.Add(folder.Path); err != nil {
+				fmt.Printf("⚠ Cannot watch %s, polling every %s: %v\n", folder.Path, w.cfg.PollInterval, err)
+			}
+		}
+		events = notifier.Events
+		notifyErrors = notifier.Errors
+	}
+
+	// Pick up files that arrived while the service was down
+	w.scan()
+
+	pollTicker := time.NewTicker(w.cfg.PollInterval)
+	defer pollTicker.Stop()
+	stableTicker := time.NewTicker(w.stableCheckInterval())
+	defer stableTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+				if folder := w.folderFor(event.Name); folder != nil {
+					w.observe(folder, event.Name)
+				}
+			}
+
+		case err, ok := <-notifyErrors:
+			if !ok {
+				notifyErrors = nil
+				continue
+			}
+			fmt.Printf("⚠ File notification error: %v\n", err)
+
+		case <-pollTicker.C:
+			w.scan()
+
+		case <-stableTicker.C:
+			w.processStable(ctx)
+		}
+	}
+}
+
+// scan lists every watched folder and records new or changed files
+func (w *Watcher) scan() {
+	for i := range w.cfg.Folders {
+		folder := &w.cfg.Folders[i]
+		entries, err := os.ReadDir(folder.Path)
+		if err != nil {
+			fmt.Printf("⚠ Failed to scan %s: %v\n", folder.Path, err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			w.observe(folder, filepath.Join(folder.Path, entry.Name()))
+		}
+	}
+}
+
+// observe records the current size and modification time of a file
+func (w *Watcher) observe(folder *FolderConfig, path string) {
+	if ignored(filepath.Base(path)) {
+		return
+	}
+	// Sidecars are picked up together with their media file
+	if folder.SidecarTemplate != "" && service.IsSidecarFile(path) {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		delete(w.candidates, path)
+		return
+	}
+
+	c, ok := w.candidates[path]
+	if !ok {
+		w.candidates[path] = &candidate{
+			folder:    folder,
+			size:      info.Size(),
+			modTime:   info.ModTime(),
+			changedAt: time.Now(),
+		}
+		return
+	}
+	if c.size != info.Size() || !c.modTime.Equal(info.ModTime()) {
+		c.size = info.Size()
+		c.modTime = info.ModTime()
+		c.changedAt = time.Now()
+	}
+}
+
+// processStable ingests files whose size and modification time have not changed for StableFor
+func (w *Watcher) processStable(ctx context.Context) {
+	now := time.Now()
+	for path, c := range w.candidates {
+		// Refresh the state so files still being written keep waiting
+		w.observe(c.folder, path)
+		current, ok := w.candidates[path]
+		if !ok || now.Sub(current.changedAt) < w.cfg.StableFor {
+			continue
+		}
+		if current.folder.SidecarTemplate != "" && !w.sidecarReady(current.folder, path, now) {
+			continue
+		}
+
+		delete(w.candidates, path)
+		fmt.Printf("Ingesting %s\n", path)
+		report := w.processor.Process(ctx, current.folder, path)
+		switch report.Status {
+		case ReportStatusIngested:
+			fmt.Printf("✓ Ingested %s as file %d\n", path, report.FileID)
+		case ReportStatusDuplicate:
+			fmt.Printf("⚠ Duplicate %s (existing file %d)\n", path, report.ExistingFileID)
+		default:
+			fmt.Printf("✗ Failed to ingest %s: %s\n", path, report.Error)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// sidecarReady reports whether the file can be ingested with respect to its sidecar: a sidecar that
+// is present must be stable, and folders requiring sidecars wait until one arrives
+func (w *Watcher) sidecarReady(folder *FolderConfig, path string, now time.Time) bool {
+	sidecar := FindSidecar(path)
+	if sidecar == "" {
+		return !folder.SidecarRequired
+	}
+	info, err := os.Stat(sidecar)
+	return err == nil && now.Sub(info.ModTime()) >= w.cfg.StableFor
+}
+
+// folderFor returns the watched folder directly containing path
+func (w *Watcher) folderFor(path string) *FolderConfig {
+	dir := filepath.Dir(path)
+	for i := range w.cfg.Folders {
+		if w.cfg.Folders[i].Path == dir {
+			return &w.cfg.Folders[i]
+		}
+	}
+	return nil
+}
+
+// stableCheckInterval returns how often candidates are checked for stability
+func (w *Watcher) stableCheckInterval() time.Duration {
+	interval := w.cfg.StableFor / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	if interval > 10*time.Second {
+		interval = 10 * time.Second
+	}
+	return interval
+}
+
+// ignored reports whether a file should never be ingested (hidden, temporary or reports)
+func ignored(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, ReportSuffix) ||
+		strings.HasSuffix(name, ".tmp") ||
+		strings.HasSuffix(name, ".part")
+}
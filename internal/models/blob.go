@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Blob is a content-addressed stored object that can be referenced by several files
+type Blob struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Hash      string    `gorm:"column:hash;type:char(64);not null;index" json:"hash"` // SHA-256 of the content
+	Path      string    `gorm:"column:path;type:varchar(255);not null;uniqueIndex" json:"path"`
+	Size      int64     `gorm:"column:size;not null;default:0" json:"size"`
+	RefCount  int64     `gorm:"column:ref_count;not null;default:0" json:"ref_count"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for Blob
+func (Blob) TableName() string {
+	return "ow_blobs"
+}
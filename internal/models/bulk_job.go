@@ -0,0 +1,65 @@
+package models
+
+// Bulk job statuses; jobs reuse the fetch job lifecycle
+const (
+	BulkStatusQueued    = "queued"
+	BulkStatusRunning   = "running"
+	BulkStatusCompleted = "completed"
+	BulkStatusFailed    = "failed"
+)
+
+// Bulk item statuses
+const (
+	BulkItemPending   = "pending"
+	BulkItemSucceeded = "succeeded"
+	BulkItemFailed    = "failed"
+)
+
+// Bulk actions
+const (
+	BulkActionSubmit  = "submit"
+	BulkActionPublish = "publish"
+	BulkActionReject  = "reject"
+	BulkActionMove    = "move"    // Change category
+	BulkActionAccess  = "access"  // Change level and/or groups
+	BulkActionCatalog = "catalog" // Patch catalog fields
+)
+
+// BulkJob applies one action to a set of files in the background. The files are resolved when the
+// job is submitted and recorded as its items.
+type BulkJob struct {
+	ID          uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Action      string `gorm:"column:action;type:varchar(16);not null" json:"action"`
+	Params      string `gorm:"column:params;type:text;not null" json:"params"` // Action parameters (JSON)
+	UserID      int    `gorm:"column:user_id;not null" json:"user_id"`
+	Username    string `gorm:"column:username;type:varchar(64);not null;index" json:"username"`
+	Status      string `gorm:"column:status;type:varchar(16);not null;index" json:"status"`
+	Total       int    `gorm:"column:total;not null;default:0" json:"total"`
+	Succeeded   int    `gorm:"column:succeeded;not null;default:0" json:"succeeded"`
+	Failed      int    `gorm:"column:failed;not null;default:0" json:"failed"`
+	Error       string `gorm:"column:error;type:varchar(1024);not null;default:''" json:"error,omitempty"`
+	Created     int    `gorm:"column:created;not null" json:"created"`                               // Unix timestamp
+	StartedAt   int    `gorm:"column:started_at;not null;default:0" json:"started_at,omitempty"`     // Unix timestamp
+	FinishedAt  int    `gorm:"column:finished_at;not null;default:0" json:"finished_at,omitempty"`   // Unix timestamp
+	HeartbeatAt int    `gorm:"column:heartbeat_at;not null;default:0" json:"heartbeat_at,omitempty"` // Unix timestamp; refreshed as a running job records results
+}
+
+// TableName specifies the table name for BulkJob
+func (BulkJob) TableName() string {
+	return "ow_bulk_jobs"
+}
+
+// BulkJobItem is the result of a bulk job for one file
+type BulkJobItem struct {
+	ID        uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	JobID     uint64 `gorm:"column:job_id;not null;index" json:"job_id"`
+	FileID    uint64 `gorm:"column:file_id;not null" json:"file_id"`
+	Status    string `gorm:"column:status;type:varchar(16);not null" json:"status"`
+	Error     string `gorm:"column:error;type:varchar(1024);not null;default:''" json:"error,omitempty"`
+	Processed int    `gorm:"column:processed;not null;default:0" json:"processed,omitempty"` // Unix timestamp
+}
+
+// TableName specifies the table name for BulkJobItem
+func (BulkJobItem) TableName() string {
+	return "ow_bulk_job_items"
+}
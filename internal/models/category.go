@@ -2,18 +2,52 @@ package models
 
 // Category represents the ow_category table for hierarchical resource classification
 type Category struct {
-	ID          int    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	ParentID    int    `gorm:"column:parent_id;not null;index" json:"parent_id"`
-	Path        string `gorm:"column:path;type:varchar(255);not null;index" json:"path"` // hierarchical path like "-1,1,2,"
-	Name        string `gorm:"column:name;type:varchar(64);not null" json:"name"`
-	Description string `gorm:"column:description;type:varchar(255);not null;default:''" json:"description"`
-	Weight      int    `gorm:"column:weight;not null;default:0" json:"weight"`
-	Enabled     bool   `gorm:"column:enabled;type:tinyint(2);not null;default:true" json:"enabled"`
-	Created     int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
-	Updated     int    `gorm:"column:updated;not null" json:"updated"` // Unix timestamp
+	ID                 int    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ParentID           int    `gorm:"column:parent_id;not null;index" json:"parent_id"`
+	Path               string `gorm:"column:path;type:varchar(255);not null;index" json:"path"` // hierarchical path like "-1,1,2,"
+	Name               string `gorm:"column:name;type:varchar(64);not null" json:"name"`
+	Description        string `gorm:"column:description;type:varchar(255);not null;default:''" json:"description"`
+	Weight             int    `gorm:"column:weight;not null;default:0" json:"weight"`
+	Enabled            bool   `gorm:"column:enabled;type:tinyint(2);not null;default:true" json:"enabled"`
+	DuplicatePolicy    string `gorm:"column:duplicate_policy;type:varchar(16);not null;default:''" json:"duplicate_policy"` // reject, warn or allow; '' inherits from the parent
+	WorkflowID         int    `gorm:"column:workflow_id;not null;default:0" json:"workflow_id"`                             // 0 inherits from the parent
+	TrashRetentionDays int    `gorm:"column:trash_retention_days;not null;default:0" json:"trash_retention_days"`           // 0 inherits from the parent, -1 keeps deleted files forever
+	ReviewSLAHours     int    `gorm:"column:review_sla_hours;not null;default:0" json:"review_sla_hours"`                   // 0 inherits from the parent, -1 for no SLA
+	ReviewGroupID      int    `gorm:"column:review_group_id;not null;default:0" json:"review_group_id"`                     // Group whose members are assigned in turn; 0 inherits from the parent, -1 for none
+	Created            int    `gorm:"column:created;not null" json:"created"`                                               // Unix timestamp
+	Updated            int    `gorm:"column:updated;not null" json:"updated"`                                               // Unix timestamp
 }
 
 // TableName specifies the table name for the Category model
 func (Category) TableName() string {
 	return "ow_category"
 }
+
+// Duplicate upload policies. Categories without a policy inherit their parent's; the root default is reject.
+const (
+	DuplicatePolicyReject = "reject" // Refuse the upload and point at the existing file
+	DuplicatePolicyWarn   = "warn"   // Accept the upload, link it to the existing file and warn the uploader
+	DuplicatePolicyAllow  = "allow"  // Accept the upload as an independent file
+)
+
+// ValidDuplicatePolicy reports whether policy is a known duplicate policy or ” (inherit)
+func ValidDuplicatePolicy(policy string) bool {
+	switch policy {
+	case "", DuplicatePolicyReject, DuplicatePolicyWarn, DuplicatePolicyAllow:
+		return true
+	}
+	return false
+}
+
+// Trash retention. Categories without a retention inherit their parent's; the root default is 30 days.
+const (
+	DefaultTrashRetentionDays = 30
+	TrashRetentionForever     = -1 // Keep deleted files until they are purged by hand
+)
+
+// Review settings. Categories without them inherit their parent's; by default files have no
+// review SLA and are not assigned automatically.
+const (
+	ReviewSLANone   = -1 // No due date below a category that sets one
+	ReviewGroupNone = -1 // No automatic assignment below a category that sets a group
+)
@@ -0,0 +1,36 @@
+package models
+
+// Fetch job statuses
+const (
+	FetchStatusQueued    = "queued"
+	FetchStatusRunning   = "running"
+	FetchStatusCompleted = "completed"
+	FetchStatusFailed    = "failed"
+)
+
+// FetchJob is a server-side download of a remote file into the archive
+type FetchJob struct {
+	ID             uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	SourceURL      string `gorm:"column:source_url;type:varchar(1024);not null" json:"source_url"`
+	Checksum       string `gorm:"column:checksum;type:varchar(160);not null;default:''" json:"checksum,omitempty"` // Expected digest, algorithm:hex
+	CategoryID     int    `gorm:"column:category_id;not null" json:"category_id"`
+	Title          string `gorm:"column:title;type:varchar(255);not null;default:''" json:"title"` // '' = derived from the source filename
+	Type           int    `gorm:"column:type;not null;default:0" json:"type"`                      // 0 = detect from extension
+	Level          int    `gorm:"column:level;not null;default:0" json:"level"`
+	Groups         string `gorm:"column:groups;type:varchar(255);not null;default:''" json:"groups"`
+	CatalogInfo    string `gorm:"column:catalog_info;type:text;not null" json:"catalog_info,omitempty"`
+	Username       string `gorm:"column:username;type:varchar(64);not null;index" json:"username"`
+	Status         string `gorm:"column:status;type:varchar(16);not null;index" json:"status"`
+	FileID         uint64 `gorm:"column:file_id;not null;default:0" json:"file_id,omitempty"`                   // Created file
+	ExistingFileID uint64 `gorm:"column:existing_file_id;not null;default:0" json:"existing_file_id,omitempty"` // Duplicate of an existing file
+	Bytes          int64  `gorm:"column:bytes;not null;default:0" json:"bytes"`
+	Error          string `gorm:"column:error;type:varchar(1024);not null;default:''" json:"error,omitempty"`
+	Created        int    `gorm:"column:created;not null" json:"created"`                             // Unix timestamp
+	StartedAt      int    `gorm:"column:started_at;not null;default:0" json:"started_at,omitempty"`   // Unix timestamp
+	FinishedAt     int    `gorm:"column:finished_at;not null;default:0" json:"finished_at,omitempty"` // Unix timestamp
+}
+
+// TableName specifies the table name for FetchJob
+func (FetchJob) TableName() string {
+	return "ow_fetch_jobs"
+}
@@ -0,0 +1,25 @@
+package models
+
+// Fingerprint kinds
+const (
+	FingerprintKindImage = "image"
+	FingerprintKindVideo = "video"
+)
+
+// FileFingerprint stores the perceptual hashes of an image or video, used to find visually
+// similar files that exact MD5 matching misses
+type FileFingerprint struct {
+	ID          uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID      uint64 `gorm:"column:file_id;not null;uniqueIndex:uk_file_id" json:"file_id"`
+	Kind        string `gorm:"column:kind;type:varchar(16);not null;index" json:"kind"`              // image or video
+	PHash       uint64 `gorm:"column:phash;not null" json:"phash,string"`                            // DCT hash; for video the bitwise majority of the frame hashes
+	DHash       uint64 `gorm:"column:dhash;not null" json:"dhash,string"`                            // Difference hash of the image or middle frame
+	FrameHashes string `gorm:"column:frame_hashes;type:text;not null" json:"frame_hashes,omitempty"` // Comma-separated hex pHashes of sampled video frames
+	Frames      int    `gorm:"column:frames;not null;default:1" json:"frames"`
+	Created     int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+}
+
+// TableName specifies the table name for FileFingerprint
+func (FileFingerprint) TableName() string {
+	return "ow_file_fingerprints"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "strings"
+
+// FileTypeRule controls which extensions may be uploaded, as which file type and up to which size.
+// Rules with CategoryID 0 apply everywhere; a rule for a category overrides the rule for the same
+// extension in that category and its subcategories.
+type FileTypeRule struct {
+	ID         int    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	CategoryID int    `gorm:"column:category_id;not null;default:0;uniqueIndex:uk_category_ext" json:"category_id"` // 0 = all categories
+	Ext        string `gorm:"column:ext;type:varchar(16);not null;uniqueIndex:uk_category_ext" json:"ext"`          // Lowercase with dot: .mp4
+	MimeTypes  string `gorm:"column:mime_types;type:varchar(255);not null;default:''" json:"mime_types"`            // Comma-separated accepted MIME types
+	Type       int    `gorm:"column:type;not null" json:"type"`                                                     // 1:video 2:audio 3:image 4:rich_media
+	MaxSize    int64  `gorm:"column:max_size;not null;default:0" json:"max_size"`                                   // Bytes, 0 = unlimited
+	Enabled    bool   `gorm:"column:enabled;type:tinyint(1);not null" json:"enabled"`
+	Created    int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+	Updated    int    `gorm:"column:updated;not null" json:"updated"` // Unix timestamp
+}
+
+// TableName specifies the table name for FileTypeRule
+func (FileTypeRule) TableName() string {
+	return "ow_file_type_rules"
+}
+
+// MimeTypeList returns the accepted MIME types
+func (r *FileTypeRule) MimeTypeList() []string {
+	var types []string
+	for _, mimeType := range strings.Split(r.MimeTypes, ",") {
+		if mimeType = strings.TrimSpace(mimeType); mimeType != "" {
+			types = append(types, mimeType)
+		}
+	}
+	return types
+}
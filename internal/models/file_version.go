@@ -0,0 +1,31 @@
+package models
+
+// FileVersion is a revision of a file's content. The current version is also described by the
+// file's own columns; files that were never revised have no version rows.
+type FileVersion struct {
+	ID           uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID       uint64 `gorm:"column:file_id;not null;uniqueIndex:idx_file_version" json:"file_id"`
+	Version      int    `gorm:"column:version;not null;uniqueIndex:idx_file_version" json:"version"`
+	Name         string `gorm:"column:name;type:varchar(255);not null" json:"md5"` // Content MD5
+	ContentHash  string `gorm:"column:content_hash;type:char(64);not null;default:''" json:"content_hash,omitempty"`
+	Ext          string `gorm:"column:ext;type:varchar(16);not null" json:"ext"`
+	MimeType     string `gorm:"column:mime_type;type:varchar(128);not null;default:''" json:"mime_type"`
+	Size         int64  `gorm:"column:size;not null;default:0" json:"size"`
+	Path         string `gorm:"column:path;type:varchar(255);not null" json:"-"`
+	ScanStatus   string `gorm:"column:scan_status;type:varchar(16);not null;default:'skipped'" json:"scan_status"`
+	ScanResult   string `gorm:"column:scan_result;type:varchar(255);not null;default:''" json:"scan_result,omitempty"`
+	RestoredFrom int    `gorm:"column:restored_from;not null;default:0" json:"restored_from,omitempty"` // Version whose content was restored
+	Comment      string `gorm:"column:comment;type:varchar(255);not null;default:''" json:"comment,omitempty"`
+	Username     string `gorm:"column:username;type:varchar(64);not null" json:"username"`
+	Created      int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+}
+
+// TableName specifies the table name for FileVersion
+func (FileVersion) TableName() string {
+	return "ow_file_versions"
+}
+
+// Downloadable reports whether the malware scan allows serving the version's content
+func (v *FileVersion) Downloadable() bool {
+	return v.ScanStatus == ScanStatusClean || v.ScanStatus == ScanStatusSkipped
+}
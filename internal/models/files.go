@@ -11,11 +11,17 @@ type Files struct {
 	CategoryName   string `gorm:"column:category_name;type:varchar(64);not null" json:"category_name"`
 	Type           int    `gorm:"column:type;not null;default:1;index" json:"type"` // 1:video 2:audio 3:image 4:rich_media
 	Title          string `gorm:"column:title;type:varchar(255);not null;index" json:"title"`
-	Name           string `gorm:"column:name;type:varchar(255);not null" json:"name"` // MD5 filename
+	Name           string `gorm:"column:name;type:varchar(255);not null;index" json:"name"` // MD5 filename
+	DuplicateOf    uint64 `gorm:"column:duplicate_of;not null;default:0;index" json:"duplicate_of,omitempty"` // Canonical file this one duplicates, set by the warn duplicate policy
+	ContentHash    string `gorm:"column:content_hash;type:char(64);not null;default:'';index" json:"content_hash,omitempty"` // SHA-256, content-addressed layout only
 	Ext            string `gorm:"column:ext;type:varchar(16);not null" json:"ext"`
+	MimeType       string `gorm:"column:mime_type;type:varchar(128);not null;default:''" json:"mime_type"` // Detected from the content at upload
 	Size           int64  `gorm:"column:size;not null;default:0" json:"size"`
+	DerivativeSize int64  `gorm:"column:derivative_size;not null;default:0" json:"derivative_size"` // Bytes used by previews and other derivatives
+	Version        int    `gorm:"column:version;not null;default:1" json:"version"` // Current version; the columns above describe it
+	VersionSize    int64  `gorm:"column:version_size;not null;default:0" json:"version_size"` // Bytes held by earlier versions' objects
 	Path           string `gorm:"column:path;type:varchar(255);not null" json:"path"`
-	Status         int    `gorm:"column:status;not null;index" json:"status"` // 0:new 1:pending 2:published 3:rejected 4:deleted
+	Status         int    `gorm:"column:status;not null;index" json:"status"` // 0:new 1:pending 2:published 3:rejected 4:deleted (in the trash)
 	Level          int    `gorm:"column:level;not null;default:1;index" json:"level"`
 	Groups         string `gorm:"column:groups;type:varchar(255);not null;default:'all'" json:"groups"` // comma-separated group IDs or 'all'
 	IsDownload     bool   `gorm:"column:is_download;not null;default:true" json:"is_download"`
@@ -26,6 +32,13 @@ type Files struct {
 	CatalogAt      *int    `gorm:"column:catalog_at" json:"catalog_at,omitempty"` // Unix timestamp
 	PutoutUsername *string `gorm:"column:putout_username;type:varchar(64)" json:"putout_username,omitempty"`
 	PutoutAt       *int    `gorm:"column:putout_at" json:"putout_at,omitempty"` // Unix timestamp
+	PublishAt      int     `gorm:"column:publish_at;not null;default:0;index" json:"publish_at,omitempty"`     // Unix timestamp; embargoed before, published by the scheduler at
+	UnpublishAt    int     `gorm:"column:unpublish_at;not null;default:0;index" json:"unpublish_at,omitempty"` // Unix timestamp; withdrawn by the scheduler at, hidden after
+	DeletedAt      int     `gorm:"column:deleted_at;not null;default:0;index" json:"deleted_at,omitempty"`             // Unix timestamp; moved to the trash at
+	DeletedBy      string  `gorm:"column:deleted_by;type:varchar(64);not null;default:''" json:"deleted_by,omitempty"`
+	ScanStatus     string  `gorm:"column:scan_status;type:varchar(16);not null;default:'skipped';index" json:"scan_status"` // pending, clean, infected, skipped
+	ScanResult     string  `gorm:"column:scan_result;type:varchar(255);not null;default:''" json:"scan_result,omitempty"`  // Detected signature or scanner error
+	ScannedAt      int     `gorm:"column:scanned_at;not null;default:0" json:"scanned_at,omitempty"`                     // Unix timestamp
 }
 
 // TableName specifies the table name for the Files model
@@ -56,4 +69,23 @@ const (
 	FileStatusPublished = 2
 	FileStatusRejected  = 3
 	FileStatusDeleted   = 4
+	FileStatusFlagged   = 5 // Quarantined by the malware scanner
 )
+
+// Malware scan statuses
+const (
+	ScanStatusPending  = "pending"  // Not scanned yet or the scanner failed; downloads are blocked
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected" // Original moved to the quarantine prefix
+	ScanStatusSkipped  = "skipped"  // No scanner was configured when the file was uploaded
+)
+
+// Embargoed reports whether the file is outside its publication window at the given Unix time
+func (f *Files) Embargoed(now int64) bool {
+	return int64(f.PublishAt) > now || (f.UnpublishAt > 0 && int64(f.UnpublishAt) <= now)
+}
+
+// Downloadable reports whether the malware scan allows serving the file's content
+func (f *Files) Downloadable() bool {
+	return f.ScanStatus == ScanStatusClean || f.ScanStatus == ScanStatusSkipped
+}
@@ -0,0 +1,49 @@
+package models
+
+// LegalHold prevents a file, or every file in a category subtree, from being deleted or altered
+// while litigation is pending. Holds are released rather than deleted so they stay auditable.
+type LegalHold struct {
+	ID            uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID        uint64 `gorm:"column:file_id;not null;default:0;index" json:"file_id,omitempty"`         // Held file, or 0 for a category hold
+	CategoryID    int    `gorm:"column:category_id;not null;default:0;index" json:"category_id,omitempty"` // Held category with its subcategories, or 0 for a file hold
+	CaseReference string `gorm:"column:case_reference;type:varchar(128);not null;index" json:"case_reference"`
+	Reason        string `gorm:"column:reason;type:varchar(1024);not null;default:''" json:"reason"`
+	CreatedBy     string `gorm:"column:created_by;type:varchar(64);not null" json:"created_by"`
+	Created       int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+	ReleasedBy    string `gorm:"column:released_by;type:varchar(64);not null;default:''" json:"released_by,omitempty"`
+	ReleasedAt    int    `gorm:"column:released_at;not null;default:0;index" json:"released_at,omitempty"` // Unix timestamp; 0 while the hold is active
+	ReleaseReason string `gorm:"column:release_reason;type:varchar(1024);not null;default:''" json:"release_reason,omitempty"`
+}
+
+// TableName specifies the table name for LegalHold
+func (LegalHold) TableName() string {
+	return "ow_legal_holds"
+}
+
+// Active reports whether the hold is still in force
+func (h *LegalHold) Active() bool {
+	return h.ReleasedAt == 0
+}
+
+// LegalHoldEvent records a hold being applied or released, and every operation a hold blocked
+type LegalHoldEvent struct {
+	ID       uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	HoldID   uint64 `gorm:"column:hold_id;not null;index" json:"hold_id"`
+	Action   string `gorm:"column:action;type:varchar(16);not null" json:"action"`              // apply, release or blocked
+	FileID   uint64 `gorm:"column:file_id;not null;default:0;index" json:"file_id,omitempty"`   // File of a blocked operation
+	Username string `gorm:"column:username;type:varchar(64);not null;index" json:"username"`    // Actor
+	Detail   string `gorm:"column:detail;type:varchar(1024);not null;default:''" json:"detail"` // Reason, or the blocked operation
+	Created  int    `gorm:"column:created;not null;index" json:"created"`                       // Unix timestamp
+}
+
+// TableName specifies the table name for LegalHoldEvent
+func (LegalHoldEvent) TableName() string {
+	return "ow_legal_hold_events"
+}
+
+// Legal hold event actions
+const (
+	LegalHoldActionApply   = "apply"
+	LegalHoldActionRelease = "release"
+	LegalHoldActionBlocked = "blocked"
+)
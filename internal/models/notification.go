@@ -0,0 +1,24 @@
+package models
+
+// Notification kinds
+const (
+	NotificationReviewAssigned  = "review_assigned"
+	NotificationReviewEscalated = "review_escalated"
+)
+
+// Notification is a message to a user, listed in their inbox and published to the notification
+// queue for delivery by other channels
+type Notification struct {
+	ID      uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID  int    `gorm:"column:user_id;not null;index" json:"user_id"`
+	Kind    string `gorm:"column:kind;type:varchar(32);not null" json:"kind"`
+	FileID  uint64 `gorm:"column:file_id;not null;default:0" json:"file_id,omitempty"`
+	Message string `gorm:"column:message;type:varchar(1024);not null" json:"message"`
+	Created int    `gorm:"column:created;not null" json:"created"`                     // Unix timestamp
+	ReadAt  int    `gorm:"column:read_at;not null;default:0" json:"read_at,omitempty"` // Unix timestamp; 0 while unread
+}
+
+// TableName specifies the table name for Notification
+func (Notification) TableName() string {
+	return "ow_notifications"
+}
@@ -0,0 +1,68 @@
+package models
+
+// Review task statuses
+const (
+	ReviewStatusOpen      = "open"
+	ReviewStatusApproved  = "approved"
+	ReviewStatusRejected  = "rejected"
+	ReviewStatusCancelled = "cancelled" // The file left pending review before the stage completed
+)
+
+// Review vote decisions
+const (
+	ReviewDecisionApprove = "approve"
+	ReviewDecisionReject  = "reject"
+)
+
+// ReviewTask is one review stage of a pending file. Its reviewers and quorum are copied from the
+// workflow when the stage starts, so editing the workflow does not change running reviews.
+type ReviewTask struct {
+	ID         uint64           `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID     uint64           `gorm:"column:file_id;not null;index" json:"file_id"`
+	WorkflowID int              `gorm:"column:workflow_id;not null;default:0" json:"workflow_id"` // 0 = built-in workflow
+	Stage      int              `gorm:"column:stage;not null" json:"stage"`                       // Index in the workflow's review stages
+	StageName  string           `gorm:"column:stage_name;type:varchar(64);not null" json:"stage_name"`
+	Quorum     int              `gorm:"column:quorum;not null" json:"quorum"`
+	Approvals  int              `gorm:"column:approvals;not null;default:0" json:"approvals"`
+	Status     string           `gorm:"column:status;type:varchar(16);not null;index" json:"status"`
+	Created    int              `gorm:"column:created;not null" json:"created"`                   // Unix timestamp
+	Closed     int              `gorm:"column:closed;not null;default:0" json:"closed,omitempty"` // Unix timestamp
+	Assignees  []ReviewAssignee `gorm:"foreignKey:TaskID" json:"assignees,omitempty"`
+	Votes      []ReviewVote     `gorm:"foreignKey:TaskID" json:"votes,omitempty"`
+	File       *Files           `gorm:"-" json:"file,omitempty"`
+}
+
+// TableName specifies the table name for ReviewTask
+func (ReviewTask) TableName() string {
+	return "ow_review_tasks"
+}
+
+// ReviewAssignee is a user or group allowed to vote on a review task
+type ReviewAssignee struct {
+	ID      uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskID  uint64 `gorm:"column:task_id;not null;index" json:"task_id"`
+	UserID  int    `gorm:"column:user_id;not null;default:0;index" json:"user_id,omitempty"`   // Set for user assignments
+	GroupID int    `gorm:"column:group_id;not null;default:0;index" json:"group_id,omitempty"` // Set for group assignments
+}
+
+// TableName specifies the table name for ReviewAssignee
+func (ReviewAssignee) TableName() string {
+	return "ow_review_assignees"
+}
+
+// ReviewVote is a reviewer's decision on a review task
+type ReviewVote struct {
+	ID       uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskID   uint64 `gorm:"column:task_id;not null;uniqueIndex:idx_task_user" json:"task_id"`
+	FileID   uint64 `gorm:"column:file_id;not null;index" json:"file_id"`
+	UserID   int    `gorm:"column:user_id;not null;uniqueIndex:idx_task_user" json:"user_id"`
+	Username string `gorm:"column:username;type:varchar(64);not null" json:"username"`
+	Decision string `gorm:"column:decision;type:varchar(16);not null" json:"decision"`
+	Comment  string `gorm:"column:comment;type:varchar(1024);not null;default:''" json:"comment"`
+	Created  int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+}
+
+// TableName specifies the table name for ReviewVote
+func (ReviewVote) TableName() string {
+	return "ow_review_votes"
+}
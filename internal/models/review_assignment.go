@@ -0,0 +1,38 @@
+package models
+
+// Review assignment statuses
+const (
+	ReviewAssignmentOpen       = "open"
+	ReviewAssignmentDone       = "done"       // The file left pending review
+	ReviewAssignmentReassigned = "reassigned" // Replaced by a newer assignment of the file
+)
+
+// ReviewAssignment makes a reviewer responsible for a pending file until it leaves review. Files
+// are assigned by hand or in turn to the members of their category's review group, and are due
+// once the category's review SLA has passed since they were submitted.
+type ReviewAssignment struct {
+	ID          uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID      uint64 `gorm:"column:file_id;not null;index" json:"file_id"`
+	CategoryID  int    `gorm:"column:category_id;not null;index" json:"category_id"`
+	ReviewerID  int    `gorm:"column:reviewer_id;not null;default:0;index" json:"reviewer_id"` // 0 while no reviewer is available
+	Reviewer    string `gorm:"column:reviewer;type:varchar(64);not null;default:''" json:"reviewer"`
+	GroupID     int    `gorm:"column:group_id;not null;default:0;index" json:"group_id,omitempty"` // Review group the reviewer was taken from in turn; 0 for manual assignments
+	AssignedBy  string `gorm:"column:assigned_by;type:varchar(64);not null" json:"assigned_by"`
+	Assigned    int    `gorm:"column:assigned;not null" json:"assigned"`                       // Unix timestamp
+	Submitted   int    `gorm:"column:submitted;not null" json:"submitted"`                     // Unix timestamp the file entered pending review
+	DueAt       int    `gorm:"column:due_at;not null;default:0;index" json:"due_at,omitempty"` // Unix timestamp; 0 without an SLA
+	EscalatedAt int    `gorm:"column:escalated_at;not null;default:0" json:"escalated_at,omitempty"`
+	Status      string `gorm:"column:status;type:varchar(16);not null;index" json:"status"`
+	Closed      int    `gorm:"column:closed;not null;default:0" json:"closed,omitempty"` // Unix timestamp
+	File        *Files `gorm:"-" json:"file,omitempty"`
+}
+
+// TableName specifies the table name for ReviewAssignment
+func (ReviewAssignment) TableName() string {
+	return "ow_review_assignments"
+}
+
+// Overdue reports whether the assignment is open past its due date
+func (a *ReviewAssignment) Overdue(now int) bool {
+	return a.Status == ReviewAssignmentOpen && a.DueAt > 0 && a.DueAt <= now
+}
@@ -0,0 +1,36 @@
+package models
+
+// ReviewComment is a reviewer's comment on a file, optionally anchored at a timecode range and a
+// region of the frame. Top-level comments start a thread that is resolved as a whole; replies
+// carry no anchor. Unresolved threads block publishing.
+type ReviewComment struct {
+	ID         uint64   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID     uint64   `gorm:"column:file_id;not null;index" json:"file_id"`
+	ParentID   uint64   `gorm:"column:parent_id;not null;default:0;index" json:"parent_id,omitempty"` // Thread the reply belongs to; 0 for top-level comments
+	UserID     int      `gorm:"column:user_id;not null" json:"user_id"`
+	Username   string   `gorm:"column:username;type:varchar(64);not null" json:"username"`
+	Body       string   `gorm:"column:body;type:text;not null" json:"body"`
+	StartMs    *int     `gorm:"column:start_ms" json:"start_ms,omitempty"` // Timecode in milliseconds from the start of the media
+	EndMs      *int     `gorm:"column:end_ms" json:"end_ms,omitempty"`     // End of a timecode range
+	RegionX    *float64 `gorm:"column:region_x" json:"region_x,omitempty"` // Rectangle relative to the frame, 0 to 1 from the top left
+	RegionY    *float64 `gorm:"column:region_y" json:"region_y,omitempty"`
+	RegionW    *float64 `gorm:"column:region_w" json:"region_w,omitempty"`
+	RegionH    *float64 `gorm:"column:region_h" json:"region_h,omitempty"`
+	Resolved   bool     `gorm:"column:resolved;type:tinyint(1);not null;default:false" json:"resolved"`
+	ResolvedBy string   `gorm:"column:resolved_by;type:varchar(64);not null;default:''" json:"resolved_by,omitempty"`
+	ResolvedAt int      `gorm:"column:resolved_at;not null;default:0" json:"resolved_at,omitempty"` // Unix timestamp
+	Created    int      `gorm:"column:created;not null" json:"created"`                             // Unix timestamp
+	Updated    int      `gorm:"column:updated;not null" json:"updated"`                             // Unix timestamp
+
+	Replies []*ReviewComment `gorm:"-" json:"replies,omitempty"`
+}
+
+// TableName specifies the table name for ReviewComment
+func (ReviewComment) TableName() string {
+	return "ow_review_comments"
+}
+
+// HasRegion reports whether the comment marks a rectangle of the frame
+func (c *ReviewComment) HasRegion() bool {
+	return c.RegionX != nil && c.RegionY != nil && c.RegionW != nil && c.RegionH != nil
+}
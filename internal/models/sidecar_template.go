@@ -0,0 +1,56 @@
+package models
+
+import "encoding/json"
+
+// SidecarTemplate maps the fields of a metadata sidecar (XML, JSON or CSV delivered next to a
+// media file) to Files columns and catalog_info keys
+type SidecarTemplate struct {
+	ID          int    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name        string `gorm:"column:name;type:varchar(64);not null;uniqueIndex" json:"name"`
+	Format      string `gorm:"column:format;type:varchar(16);not null" json:"format"` // xml, json, csv
+	Description string `gorm:"column:description;type:varchar(255);not null;default:''" json:"description"`
+	Mappings    string `gorm:"column:mappings;type:text;not null" json:"mappings"` // JSON array of SidecarMapping
+	Enabled     bool   `gorm:"column:enabled;type:tinyint(1);not null" json:"enabled"`
+	Created     int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+	Updated     int    `gorm:"column:updated;not null" json:"updated"` // Unix timestamp
+}
+
+// TableName specifies the table name for SidecarTemplate
+func (SidecarTemplate) TableName() string {
+	return "ow_sidecar_templates"
+}
+
+// SidecarMapping maps one sidecar field to a file attribute
+type SidecarMapping struct {
+	Source   string `json:"source"`            // Dotted field path ("asset.title", "rights.@territory") or CSV column
+	Target   string `json:"target"`            // title, level, groups or catalog.<name>
+	Required bool   `json:"required"`          // Missing values are reported as validation errors
+	Default  string `json:"default,omitempty"` // Used when the sidecar has no value
+}
+
+// GetMappings decodes the template's field mappings
+func (t *SidecarTemplate) GetMappings() ([]SidecarMapping, error) {
+	var mappings []SidecarMapping
+	if t.Mappings == "" {
+		return mappings, nil
+	}
+	err := json.Unmarshal([]byte(t.Mappings), &mappings)
+	return mappings, err
+}
+
+// SetMappings encodes the template's field mappings
+func (t *SidecarTemplate) SetMappings(mappings []SidecarMapping) error {
+	data, err := json.Marshal(mappings)
+	if err != nil {
+		return err
+	}
+	t.Mappings = string(data)
+	return nil
+}
+
+// Sidecar formats
+const (
+	SidecarFormatXML  = "xml"
+	SidecarFormatJSON = "json"
+	SidecarFormatCSV  = "csv"
+)
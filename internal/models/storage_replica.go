@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// StorageReplica tracks the replication state of one stored object on one replica
+type StorageReplica struct {
+	ID           uint64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	PrimaryPath  string     `gorm:"column:primary_path;type:varchar(255);not null;uniqueIndex:uk_path_replica" json:"primary_path"`
+	Replica      string     `gorm:"column:replica;type:varchar(64);not null;uniqueIndex:uk_path_replica" json:"replica"`
+	ReplicaPath  string     `gorm:"column:replica_path;type:varchar(255);not null;default:''" json:"replica_path"`
+	Status       string     `gorm:"column:status;type:varchar(16);not null;index" json:"status"` // pending, replicated, failed
+	Attempts     int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	LastError    string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	QueuedAt     time.Time  `gorm:"column:queued_at;not null" json:"queued_at"`
+	ReplicatedAt *time.Time `gorm:"column:replicated_at" json:"replicated_at,omitempty"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for StorageReplica
+func (StorageReplica) TableName() string {
+	return "ow_storage_replicas"
+}
+
+// LagSeconds returns how long the object took (or has been waiting) to reach the replica
+func (r *StorageReplica) LagSeconds(now time.Time) float64 {
+	if r.ReplicatedAt != nil {
+		return r.ReplicatedAt.Sub(r.QueuedAt).Seconds()
+	}
+	return now.Sub(r.QueuedAt).Seconds()
+}
+
+// Replica status constants
+const (
+	ReplicaStatusPending    = "pending"
+	ReplicaStatusReplicated = "replicated"
+	ReplicaStatusFailed     = "failed"
+)
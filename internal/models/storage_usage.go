@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// StorageUsage represents the ow_storage_usage table holding storage counters per user and per group
+type StorageUsage struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ScopeType string    `gorm:"column:scope_type;type:varchar(16);not null;uniqueIndex:uk_scope" json:"scope_type"` // user or group
+	ScopeID   int       `gorm:"column:scope_id;not null;uniqueIndex:uk_scope" json:"scope_id"`
+	UsedBytes int64     `gorm:"column:used_bytes;not null;default:0" json:"used_bytes"` // Originals plus derivatives
+	FileCount int64     `gorm:"column:file_count;not null;default:0" json:"file_count"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for StorageUsage
+func (StorageUsage) TableName() string {
+	return "ow_storage_usage"
+}
+
+// Usage scope constants
+const (
+	UsageScopeUser  = "user"
+	UsageScopeGroup = "group"
+)
@@ -0,0 +1,126 @@
+package models
+
+import "encoding/json"
+
+// Workflow defines the review states of files and the transitions between them. Categories are
+// assigned a workflow; categories without one use their parent's, and the root falls back to the
+// default workflow.
+type Workflow struct {
+	ID           int    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name         string `gorm:"column:name;type:varchar(64);not null;uniqueIndex:idx_name" json:"name"`
+	Description  string `gorm:"column:description;type:varchar(255);not null;default:''" json:"description"`
+	States       string `gorm:"column:states;type:text;not null" json:"states"`               // JSON array of WorkflowState
+	Transitions  string `gorm:"column:transitions;type:text;not null" json:"transitions"`     // JSON array of WorkflowTransition
+	ReviewStages string `gorm:"column:review_stages;type:text;not null" json:"review_stages"` // JSON array of WorkflowReviewStage
+	IsDefault    bool   `gorm:"column:is_default;type:tinyint(1);not null;default:0" json:"is_default"`
+	Created      int    `gorm:"column:created;not null" json:"created"` // Unix timestamp
+	Updated      int    `gorm:"column:updated;not null" json:"updated"` // Unix timestamp
+}
+
+// TableName specifies the table name for Workflow
+func (Workflow) TableName() string {
+	return "ow_workflows"
+}
+
+// WorkflowState is a file status in a workflow
+type WorkflowState struct {
+	Status int    `json:"status"` // Value of ow_files.status; 0-5 keep their built-in meaning
+	Name   string `json:"name"`   // Identifier, e.g. "legal_review"
+	Label  string `json:"label"`
+}
+
+// WorkflowTransition is an allowed status change
+type WorkflowTransition struct {
+	Name           string   `json:"name"`                      // Identifier, e.g. "publish"
+	From           []int    `json:"from"`                      // Source statuses; empty = any other state
+	To             int      `json:"to"`                        // Target status; deleted moves the file to the trash
+	Permission     string   `json:"permission,omitempty"`      // namespace.controller.action the user needs
+	RequiredFields []string `json:"required_fields,omitempty"` // Guards: "title" or "catalog.<key>" must be set
+}
+
+// WorkflowReviewStage is a review round a pending file must pass before it is published. Stages
+// run in order; a stage passes with Quorum approvals from its reviewers, and any rejection sends
+// the file to the rejected state.
+type WorkflowReviewStage struct {
+	Name   string `json:"name"` // Identifier, e.g. "legal"
+	Label  string `json:"label"`
+	Users  []int  `json:"users,omitempty"`  // Reviewer user IDs
+	Groups []int  `json:"groups,omitempty"` // Groups whose members may review
+	Quorum int    `json:"quorum"`           // Approvals required, at least 1
+}
+
+// Allows reports whether the transition leads from one status to another
+func (t *WorkflowTransition) Allows(from, to int) bool {
+	if t.To != to {
+		return false
+	}
+	if len(t.From) == 0 {
+		return from != to
+	}
+	for _, status := range t.From {
+		if status == from {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStates decodes the workflow's states
+func (w *Workflow) GetStates() ([]WorkflowState, error) {
+	var states []WorkflowState
+	if w.States == "" {
+		return states, nil
+	}
+	err := json.Unmarshal([]byte(w.States), &states)
+	return states, err
+}
+
+// SetStates encodes the workflow's states
+func (w *Workflow) SetStates(states []WorkflowState) error {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	w.States = string(data)
+	return nil
+}
+
+// GetTransitions decodes the workflow's transitions
+func (w *Workflow) GetTransitions() ([]WorkflowTransition, error) {
+	var transitions []WorkflowTransition
+	if w.Transitions == "" {
+		return transitions, nil
+	}
+	err := json.Unmarshal([]byte(w.Transitions), &transitions)
+	return transitions, err
+}
+
+// SetTransitions encodes the workflow's transitions
+func (w *Workflow) SetTransitions(transitions []WorkflowTransition) error {
+	data, err := json.Marshal(transitions)
+	if err != nil {
+		return err
+	}
+	w.Transitions = string(data)
+	return nil
+}
+
+// GetReviewStages decodes the workflow's review stages
+func (w *Workflow) GetReviewStages() ([]WorkflowReviewStage, error) {
+	var stages []WorkflowReviewStage
+	if w.ReviewStages == "" {
+		return stages, nil
+	}
+	err := json.Unmarshal([]byte(w.ReviewStages), &stages)
+	return stages, err
+}
+
+// SetReviewStages encodes the workflow's review stages
+func (w *Workflow) SetReviewStages(stages []WorkflowReviewStage) error {
+	data, err := json.Marshal(stages)
+	if err != nil {
+		return err
+	}
+	w.ReviewStages = string(data)
+	return nil
+}
@@ -0,0 +1,19 @@
+package models
+
+// WorkflowEvent records a status change of a file, or a purge or schedule change that keeps the
+// status. Events outlive the file so deletions stay auditable.
+type WorkflowEvent struct {
+	ID         uint64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	FileID     uint64 `gorm:"column:file_id;not null;index" json:"file_id"`
+	FromStatus int    `gorm:"column:from_status;not null" json:"from_status"`
+	ToStatus   int    `gorm:"column:to_status;not null" json:"to_status"`
+	Transition string `gorm:"column:transition;type:varchar(64);not null;default:''" json:"transition"` // Workflow transition name, or new_version / restore_version / schedule / purge
+	Username   string `gorm:"column:username;type:varchar(64);not null;index" json:"username"`          // Actor
+	Comment    string `gorm:"column:comment;type:varchar(1024);not null;default:''" json:"comment"`     // Rejection reason, review or version comment
+	Created    int    `gorm:"column:created;not null;index" json:"created"`                             // Unix timestamp
+}
+
+// TableName specifies the table name for WorkflowEvent
+func (WorkflowEvent) TableName() string {
+	return "ow_workflow_events"
+}
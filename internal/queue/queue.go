@@ -28,6 +28,9 @@ const (
 	JobTypeTranscode    JobType = "transcode"
 	JobTypeNotification JobType = "notification"
 	JobTypeIndexing     JobType = "indexing"
+	JobTypeReplication  JobType = "replication"
+	JobTypeFingerprint  JobType = "fingerprint"
+	JobTypeFetch        JobType = "fetch"
 )
 
 // TranscodeJob represents a transcoding job payload
@@ -38,3 +41,34 @@ type TranscodeJob struct {
 	Parameters  string `json:"parameters"`
 	StorageType string `json:"storage_type"` // local or s3
 }
+
+// FingerprintJob represents a perceptual hashing job payload
+type FingerprintJob struct {
+	FileID uint64 `json:"file_id"`
+}
+
+// FetchJob represents a server-side URL fetch payload; the details are stored with the job record
+type FetchJob struct {
+	JobID uint64 `json:"job_id"`
+}
+
+// BulkJob represents a bulk file operation payload; the action and files are stored with the job record
+type BulkJob struct {
+	JobID uint64 `json:"job_id"`
+}
+
+// NotificationJob represents a notification to deliver; it is also stored in the user's inbox
+type NotificationJob struct {
+	NotificationID uint64 `json:"notification_id"`
+	UserID         int    `json:"user_id"`
+	Kind           string `json:"kind"`
+	FileID         uint64 `json:"file_id,omitempty"`
+	Message        string `json:"message"`
+}
+
+// ReplicationJob represents a storage replication job payload
+type ReplicationJob struct {
+	Path     string            `json:"path"`    // Object path on the primary storage
+	Replica  string            `json:"replica"` // Replica name
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlobStats summarizes deduplication of content-addressed storage
+type BlobStats struct {
+	Blobs        int64 `json:"blobs"`
+	References   int64 `json:"references"`
+	StoredBytes  int64 `json:"stored_bytes"`  // Bytes actually stored
+	LogicalBytes int64 `json:"logical_bytes"` // Bytes referenced by files
+}
+
+// blobRepository implements BlobRepository
+type blobRepository struct {
+	db *gorm.DB
+}
+
+// NewBlobRepository creates a new blob repository
+func NewBlobRepository(db *gorm.DB) BlobRepository {
+	return &blobRepository{db: db}
+}
+
+func (r *blobRepository) AddReference(ctx context.Context, hash, path string, size int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		blob := &models.Blob{
+			Hash:     hash,
+			Path:     path,
+			Size:     size,
+			RefCount: 1,
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "path"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("ref_count + 1")}),
+		}).Create(blob).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Blob{}).Where("path = ?", path).Pluck("ref_count", &count).Error
+	})
+	return count, err
+}
+
+func (r *blobRepository) ReleaseReference(ctx context.Context, path string) (int64, error) {
+	var remaining int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var blob models.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path = ?", path).First(&blob).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				// Unknown objects are unreferenced
+				return nil
+			}
+			return err
+		}
+
+		// The row is kept at zero references for RemoveUnreferenced to lock
+		remaining = max(blob.RefCount-1, 0)
+		return tx.Model(&blob).Update("ref_count", remaining).Error
+	})
+	return remaining, err
+}
+
+// RemoveUnreferenced calls remove while holding the object's row lock if it has no references,
+// then deletes the row. AddReference waits for the lock, so it either counts a reference before
+// the check or recreates the row after the object is gone.
+func (r *blobRepository) RemoveUnreferenced(ctx context.Context, path string, remove func() error) (bool, error) {
+	removed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var blob models.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path = ?", path).First(&blob).Error
+		if err == gorm.ErrRecordNotFound {
+			// Objects stored before the index existed have no row
+			removed = true
+			return remove()
+		}
+		if err != nil {
+			return err
+		}
+		if blob.RefCount > 0 {
+			return nil
+		}
+
+		removed = true
+		if err := remove(); err != nil {
+			return err
+		}
+		return tx.Delete(&blob).Error
+	})
+	return removed, err
+}
+
+func (r *blobRepository) CountReferences(ctx context.Context, hash string) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.Blob{}).
+		Select("COALESCE(SUM(ref_count), 0)").
+		Where("hash = ?", hash).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *blobRepository) FindByPath(ctx context.Context, path string) (*models.Blob, error) {
+	var blob models.Blob
+	err := r.db.WithContext(ctx).Where("path = ?", path).First(&blob).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &blob, nil
+}
+
+func (r *blobRepository) GetStats(ctx context.Context) (*BlobStats, error) {
+	var stats BlobStats
+	err := r.db.WithContext(ctx).Model(&models.Blob{}).
+		Select("COUNT(*) AS blobs, COALESCE(SUM(ref_count), 0) AS `references`, " +
+			"COALESCE(SUM(size), 0) AS stored_bytes, COALESCE(SUM(size * ref_count), 0) AS logical_bytes").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bulkJobItemBatchSize is the number of items inserted per statement when a job is created
+const bulkJobItemBatchSize = 500
+
+// bulkJobRepository implements BulkJobRepository
+type bulkJobRepository struct {
+	db *gorm.DB
+}
+
+// NewBulkJobRepository creates a new bulk job repository
+func NewBulkJobRepository(db *gorm.DB) BulkJobRepository {
+	return &bulkJobRepository{db: db}
+}
+
+// Create stores a job with a pending item per file, in one transaction
+func (r *bulkJobRepository) Create(ctx context.Context, job *models.BulkJob, fileIDs []uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		job.Total = len(fileIDs)
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		items := make([]*models.BulkJobItem, 0, len(fileIDs))
+		for _, fileID := range fileIDs {
+			items = append(items, &models.BulkJobItem{
+				JobID:  job.ID,
+				FileID: fileID,
+				Status: models.BulkItemPending,
+			})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(items, bulkJobItemBatchSize).Error
+	})
+}
+
+func (r *bulkJobRepository) FindByID(ctx context.Context, id uint64) (*models.BulkJob, error) {
+	var job models.BulkJob
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindAll returns jobs newest first; an empty username returns every user's jobs
+func (r *bulkJobRepository) FindAll(ctx context.Context, username string, limit, offset int) ([]*models.BulkJob, int64, error) {
+	var jobs []*models.BulkJob
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.BulkJob{})
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+	return jobs, total, err
+}
+
+func (r *bulkJobRepository) Update(ctx context.Context, job *models.BulkJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// MarkRunning moves a queued job to running, returning false if another worker already took it.
+// A requeued job keeps its first start time.
+func (r *bulkJobRepository) MarkRunning(ctx context.Context, id uint64, startedAt int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.BulkJob{}).
+		Where("id = ? AND status = ?", id, models.BulkStatusQueued).
+		Updates(map[string]interface{}{
+			"status":       models.BulkStatusRunning,
+			"started_at":   gorm.Expr("IF(started_at = 0, ?, started_at)", startedAt),
+			"heartbeat_at": startedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RequeueStale moves running jobs whose heartbeat is older than staleBefore back to queued and
+// returns their IDs
+func (r *bulkJobRepository) RequeueStale(ctx context.Context, staleBefore int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.BulkJob{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND heartbeat_at < ?", models.BulkStatusRunning, staleBefore).
+			Order("id ASC").Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.BulkJob{}).
+			Where("id IN ? AND status = ?", ids, models.BulkStatusRunning).
+			Update("status", models.BulkStatusQueued).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindItems returns a job's items in file order; an empty status returns all of them. A limit
+// of 0 or less returns every item.
+func (r *bulkJobRepository) FindItems(ctx context.Context, jobID uint64, status string, limit, offset int) ([]*models.BulkJobItem, int64, error) {
+	var items []*models.BulkJobItem
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.BulkJobItem{}).Where("job_id = ?", jobID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("id ASC")
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+	err := query.Find(&items).Error
+	return items, total, err
+}
+
+// FindPendingItems returns up to limit of a job's unprocessed items after afterID
+func (r *bulkJobRepository) FindPendingItems(ctx context.Context, jobID, afterID uint64, limit int) ([]*models.BulkJobItem, error) {
+	var items []*models.BulkJobItem
+	err := r.db.WithContext(ctx).
+		Where("job_id = ? AND id > ? AND status = ?", jobID, afterID, models.BulkItemPending).
+		Order("id ASC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// CompleteItem records an item's result, counts it on its job and refreshes the job's heartbeat,
+// in one transaction
+func (r *bulkJobRepository) CompleteItem(ctx context.Context, item *models.BulkJobItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		counter := "succeeded"
+		if item.Status == models.BulkItemFailed {
+			counter = "failed"
+		}
+		return tx.Model(&models.BulkJob{}).Where("id = ?", item.JobID).
+			Updates(map[string]interface{}{
+				counter:        gorm.Expr(counter + " + 1"),
+				"heartbeat_at": item.Processed,
+			}).Error
+	})
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// fetchJobRepository implements FetchJobRepository
+type fetchJobRepository struct {
+	db *gorm.DB
+}
+
+// NewFetchJobRepository creates a new fetch job repository
+func NewFetchJobRepository(db *gorm.DB) FetchJobRepository {
+	return &fetchJobRepository{db: db}
+}
+
+func (r *fetchJobRepository) Create(ctx context.Context, job *models.FetchJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *fetchJobRepository) FindByID(ctx context.Context, id uint64) (*models.FetchJob, error) {
+	var job models.FetchJob
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindAll returns jobs newest first; an empty username returns every user's jobs
+func (r *fetchJobRepository) FindAll(ctx context.Context, username string, limit, offset int) ([]*models.FetchJob, int64, error) {
+	var jobs []*models.FetchJob
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.FetchJob{})
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+	return jobs, total, err
+}
+
+func (r *fetchJobRepository) Update(ctx context.Context, job *models.FetchJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// MarkRunning moves a queued job to running, returning false if another worker already took it
+func (r *fetchJobRepository) MarkRunning(ctx context.Context, id uint64, startedAt int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.FetchJob{}).
+		Where("id = ? AND status = ?", id, models.FetchStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     models.FetchStatusRunning,
+			"started_at": startedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
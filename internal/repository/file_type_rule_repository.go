@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// fileTypeRuleRepository implements FileTypeRuleRepository
+type fileTypeRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewFileTypeRuleRepository creates a new file type rule repository
+func NewFileTypeRuleRepository(db *gorm.DB) FileTypeRuleRepository {
+	return &fileTypeRuleRepository{db: db}
+}
+
+func (r *fileTypeRuleRepository) Create(ctx context.Context, rule *models.FileTypeRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *fileTypeRuleRepository) CreateBatch(ctx context.Context, rules []*models.FileTypeRule) error {
+	return r.db.WithContext(ctx).Create(rules).Error
+}
+
+func (r *fileTypeRuleRepository) FindByID(ctx context.Context, id int) (*models.FileTypeRule, error) {
+	var rule models.FileTypeRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *fileTypeRuleRepository) FindAll(ctx context.Context) ([]*models.FileTypeRule, error) {
+	var rules []*models.FileTypeRule
+	err := r.db.WithContext(ctx).Order("category_id ASC, type ASC, ext ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *fileTypeRuleRepository) Update(ctx context.Context, rule *models.FileTypeRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *fileTypeRuleRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&models.FileTypeRule{}, id).Error
+}
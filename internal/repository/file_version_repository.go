@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// fileVersionRepository implements FileVersionRepository
+type fileVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewFileVersionRepository creates a new file version repository
+func NewFileVersionRepository(db *gorm.DB) FileVersionRepository {
+	return &fileVersionRepository{db: db}
+}
+
+// FindByFileID returns a file's versions, newest first
+func (r *fileVersionRepository) FindByFileID(ctx context.Context, fileID uint64) ([]*models.FileVersion, error) {
+	var versions []*models.FileVersion
+	err := r.db.WithContext(ctx).Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+// FindVersion returns one version of a file, or nil if it does not exist
+func (r *fileVersionRepository) FindVersion(ctx context.Context, fileID uint64, version int) (*models.FileVersion, error) {
+	var v models.FileVersion
+	err := r.db.WithContext(ctx).Where("file_id = ? AND version = ?", fileID, version).First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *fileVersionRepository) DeleteByFileID(ctx context.Context, fileID uint64) error {
+	return r.db.WithContext(ctx).Where("file_id = ?", fileID).Delete(&models.FileVersion{}).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/openwan/media-asset-management/internal/models"
 	"gorm.io/gorm"
@@ -39,6 +40,9 @@ func (r *filesRepository) FindAll(ctx context.Context, filters map[string]interf
 	// Apply filters
 	if status, ok := filters["status"]; ok {
 		query = query.Where("status = ?", status)
+	} else {
+		// Files in the trash are only listed when asked for
+		query = query.Where("status <> ?", models.FileStatusDeleted)
 	}
 	if fileType, ok := filters["type"]; ok {
 		query = query.Where("type = ?", fileType)
@@ -46,6 +50,9 @@ func (r *filesRepository) FindAll(ctx context.Context, filters map[string]interf
 	if categoryID, ok := filters["category_id"]; ok {
 		query = query.Where("category_id = ?", categoryID)
 	}
+	if scanStatus, ok := filters["scan_status"]; ok {
+		query = query.Where("scan_status = ?", scanStatus)
+	}
 	if level, ok := filters["level"]; ok {
 		query = query.Where("level <= ?", level)
 	}
@@ -55,6 +62,10 @@ func (r *filesRepository) FindAll(ctx context.Context, filters map[string]interf
 	if uploadDateTo, ok := filters["upload_date_to"]; ok {
 		query = query.Where("upload_at <= ?", uploadDateTo)
 	}
+	if visibleAt, ok := filters["visible_at"]; ok {
+		// Inside the publication window
+		query = query.Where("publish_at <= ? AND (unpublish_at = 0 OR unpublish_at > ?)", visibleAt, visibleAt)
+	}
 	
 	// Add text search filter (CRITICAL FIX: This was missing!)
 	if searchQuery, ok := filters["search_query"]; ok {
@@ -89,7 +100,8 @@ func (r *filesRepository) Delete(ctx context.Context, id uint64) error {
 
 func (r *filesRepository) FindByMD5(ctx context.Context, md5 string) (*models.Files, error) {
 	var file models.Files
-	err := r.db.WithContext(ctx).Where("name = ?", md5).First(&file).Error
+	// Prefer the canonical record over duplicates linked to it; files in the trash do not count
+	err := r.db.WithContext(ctx).Where("name = ? AND status <> ?", md5, models.FileStatusDeleted).Order("duplicate_of ASC, id ASC").First(&file).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -114,6 +126,29 @@ func (r *filesRepository) FindByStatusAndType(ctx context.Context, status, fileT
 }
 
 func (r *filesRepository) UpdateStatus(ctx context.Context, id uint64, status int, username string) error {
+	return r.db.WithContext(ctx).Model(&models.Files{}).Where("id = ?", id).Updates(statusUpdates(status, username)).Error
+}
+
+// TransitionStatus changes a file's status if it is still from, and records the event in the same
+// transaction. It reports false if the status was changed concurrently.
+func (r *filesRepository) TransitionStatus(ctx context.Context, id uint64, from, to int, username string, event *models.WorkflowEvent) (bool, error) {
+	changed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Files{}).Where("id = ? AND status = ?", id, from).Updates(statusUpdates(to, username))
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+// statusUpdates returns the columns set when a file enters a status
+func statusUpdates(status int, username string) map[string]interface{} {
 	updates := map[string]interface{}{
 		"status": status,
 	}
@@ -130,5 +165,184 @@ func (r *filesRepository) UpdateStatus(ctx context.Context, id uint64, status in
 		updates["putout_at"] = gorm.Expr("UNIX_TIMESTAMP()")
 	}
 
-	return r.db.WithContext(ctx).Model(&models.Files{}).Where("id = ?", id).Updates(updates).Error
+	// The trash retention runs from the deletion; leaving the trash clears it
+	if status == models.FileStatusDeleted {
+		updates["deleted_at"] = gorm.Expr("UNIX_TIMESTAMP()")
+		updates["deleted_by"] = username
+	} else {
+		updates["deleted_at"] = 0
+		updates["deleted_by"] = ""
+	}
+	return updates
+}
+
+// FindAfterID returns files with ID greater than afterID in ID order, for batch processing
+func (r *filesRepository) FindAfterID(ctx context.Context, afterID uint64, limit int) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// AddDerivativeSize adds bytes used by a derivative (preview, proxy, ...) to a file
+func (r *filesRepository) AddDerivativeSize(ctx context.Context, id uint64, bytes int64) error {
+	return r.db.WithContext(ctx).Model(&models.Files{}).Where("id = ?", id).
+		Update("derivative_size", gorm.Expr("derivative_size + ?", bytes)).Error
+}
+
+// UpdateStoragePath points a file at a new stored object (used when migrating storage layouts)
+func (r *filesRepository) UpdateStoragePath(ctx context.Context, id uint64, path, contentHash string) error {
+	return r.db.WithContext(ctx).Model(&models.Files{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"path":         path,
+			"content_hash": contentHash,
+		}).Error
+}
+
+// FindDuplicateGroups returns content MD5s shared by more than one file, largest groups first.
+// Deleted files are not counted.
+func (r *filesRepository) FindDuplicateGroups(ctx context.Context, limit, offset int) ([]*DuplicateGroupSummary, int64, error) {
+	groups := func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Model(&models.Files{}).
+			Select("name, COUNT(*) AS count, SUM(size) AS total_size, MIN(id) AS first_id").
+			Where("status <> ?", models.FileStatusDeleted).
+			Group("name").
+			Having("COUNT(*) > 1")
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Table("(?) AS duplicate_groups", groups()).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var summaries []*DuplicateGroupSummary
+	err := groups().Order("count DESC, total_size DESC, first_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&summaries).Error
+	return summaries, total, err
+}
+
+// FindAllByMD5 returns the files with the given content MD5, canonical records first
+func (r *filesRepository) FindAllByMD5(ctx context.Context, md5 string) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).
+		Where("name = ? AND status <> ?", md5, models.FileStatusDeleted).
+		Order("duplicate_of ASC, id ASC").
+		Find(&files).Error
+	return files, err
+}
+
+// FindAllByContentHash returns the files stored as the content-addressed blob with the given hash,
+// including files in the trash
+func (r *filesRepository) FindAllByContentHash(ctx context.Context, contentHash string) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).Where("content_hash = ?", contentHash).Order("id ASC").Find(&files).Error
+	return files, err
+}
+
+// LinkDuplicates points every other linked duplicate of the content at the canonical file
+func (r *filesRepository) LinkDuplicates(ctx context.Context, md5 string, canonicalID uint64) error {
+	return r.db.WithContext(ctx).Model(&models.Files{}).
+		Where("name = ? AND id <> ? AND duplicate_of <> 0", md5, canonicalID).
+		Update("duplicate_of", canonicalID).Error
+}
+
+// DuplicateGroupSummary counts the files sharing one content MD5
+type DuplicateGroupSummary struct {
+	Name      string `json:"name"`
+	Count     int64  `json:"count"`
+	TotalSize int64  `json:"total_size"`
+	FirstID   uint64 `json:"first_id"`
+}
+
+// UpdateSchedule sets a file's publication window
+func (r *filesRepository) UpdateSchedule(ctx context.Context, id uint64, publishAt, unpublishAt int) error {
+	return r.db.WithContext(ctx).Model(&models.Files{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"publish_at":   publishAt,
+			"unpublish_at": unpublishAt,
+		}).Error
+}
+
+// FindScheduled returns files with a publication or withdrawal still to come, soonest first
+func (r *filesRepository) FindScheduled(ctx context.Context, now int, limit, offset int) ([]*models.Files, int64, error) {
+	var files []*models.Files
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Files{}).
+		Where("publish_at > ? OR unpublish_at > ?", now, now)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	// Next event first: the publication if it is still to come, else the withdrawal
+	err := query.Order(fmt.Sprintf("IF(publish_at > %d, publish_at, unpublish_at) ASC, id ASC", now)).
+		Limit(limit).Offset(offset).Find(&files).Error
+	return files, total, err
+}
+
+// FindDueForPublish returns pending files whose scheduled publication time has passed, ordered by
+// publication time and ID after the given position. Files with unresolved review comments are left
+// out until they are resolved.
+func (r *filesRepository) FindDueForPublish(ctx context.Context, now int, afterTime int, afterID uint64, limit int) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND publish_at > 0 AND publish_at <= ?", models.FileStatusPending, now).
+		Where("(publish_at > ? OR (publish_at = ? AND id > ?))", afterTime, afterTime, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM ow_review_comments c WHERE c.file_id = ow_files.id AND c.parent_id = 0 AND c.resolved = ?)", false).
+		Order("publish_at ASC, id ASC").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// FindDueForUnpublish returns published files whose scheduled withdrawal time has passed, ordered
+// by withdrawal time and ID after the given position
+func (r *filesRepository) FindDueForUnpublish(ctx context.Context, now int, afterTime int, afterID uint64, limit int) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND unpublish_at > 0 AND unpublish_at <= ?", models.FileStatusPublished, now).
+		Where("(unpublish_at > ? OR (unpublish_at = ? AND id > ?))", afterTime, afterTime, afterID).
+		Order("unpublish_at ASC, id ASC").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// TrashFilter narrows the files listed from the trash
+type TrashFilter struct {
+	CategoryID int
+	DeletedBy  string
+}
+
+// FindTrashed returns files in the trash, most recently deleted first
+func (r *filesRepository) FindTrashed(ctx context.Context, filter TrashFilter, limit, offset int) ([]*models.Files, int64, error) {
+	var files []*models.Files
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Files{}).Where("status = ?", models.FileStatusDeleted)
+	if filter.CategoryID > 0 {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+	if filter.DeletedBy != "" {
+		query = query.Where("deleted_by = ?", filter.DeletedBy)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("deleted_at DESC, id DESC").Limit(limit).Offset(offset).Find(&files).Error
+	return files, total, err
+}
+
+// FindTrashedAfterID returns files in the trash deleted at or before deletedBefore with ID greater
+// than afterID in ID order, for the purger
+func (r *filesRepository) FindTrashedAfterID(ctx context.Context, afterID uint64, deletedBefore int, limit int) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND status = ? AND deleted_at <= ?", afterID, models.FileStatusDeleted, deletedBefore).
+		Order("id ASC").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// DeleteTrashed removes a file's row if it is still in the trash. It reports false if the file was
+// restored or removed concurrently.
+func (r *filesRepository) DeleteTrashed(ctx context.Context, id uint64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND status = ?", id, models.FileStatusDeleted).Delete(&models.Files{})
+	return result.RowsAffected > 0, result.Error
 }
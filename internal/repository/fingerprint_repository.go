@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fingerprintRepository implements FingerprintRepository
+type fingerprintRepository struct {
+	db *gorm.DB
+}
+
+// NewFingerprintRepository creates a new fingerprint repository
+func NewFingerprintRepository(db *gorm.DB) FingerprintRepository {
+	return &fingerprintRepository{db: db}
+}
+
+// Save creates or replaces the fingerprint of a file
+func (r *fingerprintRepository) Save(ctx context.Context, fingerprint *models.FileFingerprint) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "phash", "dhash", "frame_hashes", "frames", "created"}),
+	}).Create(fingerprint).Error
+}
+
+func (r *fingerprintRepository) FindByFileID(ctx context.Context, fileID uint64) (*models.FileFingerprint, error) {
+	var fingerprint models.FileFingerprint
+	err := r.db.WithContext(ctx).Where("file_id = ?", fileID).First(&fingerprint).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fingerprint, nil
+}
+
+func (r *fingerprintRepository) DeleteByFileID(ctx context.Context, fileID uint64) error {
+	return r.db.WithContext(ctx).Where("file_id = ?", fileID).Delete(&models.FileFingerprint{}).Error
+}
+
+// FindWithinDistance returns fingerprints of the given kind whose pHash differs from hash in at
+// most maxDistance bits, closest first
+func (r *fingerprintRepository) FindWithinDistance(ctx context.Context, kind string, hash uint64, maxDistance int, excludeFileID uint64, limit int) ([]*FingerprintMatch, error) {
+	var matches []*FingerprintMatch
+	err := r.db.WithContext(ctx).
+		Model(&models.FileFingerprint{}).
+		Select("*, BIT_COUNT(phash ^ ?) AS distance", hash).
+		Where("kind = ? AND file_id <> ? AND BIT_COUNT(phash ^ ?) <= ?", kind, excludeFileID, hash, maxDistance).
+		Order("distance ASC, file_id ASC").
+		Limit(limit).
+		Scan(&matches).Error
+	return matches, err
+}
+
+// FindPairsWithinDistance returns pairs of fingerprints of the same kind whose pHashes differ in at
+// most maxDistance bits, closest first. The self-join compares every pair, so it suits reports
+// rather than request paths.
+func (r *fingerprintRepository) FindPairsWithinDistance(ctx context.Context, maxDistance int, limit, offset int) ([]*FingerprintPair, int64, error) {
+	pairs := func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Table("ow_file_fingerprints AS a").
+			Joins("JOIN ow_file_fingerprints AS b ON b.kind = a.kind AND b.file_id > a.file_id").
+			Where("BIT_COUNT(a.phash ^ b.phash) <= ?", maxDistance)
+	}
+
+	var total int64
+	if err := pairs().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var results []*FingerprintPair
+	err := pairs().
+		Select("a.file_id AS file_id, b.file_id AS other_file_id, a.kind AS kind, BIT_COUNT(a.phash ^ b.phash) AS distance").
+		Order("distance ASC, a.file_id ASC, b.file_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&results).Error
+	return results, total, err
+}
+
+// FindUnfingerprinted returns files of the given types without a fingerprint, in ID order after afterID
+func (r *fingerprintRepository) FindUnfingerprinted(ctx context.Context, types []int, afterID uint64, limit int) ([]*models.Files, error) {
+	var files []*models.Files
+	err := r.db.WithContext(ctx).
+		Model(&models.Files{}).
+		Joins("LEFT JOIN ow_file_fingerprints ON ow_file_fingerprints.file_id = ow_files.id").
+		Where("ow_file_fingerprints.id IS NULL").
+		Where("ow_files.id > ? AND ow_files.type IN ? AND ow_files.status <> ?", afterID, types, models.FileStatusDeleted).
+		Where("ow_files.scan_status IN ?", []string{models.ScanStatusClean, models.ScanStatusSkipped}).
+		Order("ow_files.id ASC").
+		Limit(limit).
+		Find(&files).Error
+	return files, err
+}
+
+// FingerprintMatch is a fingerprint with its distance from the searched hash
+type FingerprintMatch struct {
+	models.FileFingerprint
+	Distance int `json:"distance"`
+}
+
+// FingerprintPair is two files whose fingerprints are within the searched distance
+type FingerprintPair struct {
+	FileID      uint64 `json:"file_id"`
+	OtherFileID uint64 `json:"other_file_id"`
+	Kind        string `json:"kind"`
+	Distance    int    `json:"distance"`
+}
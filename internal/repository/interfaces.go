@@ -17,6 +17,21 @@ type FilesRepository interface {
 	FindByMD5(ctx context.Context, md5 string) (*models.Files, error)
 	FindByStatusAndType(ctx context.Context, status, fileType int, limit, offset int) ([]*models.Files, int64, error)
 	UpdateStatus(ctx context.Context, id uint64, status int, username string) error
+	TransitionStatus(ctx context.Context, id uint64, from, to int, username string, event *models.WorkflowEvent) (bool, error)
+	FindAfterID(ctx context.Context, afterID uint64, limit int) ([]*models.Files, error)
+	AddDerivativeSize(ctx context.Context, id uint64, bytes int64) error
+	UpdateStoragePath(ctx context.Context, id uint64, path, contentHash string) error
+	FindDuplicateGroups(ctx context.Context, limit, offset int) ([]*DuplicateGroupSummary, int64, error)
+	FindAllByMD5(ctx context.Context, md5 string) ([]*models.Files, error)
+	FindAllByContentHash(ctx context.Context, contentHash string) ([]*models.Files, error)
+	LinkDuplicates(ctx context.Context, md5 string, canonicalID uint64) error
+	UpdateSchedule(ctx context.Context, id uint64, publishAt, unpublishAt int) error
+	FindScheduled(ctx context.Context, now int, limit, offset int) ([]*models.Files, int64, error)
+	FindDueForPublish(ctx context.Context, now int, afterTime int, afterID uint64, limit int) ([]*models.Files, error)
+	FindDueForUnpublish(ctx context.Context, now int, afterTime int, afterID uint64, limit int) ([]*models.Files, error)
+	FindTrashed(ctx context.Context, filter TrashFilter, limit, offset int) ([]*models.Files, int64, error)
+	FindTrashedAfterID(ctx context.Context, afterID uint64, deletedBefore int, limit int) ([]*models.Files, error)
+	DeleteTrashed(ctx context.Context, id uint64) (bool, error)
 }
 
 // CatalogRepository interface for Catalog data access
@@ -58,6 +73,8 @@ type UsersRepository interface {
 	SearchUsers(ctx context.Context, params UserSearchParams) ([]*models.Users, int64, error)
 	BatchDelete(ctx context.Context, ids []int) error
 	CheckUsernameExists(ctx context.Context, username string, excludeID int) (bool, error)
+	FindEnabledByGroupID(ctx context.Context, groupID int) ([]*models.Users, error)
+	FindEnabledByRoleName(ctx context.Context, roleName string) ([]*models.Users, error)
 }
 
 // GroupsRepository interface for Groups data access
@@ -117,6 +134,176 @@ type ACLRepository interface {
 	IsAdmin(ctx context.Context, userID int) (bool, error)
 }
 
+// StorageReplicaRepository interface for storage replication tracking
+type StorageReplicaRepository interface {
+	Upsert(ctx context.Context, replica *models.StorageReplica) error
+	FindByPath(ctx context.Context, primaryPath, replica string) (*models.StorageReplica, error)
+	FindByStatus(ctx context.Context, status string, limit int) ([]*models.StorageReplica, error)
+	DeleteByPath(ctx context.Context, primaryPath string) error
+	GetStats(ctx context.Context) ([]*ReplicationStats, error)
+}
+
+// StorageUsageRepository interface for per-user and per-group storage accounting
+type StorageUsageRepository interface {
+	Increment(ctx context.Context, scopeType string, scopeID int, bytes, files int64) error
+	// Reserve applies a positive delta only if used_bytes stays within limit, reporting whether it did
+	Reserve(ctx context.Context, scopeType string, scopeID int, bytes, files, limit int64) (bool, error)
+	Find(ctx context.Context, scopeType string, scopeID int) (*models.StorageUsage, error)
+	FindByScope(ctx context.Context, scopeType string) ([]*models.StorageUsage, error)
+	ListUserUsage(ctx context.Context, groupID int) ([]*UserUsage, error)
+	Recalculate(ctx context.Context) error
+}
+
+// BlobRepository interface for content-addressed object reference counts
+type BlobRepository interface {
+	AddReference(ctx context.Context, hash, path string, size int64) (int64, error)
+	ReleaseReference(ctx context.Context, path string) (int64, error)
+	CountReferences(ctx context.Context, hash string) (int64, error)
+	RemoveUnreferenced(ctx context.Context, path string, remove func() error) (bool, error)
+	FindByPath(ctx context.Context, path string) (*models.Blob, error)
+	GetStats(ctx context.Context) (*BlobStats, error)
+}
+
+// SidecarTemplateRepository interface for sidecar metadata mapping templates
+type SidecarTemplateRepository interface {
+	Create(ctx context.Context, template *models.SidecarTemplate) error
+	FindByID(ctx context.Context, id int) (*models.SidecarTemplate, error)
+	FindByName(ctx context.Context, name string) (*models.SidecarTemplate, error)
+	FindAll(ctx context.Context) ([]*models.SidecarTemplate, error)
+	Update(ctx context.Context, template *models.SidecarTemplate) error
+	Delete(ctx context.Context, id int) error
+}
+
+// FileTypeRuleRepository interface for upload file type rules
+type FileTypeRuleRepository interface {
+	Create(ctx context.Context, rule *models.FileTypeRule) error
+	CreateBatch(ctx context.Context, rules []*models.FileTypeRule) error
+	FindByID(ctx context.Context, id int) (*models.FileTypeRule, error)
+	FindAll(ctx context.Context) ([]*models.FileTypeRule, error)
+	Update(ctx context.Context, rule *models.FileTypeRule) error
+	Delete(ctx context.Context, id int) error
+}
+
+// FingerprintRepository interface for perceptual hashes of images and videos
+type FingerprintRepository interface {
+	Save(ctx context.Context, fingerprint *models.FileFingerprint) error
+	FindByFileID(ctx context.Context, fileID uint64) (*models.FileFingerprint, error)
+	DeleteByFileID(ctx context.Context, fileID uint64) error
+	FindWithinDistance(ctx context.Context, kind string, hash uint64, maxDistance int, excludeFileID uint64, limit int) ([]*FingerprintMatch, error)
+	FindPairsWithinDistance(ctx context.Context, maxDistance int, limit, offset int) ([]*FingerprintPair, int64, error)
+	FindUnfingerprinted(ctx context.Context, types []int, afterID uint64, limit int) ([]*models.Files, error)
+}
+
+// FetchJobRepository interface for server-side URL fetch jobs
+type FetchJobRepository interface {
+	Create(ctx context.Context, job *models.FetchJob) error
+	FindByID(ctx context.Context, id uint64) (*models.FetchJob, error)
+	FindAll(ctx context.Context, username string, limit, offset int) ([]*models.FetchJob, int64, error)
+	Update(ctx context.Context, job *models.FetchJob) error
+	MarkRunning(ctx context.Context, id uint64, startedAt int) (bool, error)
+}
+
+// FileVersionRepository interface for file content revisions. Versions are created together with
+// the file update in a transaction by the version service.
+type FileVersionRepository interface {
+	FindByFileID(ctx context.Context, fileID uint64) ([]*models.FileVersion, error)
+	FindVersion(ctx context.Context, fileID uint64, version int) (*models.FileVersion, error)
+	DeleteByFileID(ctx context.Context, fileID uint64) error
+}
+
+// WorkflowRepository interface for workflow definitions
+type WorkflowRepository interface {
+	Create(ctx context.Context, workflow *models.Workflow) error
+	FindByID(ctx context.Context, id int) (*models.Workflow, error)
+	FindAll(ctx context.Context) ([]*models.Workflow, error)
+	FindDefault(ctx context.Context) (*models.Workflow, error)
+	Update(ctx context.Context, workflow *models.Workflow) error
+	Delete(ctx context.Context, id int) error
+	CountCategories(ctx context.Context, id int) (int64, error)
+}
+
+// ReviewRepository interface for review tasks. Votes are recorded together with the task update
+// in a transaction by the review service.
+type ReviewRepository interface {
+	CreateTask(ctx context.Context, task *models.ReviewTask) error
+	FindTask(ctx context.Context, id uint64) (*models.ReviewTask, error)
+	FindByFileID(ctx context.Context, fileID uint64) ([]*models.ReviewTask, error)
+	FindOpenByFileID(ctx context.Context, fileID uint64) (*models.ReviewTask, error)
+	FindQueue(ctx context.Context, userID, groupID int, limit, offset int) ([]*models.ReviewTask, int64, error)
+	CancelOpen(ctx context.Context, fileID uint64, closed int) error
+}
+
+// WorkflowEventRepository interface for workflow history. Status changes write their event with
+// FilesRepository.TransitionStatus.
+type WorkflowEventRepository interface {
+	Create(ctx context.Context, event *models.WorkflowEvent) error
+	FindAll(ctx context.Context, filter WorkflowEventFilter, limit, offset int) ([]*models.WorkflowEvent, int64, error)
+}
+
+// StatsRepository interface for aggregate file and workflow statistics
+type StatsRepository interface {
+	CountFiles(ctx context.Context, filter StatsFilter) (*FileCounts, error)
+	DailyTransitions(ctx context.Context, filter StatsFilter) ([]*DailyTransitions, error)
+	ReviewDurations(ctx context.Context, filter StatsFilter) ([]int64, error)
+}
+
+// LegalHoldRepository interface for legal holds and their log. Applying and releasing a hold
+// write its log entry in the same transaction.
+type LegalHoldRepository interface {
+	Create(ctx context.Context, hold *models.LegalHold) error
+	FindByID(ctx context.Context, id uint64) (*models.LegalHold, error)
+	FindAll(ctx context.Context, filter LegalHoldFilter, limit, offset int) ([]*models.LegalHold, int64, error)
+	FindActive(ctx context.Context, fileID uint64, categoryIDs []int) ([]*models.LegalHold, error)
+	Release(ctx context.Context, id uint64, username, reason string, releasedAt int) (bool, error)
+	CreateEvent(ctx context.Context, event *models.LegalHoldEvent) error
+	FindEvents(ctx context.Context, filter LegalHoldEventFilter, limit, offset int) ([]*models.LegalHoldEvent, int64, error)
+}
+
+// BulkJobRepository interface for bulk file operations and their per-file results
+type BulkJobRepository interface {
+	Create(ctx context.Context, job *models.BulkJob, fileIDs []uint64) error
+	FindByID(ctx context.Context, id uint64) (*models.BulkJob, error)
+	FindAll(ctx context.Context, username string, limit, offset int) ([]*models.BulkJob, int64, error)
+	Update(ctx context.Context, job *models.BulkJob) error
+	MarkRunning(ctx context.Context, id uint64, startedAt int) (bool, error)
+	RequeueStale(ctx context.Context, staleBefore int) ([]uint64, error)
+	FindItems(ctx context.Context, jobID uint64, status string, limit, offset int) ([]*models.BulkJobItem, int64, error)
+	FindPendingItems(ctx context.Context, jobID, afterID uint64, limit int) ([]*models.BulkJobItem, error)
+	CompleteItem(ctx context.Context, item *models.BulkJobItem) error
+}
+
+// ReviewAssignmentRepository interface for the reviewers responsible for pending files
+type ReviewAssignmentRepository interface {
+	Create(ctx context.Context, assignment *models.ReviewAssignment) error
+	FindByID(ctx context.Context, id uint64) (*models.ReviewAssignment, error)
+	FindOpenByFileID(ctx context.Context, fileID uint64) (*models.ReviewAssignment, error)
+	FindByFileID(ctx context.Context, fileID uint64) ([]*models.ReviewAssignment, error)
+	FindAll(ctx context.Context, filter ReviewAssignmentFilter, limit, offset int) ([]*models.ReviewAssignment, int64, error)
+	Reassign(ctx context.Context, open, next *models.ReviewAssignment) (bool, error)
+	CloseOpen(ctx context.Context, fileID uint64, closed int) error
+	FindUnescalated(ctx context.Context, dueBefore int, limit int) ([]*models.ReviewAssignment, error)
+	MarkEscalated(ctx context.Context, id uint64, escalatedAt int) (bool, error)
+	LastRotationReviewer(ctx context.Context, groupID int) (int, error)
+}
+
+// NotificationRepository interface for user notifications
+type NotificationRepository interface {
+	Create(ctx context.Context, notifications []*models.Notification) error
+	FindByUserID(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*models.Notification, int64, error)
+	MarkRead(ctx context.Context, id uint64, userID int, readAt int) (bool, error)
+}
+
+// ReviewCommentRepository interface for review comments and their replies
+type ReviewCommentRepository interface {
+	Create(ctx context.Context, comment *models.ReviewComment) error
+	FindByID(ctx context.Context, id uint64) (*models.ReviewComment, error)
+	FindByFileID(ctx context.Context, fileID uint64) ([]*models.ReviewComment, error)
+	Update(ctx context.Context, comment *models.ReviewComment) error
+	SetResolved(ctx context.Context, id uint64, resolved bool, username string, at int) (bool, error)
+	Delete(ctx context.Context, id uint64) error
+	CountUnresolved(ctx context.Context, fileID uint64) (int64, error)
+}
+
 // TransactionFunc is a function type for transaction operations
 type TransactionFunc func(ctx context.Context, tx *gorm.DB) error
 
@@ -131,7 +318,24 @@ type Repository interface {
 	Permissions() PermissionsRepository
 	Levels() LevelsRepository
 	ACL() ACLRepository
-	
+	StorageReplicas() StorageReplicaRepository
+	StorageUsage() StorageUsageRepository
+	Blobs() BlobRepository
+	SidecarTemplates() SidecarTemplateRepository
+	FileTypeRules() FileTypeRuleRepository
+	Fingerprints() FingerprintRepository
+	FetchJobs() FetchJobRepository
+	FileVersions() FileVersionRepository
+	Workflows() WorkflowRepository
+	Reviews() ReviewRepository
+	WorkflowEvents() WorkflowEventRepository
+	Stats() StatsRepository
+	LegalHolds() LegalHoldRepository
+	BulkJobs() BulkJobRepository
+	ReviewAssignments() ReviewAssignmentRepository
+	Notifications() NotificationRepository
+	ReviewComments() ReviewCommentRepository
+
 	// Transaction support
 	WithTransaction(ctx context.Context, fn TransactionFunc) error
 }
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// LegalHoldFilter selects legal holds; zero values match everything
+type LegalHoldFilter struct {
+	ActiveOnly    bool
+	CaseReference string
+	FileID        uint64
+	CategoryID    int
+}
+
+// LegalHoldEventFilter selects legal hold log entries; zero values match everything
+type LegalHoldEventFilter struct {
+	HoldID uint64
+	FileID uint64
+	Action string
+}
+
+// legalHoldRepository implements LegalHoldRepository
+type legalHoldRepository struct {
+	db *gorm.DB
+}
+
+// NewLegalHoldRepository creates a new legal hold repository
+func NewLegalHoldRepository(db *gorm.DB) LegalHoldRepository {
+	return &legalHoldRepository{db: db}
+}
+
+// Create stores a new hold and logs it being applied, in one transaction
+func (r *legalHoldRepository) Create(ctx context.Context, hold *models.LegalHold) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(hold).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LegalHoldEvent{
+			HoldID:   hold.ID,
+			Action:   models.LegalHoldActionApply,
+			FileID:   hold.FileID,
+			Username: hold.CreatedBy,
+			Detail:   hold.Reason,
+			Created:  hold.Created,
+		}).Error
+	})
+}
+
+func (r *legalHoldRepository) FindByID(ctx context.Context, id uint64) (*models.LegalHold, error) {
+	var hold models.LegalHold
+	if err := r.db.WithContext(ctx).First(&hold, id).Error; err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// FindAll returns the holds matching the filter, newest first
+func (r *legalHoldRepository) FindAll(ctx context.Context, filter LegalHoldFilter, limit, offset int) ([]*models.LegalHold, int64, error) {
+	var holds []*models.LegalHold
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.LegalHold{})
+	if filter.ActiveOnly {
+		query = query.Where("released_at = 0")
+	}
+	if filter.CaseReference != "" {
+		query = query.Where("case_reference = ?", filter.CaseReference)
+	}
+	if filter.FileID > 0 {
+		query = query.Where("file_id = ?", filter.FileID)
+	}
+	if filter.CategoryID > 0 {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created DESC, id DESC").Limit(limit).Offset(offset).Find(&holds).Error
+	return holds, total, err
+}
+
+// FindActive returns the active holds on a file directly or on any of the given categories
+func (r *legalHoldRepository) FindActive(ctx context.Context, fileID uint64, categoryIDs []int) ([]*models.LegalHold, error) {
+	var holds []*models.LegalHold
+	query := r.db.WithContext(ctx).Where("released_at = 0")
+	if len(categoryIDs) > 0 {
+		// File holds have category 0, so only category holds are matched by category
+		query = query.Where("file_id = ? OR (file_id = 0 AND category_id IN ?)", fileID, categoryIDs)
+	} else {
+		query = query.Where("file_id = ?", fileID)
+	}
+	err := query.Order("id ASC").Find(&holds).Error
+	return holds, err
+}
+
+// Release ends a hold if it is still active and logs the release, in one transaction. It reports
+// false if the hold was already released.
+func (r *legalHoldRepository) Release(ctx context.Context, id uint64, username, reason string, releasedAt int) (bool, error) {
+	released := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LegalHold{}).Where("id = ? AND released_at = 0", id).
+			Updates(map[string]interface{}{
+				"released_by":    username,
+				"released_at":    releasedAt,
+				"release_reason": reason,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		released = true
+		return tx.Create(&models.LegalHoldEvent{
+			HoldID:   id,
+			Action:   models.LegalHoldActionRelease,
+			Username: username,
+			Detail:   reason,
+			Created:  releasedAt,
+		}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return released, nil
+}
+
+func (r *legalHoldRepository) CreateEvent(ctx context.Context, event *models.LegalHoldEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// FindEvents returns the log entries matching the filter, newest first
+func (r *legalHoldRepository) FindEvents(ctx context.Context, filter LegalHoldEventFilter, limit, offset int) ([]*models.LegalHoldEvent, int64, error) {
+	var events []*models.LegalHoldEvent
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.LegalHoldEvent{})
+	if filter.HoldID > 0 {
+		query = query.Where("hold_id = ?", filter.HoldID)
+	}
+	if filter.FileID > 0 {
+		query = query.Where("file_id = ?", filter.FileID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created DESC, id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
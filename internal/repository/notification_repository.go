@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// notificationRepository implements NotificationRepository
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(notifications).Error
+}
+
+// FindByUserID returns a user's notifications, newest first
+func (r *notificationRepository) FindByUserID(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*models.Notification, int64, error) {
+	var notifications []*models.Notification
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at = 0")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// MarkRead marks a user's notification as read, returning false if it does not exist or belongs
+// to another user
+func (r *notificationRepository) MarkRead(ctx context.Context, id uint64, userID int, readAt int) (bool, error) {
+	var notification models.Notification
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if notification.ReadAt == 0 {
+		err = r.db.WithContext(ctx).Model(&models.Notification{}).
+			Where("id = ? AND read_at = 0", id).Update("read_at", readAt).Error
+	}
+	return err == nil, err
+}
@@ -8,31 +8,65 @@ import (
 
 // repository implements Repository interface
 type repository struct {
-	db                *gorm.DB
-	filesRepo         FilesRepository
-	catalogRepo       CatalogRepository
-	categoryRepo      CategoryRepository
-	usersRepo         UsersRepository
-	groupsRepo        GroupsRepository
-	rolesRepo         RolesRepository
-	permissionsRepo   PermissionsRepository
-	levelsRepo        LevelsRepository
-	aclRepo           ACLRepository
+	db                 *gorm.DB
+	filesRepo          FilesRepository
+	catalogRepo        CatalogRepository
+	categoryRepo       CategoryRepository
+	usersRepo          UsersRepository
+	groupsRepo         GroupsRepository
+	rolesRepo          RolesRepository
+	permissionsRepo    PermissionsRepository
+	levelsRepo         LevelsRepository
+	aclRepo            ACLRepository
+	storageReplicaRepo StorageReplicaRepository
+	storageUsageRepo   StorageUsageRepository
+	blobRepo           BlobRepository
+	sidecarRepo        SidecarTemplateRepository
+	fileTypeRuleRepo   FileTypeRuleRepository
+	fingerprintRepo    FingerprintRepository
+	fetchJobRepo       FetchJobRepository
+	fileVersionRepo    FileVersionRepository
+	workflowRepo       WorkflowRepository
+	reviewRepo         ReviewRepository
+	workflowEventRepo  WorkflowEventRepository
+	statsRepo          StatsRepository
+	legalHoldRepo      LegalHoldRepository
+	bulkJobRepo        BulkJobRepository
+	assignmentRepo     ReviewAssignmentRepository
+	notificationRepo   NotificationRepository
+	commentRepo        ReviewCommentRepository
 }
 
 // NewRepository creates a new repository factory
 func NewRepository(db *gorm.DB) Repository {
 	return &repository{
-		db:              db,
-		filesRepo:       NewFilesRepository(db),
-		catalogRepo:     NewCatalogRepository(db),
-		categoryRepo:    NewCategoryRepository(db),
-		usersRepo:       NewUsersRepository(db),
-		groupsRepo:      NewGroupsRepository(db),
-		rolesRepo:       NewRolesRepository(db),
-		permissionsRepo: NewPermissionsRepository(db),
-		levelsRepo:      NewLevelsRepository(db),
-		aclRepo:         NewACLRepository(db),
+		db:                 db,
+		filesRepo:          NewFilesRepository(db),
+		catalogRepo:        NewCatalogRepository(db),
+		categoryRepo:       NewCategoryRepository(db),
+		usersRepo:          NewUsersRepository(db),
+		groupsRepo:         NewGroupsRepository(db),
+		rolesRepo:          NewRolesRepository(db),
+		permissionsRepo:    NewPermissionsRepository(db),
+		levelsRepo:         NewLevelsRepository(db),
+		aclRepo:            NewACLRepository(db),
+		storageReplicaRepo: NewStorageReplicaRepository(db),
+		storageUsageRepo:   NewStorageUsageRepository(db),
+		blobRepo:           NewBlobRepository(db),
+		sidecarRepo:        NewSidecarTemplateRepository(db),
+		fileTypeRuleRepo:   NewFileTypeRuleRepository(db),
+		fingerprintRepo:    NewFingerprintRepository(db),
+		fetchJobRepo:       NewFetchJobRepository(db),
+		fileVersionRepo:    NewFileVersionRepository(db),
+		workflowRepo:       NewWorkflowRepository(db),
+		reviewRepo:         NewReviewRepository(db),
+		workflowEventRepo:  NewWorkflowEventRepository(db),
+		statsRepo:          NewStatsRepository(db),
+		legalHoldRepo:      NewLegalHoldRepository(db),
+		bulkJobRepo:        NewBulkJobRepository(db),
+		assignmentRepo:     NewReviewAssignmentRepository(db),
+		notificationRepo:   NewNotificationRepository(db),
+		commentRepo:        NewReviewCommentRepository(db),
 	}
 }
 
@@ -72,6 +106,74 @@ func (r *repository) ACL() ACLRepository {
 	return r.aclRepo
 }
 
+func (r *repository) StorageReplicas() StorageReplicaRepository {
+	return r.storageReplicaRepo
+}
+
+func (r *repository) StorageUsage() StorageUsageRepository {
+	return r.storageUsageRepo
+}
+
+func (r *repository) Blobs() BlobRepository {
+	return r.blobRepo
+}
+
+func (r *repository) SidecarTemplates() SidecarTemplateRepository {
+	return r.sidecarRepo
+}
+
+func (r *repository) FileTypeRules() FileTypeRuleRepository {
+	return r.fileTypeRuleRepo
+}
+
+func (r *repository) Fingerprints() FingerprintRepository {
+	return r.fingerprintRepo
+}
+
+func (r *repository) FetchJobs() FetchJobRepository {
+	return r.fetchJobRepo
+}
+
+func (r *repository) FileVersions() FileVersionRepository {
+	return r.fileVersionRepo
+}
+
+func (r *repository) Workflows() WorkflowRepository {
+	return r.workflowRepo
+}
+
+func (r *repository) Reviews() ReviewRepository {
+	return r.reviewRepo
+}
+
+func (r *repository) WorkflowEvents() WorkflowEventRepository {
+	return r.workflowEventRepo
+}
+
+func (r *repository) Stats() StatsRepository {
+	return r.statsRepo
+}
+
+func (r *repository) LegalHolds() LegalHoldRepository {
+	return r.legalHoldRepo
+}
+
+func (r *repository) BulkJobs() BulkJobRepository {
+	return r.bulkJobRepo
+}
+
+func (r *repository) ReviewAssignments() ReviewAssignmentRepository {
+	return r.assignmentRepo
+}
+
+func (r *repository) Notifications() NotificationRepository {
+	return r.notificationRepo
+}
+
+func (r *repository) ReviewComments() ReviewCommentRepository {
+	return r.commentRepo
+}
+
 func (r *repository) WithTransaction(ctx context.Context, fn TransactionFunc) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		return fn(ctx, tx)
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReviewAssignmentFilter selects review assignments; zero values match everything
+type ReviewAssignmentFilter struct {
+	ReviewerID int
+	CategoryID int
+	OpenOnly   bool
+	DueBefore  int // Open assignments due at or before this Unix timestamp, most overdue first
+}
+
+// reviewAssignmentRepository implements ReviewAssignmentRepository
+type reviewAssignmentRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewAssignmentRepository creates a new review assignment repository
+func NewReviewAssignmentRepository(db *gorm.DB) ReviewAssignmentRepository {
+	return &reviewAssignmentRepository{db: db}
+}
+
+func (r *reviewAssignmentRepository) Create(ctx context.Context, assignment *models.ReviewAssignment) error {
+	return r.db.WithContext(ctx).Create(assignment).Error
+}
+
+func (r *reviewAssignmentRepository) FindByID(ctx context.Context, id uint64) (*models.ReviewAssignment, error) {
+	var assignment models.ReviewAssignment
+	err := r.db.WithContext(ctx).First(&assignment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// FindOpenByFileID returns the open assignment of a file, or nil
+func (r *reviewAssignmentRepository) FindOpenByFileID(ctx context.Context, fileID uint64) (*models.ReviewAssignment, error) {
+	var assignment models.ReviewAssignment
+	err := r.db.WithContext(ctx).Where("file_id = ? AND status = ?", fileID, models.ReviewAssignmentOpen).
+		Order("id DESC").First(&assignment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// FindByFileID returns a file's assignments, oldest first
+func (r *reviewAssignmentRepository) FindByFileID(ctx context.Context, fileID uint64) ([]*models.ReviewAssignment, error) {
+	var assignments []*models.ReviewAssignment
+	err := r.db.WithContext(ctx).Where("file_id = ?", fileID).Order("id ASC").Find(&assignments).Error
+	return assignments, err
+}
+
+// FindAll returns the assignments matching the filter, newest first unless filtered by due date
+func (r *reviewAssignmentRepository) FindAll(ctx context.Context, filter ReviewAssignmentFilter, limit, offset int) ([]*models.ReviewAssignment, int64, error) {
+	var assignments []*models.ReviewAssignment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ReviewAssignment{})
+	if filter.ReviewerID > 0 {
+		query = query.Where("reviewer_id = ?", filter.ReviewerID)
+	}
+	if filter.CategoryID > 0 {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+	if filter.OpenOnly || filter.DueBefore > 0 {
+		query = query.Where("status = ?", models.ReviewAssignmentOpen)
+	}
+	order := "id DESC"
+	if filter.DueBefore > 0 {
+		query = query.Where("due_at > 0 AND due_at <= ?", filter.DueBefore)
+		order = "due_at ASC, id ASC"
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order(order).Limit(limit).Offset(offset).Find(&assignments).Error
+	return assignments, total, err
+}
+
+// Reassign replaces a file's open assignment with a new one, in one transaction. It returns false
+// if the open assignment was closed meanwhile.
+func (r *reviewAssignmentRepository) Reassign(ctx context.Context, open, next *models.ReviewAssignment) (bool, error) {
+	replaced := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ReviewAssignment{}).
+			Where("id = ? AND status = ?", open.ID, models.ReviewAssignmentOpen).
+			Updates(map[string]interface{}{
+				"status": models.ReviewAssignmentReassigned,
+				"closed": next.Assigned,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		replaced = true
+		return tx.Create(next).Error
+	})
+	return replaced, err
+}
+
+// CloseOpen closes a file's open assignments once it left pending review
+func (r *reviewAssignmentRepository) CloseOpen(ctx context.Context, fileID uint64, closed int) error {
+	return r.db.WithContext(ctx).Model(&models.ReviewAssignment{}).
+		Where("file_id = ? AND status = ?", fileID, models.ReviewAssignmentOpen).
+		Updates(map[string]interface{}{
+			"status": models.ReviewAssignmentDone,
+			"closed": closed,
+		}).Error
+}
+
+// FindUnescalated returns up to limit open assignments due at or before dueBefore that were not
+// escalated yet, most overdue first
+func (r *reviewAssignmentRepository) FindUnescalated(ctx context.Context, dueBefore int, limit int) ([]*models.ReviewAssignment, error) {
+	var assignments []*models.ReviewAssignment
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND due_at > 0 AND due_at <= ? AND escalated_at = 0", models.ReviewAssignmentOpen, dueBefore).
+		Order("due_at ASC, id ASC").Limit(limit).Find(&assignments).Error
+	return assignments, err
+}
+
+// MarkEscalated records an assignment's escalation, returning false if it was already escalated
+// or closed
+func (r *reviewAssignmentRepository) MarkEscalated(ctx context.Context, id uint64, escalatedAt int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ReviewAssignment{}).
+		Where("id = ? AND status = ? AND escalated_at = 0", id, models.ReviewAssignmentOpen).
+		Update("escalated_at", escalatedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// LastRotationReviewer returns the reviewer most recently assigned in turn from a group, or 0
+func (r *reviewAssignmentRepository) LastRotationReviewer(ctx context.Context, groupID int) (int, error) {
+	var assignment models.ReviewAssignment
+	err := r.db.WithContext(ctx).Where("group_id = ? AND reviewer_id > 0", groupID).
+		Order("id DESC").First(&assignment).Error
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	return assignment.ReviewerID, err
+}
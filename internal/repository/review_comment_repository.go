@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// reviewCommentRepository implements ReviewCommentRepository
+type reviewCommentRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewCommentRepository creates a new review comment repository
+func NewReviewCommentRepository(db *gorm.DB) ReviewCommentRepository {
+	return &reviewCommentRepository{db: db}
+}
+
+func (r *reviewCommentRepository) Create(ctx context.Context, comment *models.ReviewComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *reviewCommentRepository) FindByID(ctx context.Context, id uint64) (*models.ReviewComment, error) {
+	var comment models.ReviewComment
+	err := r.db.WithContext(ctx).First(&comment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// FindByFileID returns a file's comments and replies, anchored comments in timecode order first
+// and the rest in the order they were made
+func (r *reviewCommentRepository) FindByFileID(ctx context.Context, fileID uint64) ([]*models.ReviewComment, error) {
+	var comments []*models.ReviewComment
+	err := r.db.WithContext(ctx).Where("file_id = ?", fileID).
+		Order("start_ms IS NULL, start_ms ASC, id ASC").Find(&comments).Error
+	return comments, err
+}
+
+func (r *reviewCommentRepository) Update(ctx context.Context, comment *models.ReviewComment) error {
+	return r.db.WithContext(ctx).Save(comment).Error
+}
+
+// SetResolved resolves or reopens a top-level comment, returning false if it already was
+func (r *reviewCommentRepository) SetResolved(ctx context.Context, id uint64, resolved bool, username string, at int) (bool, error) {
+	updates := map[string]interface{}{
+		"resolved":    resolved,
+		"resolved_by": "",
+		"resolved_at": 0,
+		"updated":     at,
+	}
+	if resolved {
+		updates["resolved_by"] = username
+		updates["resolved_at"] = at
+	}
+	result := r.db.WithContext(ctx).Model(&models.ReviewComment{}).
+		Where("id = ? AND parent_id = 0 AND resolved = ?", id, !resolved).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete removes a comment with its replies
+func (r *reviewCommentRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Where("id = ? OR parent_id = ?", id, id).
+		Delete(&models.ReviewComment{}).Error
+}
+
+// CountUnresolved returns the number of a file's unresolved top-level comments
+func (r *reviewCommentRepository) CountUnresolved(ctx context.Context, fileID uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ReviewComment{}).
+		Where("file_id = ? AND parent_id = 0 AND resolved = ?", fileID, false).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// reviewRepository implements ReviewRepository
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository creates a new review repository
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// CreateTask stores a review task with its assignees
+func (r *reviewRepository) CreateTask(ctx context.Context, task *models.ReviewTask) error {
+	return r.db.WithContext(ctx).Create(task).Error
+}
+
+// FindTask returns a review task with its assignees and votes
+func (r *reviewRepository) FindTask(ctx context.Context, id uint64) (*models.ReviewTask, error) {
+	var task models.ReviewTask
+	err := r.db.WithContext(ctx).Preload("Assignees").Preload("Votes").First(&task, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindByFileID returns a file's review tasks with their assignees and votes, oldest first
+func (r *reviewRepository) FindByFileID(ctx context.Context, fileID uint64) ([]*models.ReviewTask, error) {
+	var tasks []*models.ReviewTask
+	err := r.db.WithContext(ctx).Preload("Assignees").Preload("Votes").
+		Where("file_id = ?", fileID).Order("id ASC").Find(&tasks).Error
+	return tasks, err
+}
+
+// FindOpenByFileID returns the open review task of a file, or nil
+func (r *reviewRepository) FindOpenByFileID(ctx context.Context, fileID uint64) (*models.ReviewTask, error) {
+	var task models.ReviewTask
+	err := r.db.WithContext(ctx).Where("file_id = ? AND status = ?", fileID, models.ReviewStatusOpen).
+		Order("id DESC").First(&task).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindQueue returns the open tasks assigned to a user directly or through their group that the
+// user has not voted on, oldest first
+func (r *reviewRepository) FindQueue(ctx context.Context, userID, groupID int, limit, offset int) ([]*models.ReviewTask, int64, error) {
+	var tasks []*models.ReviewTask
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ReviewTask{}).
+		Where("status = ?", models.ReviewStatusOpen).
+		Where("EXISTS (SELECT 1 FROM ow_review_assignees a WHERE a.task_id = ow_review_tasks.id AND (a.user_id = ? OR (a.group_id > 0 AND a.group_id = ?)))", userID, groupID).
+		Where("NOT EXISTS (SELECT 1 FROM ow_review_votes v WHERE v.task_id = ow_review_tasks.id AND v.user_id = ?)", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("Assignees").Preload("Votes").
+		Order("created ASC, id ASC").Limit(limit).Offset(offset).Find(&tasks).Error
+	return tasks, total, err
+}
+
+// CancelOpen cancels a file's open review tasks
+func (r *reviewRepository) CancelOpen(ctx context.Context, fileID uint64, closed int) error {
+	return r.db.WithContext(ctx).Model(&models.ReviewTask{}).
+		Where("file_id = ? AND status = ?", fileID, models.ReviewStatusOpen).
+		Updates(map[string]interface{}{
+			"status": models.ReviewStatusCancelled,
+			"closed": closed,
+		}).Error
+}
@@ -34,6 +34,7 @@ type SearchParams struct {
 	GroupID    uint
 	DateFrom   time.Time
 	DateTo     time.Time
+	VisibleAt  time.Time // Only files inside their publication window at this time; zero = all
 	Page       int
 	PageSize   int
 	SortBy     string // relevance, date, title
@@ -214,6 +215,9 @@ func (r *SearchRepository) buildSearchQuery(params SearchParams) string {
 	if !params.DateTo.IsZero() {
 		conditions = append(conditions, fmt.Sprintf("putout_at <= %d", params.DateTo.Unix()))
 	}
+	if !params.VisibleAt.IsZero() {
+		conditions = append(conditions, r.visibleCondition(params.VisibleAt))
+	}
 	
 	// Build WHERE clause
 	whereClause := ""
@@ -283,6 +287,9 @@ func (r *SearchRepository) buildCountQuery(params SearchParams) string {
 	if !params.DateTo.IsZero() {
 		conditions = append(conditions, fmt.Sprintf("putout_at <= %d", params.DateTo.Unix()))
 	}
+	if !params.VisibleAt.IsZero() {
+		conditions = append(conditions, r.visibleCondition(params.VisibleAt))
+	}
 	
 	whereClause := ""
 	if len(conditions) > 0 {
@@ -292,6 +299,12 @@ func (r *SearchRepository) buildCountQuery(params SearchParams) string {
 	return fmt.Sprintf("SELECT COUNT(*) FROM %s %s", r.mainIndex, whereClause)
 }
 
+// visibleCondition filters files inside their publication window. The index stores files without
+// a withdrawal time with the largest unpublish_at.
+func (r *SearchRepository) visibleCondition(at time.Time) string {
+	return fmt.Sprintf("publish_at <= %d AND unpublish_at > %d", at.Unix(), at.Unix())
+}
+
 // buildOrderByClause builds the ORDER BY clause
 func (r *SearchRepository) buildOrderByClause(sortBy string) string {
 	switch sortBy {
@@ -354,6 +367,9 @@ func (r *SearchRepository) getFacetCounts(ctx context.Context, params SearchPara
 	} else {
 		conditions = append(conditions, "status = 2")
 	}
+	if !params.VisibleAt.IsZero() {
+		conditions = append(conditions, r.visibleCondition(params.VisibleAt))
+	}
 	
 	whereClause := ""
 	if len(conditions) > 0 {
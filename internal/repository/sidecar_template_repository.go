@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// sidecarTemplateRepository implements SidecarTemplateRepository
+type sidecarTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewSidecarTemplateRepository creates a new sidecar template repository
+func NewSidecarTemplateRepository(db *gorm.DB) SidecarTemplateRepository {
+	return &sidecarTemplateRepository{db: db}
+}
+
+func (r *sidecarTemplateRepository) Create(ctx context.Context, template *models.SidecarTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+func (r *sidecarTemplateRepository) FindByID(ctx context.Context, id int) (*models.SidecarTemplate, error) {
+	var template models.SidecarTemplate
+	err := r.db.WithContext(ctx).First(&template, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *sidecarTemplateRepository) FindByName(ctx context.Context, name string) (*models.SidecarTemplate, error) {
+	var template models.SidecarTemplate
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&template).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *sidecarTemplateRepository) FindAll(ctx context.Context) ([]*models.SidecarTemplate, error) {
+	var templates []*models.SidecarTemplate
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&templates).Error
+	return templates, err
+}
+
+func (r *sidecarTemplateRepository) Update(ctx context.Context, template *models.SidecarTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+func (r *sidecarTemplateRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&models.SidecarTemplate{}, id).Error
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// StatsFilter limits aggregate statistics; zero values match everything
+type StatsFilter struct {
+	CategoryID int // Includes the category's descendants
+	From       int // Unix timestamp, inclusive
+	To         int // Unix timestamp, inclusive
+}
+
+// FileCounts are file counts grouped by status, type, category and uploader
+type FileCounts struct {
+	Total      int64            `json:"total"`
+	ByStatus   []*StatusCount   `json:"by_status"`
+	ByType     []*TypeCount     `json:"by_type"`
+	ByCategory []*CategoryCount `json:"by_category"`
+	ByUploader []*UploaderCount `json:"by_uploader"` // Top uploaders
+}
+
+// StatusCount is the number of files in a status
+type StatusCount struct {
+	Status int   `json:"status"`
+	Count  int64 `json:"count"`
+}
+
+// TypeCount is the number of files of a type
+type TypeCount struct {
+	Type  int   `json:"type"`
+	Count int64 `json:"count"`
+}
+
+// CategoryCount is the number of files directly in a category
+type CategoryCount struct {
+	CategoryID int    `json:"category_id"`
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+}
+
+// UploaderCount is the number of files a user uploaded
+type UploaderCount struct {
+	Username string `json:"username"`
+	Count    int64  `json:"count"`
+}
+
+// DailyTransitions counts the files submitted for review and published on a day
+type DailyTransitions struct {
+	Day       string `json:"day"` // YYYY-MM-DD in database time
+	Submitted int64  `json:"submitted"`
+	Published int64  `json:"published"`
+	Rejected  int64  `json:"rejected"`
+}
+
+// topUploaders is the number of uploaders reported by CountFiles
+const topUploaders = 20
+
+// statsRepository implements StatsRepository
+type statsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository creates a new statistics repository
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+// CountFiles counts the files uploaded in the filter's range
+func (r *statsRepository) CountFiles(ctx context.Context, filter StatsFilter) (*FileCounts, error) {
+	counts := &FileCounts{}
+	files := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&models.Files{})
+		if filter.CategoryID > 0 {
+			query = query.Where("ow_files.category_id IN (?)", categorySubtree(r.db, filter.CategoryID))
+		}
+		if filter.From > 0 {
+			query = query.Where("ow_files.upload_at >= ?", filter.From)
+		}
+		if filter.To > 0 {
+			query = query.Where("ow_files.upload_at <= ?", filter.To)
+		}
+		return query
+	}
+
+	if err := files().Count(&counts.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := files().Select("status, COUNT(*) AS count").Group("status").Order("status").Scan(&counts.ByStatus).Error; err != nil {
+		return nil, err
+	}
+	if err := files().Select("type, COUNT(*) AS count").Group("type").Order("type").Scan(&counts.ByType).Error; err != nil {
+		return nil, err
+	}
+	err := files().Select("ow_files.category_id, MAX(COALESCE(c.name, ow_files.category_name)) AS name, COUNT(*) AS count").
+		Joins("LEFT JOIN ow_category c ON c.id = ow_files.category_id").
+		Group("ow_files.category_id").Order("count DESC").
+		Scan(&counts.ByCategory).Error
+	if err != nil {
+		return nil, err
+	}
+	err = files().Select("upload_username AS username, COUNT(*) AS count").
+		Group("upload_username").Order("count DESC").Limit(topUploaders).
+		Scan(&counts.ByUploader).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// DailyTransitions counts submissions, publications and rejections per day from the workflow history
+func (r *statsRepository) DailyTransitions(ctx context.Context, filter StatsFilter) ([]*DailyTransitions, error) {
+	var days []*DailyTransitions
+	query := r.events(ctx, filter).
+		Select("FROM_UNIXTIME(e.created, '%Y-%m-%d') AS day, "+
+			"SUM(e.to_status = ?) AS submitted, SUM(e.to_status = ?) AS published, SUM(e.to_status = ?) AS rejected",
+			models.FileStatusPending, models.FileStatusPublished, models.FileStatusRejected).
+		Where("e.to_status IN ?", []int{models.FileStatusPending, models.FileStatusPublished, models.FileStatusRejected}).
+		Group("day").Order("day")
+	err := query.Scan(&days).Error
+	return days, err
+}
+
+// ReviewDurations returns, for each file that left pending review in the filter's range, the
+// seconds since it entered review
+func (r *statsRepository) ReviewDurations(ctx context.Context, filter StatsFilter) ([]int64, error) {
+	var durations []int64
+	entered := r.db.Table("ow_workflow_events s").
+		Select("MAX(s.created)").
+		Where("s.file_id = e.file_id AND s.to_status = ? AND s.from_status <> s.to_status AND s.created <= e.created", models.FileStatusPending)
+	err := r.events(ctx, filter).
+		Select("e.created - (?) AS duration", entered).
+		Where("e.from_status = ? AND e.to_status <> ?", models.FileStatusPending, models.FileStatusPending).
+		Having("duration IS NOT NULL").
+		Pluck("duration", &durations).Error
+	return durations, err
+}
+
+// events selects workflow events in the filter's range as e
+func (r *statsRepository) events(ctx context.Context, filter StatsFilter) *gorm.DB {
+	// History entries that do not change the status, such as schedule changes, are not transitions
+	query := r.db.WithContext(ctx).Table("ow_workflow_events e").Where("e.from_status <> e.to_status")
+	if filter.CategoryID > 0 {
+		query = query.Where("e.file_id IN (?)",
+			r.db.Model(&models.Files{}).Select("id").Where("category_id IN (?)", categorySubtree(r.db, filter.CategoryID)))
+	}
+	if filter.From > 0 {
+		query = query.Where("e.created >= ?", filter.From)
+	}
+	if filter.To > 0 {
+		query = query.Where("e.created <= ?", filter.To)
+	}
+	return query
+}
+
+// categorySubtree selects the IDs of a category and its descendants
+func categorySubtree(db *gorm.DB, categoryID int) *gorm.DB {
+	return db.Model(&models.Category{}).Select("id").Where("id = ? OR FIND_IN_SET(?, path)", categoryID, categoryID)
+}
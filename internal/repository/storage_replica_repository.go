@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storageReplicaRepository implements StorageReplicaRepository
+type storageReplicaRepository struct {
+	db *gorm.DB
+}
+
+// NewStorageReplicaRepository creates a new storage replica repository
+func NewStorageReplicaRepository(db *gorm.DB) StorageReplicaRepository {
+	return &storageReplicaRepository{db: db}
+}
+
+func (r *storageReplicaRepository) Upsert(ctx context.Context, replica *models.StorageReplica) error {
+	updates := map[string]interface{}{
+		"status":     replica.Status,
+		"last_error": replica.LastError,
+		"attempts":   gorm.Expr("attempts + 1"),
+	}
+	if replica.ReplicaPath != "" {
+		updates["replica_path"] = replica.ReplicaPath
+	}
+	if replica.ReplicatedAt != nil {
+		updates["replicated_at"] = replica.ReplicatedAt
+	}
+	if replica.Status == models.ReplicaStatusPending {
+		// A new pending entry restarts the lag clock
+		updates["queued_at"] = replica.QueuedAt
+		updates["replicated_at"] = nil
+		updates["attempts"] = 0
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "primary_path"}, {Name: "replica"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(replica).Error
+}
+
+func (r *storageReplicaRepository) FindByPath(ctx context.Context, primaryPath, replica string) (*models.StorageReplica, error) {
+	var record models.StorageReplica
+	err := r.db.WithContext(ctx).
+		Where("primary_path = ? AND replica = ?", primaryPath, replica).
+		First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *storageReplicaRepository) FindByStatus(ctx context.Context, status string, limit int) ([]*models.StorageReplica, error) {
+	var records []*models.StorageReplica
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("queued_at ASC").
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+func (r *storageReplicaRepository) DeleteByPath(ctx context.Context, primaryPath string) error {
+	return r.db.WithContext(ctx).Where("primary_path = ?", primaryPath).Delete(&models.StorageReplica{}).Error
+}
+
+func (r *storageReplicaRepository) GetStats(ctx context.Context) ([]*ReplicationStats, error) {
+	var stats []*ReplicationStats
+	err := r.db.WithContext(ctx).
+		Model(&models.StorageReplica{}).
+		Select("replica, status, COUNT(*) AS count, MIN(queued_at) AS oldest_queued_at").
+		Group("replica, status").
+		Order("replica, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// ReplicationStats summarizes replication state per replica and status
+type ReplicationStats struct {
+	Replica        string     `json:"replica"`
+	Status         string     `json:"status"`
+	Count          int64      `json:"count"`
+	OldestQueuedAt *time.Time `json:"oldest_queued_at,omitempty"`
+}
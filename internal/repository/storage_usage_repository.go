@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storageUsageRepository implements StorageUsageRepository
+type storageUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewStorageUsageRepository creates a new storage usage repository
+func NewStorageUsageRepository(db *gorm.DB) StorageUsageRepository {
+	return &storageUsageRepository{db: db}
+}
+
+func (r *storageUsageRepository) Increment(ctx context.Context, scopeType string, scopeID int, bytes, files int64) error {
+	usage := &models.StorageUsage{
+		ScopeType: scopeType,
+		ScopeID:   scopeID,
+		UsedBytes: max(bytes, 0), // A counter created by a delete (no usage recorded yet) starts at zero
+		FileCount: max(files, 0),
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope_type"}, {Name: "scope_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"used_bytes": gorm.Expr("GREATEST(used_bytes + ?, 0)", bytes),
+			"file_count": gorm.Expr("GREATEST(file_count + ?, 0)", files),
+			"updated_at": gorm.Expr("NOW()"),
+		}),
+	}).Create(usage).Error
+}
+
+func (r *storageUsageRepository) Reserve(ctx context.Context, scopeType string, scopeID int, bytes, files, limit int64) (bool, error) {
+	// Make sure the counter exists so the conditional update has a row to lock
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.StorageUsage{
+		ScopeType: scopeType,
+		ScopeID:   scopeID,
+	}).Error
+	if err != nil {
+		return false, err
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.StorageUsage{}).
+		Where("scope_type = ? AND scope_id = ? AND used_bytes + ? <= ?", scopeType, scopeID, bytes, limit).
+		Updates(map[string]interface{}{
+			"used_bytes": gorm.Expr("used_bytes + ?", bytes),
+			"file_count": gorm.Expr("file_count + ?", files),
+			"updated_at": gorm.Expr("NOW()"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *storageUsageRepository) Find(ctx context.Context, scopeType string, scopeID int) (*models.StorageUsage, error) {
+	var usage models.StorageUsage
+	err := r.db.WithContext(ctx).
+		Where("scope_type = ? AND scope_id = ?", scopeType, scopeID).
+		First(&usage).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &models.StorageUsage{ScopeType: scopeType, ScopeID: scopeID}, nil
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *storageUsageRepository) FindByScope(ctx context.Context, scopeType string) ([]*models.StorageUsage, error) {
+	var usages []*models.StorageUsage
+	err := r.db.WithContext(ctx).
+		Where("scope_type = ?", scopeType).
+		Order("used_bytes DESC").
+		Find(&usages).Error
+	return usages, err
+}
+
+func (r *storageUsageRepository) ListUserUsage(ctx context.Context, groupID int) ([]*UserUsage, error) {
+	var usages []*UserUsage
+	query := r.db.WithContext(ctx).
+		Table("ow_users u").
+		Select("u.id AS user_id, u.username, u.group_id, COALESCE(s.used_bytes, 0) AS used_bytes, COALESCE(s.file_count, 0) AS file_count").
+		Joins("LEFT JOIN ow_storage_usage s ON s.scope_type = ? AND s.scope_id = u.id", models.UsageScopeUser)
+	if groupID > 0 {
+		query = query.Where("u.group_id = ?", groupID)
+	}
+	err := query.Order("used_bytes DESC, u.id ASC").Scan(&usages).Error
+	return usages, err
+}
+
+func (r *storageUsageRepository) Recalculate(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM ow_storage_usage").Error; err != nil {
+			return err
+		}
+
+		// Per-user usage: files are attributed to users by upload_username
+		if err := tx.Exec(`INSERT INTO ow_storage_usage (scope_type, scope_id, used_bytes, file_count, updated_at)
+			SELECT ?, u.id, COALESCE(SUM(f.size + f.derivative_size + f.version_size), 0), COUNT(f.id), NOW()
+			FROM ow_users u
+			JOIN ow_files f ON f.upload_username = u.username
+			GROUP BY u.id`, models.UsageScopeUser).Error; err != nil {
+			return err
+		}
+
+		// Per-group usage: sum over the uploaders' current groups
+		return tx.Exec(`INSERT INTO ow_storage_usage (scope_type, scope_id, used_bytes, file_count, updated_at)
+			SELECT ?, u.group_id, COALESCE(SUM(f.size + f.derivative_size + f.version_size), 0), COUNT(f.id), NOW()
+			FROM ow_users u
+			JOIN ow_files f ON f.upload_username = u.username
+			GROUP BY u.group_id`, models.UsageScopeGroup).Error
+	})
+}
+
+// UserUsage is a user's storage usage joined with user information
+type UserUsage struct {
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	GroupID   int    `json:"group_id"`
+	UsedBytes int64  `json:"used_bytes"`
+	FileCount int64  `json:"file_count"`
+}
@@ -231,3 +231,22 @@ func (r *usersRepository) CheckUsernameExists(ctx context.Context, username stri
 	err := query.Count(&count).Error
 	return count > 0, err
 }
+
+// FindEnabledByGroupID returns the enabled users of a group in ID order
+func (r *usersRepository) FindEnabledByGroupID(ctx context.Context, groupID int) ([]*models.Users, error) {
+	var users []*models.Users
+	err := r.db.WithContext(ctx).Where("group_id = ? AND enabled = ?", groupID, true).
+		Order("id ASC").Find(&users).Error
+	return users, err
+}
+
+// FindEnabledByRoleName returns the enabled users whose group has the named role
+func (r *usersRepository) FindEnabledByRoleName(ctx context.Context, roleName string) ([]*models.Users, error) {
+	var users []*models.Users
+	err := r.db.WithContext(ctx).Table("ow_users u").Select("u.*").
+		Joins("JOIN ow_groups_has_roles ghr ON u.group_id = ghr.group_id").
+		Joins("JOIN ow_roles r ON r.id = ghr.role_id").
+		Where("r.name = ? AND r.enabled = ? AND u.enabled = ?", roleName, true, true).
+		Order("u.id ASC").Find(&users).Error
+	return users, err
+}
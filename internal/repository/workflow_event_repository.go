@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// WorkflowEventFilter selects workflow events; zero values match everything
+type WorkflowEventFilter struct {
+	FileID   uint64
+	Username string
+	From     int // Unix timestamp, inclusive
+	To       int // Unix timestamp, inclusive
+}
+
+// workflowEventRepository implements WorkflowEventRepository
+type workflowEventRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkflowEventRepository creates a new workflow event repository
+func NewWorkflowEventRepository(db *gorm.DB) WorkflowEventRepository {
+	return &workflowEventRepository{db: db}
+}
+
+func (r *workflowEventRepository) Create(ctx context.Context, event *models.WorkflowEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// FindAll returns the events matching the filter, newest first
+func (r *workflowEventRepository) FindAll(ctx context.Context, filter WorkflowEventFilter, limit, offset int) ([]*models.WorkflowEvent, int64, error) {
+	var events []*models.WorkflowEvent
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.WorkflowEvent{})
+	if filter.FileID > 0 {
+		query = query.Where("file_id = ?", filter.FileID)
+	}
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.From > 0 {
+		query = query.Where("created >= ?", filter.From)
+	}
+	if filter.To > 0 {
+		query = query.Where("created <= ?", filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created DESC, id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"gorm.io/gorm"
+)
+
+// workflowRepository implements WorkflowRepository
+type workflowRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkflowRepository creates a new workflow repository
+func NewWorkflowRepository(db *gorm.DB) WorkflowRepository {
+	return &workflowRepository{db: db}
+}
+
+// Create stores a workflow; a new default workflow replaces the previous one
+func (r *workflowRepository) Create(ctx context.Context, workflow *models.Workflow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultWorkflow(tx, workflow); err != nil {
+			return err
+		}
+		return tx.Create(workflow).Error
+	})
+}
+
+func (r *workflowRepository) FindByID(ctx context.Context, id int) (*models.Workflow, error) {
+	var workflow models.Workflow
+	err := r.db.WithContext(ctx).First(&workflow, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+func (r *workflowRepository) FindAll(ctx context.Context) ([]*models.Workflow, error) {
+	var workflows []*models.Workflow
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&workflows).Error
+	return workflows, err
+}
+
+// FindDefault returns the default workflow, or nil if none is marked as default
+func (r *workflowRepository) FindDefault(ctx context.Context) (*models.Workflow, error) {
+	var workflow models.Workflow
+	err := r.db.WithContext(ctx).Where("is_default = ?", true).Order("id ASC").First(&workflow).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// Update saves a workflow; marking it as default clears the flag on the others
+func (r *workflowRepository) Update(ctx context.Context, workflow *models.Workflow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultWorkflow(tx, workflow); err != nil {
+			return err
+		}
+		return tx.Save(workflow).Error
+	})
+}
+
+func (r *workflowRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&models.Workflow{}, id).Error
+}
+
+// CountCategories returns the number of categories assigned the workflow
+func (r *workflowRepository) CountCategories(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Category{}).Where("workflow_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// clearDefaultWorkflow unmarks the other workflows when the given one becomes the default
+func clearDefaultWorkflow(tx *gorm.DB, workflow *models.Workflow) error {
+	if !workflow.IsDefault {
+		return nil
+	}
+	return tx.Model(&models.Workflow{}).Where("is_default = ? AND id <> ?", true, workflow.ID).
+		Update("is_default", false).Error
+}
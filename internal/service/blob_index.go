@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// BlobIndex persists reference counts of content-addressed objects in the database.
+// It implements storage.BlobIndex.
+type BlobIndex struct {
+	repo repository.Repository
+}
+
+// NewBlobIndex creates a new blob index
+func NewBlobIndex(repo repository.Repository) *BlobIndex {
+	return &BlobIndex{repo: repo}
+}
+
+// AddReference records one more file referencing the object at path
+func (i *BlobIndex) AddReference(ctx context.Context, hash, path string, size int64) (int64, error) {
+	return i.repo.Blobs().AddReference(ctx, hash, path, size)
+}
+
+// ReleaseReference removes one file reference from the object at path
+func (i *BlobIndex) ReleaseReference(ctx context.Context, path string) (int64, error) {
+	return i.repo.Blobs().ReleaseReference(ctx, path)
+}
+
+// CountReferences returns the number of file references to content with the given hash
+func (i *BlobIndex) CountReferences(ctx context.Context, hash string) (int64, error) {
+	return i.repo.Blobs().CountReferences(ctx, hash)
+}
+
+// RemoveUnreferenced calls remove under the object's row lock if no file references it
+func (i *BlobIndex) RemoveUnreferenced(ctx context.Context, path string, remove func() error) (bool, error) {
+	return i.repo.Blobs().RemoveUnreferenced(ctx, path, remove)
+}
+
+// GetStats returns deduplication statistics
+func (i *BlobIndex) GetStats(ctx context.Context) (*repository.BlobStats, error) {
+	return i.repo.Blobs().GetStats(ctx)
+}
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// BulkQueueName is the queue consumed by workers for bulk file operations
+const BulkQueueName = "openwan_bulk_jobs"
+
+const (
+	// MaxBulkFiles is the largest number of files one bulk job may change
+	MaxBulkFiles = 1000
+	// bulkBatchSize is the number of items a running job loads at a time
+	bulkBatchSize = 100
+	// BulkStaleAfter is how long a running job may go without recording a result before it is
+	// considered abandoned by its worker and requeued
+	BulkStaleAfter = 15 * time.Minute
+)
+
+var (
+	// ErrInvalidBulkAction is returned for unknown bulk actions or missing action parameters
+	ErrInvalidBulkAction = errors.New("invalid bulk action")
+	// ErrEmptyBulkSelection is returned when neither file IDs nor a matching query select files
+	ErrEmptyBulkSelection = errors.New("no files selected, give file_ids or a query matching files")
+	// ErrBulkTooLarge is returned when a bulk job would change more than MaxBulkFiles files
+	ErrBulkTooLarge = fmt.Errorf("a bulk job may change at most %d files", MaxBulkFiles)
+	// ErrBulkPermission is returned when the user lacks the permission a bulk action requires
+	ErrBulkPermission = errors.New("permission denied")
+)
+
+// bulkPermissions is the permission each bulk action requires; items are checked again one by one
+var bulkPermissions = map[string]string{
+	models.BulkActionSubmit:  "files.workflow.submit",
+	models.BulkActionPublish: "files.workflow.publish",
+	models.BulkActionReject:  "files.workflow.reject",
+	models.BulkActionMove:    "files.edit.update",
+	models.BulkActionAccess:  "files.edit.update",
+	models.BulkActionCatalog: "files.edit.update",
+}
+
+// BulkParams are the parameters of a bulk action
+type BulkParams struct {
+	Comment    string                 `json:"comment,omitempty"`     // Workflow actions; the rejection reason for reject
+	CategoryID int                    `json:"category_id,omitempty"` // move
+	Level      *int                   `json:"level,omitempty"`       // access
+	Groups     *string                `json:"groups,omitempty"`      // access
+	Catalog    map[string]interface{} `json:"catalog,omitempty"`     // catalog; null removes a field
+}
+
+// BulkQuery selects files with the filters of the file list
+type BulkQuery struct {
+	Search     string `json:"search"`
+	Status     *int   `json:"status"`
+	Type       int    `json:"type"`
+	CategoryID int    `json:"category_id"`
+}
+
+// BulkRequest describes a bulk operation on files given by ID or by a query
+type BulkRequest struct {
+	Action   string
+	FileIDs  []uint64
+	Query    *BulkQuery
+	Params   BulkParams
+	UserID   int
+	Username string
+	IsAdmin  bool
+}
+
+// BulkService applies workflow transitions and metadata changes to many files as background
+// jobs. Every file is checked on its own: the user must see it, the workflow must allow the
+// transition and legal holds must not block the change. Results are recorded per file.
+type BulkService struct {
+	repo  repository.Repository
+	files *FilesService
+	queue queue.QueueService
+}
+
+// NewBulkService creates a new bulk service
+func NewBulkService(repo repository.Repository, files *FilesService) *BulkService {
+	return &BulkService{
+		repo:  repo,
+		files: files,
+	}
+}
+
+// SetQueue sets the queue bulk jobs are published to; without one, jobs run in-process
+func (s *BulkService) SetQueue(queueService queue.QueueService) {
+	s.queue = queueService
+}
+
+// Submit validates the request, resolves the selected files and queues the job. It returns
+// ErrInvalidBulkAction, ErrBulkPermission, ErrEmptyBulkSelection or ErrBulkTooLarge.
+func (s *BulkService) Submit(ctx context.Context, req *BulkRequest) (*models.BulkJob, error) {
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	if !req.IsAdmin {
+		parts := strings.Split(bulkPermissions[req.Action], ".")
+		allowed, err := s.repo.ACL().HasPermission(ctx, req.UserID, parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: %s requires %s", ErrBulkPermission, req.Action, bulkPermissions[req.Action])
+		}
+	}
+
+	fileIDs, err := s.selectFiles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.BulkJob{
+		Action:   req.Action,
+		Params:   string(params),
+		UserID:   req.UserID,
+		Username: req.Username,
+		Status:   models.BulkStatusQueued,
+		Created:  int(time.Now().Unix()),
+	}
+	if err := s.repo.BulkJobs().Create(ctx, job, fileIDs); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+
+	if err := s.publish(ctx, job); err != nil {
+		// Run in-process rather than leaving the job queued forever
+		fmt.Printf("⚠ Queue unavailable for bulk job %d, running in-process: %v\n", job.ID, err)
+		go s.Run(context.Background(), job.ID)
+	}
+	return job, nil
+}
+
+// validate checks the action and its parameters
+func (s *BulkService) validate(ctx context.Context, req *BulkRequest) error {
+	if _, ok := bulkPermissions[req.Action]; !ok {
+		return fmt.Errorf("%w: action must be submit, publish, reject, move, access or catalog", ErrInvalidBulkAction)
+	}
+	switch req.Action {
+	case models.BulkActionMove:
+		if req.Params.CategoryID <= 0 {
+			return fmt.Errorf("%w: move requires category_id", ErrInvalidBulkAction)
+		}
+		if _, err := s.repo.Category().FindByID(ctx, req.Params.CategoryID); err != nil {
+			return fmt.Errorf("%w: category %d does not exist", ErrInvalidBulkAction, req.Params.CategoryID)
+		}
+	case models.BulkActionAccess:
+		if req.Params.Level == nil && req.Params.Groups == nil {
+			return fmt.Errorf("%w: access requires level or groups", ErrInvalidBulkAction)
+		}
+		if req.Params.Level != nil && *req.Params.Level < 0 {
+			return fmt.Errorf("%w: level must not be negative", ErrInvalidBulkAction)
+		}
+	case models.BulkActionCatalog:
+		if len(req.Params.Catalog) == 0 {
+			return fmt.Errorf("%w: catalog requires fields to set", ErrInvalidBulkAction)
+		}
+	}
+	return nil
+}
+
+// selectFiles returns the requested file IDs without repeats, or the IDs of the visible files
+// matching the query
+func (s *BulkService) selectFiles(ctx context.Context, req *BulkRequest) ([]uint64, error) {
+	var fileIDs []uint64
+	if len(req.FileIDs) > 0 {
+		seen := make(map[uint64]bool, len(req.FileIDs))
+		for _, id := range req.FileIDs {
+			if id > 0 && !seen[id] {
+				seen[id] = true
+				fileIDs = append(fileIDs, id)
+			}
+		}
+	} else if req.Query != nil {
+		filters := map[string]interface{}{}
+		if req.Query.Search != "" {
+			filters["search_query"] = req.Query.Search
+		}
+		if req.Query.Status != nil {
+			filters["status"] = *req.Query.Status
+		}
+		if req.Query.Type > 0 {
+			filters["type"] = req.Query.Type
+		}
+		if req.Query.CategoryID > 0 {
+			filters["category_id"] = req.Query.CategoryID
+		}
+		files, _, err := s.repo.Files().FindAll(ctx, filters, MaxBulkFiles+1, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			// Queries only select files the user can see
+			if s.files.CanViewFile(ctx, req.UserID, req.IsAdmin, file.ID) {
+				fileIDs = append(fileIDs, file.ID)
+			}
+		}
+	}
+
+	if len(fileIDs) == 0 {
+		return nil, ErrEmptyBulkSelection
+	}
+	if len(fileIDs) > MaxBulkFiles {
+		return nil, ErrBulkTooLarge
+	}
+	return fileIDs, nil
+}
+
+// publish sends the job to the bulk queue
+func (s *BulkService) publish(ctx context.Context, job *models.BulkJob) error {
+	if s.queue == nil {
+		return fmt.Errorf("no queue service configured")
+	}
+	body, err := json.Marshal(queue.BulkJob{JobID: job.ID})
+	if err != nil {
+		return err
+	}
+	return s.queue.Publish(ctx, BulkQueueName, &queue.Message{
+		ID:        fmt.Sprintf("bulk-%d", job.ID),
+		Body:      string(body),
+		Timestamp: time.Now(),
+		Attributes: map[string]string{
+			"job_id":   strconv.FormatUint(job.ID, 10),
+			"action":   job.Action,
+			"username": job.Username,
+		},
+	})
+}
+
+// HandleJob runs a queued bulk job. Failures are recorded on the job and its items, so the
+// message is only retried when the job record itself cannot be read.
+func (s *BulkService) HandleJob(ctx context.Context, message *queue.Message) error {
+	var job queue.BulkJob
+	if err := json.Unmarshal([]byte(message.Body), &job); err != nil {
+		return fmt.Errorf("failed to parse bulk job: %w", err)
+	}
+	return s.Run(ctx, job.JobID)
+}
+
+// RequeueStale queues running jobs again whose worker stopped recording results, and returns how
+// many were requeued. Items already processed are not applied again.
+func (s *BulkService) RequeueStale(ctx context.Context) (int, error) {
+	staleBefore := int(time.Now().Add(-BulkStaleAfter).Unix())
+	jobIDs, err := s.repo.BulkJobs().RequeueStale(ctx, staleBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale bulk jobs: %w", err)
+	}
+	for _, jobID := range jobIDs {
+		if err := s.publish(ctx, &models.BulkJob{ID: jobID}); err != nil {
+			log.Printf("Queue unavailable for requeued bulk job %d, running in-process: %v", jobID, err)
+			go s.Run(context.Background(), jobID)
+		}
+	}
+	return len(jobIDs), nil
+}
+
+// Run applies a queued job's action to its pending items. Jobs that are no longer queued are
+// skipped.
+func (s *BulkService) Run(ctx context.Context, jobID uint64) error {
+	started, err := s.repo.BulkJobs().MarkRunning(ctx, jobID, int(time.Now().Unix()))
+	if err != nil {
+		return fmt.Errorf("failed to start bulk job %d: %w", jobID, err)
+	}
+	if !started {
+		return nil
+	}
+	job, err := s.repo.BulkJobs().FindByID(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to load bulk job %d: %w", jobID, err)
+	}
+
+	runErr := s.runItems(ctx, job)
+
+	// Item results were counted on the job row while it ran
+	if reloaded, err := s.repo.BulkJobs().FindByID(context.Background(), jobID); err == nil {
+		job = reloaded
+	}
+	job.FinishedAt = int(time.Now().Unix())
+	job.Status = models.BulkStatusCompleted
+	if runErr != nil {
+		job.Status = models.BulkStatusFailed
+		job.Error = truncate(runErr.Error(), 1024)
+		log.Printf("Bulk job %d failed: %v", job.ID, runErr)
+	} else {
+		log.Printf("Bulk job %d (%s by %s) completed: %d succeeded, %d failed", job.ID, job.Action, job.Username, job.Succeeded, job.Failed)
+	}
+	if err := s.repo.BulkJobs().Update(context.Background(), job); err != nil {
+		return fmt.Errorf("failed to update bulk job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// runItems applies the job's action to each pending item and records the results
+func (s *BulkService) runItems(ctx context.Context, job *models.BulkJob) error {
+	var params BulkParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	isAdmin, err := s.repo.ACL().IsAdmin(ctx, job.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load requester: %w", err)
+	}
+	var catalogs []*models.Catalog
+	if job.Action == models.BulkActionCatalog {
+		if catalogs, err = s.repo.Catalog().BuildTree(ctx); err != nil {
+			return fmt.Errorf("failed to load catalog fields: %w", err)
+		}
+	}
+
+	var lastID uint64
+	for {
+		items, err := s.repo.BulkJobs().FindPendingItems(ctx, job.ID, lastID, bulkBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			lastID = item.ID
+			item.Status = models.BulkItemSucceeded
+			if err := s.apply(ctx, job, &params, catalogs, isAdmin, item.FileID); err != nil {
+				item.Status = models.BulkItemFailed
+				item.Error = truncate(err.Error(), 1024)
+			}
+			item.Processed = int(time.Now().Unix())
+			if err := s.repo.BulkJobs().CompleteItem(ctx, item); err != nil {
+				return fmt.Errorf("failed to record result for file %d: %w", item.FileID, err)
+			}
+		}
+		if len(items) < bulkBatchSize {
+			return nil
+		}
+	}
+}
+
+// apply performs the job's action on one file on behalf of the requester. Catalog patches may
+// only set the enabled catalog fields of the file's type.
+func (s *BulkService) apply(ctx context.Context, job *models.BulkJob, params *BulkParams, catalogs []*models.Catalog, isAdmin bool, fileID uint64) error {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil || !s.files.CanViewFile(ctx, job.UserID, isAdmin, fileID) {
+		return errors.New("file not found")
+	}
+	if file.Status == models.FileStatusDeleted {
+		return errors.New("file is in the trash")
+	}
+
+	switch job.Action {
+	case models.BulkActionSubmit:
+		return s.files.changeStatus(ctx, file, models.FileStatusPending, job.Username, params.Comment, false)
+	case models.BulkActionPublish:
+		return s.files.changeStatus(ctx, file, models.FileStatusPublished, job.Username, params.Comment, false)
+	case models.BulkActionReject:
+		return s.files.changeStatus(ctx, file, models.FileStatusRejected, job.Username, params.Comment, false)
+	}
+
+	// Holds are checked before the change so a move sees the file's current category
+	if err := s.files.holds.Check(ctx, file, HoldOperationUpdate, job.Username); err != nil {
+		return err
+	}
+	switch job.Action {
+	case models.BulkActionMove:
+		category, err := s.repo.Category().FindByID(ctx, params.CategoryID)
+		if err != nil {
+			return fmt.Errorf("category %d does not exist", params.CategoryID)
+		}
+		file.CategoryID = category.ID
+		file.CategoryName = category.Name
+	case models.BulkActionAccess:
+		if params.Level != nil {
+			file.Level = *params.Level
+		}
+		if params.Groups != nil {
+			file.Groups = *params.Groups
+		}
+	case models.BulkActionCatalog:
+		for field := range params.Catalog {
+			if !hasCatalogField(catalogs, field, file.Type) {
+				return fmt.Errorf("%s is not a catalog field of this file type", field)
+			}
+		}
+		catalog := map[string]interface{}{}
+		if strings.TrimSpace(file.CatalogInfo) != "" {
+			if err := json.Unmarshal([]byte(file.CatalogInfo), &catalog); err != nil {
+				return fmt.Errorf("file has invalid catalog info: %w", err)
+			}
+		}
+		for field, value := range params.Catalog {
+			if value == nil {
+				delete(catalog, field)
+			} else {
+				catalog[field] = value
+			}
+		}
+		data, err := json.Marshal(catalog)
+		if err != nil {
+			return err
+		}
+		file.CatalogInfo = string(data)
+	default:
+		return ErrInvalidBulkAction
+	}
+	return s.repo.Files().Update(ctx, file)
+}
+
+// hasCatalogField reports whether an enabled catalog field with the name applies to the file type;
+// fields of type 0 apply to every type
+func hasCatalogField(catalogs []*models.Catalog, name string, fileType int) bool {
+	for _, catalog := range catalogs {
+		if catalog.Name == name && (catalog.Type == 0 || catalog.Type == fileType) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetJob returns a bulk job
+func (s *BulkService) GetJob(ctx context.Context, id uint64) (*models.BulkJob, error) {
+	return s.repo.BulkJobs().FindByID(ctx, id)
+}
+
+// ListJobs returns bulk jobs newest first; an empty username returns every user's jobs
+func (s *BulkService) ListJobs(ctx context.Context, username string, limit, offset int) ([]*models.BulkJob, int64, error) {
+	return s.repo.BulkJobs().FindAll(ctx, username, limit, offset)
+}
+
+// ListItems returns a job's per-file results; an empty status returns all of them
+func (s *BulkService) ListItems(ctx context.Context, jobID uint64, status string, limit, offset int) ([]*models.BulkJobItem, int64, error) {
+	return s.repo.BulkJobs().FindItems(ctx, jobID, status, limit, offset)
+}
+
+// WriteReport writes a job's per-file results as CSV
+func (s *BulkService) WriteReport(ctx context.Context, job *models.BulkJob, w io.Writer) error {
+	items, _, err := s.repo.BulkJobs().FindItems(ctx, job.ID, "", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"job_id", "action", "file_id", "status", "error", "processed_at"})
+	for _, item := range items {
+		processed := ""
+		if item.Processed > 0 {
+			processed = time.Unix(int64(item.Processed), 0).Format(time.RFC3339)
+		}
+		out.Write([]string{
+			strconv.FormatUint(job.ID, 10),
+			job.Action,
+			strconv.FormatUint(item.FileID, 10),
+			item.Status,
+			item.Error,
+			processed,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
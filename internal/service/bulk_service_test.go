@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/queue"
+)
+
+// recordingQueue records published messages instead of delivering them
+type recordingQueue struct {
+	messages []*queue.Message
+}
+
+func (q *recordingQueue) Publish(ctx context.Context, queueName string, message *queue.Message) error {
+	q.messages = append(q.messages, message)
+	return nil
+}
+
+func (q *recordingQueue) Subscribe(ctx context.Context, queueName string, handler func(*queue.Message) error) error {
+	return nil
+}
+
+func (q *recordingQueue) Close() error {
+	return nil
+}
+
+// bulkAdmin creates a user with the administrator role
+func (f *testFixture) bulkAdmin() *models.Users {
+	f.t.Helper()
+	user := f.user("admin")
+	if err := f.db.Model(&models.Roles{}).Where("name = ?", "admin-role").Update("name", models.RoleAdmin).Error; err != nil {
+		f.t.Fatalf("failed to rename role: %v", err)
+	}
+	return user
+}
+
+// runBulkJob creates a job for the files and runs it, returning its items
+func (f *testFixture) runBulkJob(service *BulkService, user *models.Users, action string, params BulkParams, files ...*models.Files) []*models.BulkJobItem {
+	f.t.Helper()
+	data, _ := json.Marshal(params)
+	job := &models.BulkJob{Action: action, Params: string(data), UserID: user.ID, Username: user.Username, Status: models.BulkStatusQueued}
+	var fileIDs []uint64
+	for _, file := range files {
+		fileIDs = append(fileIDs, file.ID)
+	}
+	if err := f.repo.BulkJobs().Create(f.ctx, job, fileIDs); err != nil {
+		f.t.Fatalf("failed to create bulk job: %v", err)
+	}
+	if err := service.Run(f.ctx, job.ID); err != nil {
+		f.t.Fatalf("Run() error = %v", err)
+	}
+	items, _, err := service.ListItems(f.ctx, job.ID, "", 0, 0)
+	if err != nil {
+		f.t.Fatalf("ListItems() error = %v", err)
+	}
+	return items
+}
+
+func TestBulkCatalogChecksEachFile(t *testing.T) {
+	f := newTestFixture(t)
+	admin := f.bulkAdmin()
+	f.create(&models.Catalog{Type: 0, Name: "director", Enabled: true})
+	f.create(&models.Catalog{Type: models.FileTypeAudio, Name: "composer", Enabled: true})
+	video := f.file("video", models.FileStatusNew, "uploader")
+	trashed := f.file("trashed", models.FileStatusDeleted, "uploader")
+	service := NewBulkService(f.repo, NewFilesService(f.repo))
+
+	tests := []struct {
+		name      string
+		catalog   map[string]interface{}
+		file      *models.Files
+		wantError string
+	}{
+		{"field of every type", map[string]interface{}{"director": "Lang"}, video, ""},
+		{"field of another type", map[string]interface{}{"composer": "Eisler"}, video, "composer is not a catalog field of this file type"},
+		{"unknown field", map[string]interface{}{"budget": 100}, video, "budget is not a catalog field of this file type"},
+		{"file in the trash", map[string]interface{}{"director": "Lang"}, trashed, "file is in the trash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := f.runBulkJob(service, admin, models.BulkActionCatalog, BulkParams{Catalog: tt.catalog}, tt.file)
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+			wantStatus := models.BulkItemSucceeded
+			if tt.wantError != "" {
+				wantStatus = models.BulkItemFailed
+			}
+			if items[0].Status != wantStatus || items[0].Error != tt.wantError {
+				t.Errorf("item = %s %q, want %s %q", items[0].Status, items[0].Error, wantStatus, tt.wantError)
+			}
+		})
+	}
+
+	if catalog := f.reload(video).CatalogInfo; catalog != `{"director":"Lang"}` {
+		t.Errorf("catalog info = %s, want only the director", catalog)
+	}
+}
+
+func TestBulkReportsPermissionPerFile(t *testing.T) {
+	f := newTestFixture(t)
+	editor := f.user("editor", "files.workflow.submit")
+	level := &models.Levels{Name: "Public", Level: 1, Enabled: true}
+	f.create(level)
+	if err := f.db.Model(editor).Update("level_id", level.ID).Error; err != nil {
+		t.Fatalf("failed to set user level: %v", err)
+	}
+
+	// Submitting in the restricted category needs a permission the editor lacks
+	restricted := defaultWorkflow()
+	restricted.Name = "restricted"
+	restricted.IsDefault = false
+	transitions, _ := restricted.GetTransitions()
+	for i := range transitions {
+		if transitions[i].Name == "submit" {
+			transitions[i].Permission = "files.workflow.legal"
+		}
+	}
+	restricted.SetTransitions(transitions)
+	if err := NewWorkflowService(f.repo).CreateWorkflow(f.ctx, restricted); err != nil {
+		t.Fatalf("CreateWorkflow() error = %v", err)
+	}
+	category := &models.Category{Name: "Legal", Path: "-1,", WorkflowID: restricted.ID, Enabled: true}
+	f.create(category)
+
+	open := f.file("open", models.FileStatusNew, "uploader")
+	legal := f.file("legal", models.FileStatusNew, "uploader")
+	if err := f.db.Model(legal).Update("category_id", category.ID).Error; err != nil {
+		t.Fatalf("failed to move file: %v", err)
+	}
+
+	service := NewBulkService(f.repo, NewFilesService(f.repo))
+	items := f.runBulkJob(service, editor, models.BulkActionSubmit, BulkParams{}, open, legal)
+	job, err := service.GetJob(f.ctx, items[0].JobID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != models.BulkStatusCompleted || job.Succeeded != 1 || job.Failed != 1 {
+		t.Errorf("job = %s with %d succeeded and %d failed, want completed with 1 and 1", job.Status, job.Succeeded, job.Failed)
+	}
+
+	var report bytes.Buffer
+	if err := service.WriteReport(f.ctx, job, &report); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	rows, err := csv.NewReader(&report).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("report has %d rows, want a header and 2 files", len(rows))
+	}
+	want := map[string][2]string{
+		fmt.Sprint(open.ID):  {models.BulkItemSucceeded, ""},
+		fmt.Sprint(legal.ID): {models.BulkItemFailed, "permission denied for workflow transition: submit requires files.workflow.legal"},
+	}
+	for _, row := range rows[1:] {
+		if got := [2]string{row[3], row[4]}; got != want[row[2]] {
+			t.Errorf("report row for file %s = %q, want %q", row[2], got, want[row[2]])
+		}
+	}
+	if status := f.reload(legal).Status; status != models.FileStatusNew {
+		t.Errorf("denied file status = %d, want %d", status, models.FileStatusNew)
+	}
+}
+
+func TestBulkRequeueStale(t *testing.T) {
+	f := newTestFixture(t)
+	admin := f.bulkAdmin()
+	service := NewBulkService(f.repo, NewFilesService(f.repo))
+	jobs := &recordingQueue{}
+	service.SetQueue(jobs)
+
+	now := int(time.Now().Unix())
+	stale := int(time.Now().Add(-BulkStaleAfter - time.Minute).Unix())
+	abandoned := &models.BulkJob{Action: models.BulkActionCatalog, UserID: admin.ID, Username: admin.Username, Status: models.BulkStatusRunning, StartedAt: stale, HeartbeatAt: stale}
+	active := &models.BulkJob{Action: models.BulkActionCatalog, UserID: admin.ID, Username: admin.Username, Status: models.BulkStatusRunning, StartedAt: stale, HeartbeatAt: now}
+	for _, job := range []*models.BulkJob{abandoned, active} {
+		if err := f.repo.BulkJobs().Create(f.ctx, job, []uint64{1}); err != nil {
+			t.Fatalf("failed to create bulk job: %v", err)
+		}
+	}
+
+	requeued, err := service.RequeueStale(f.ctx)
+	if err != nil {
+		t.Fatalf("RequeueStale() error = %v", err)
+	}
+	if requeued != 1 || len(jobs.messages) != 1 || jobs.messages[0].Attributes["job_id"] != "1" {
+		t.Fatalf("RequeueStale() = %d, published %d messages, want the abandoned job requeued", requeued, len(jobs.messages))
+	}
+
+	started, err := f.repo.BulkJobs().MarkRunning(f.ctx, abandoned.ID, now)
+	if err != nil || !started {
+		t.Fatalf("MarkRunning() = %v, %v, want the requeued job taken", started, err)
+	}
+	job, err := service.GetJob(f.ctx, abandoned.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.StartedAt != stale || job.HeartbeatAt != now {
+		t.Errorf("started at %d, heartbeat at %d, want the first start %d and a heartbeat at %d", job.StartedAt, job.HeartbeatAt, stale, now)
+	}
+	if job, _ := service.GetJob(f.ctx, active.ID); job.Status != models.BulkStatusRunning {
+		t.Errorf("active job status = %s, want %s", job.Status, models.BulkStatusRunning)
+	}
+}
@@ -2,14 +2,31 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/openwan/media-asset-management/internal/models"
 	"github.com/openwan/media-asset-management/internal/repository"
 )
 
+// ErrInvalidDuplicatePolicy is returned for duplicate policies other than reject, warn, allow or ''
+var ErrInvalidDuplicatePolicy = errors.New("duplicate policy must be reject, warn, allow or empty to inherit")
+
+// ErrUnknownWorkflow is returned when a category is assigned a workflow that does not exist
+var ErrUnknownWorkflow = errors.New("workflow does not exist")
+
+// ErrInvalidTrashRetention is returned for trash retentions below -1 (keep forever)
+var ErrInvalidTrashRetention = errors.New("trash retention must be a number of days, 0 to inherit or -1 to keep forever")
+
+// ErrInvalidReviewSettings is returned for review SLAs or review groups below -1 (none)
+var ErrInvalidReviewSettings = errors.New("review SLA must be a number of hours and review group a group ID, 0 to inherit or -1 for none")
+
 // CategoryService handles category operations
 type CategoryService struct {
 	categoryRepo repository.CategoryRepository
+	workflows    *WorkflowService
 }
 
 // NewCategoryService creates a new category service
@@ -19,6 +36,11 @@ func NewCategoryService(categoryRepo repository.CategoryRepository) *CategorySer
 	}
 }
 
+// SetWorkflowService enables checking the workflows assigned to categories
+func (s *CategoryService) SetWorkflowService(workflows *WorkflowService) {
+	s.workflows = workflows
+}
+
 // GetAllCategories retrieves all categories
 func (s *CategoryService) GetAllCategories(ctx context.Context) ([]models.Category, error) {
 	categories, err := s.categoryRepo.FindAll(ctx)
@@ -41,6 +63,18 @@ func (s *CategoryService) GetCategoryByID(ctx context.Context, id uint) (*models
 
 // CreateCategory creates a new category
 func (s *CategoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	if !models.ValidDuplicatePolicy(category.DuplicatePolicy) {
+		return ErrInvalidDuplicatePolicy
+	}
+	if category.TrashRetentionDays < models.TrashRetentionForever {
+		return ErrInvalidTrashRetention
+	}
+	if category.ReviewSLAHours < models.ReviewSLANone || category.ReviewGroupID < models.ReviewGroupNone {
+		return ErrInvalidReviewSettings
+	}
+	if err := s.checkWorkflow(ctx, category.WorkflowID); err != nil {
+		return err
+	}
 	return s.categoryRepo.Create(ctx, category)
 }
 
@@ -67,10 +101,51 @@ func (s *CategoryService) UpdateCategory(ctx context.Context, id uint, updates m
 	if enabled, ok := updates["enabled"].(bool); ok {
 		category.Enabled = enabled
 	}
+	if policy, ok := updates["duplicate_policy"].(string); ok {
+		if !models.ValidDuplicatePolicy(policy) {
+			return ErrInvalidDuplicatePolicy
+		}
+		category.DuplicatePolicy = policy
+	}
+	if workflowID, ok := updates["workflow_id"].(float64); ok {
+		if err := s.checkWorkflow(ctx, int(workflowID)); err != nil {
+			return err
+		}
+		category.WorkflowID = int(workflowID)
+	}
+	if retention, ok := updates["trash_retention_days"].(float64); ok {
+		if retention < models.TrashRetentionForever || retention != float64(int(retention)) {
+			return ErrInvalidTrashRetention
+		}
+		category.TrashRetentionDays = int(retention)
+	}
+	if hours, ok := updates["review_sla_hours"].(float64); ok {
+		if hours < models.ReviewSLANone || hours != float64(int(hours)) {
+			return ErrInvalidReviewSettings
+		}
+		category.ReviewSLAHours = int(hours)
+	}
+	if groupID, ok := updates["review_group_id"].(float64); ok {
+		if groupID < models.ReviewGroupNone || groupID != float64(int(groupID)) {
+			return ErrInvalidReviewSettings
+		}
+		category.ReviewGroupID = int(groupID)
+	}
 	
 	return s.categoryRepo.Update(ctx, category)
 }
 
+// checkWorkflow validates a category's workflow ID; 0 inherits the parent's workflow
+func (s *CategoryService) checkWorkflow(ctx context.Context, workflowID int) error {
+	if workflowID < 0 {
+		return ErrUnknownWorkflow
+	}
+	if workflowID > 0 && s.workflows != nil && !s.workflows.WorkflowExists(ctx, workflowID) {
+		return ErrUnknownWorkflow
+	}
+	return nil
+}
+
 // DeleteCategory deletes a category
 func (s *CategoryService) DeleteCategory(ctx context.Context, id uint) error {
 	return s.categoryRepo.Delete(ctx, int(id))
@@ -108,3 +183,19 @@ func (s *CategoryService) GetAccessibleCategories(ctx context.Context, groupID i
 func (s *CategoryService) BuildCategoryTree(ctx context.Context) ([]*models.Category, error) {
 	return s.categoryRepo.BuildTree(ctx)
 }
+
+// categoryAncestors returns the category's ancestor IDs from the root down, ending with the category
+func categoryAncestors(ctx context.Context, categories repository.CategoryRepository, categoryID int) ([]int, error) {
+	category, err := categories.FindByID(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("category %d not found: %w", categoryID, err)
+	}
+
+	var ids []int
+	for _, part := range strings.Split(category.Path, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && id > 0 && id != category.ID {
+			ids = append(ids, id)
+		}
+	}
+	return append(ids, category.ID), nil
+}
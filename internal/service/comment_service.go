@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultMarkerFPS is the frame rate marker timecodes are exported at unless another is given
+	DefaultMarkerFPS = 25
+	// maxCommentLength is the longest comment body accepted, in bytes
+	maxCommentLength = 4000
+)
+
+var (
+	// ErrInvalidComment is returned for empty comments and invalid timecodes or regions
+	ErrInvalidComment = errors.New("invalid comment")
+	// ErrCommentNotFound is returned for comments that do not exist on the file
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrCommentNotAuthor is returned when editing or deleting another user's comment
+	ErrCommentNotAuthor = errors.New("only the author may change this comment")
+	// ErrCommentReply is returned when resolving a reply instead of its thread
+	ErrCommentReply = errors.New("replies are resolved with their thread")
+	// ErrCommentState is returned when resolving a resolved thread or reopening an open one
+	ErrCommentState = errors.New("comment thread is already in that state")
+	// ErrUnresolvedComments is returned when publishing a file with unresolved comment threads
+	ErrUnresolvedComments = errors.New("file has unresolved review comments")
+)
+
+// CommentRegion is a rectangle of the frame, relative to its size from the top left corner
+type CommentRegion struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// CommentRequest describes a new comment or reply. Replies carry no timecode or region.
+type CommentRequest struct {
+	Body     string         `json:"body"`
+	ParentID uint64         `json:"parent_id"`
+	StartMs  *int           `json:"start_ms"`
+	EndMs    *int           `json:"end_ms"`
+	Region   *CommentRegion `json:"region"`
+}
+
+// CommentService manages review comments on files: threads anchored at timecodes or regions of
+// the frame, their replies and resolution, and their export as marker lists for editors.
+type CommentService struct {
+	repo repository.Repository
+}
+
+// NewCommentService creates a new comment service
+func NewCommentService(repo repository.Repository) *CommentService {
+	return &CommentService{repo: repo}
+}
+
+// List returns a file's comment threads with their replies, optionally only the unresolved ones
+func (s *CommentService) List(ctx context.Context, fileID uint64, unresolvedOnly bool) ([]*models.ReviewComment, error) {
+	comments, err := s.repo.ReviewComments().FindByFileID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make([]*models.ReviewComment, 0, len(comments))
+	byID := make(map[uint64]*models.ReviewComment, len(comments))
+	for _, comment := range comments {
+		if comment.ParentID == 0 {
+			byID[comment.ID] = comment
+			if !unresolvedOnly || !comment.Resolved {
+				threads = append(threads, comment)
+			}
+		}
+	}
+	for _, comment := range comments {
+		if thread, ok := byID[comment.ParentID]; ok {
+			thread.Replies = append(thread.Replies, comment)
+		}
+	}
+	return threads, nil
+}
+
+// Add comments on a file or replies to one of its threads
+func (s *CommentService) Add(ctx context.Context, fileID uint64, req *CommentRequest, userID int, username string) (*models.ReviewComment, error) {
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	now := int(time.Now().Unix())
+	comment := &models.ReviewComment{
+		FileID:   fileID,
+		UserID:   userID,
+		Username: username,
+		Body:     body,
+		Created:  now,
+		Updated:  now,
+	}
+
+	if req.ParentID > 0 {
+		if req.StartMs != nil || req.EndMs != nil || req.Region != nil {
+			return nil, fmt.Errorf("%w: replies carry no timecode or region", ErrInvalidComment)
+		}
+		parent, err := s.find(ctx, fileID, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		// Replies to replies join the thread
+		comment.ParentID = parent.ID
+		if parent.ParentID > 0 {
+			comment.ParentID = parent.ParentID
+		}
+	} else if err := anchorComment(comment, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReviewComments().Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Edit changes the text of one of the user's comments
+func (s *CommentService) Edit(ctx context.Context, fileID, commentID uint64, body string, userID int) (*models.ReviewComment, error) {
+	comment, err := s.find(ctx, fileID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, ErrCommentNotAuthor
+	}
+	if comment.Body, err = commentBody(body); err != nil {
+		return nil, err
+	}
+	comment.Updated = int(time.Now().Unix())
+	if err := s.repo.ReviewComments().Update(ctx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Delete removes a comment, with its replies for a thread. Users delete their own comments;
+// administrators any.
+func (s *CommentService) Delete(ctx context.Context, fileID, commentID uint64, userID int, isAdmin bool) error {
+	comment, err := s.find(ctx, fileID, commentID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && comment.UserID != userID {
+		return ErrCommentNotAuthor
+	}
+	return s.repo.ReviewComments().Delete(ctx, comment.ID)
+}
+
+// Resolve marks a comment thread as resolved, or reopens it
+func (s *CommentService) Resolve(ctx context.Context, fileID, commentID uint64, resolved bool, username string) (*models.ReviewComment, error) {
+	comment, err := s.find(ctx, fileID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ParentID > 0 {
+		return nil, ErrCommentReply
+	}
+	changed, err := s.repo.ReviewComments().SetResolved(ctx, comment.ID, resolved, username, int(time.Now().Unix()))
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrCommentState
+	}
+	return s.repo.ReviewComments().FindByID(ctx, comment.ID)
+}
+
+// WriteCSV writes a file's comment threads as a CSV marker list with timecodes at the frame rate
+func (s *CommentService) WriteCSV(ctx context.Context, file *models.Files, fps int, w io.Writer) error {
+	threads, err := s.List(ctx, file.ID, false)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "start_timecode", "end_timecode", "start_ms", "end_ms", "region_x", "region_y", "region_width", "region_height", "author", "status", "created", "comment", "replies"})
+	for _, thread := range threads {
+		record := []string{strconv.FormatUint(thread.ID, 10), "", "", "", "", "", "", "", ""}
+		if thread.StartMs != nil {
+			record[1] = timecode(frameAt(*thread.StartMs, fps), fps)
+			record[3] = strconv.Itoa(*thread.StartMs)
+		}
+		if thread.EndMs != nil {
+			record[2] = timecode(frameAt(*thread.EndMs, fps), fps)
+			record[4] = strconv.Itoa(*thread.EndMs)
+		}
+		if thread.HasRegion() {
+			for i, value := range []float64{*thread.RegionX, *thread.RegionY, *thread.RegionW, *thread.RegionH} {
+				record[5+i] = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+		}
+		status := "open"
+		if thread.Resolved {
+			status = "resolved"
+		}
+		replies := make([]string, 0, len(thread.Replies))
+		for _, reply := range thread.Replies {
+			replies = append(replies, reply.Username+": "+reply.Body)
+		}
+		record = append(record,
+			thread.Username,
+			status,
+			time.Unix(int64(thread.Created), 0).Format(time.RFC3339),
+			thread.Body,
+			strings.Join(replies, "\n"),
+		)
+		out.Write(record)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteEDL writes a file's timecoded comment threads as a CMX 3600 EDL with a locator per thread,
+// red while open and green once resolved
+func (s *CommentService) WriteEDL(ctx context.Context, file *models.Files, fps int, w io.Writer) error {
+	threads, err := s.List(ctx, file.ID, false)
+	if err != nil {
+		return err
+	}
+	return writeEDL(w, file.Title, threads, fps)
+}
+
+// writeEDL writes the EDL of comment threads; threads without a timecode are left out
+func writeEDL(w io.Writer, title string, threads []*models.ReviewComment, fps int) error {
+	if _, err := fmt.Fprintf(w, "TITLE: %s\r\nFCM: NON-DROP FRAME\r\n\r\n", edlText(title)); err != nil {
+		return err
+	}
+	event := 0
+	for _, thread := range threads {
+		if thread.StartMs == nil {
+			continue
+		}
+		event++
+		inFrame := frameAt(*thread.StartMs, fps)
+		// Events last at least a frame; the record out point is exclusive
+		outFrame := inFrame + 1
+		if thread.EndMs != nil && frameAt(*thread.EndMs, fps) > outFrame {
+			outFrame = frameAt(*thread.EndMs, fps)
+		}
+		in, out := timecode(inFrame, fps), timecode(outFrame, fps)
+		color := "RED"
+		if thread.Resolved {
+			color = "GREEN"
+		}
+		_, err := fmt.Fprintf(w, "%03d  AX       V     C        %s %s %s %s\r\n* LOC: %s %-7s %s\r\n\r\n",
+			event, in, out, in, out, in, color, edlText(thread.Username+": "+thread.Body))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find returns a comment of the file
+func (s *CommentService) find(ctx context.Context, fileID, commentID uint64) (*models.ReviewComment, error) {
+	comment, err := s.repo.ReviewComments().FindByID(ctx, commentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && comment.FileID != fileID) {
+		return nil, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// commentBody validates a comment's text
+func commentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: body is required", ErrInvalidComment)
+	}
+	if len(body) > maxCommentLength {
+		return "", fmt.Errorf("%w: body is longer than %d bytes", ErrInvalidComment, maxCommentLength)
+	}
+	return body, nil
+}
+
+// anchorComment validates and sets a top-level comment's timecode range and region
+func anchorComment(comment *models.ReviewComment, req *CommentRequest) error {
+	if req.EndMs != nil && req.StartMs == nil {
+		return fmt.Errorf("%w: end_ms requires start_ms", ErrInvalidComment)
+	}
+	if req.StartMs != nil {
+		if *req.StartMs < 0 {
+			return fmt.Errorf("%w: start_ms must not be negative", ErrInvalidComment)
+		}
+		if req.EndMs != nil && *req.EndMs < *req.StartMs {
+			return fmt.Errorf("%w: end_ms must not be before start_ms", ErrInvalidComment)
+		}
+		comment.StartMs = req.StartMs
+		comment.EndMs = req.EndMs
+	}
+	if region := req.Region; region != nil {
+		if region.X < 0 || region.Y < 0 || region.Width <= 0 || region.Height <= 0 ||
+			region.X+region.Width > 1 || region.Y+region.Height > 1 {
+			return fmt.Errorf("%w: region must lie within the frame, with coordinates from 0 to 1", ErrInvalidComment)
+		}
+		comment.RegionX = &region.X
+		comment.RegionY = &region.Y
+		comment.RegionW = &region.Width
+		comment.RegionH = &region.Height
+	}
+	return nil
+}
+
+// checkComments returns ErrUnresolvedComments if the file has unresolved comment threads
+func checkComments(ctx context.Context, repo repository.Repository, file *models.Files) error {
+	count, err := repo.ReviewComments().CountUnresolved(ctx, file.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check review comments: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d open", ErrUnresolvedComments, count)
+	}
+	return nil
+}
+
+// frameAt returns the number of the frame shown at a time in milliseconds
+func frameAt(ms, fps int) int {
+	return ms * fps / 1000
+}
+
+// timecode formats a frame number as a non-drop-frame HH:MM:SS:FF timecode
+func timecode(frame, fps int) string {
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", frame/(3600*fps), frame/(60*fps)%60, frame/fps%60, frame%fps)
+}
+
+// edlText flattens text onto a single EDL line
+func edlText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
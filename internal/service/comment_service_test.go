@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/models"
+)
+
+func TestTimecode(t *testing.T) {
+	tests := []struct {
+		ms   int
+		fps  int
+		want string
+	}{
+		{0, 25, "00:00:00:00"},
+		{39, 25, "00:00:00:00"}, // Still the first frame
+		{40, 25, "00:00:00:01"},
+		{999, 25, "00:00:00:24"},
+		{1000, 25, "00:00:01:00"},
+		{59999, 25, "00:00:59:24"},
+		{60000, 25, "00:01:00:00"},
+		{3599999, 25, "00:59:59:24"},
+		{3600000, 25, "01:00:00:00"},
+		{36000000 + 1500, 30, "10:00:01:15"},
+		{1001, 24, "00:00:01:00"},
+		{999, 1, "00:00:00:00"},
+		{123456, 120, "00:02:03:54"},
+	}
+	for _, tt := range tests {
+		if got := timecode(frameAt(tt.ms, tt.fps), tt.fps); got != tt.want {
+			t.Errorf("timecode of %d ms at %d fps = %s, want %s", tt.ms, tt.fps, got, tt.want)
+		}
+	}
+}
+
+func TestWriteEDL(t *testing.T) {
+	ms := func(value int) *int { return &value }
+	threads := []*models.ReviewComment{
+		{ID: 1, Username: "anna", Body: "Logo\nflickers", StartMs: ms(0)},
+		{ID: 2, Username: "ben", Body: "General note"}, // No timecode
+		{ID: 3, Username: "carl", Body: "Audio drops", StartMs: ms(61000), EndMs: ms(65500), Resolved: true},
+		{ID: 4, Username: "dana", Body: "Same frame", StartMs: ms(1000), EndMs: ms(1010)},
+	}
+
+	var out strings.Builder
+	if err := writeEDL(&out, "Promo  cut\n2", threads, 30); err != nil {
+		t.Fatalf("writeEDL returned error: %v", err)
+	}
+	want := "TITLE: Promo cut 2\r\nFCM: NON-DROP FRAME\r\n\r\n" +
+		"001  AX       V     C        00:00:00:00 00:00:00:01 00:00:00:00 00:00:00:01\r\n" +
+		"* LOC: 00:00:00:00 RED     anna: Logo flickers\r\n\r\n" +
+		"002  AX       V     C        00:01:01:00 00:01:05:15 00:01:01:00 00:01:05:15\r\n" +
+		"* LOC: 00:01:01:00 GREEN   carl: Audio drops\r\n\r\n" +
+		"003  AX       V     C        00:00:01:00 00:00:01:01 00:00:01:00 00:00:01:01\r\n" +
+		"* LOC: 00:00:01:00 RED     dana: Same frame\r\n\r\n"
+	if out.String() != want {
+		t.Errorf("writeEDL wrote\n%q\nwant\n%q", out.String(), want)
+	}
+}
+
+func TestAnchorComment(t *testing.T) {
+	ms := func(value int) *int { return &value }
+	region := func(x, y, w, h float64) *CommentRegion { return &CommentRegion{X: x, Y: y, Width: w, Height: h} }
+
+	tests := []struct {
+		name    string
+		req     CommentRequest
+		wantErr bool
+	}{
+		{"no anchor", CommentRequest{}, false},
+		{"point", CommentRequest{StartMs: ms(0)}, false},
+		{"range", CommentRequest{StartMs: ms(1000), EndMs: ms(1000)}, false},
+		{"full frame", CommentRequest{Region: region(0, 0, 1, 1)}, false},
+		{"end without start", CommentRequest{EndMs: ms(1000)}, true},
+		{"negative start", CommentRequest{StartMs: ms(-1)}, true},
+		{"end before start", CommentRequest{StartMs: ms(2000), EndMs: ms(1999)}, true},
+		{"empty region", CommentRequest{Region: region(0.5, 0.5, 0, 0.1)}, true},
+		{"region past the right edge", CommentRequest{Region: region(0.5, 0, 0.6, 0.5)}, true},
+		{"region past the bottom edge", CommentRequest{Region: region(0, 0.9, 0.5, 0.2)}, true},
+		{"negative region", CommentRequest{Region: region(-0.1, 0, 0.5, 0.5)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := &models.ReviewComment{}
+			err := anchorComment(comment, &tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidComment) {
+					t.Fatalf("anchorComment error = %v, want ErrInvalidComment", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("anchorComment returned error: %v", err)
+			}
+			if (comment.StartMs != nil) != (tt.req.StartMs != nil) || comment.HasRegion() != (tt.req.Region != nil) {
+				t.Errorf("anchorComment set %+v for %+v", comment, tt.req)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/transcoding"
+)
+
+// sniffLength is how much of the content is read for signature detection
+const sniffLength = 4096
+
+// contentFormat is a container or file format recognised by its signature. Formats shared by
+// several extensions (ZIP for Office documents, ASF for WMV/WMA) report the MIME type of the
+// file's extension when it belongs to the format, and mimeType otherwise.
+type contentFormat struct {
+	mimeType   string
+	extensions map[string]string // extension -> MIME type
+	executable bool
+	media      bool // Verified with ffprobe when available
+}
+
+// contentSignature matches a format by its leading bytes. Short magics that ordinary text can
+// start with also need check to accept the header that follows them.
+type contentSignature struct {
+	offset int
+	magic  []byte
+	format *contentFormat
+	check  func(head []byte) bool
+}
+
+var (
+	formatMP4 = &contentFormat{mimeType: "video/mp4", media: true, extensions: map[string]string{
+		".mp4": "video/mp4", ".m4a": "audio/mp4", ".mov": "video/quicktime",
+	}}
+	formatAVI       = &contentFormat{mimeType: "video/x-msvideo", media: true}
+	formatWAV       = &contentFormat{mimeType: "audio/wav", media: true}
+	formatWebP      = &contentFormat{mimeType: "image/webp"}
+	formatMatroska  = &contentFormat{mimeType: "video/x-matroska", media: true}
+	formatFLV       = &contentFormat{mimeType: "video/x-flv", media: true}
+	formatMPEGVideo = &contentFormat{mimeType: "video/mpeg", media: true}
+	formatMPEGTS    = &contentFormat{mimeType: "video/mp2t", media: true, extensions: map[string]string{
+		".mpg": "video/mpeg", ".mpeg": "video/mpeg",
+	}}
+	formatASF = &contentFormat{mimeType: "video/x-ms-asf", media: true, extensions: map[string]string{
+		".wmv": "video/x-ms-wmv", ".wma": "audio/x-ms-wma", ".asf": "video/x-ms-asf",
+	}}
+	formatMP3  = &contentFormat{mimeType: "audio/mpeg", media: true}
+	formatAAC  = &contentFormat{mimeType: "audio/aac", media: true}
+	formatFLAC = &contentFormat{mimeType: "audio/flac", media: true}
+	formatOgg  = &contentFormat{mimeType: "audio/ogg", media: true}
+	formatJPEG = &contentFormat{mimeType: "image/jpeg"}
+	formatPNG  = &contentFormat{mimeType: "image/png"}
+	formatGIF  = &contentFormat{mimeType: "image/gif"}
+	formatBMP  = &contentFormat{mimeType: "image/bmp"}
+	formatTIFF = &contentFormat{mimeType: "image/tiff"}
+	formatPDF  = &contentFormat{mimeType: "application/pdf"}
+	formatSWF  = &contentFormat{mimeType: "application/x-shockwave-flash"}
+	formatZIP  = &contentFormat{mimeType: "application/zip", extensions: map[string]string{
+		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	}}
+	formatOLE = &contentFormat{mimeType: "application/x-ole-storage", extensions: map[string]string{
+		".doc": "application/msword", ".xls": "application/vnd.ms-excel", ".ppt": "application/vnd.ms-powerpoint",
+	}}
+	formatText = &contentFormat{mimeType: "text/plain", extensions: map[string]string{
+		".txt": "text/plain", ".lrc": "text/plain", ".html": "text/html", ".htm": "text/html", ".svg": "image/svg+xml",
+	}}
+
+	formatWindowsExecutable = &contentFormat{mimeType: "application/x-msdownload", executable: true}
+	formatELF               = &contentFormat{mimeType: "application/x-executable", executable: true}
+	formatMachO             = &contentFormat{mimeType: "application/x-mach-binary", executable: true}
+)
+
+// contentSignatures are checked in order; RIFF, ISO media and MPEG audio are matched in sniffFormat
+var contentSignatures = []contentSignature{
+	{0, []byte("MZ"), formatWindowsExecutable, isWindowsExecutable},
+	{0, []byte("\x7fELF"), formatELF, nil},
+	{0, []byte{0xFE, 0xED, 0xFA, 0xCE}, formatMachO, nil},
+	{0, []byte{0xFE, 0xED, 0xFA, 0xCF}, formatMachO, nil},
+	{0, []byte{0xCE, 0xFA, 0xED, 0xFE}, formatMachO, nil},
+	{0, []byte{0xCF, 0xFA, 0xED, 0xFE}, formatMachO, nil},
+	{0, []byte{0x1A, 0x45, 0xDF, 0xA3}, formatMatroska, nil},
+	{0, []byte("FLV\x01"), formatFLV, nil},
+	{0, []byte{0x00, 0x00, 0x01, 0xBA}, formatMPEGVideo, nil},
+	{0, []byte{0x00, 0x00, 0x01, 0xB3}, formatMPEGVideo, nil},
+	{0, []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11}, formatASF, nil},
+	{0, []byte("ID3"), formatMP3, isID3Tag},
+	{0, []byte("fLaC"), formatFLAC, nil},
+	{0, []byte("OggS"), formatOgg, nil},
+	{0, []byte{0xFF, 0xD8, 0xFF}, formatJPEG, nil},
+	{0, []byte("\x89PNG\r\n\x1a\n"), formatPNG, nil},
+	{0, []byte("GIF87a"), formatGIF, nil},
+	{0, []byte("GIF89a"), formatGIF, nil},
+	{0, []byte("BM"), formatBMP, isBitmapHeader},
+	{0, []byte("II*\x00"), formatTIFF, nil},
+	{0, []byte("MM\x00*"), formatTIFF, nil},
+	{0, []byte("%PDF-"), formatPDF, nil},
+	{0, []byte("FWS"), formatSWF, isFlashHeader},
+	{0, []byte("CWS"), formatSWF, isFlashHeader},
+	{0, []byte("ZWS"), formatSWF, isFlashHeader},
+	{0, []byte("PK\x03\x04"), formatZIP, nil},
+	{0, []byte("PK\x05\x06"), formatZIP, nil},
+	{0, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, formatOLE, nil},
+	{0, []byte{0xFF, 0xFE}, formatText, nil}, // UTF-16 byte order marks
+	{0, []byte{0xFE, 0xFF}, formatText, nil},
+}
+
+// SniffResult is the content type detected from an upload's content
+type SniffResult struct {
+	MimeType   string
+	Executable bool
+	Media      bool // Audio or video container
+}
+
+// MediaProber inspects media containers; implemented by *transcoding.FFprobe
+type MediaProber interface {
+	ProbeStreams(ctx context.Context, filePath string) (*transcoding.StreamInfo, error)
+}
+
+// ContentSniffer detects the real content type of uploads from file signatures and, for audio
+// and video containers, ffprobe
+type ContentSniffer struct {
+	prober MediaProber
+}
+
+// NewContentSniffer creates a content sniffer; prober may be nil to skip ffprobe verification
+func NewContentSniffer(prober MediaProber) *ContentSniffer {
+	return &ContentSniffer{prober: prober}
+}
+
+// defaultMediaProber returns ffprobe if it is installed
+func defaultMediaProber() MediaProber {
+	if prober := transcoding.LookupFFprobe(); prober != nil {
+		return prober
+	}
+	return nil
+}
+
+// Sniff detects the MIME type of content from its leading bytes and rewinds it. Unrecognised
+// binary content is reported as application/octet-stream.
+func (s *ContentSniffer) Sniff(filename string, content io.ReadSeeker) (*SniffResult, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	format := sniffFormat(head)
+	if format == nil {
+		return &SniffResult{MimeType: normalizeMimeType(http.DetectContentType(head))}, nil
+	}
+
+	mimeType := format.mimeType
+	if extMime, ok := format.extensions[ext]; ok {
+		mimeType = extMime
+	}
+	return &SniffResult{MimeType: mimeType, Executable: format.executable, Media: format.media}, nil
+}
+
+// Verify checks an audio or video upload with ffprobe. It rejects content without audio or video
+// streams and, when reclassify is set, turns video containers holding only audio into audio files.
+// It returns the file type and MIME type to record.
+func (s *ContentSniffer) Verify(ctx context.Context, content io.ReadSeeker, sniffed *SniffResult, fileType int, reclassify bool) (int, string, error) {
+	if s.prober == nil || !sniffed.Media {
+		return fileType, sniffed.MimeType, nil
+	}
+
+	path, cleanup, err := localCopy(content)
+	if err != nil {
+		return 0, "", err
+	}
+	defer cleanup()
+
+	info, err := s.prober.ProbeStreams(ctx, path)
+	if err != nil {
+		return 0, "", &UploadValidationError{Message: fmt.Sprintf("File is not a readable %s file: %v", sniffed.MimeType, err)}
+	}
+	if info.VideoStreams == 0 && info.AudioStreams == 0 {
+		return 0, "", &UploadValidationError{Message: "File contains no audio or video streams"}
+	}
+	if fileType == models.FileTypeVideo && info.VideoStreams == 0 {
+		if !reclassify {
+			return 0, "", &UploadValidationError{Message: "File contains no video stream"}
+		}
+		return models.FileTypeAudio, audioMimeType(sniffed.MimeType), nil
+	}
+	return fileType, sniffed.MimeType, nil
+}
+
+// sniffFormat matches the leading bytes against the known signatures
+func sniffFormat(head []byte) *contentFormat {
+	switch {
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")):
+		if bytes.Equal(head[8:12], []byte("qt  ")) {
+			return &contentFormat{mimeType: "video/quicktime", media: true, extensions: formatMP4.extensions}
+		}
+		return formatMP4
+	case len(head) >= 12 && bytes.Equal(head[0:4], []byte("RIFF")):
+		switch string(head[8:12]) {
+		case "AVI ":
+			return formatAVI
+		case "WAVE":
+			return formatWAV
+		case "WEBP":
+			return formatWebP
+		}
+	case len(head) >= 8 && (bytes.Equal(head[4:8], []byte("moov")) || bytes.Equal(head[4:8], []byte("mdat")) || bytes.Equal(head[4:8], []byte("wide"))):
+		return formatMP4 // QuickTime files without ftyp
+	}
+
+	for _, sig := range contentSignatures {
+		if len(head) >= sig.offset+len(sig.magic) && bytes.Equal(head[sig.offset:sig.offset+len(sig.magic)], sig.magic) &&
+			(sig.check == nil || sig.check(head)) {
+			return sig.format
+		}
+	}
+
+	// MPEG transport streams repeat the 0x47 sync byte every 188 bytes
+	if len(head) >= 377 && head[0] == 0x47 && head[188] == 0x47 && head[376] == 0x47 {
+		return formatMPEGTS
+	}
+
+	// MPEG audio and ADTS AAC frames start with an 11/12-bit sync word
+	if len(head) >= 2 && head[0] == 0xFF {
+		if head[1]&0xF6 == 0xF0 {
+			return formatAAC
+		}
+		if head[1]&0xE0 == 0xE0 {
+			return formatMP3
+		}
+	}
+
+	if isText(head) {
+		return formatText
+	}
+	return nil
+}
+
+// isWindowsExecutable checks for the "PE\0\0" header that an MZ stub's e_lfanew points to. A
+// header beyond the sniffed bytes cannot be checked, and DOS programs have none; both are accepted
+// when the content is binary.
+func isWindowsExecutable(head []byte) bool {
+	if len(head) < 0x40 {
+		return false
+	}
+	peOffset := int64(binary.LittleEndian.Uint32(head[0x3C:0x40]))
+	if peOffset >= 0x40 && peOffset+4 <= int64(len(head)) {
+		return bytes.Equal(head[peOffset:peOffset+4], []byte("PE\x00\x00"))
+	}
+	return !isText(head)
+}
+
+// isBitmapHeader checks the BITMAPFILEHEADER after "BM": zero reserved fields, a known DIB header
+// size and a pixel data offset past both headers
+func isBitmapHeader(head []byte) bool {
+	if len(head) < 18 {
+		return false
+	}
+	fileSize := binary.LittleEndian.Uint32(head[2:6])
+	reserved := binary.LittleEndian.Uint32(head[6:10])
+	pixelOffset := binary.LittleEndian.Uint32(head[10:14])
+	dibSize := binary.LittleEndian.Uint32(head[14:18])
+	switch dibSize {
+	case 12, 40, 52, 56, 64, 108, 124:
+		return reserved == 0 && pixelOffset >= 14+dibSize && (fileSize == 0 || fileSize >= pixelOffset)
+	}
+	return false
+}
+
+// isID3Tag checks the ID3v2 header after "ID3": version 2.2 to 2.4, only the flags the version
+// defines, and a syncsafe tag size
+func isID3Tag(head []byte) bool {
+	if len(head) < 10 || head[4] == 0xFF {
+		return false
+	}
+	var flags byte
+	switch head[3] {
+	case 2:
+		flags = 0xC0
+	case 3:
+		flags = 0xE0
+	case 4:
+		flags = 0xF0
+	default:
+		return false
+	}
+	if head[5]&^flags != 0 {
+		return false
+	}
+	for _, b := range head[6:10] {
+		if b&0x80 != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isFlashHeader checks the SWF header after its signature: a plausible version and uncompressed
+// length. The rest of an SWF file is binary.
+func isFlashHeader(head []byte) bool {
+	if len(head) < 8 || head[3] == 0 || head[3] > 50 {
+		return false
+	}
+	return binary.LittleEndian.Uint32(head[4:8]) >= 8 && !isText(head)
+}
+
+// isText reports whether content has no control bytes. Multi-byte and legacy 8-bit encodings
+// (GBK lyrics) pass; binary formats practically always contain NUL or control bytes.
+func isText(head []byte) bool {
+	for _, b := range head {
+		if (b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f') || b == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// audioMimeType returns the audio variant of a video container MIME type
+func audioMimeType(mimeType string) string {
+	switch mimeType {
+	case "video/mp4", "video/quicktime":
+		return "audio/mp4"
+	case "video/x-matroska":
+		return "audio/x-matroska"
+	case "video/x-ms-wmv", "video/x-ms-asf":
+		return "audio/x-ms-wma"
+	}
+	return mimeType
+}
+
+// localCopy returns a file path for content, copying it to a temporary file unless it already is one
+func localCopy(content io.ReadSeeker) (string, func(), error) {
+	if file, ok := content.(*os.File); ok {
+		return file.Name(), func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "openwan-probe-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, content); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return tmp.Name(), cleanup, nil
+}
@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/service"
+)
+
+func TestContentSnifferSignatures(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		content    []byte
+		mimeType   string
+		executable bool
+	}{
+		// Text that starts like a short magic number
+		{"text starting MZ", "notes.txt", []byte("MZ-2041 shipment arrived\nMZ-2042 pending\n"), "text/plain", false},
+		{"csv starting MZ", "codes.csv", []byte("MZ,Mozambique\nMW,Malawi\n"), "text/plain", false},
+		{"text starting BM", "notes.txt", []byte("BM meeting notes, see attached\n"), "text/plain", false},
+		{"text starting ID3", "tags.txt", []byte("ID3 tags to fix before release\n"), "text/plain", false},
+		{"text starting FWS", "report.txt", []byte("FWS2 weekly report\n"), "text/plain", false},
+		{"text starting CWS", "report.txt", []byte("CWS\x09 notes\n"), "text/plain", false},
+
+		// Real headers behind the same magics
+		{"PE executable", "setup.txt", peExecutable(), "application/x-msdownload", true},
+		{"DOS executable", "tool.com", append([]byte("MZ"), make([]byte, 62)...), "application/x-msdownload", true},
+		{"bitmap", "frame.bmp", bitmap(), "image/bmp", false},
+		{"ID3v2.4 tag", "track.mp3", append([]byte("ID3\x04\x00\x00\x00\x00\x02\x01"), make([]byte, 64)...), "audio/mpeg", false},
+		{"SWF", "intro.swf", swf(), "application/x-shockwave-flash", false},
+	}
+
+	sniffer := service.NewContentSniffer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sniffer.Sniff(tt.filename, bytes.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("Sniff returned error: %v", err)
+			}
+			if result.MimeType != tt.mimeType {
+				t.Errorf("MimeType = %q, want %q", result.MimeType, tt.mimeType)
+			}
+			if result.Executable != tt.executable {
+				t.Errorf("Executable = %v, want %v", result.Executable, tt.executable)
+			}
+		})
+	}
+}
+
+// peExecutable returns an MZ stub pointing at a PE header
+func peExecutable() []byte {
+	head := make([]byte, 0x80)
+	copy(head, "MZ")
+	binary.LittleEndian.PutUint32(head[0x3C:], 0x40)
+	copy(head[0x40:], "PE\x00\x00")
+	return head
+}
+
+// bitmap returns a 2x2 24-bit BMP
+func bitmap() []byte {
+	head := make([]byte, 70)
+	copy(head, "BM")
+	binary.LittleEndian.PutUint32(head[2:], 70)
+	binary.LittleEndian.PutUint32(head[10:], 54)
+	binary.LittleEndian.PutUint32(head[14:], 40)
+	binary.LittleEndian.PutUint32(head[18:], 2)
+	binary.LittleEndian.PutUint32(head[22:], 2)
+	binary.LittleEndian.PutUint16(head[26:], 1)
+	binary.LittleEndian.PutUint16(head[28:], 24)
+	return head
+}
+
+// swf returns an uncompressed SWF header
+func swf() []byte {
+	head := make([]byte, 32)
+	copy(head, "FWS\x0a")
+	binary.LittleEndian.PutUint32(head[4:], 32)
+	return head
+}
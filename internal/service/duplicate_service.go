@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// ErrInvalidMerge is returned when the files to merge are not duplicates of the canonical file
+var ErrInvalidMerge = errors.New("files to merge must share the canonical file's content")
+
+// DuplicateGroup is a set of files with the same content
+type DuplicateGroup struct {
+	MD5         string          `json:"md5"`
+	Count       int64           `json:"count"`
+	TotalSize   int64           `json:"total_size"`
+	WastedBytes int64           `json:"wasted_bytes"` // Bytes stored beyond one copy, before deduplicating storage
+	CanonicalID uint64          `json:"canonical_id"`
+	Files       []*models.Files `json:"files"`
+}
+
+// MergeResult describes a completed duplicate merge
+type MergeResult struct {
+	Canonical    *models.Files `json:"canonical"`
+	RemovedIDs   []uint64      `json:"removed_ids"`
+	MergedFields []string      `json:"merged_fields"`
+}
+
+// DuplicateService reports duplicate files across the archive and merges them into a canonical file
+type DuplicateService struct {
+	repo    repository.Repository
+	files   *FilesService
+	storage storage.StorageService
+}
+
+// NewDuplicateService creates a new duplicate service
+func NewDuplicateService(repo repository.Repository, files *FilesService, storageService storage.StorageService) *DuplicateService {
+	return &DuplicateService{
+		repo:    repo,
+		files:   files,
+		storage: storageService,
+	}
+}
+
+// ListGroups returns duplicate groups, largest first
+func (s *DuplicateService) ListGroups(ctx context.Context, limit, offset int) ([]*DuplicateGroup, int64, error) {
+	summaries, total, err := s.repo.Files().FindDuplicateGroups(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groups := make([]*DuplicateGroup, 0, len(summaries))
+	for _, summary := range summaries {
+		group, err := s.GetGroup(ctx, summary.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, total, nil
+}
+
+// GetGroup returns the files with the given content MD5; the canonical file is the oldest one not
+// linked to another
+func (s *DuplicateService) GetGroup(ctx context.Context, md5 string) (*DuplicateGroup, error) {
+	files, err := s.repo.Files().FindAllByMD5(ctx, md5)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files with content %s", md5)
+	}
+
+	group := &DuplicateGroup{
+		MD5:         md5,
+		Count:       int64(len(files)),
+		CanonicalID: files[0].ID,
+		Files:       files,
+	}
+	for _, file := range files {
+		group.TotalSize += file.Size
+	}
+	group.WastedBytes = group.TotalSize - files[0].Size
+	return group, nil
+}
+
+// Merge folds the duplicates' metadata into the canonical file and removes the duplicates. The
+// canonical file's values win; catalog fields it lacks are filled from the duplicates, and its
+// level and groups are widened so everyone who could see a duplicate can see the canonical file.
+// An empty duplicateIDs merges the whole group. Files under legal hold return *LegalHoldError.
+func (s *DuplicateService) Merge(ctx context.Context, canonicalID uint64, duplicateIDs []uint64, username string) (*MergeResult, error) {
+	canonical, err := s.repo.Files().FindByID(ctx, canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.GetGroup(ctx, canonical.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[uint64]*models.Files, len(group.Files))
+	for _, file := range group.Files {
+		members[file.ID] = file
+	}
+	if len(duplicateIDs) == 0 {
+		for _, file := range group.Files {
+			if file.ID != canonical.ID {
+				duplicateIDs = append(duplicateIDs, file.ID)
+			}
+		}
+	}
+
+	var duplicates []*models.Files
+	for _, id := range duplicateIDs {
+		file, ok := members[id]
+		if !ok || id == canonical.ID {
+			return nil, ErrInvalidMerge
+		}
+		duplicates = append(duplicates, file)
+	}
+	if len(duplicates) == 0 {
+		return nil, ErrInvalidMerge
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].ID < duplicates[j].ID })
+
+	// Held files are neither altered nor removed, so the merge is refused as a whole
+	if err := s.files.holds.Check(ctx, canonical, HoldOperationMerge, username); err != nil {
+		return nil, err
+	}
+	for _, duplicate := range duplicates {
+		if err := s.files.holds.Check(ctx, duplicate, HoldOperationMerge, username); err != nil {
+			return nil, err
+		}
+	}
+
+	merged, err := mergeMetadata(canonical, duplicates)
+	if err != nil {
+		return nil, err
+	}
+	canonical.DuplicateOf = 0
+	if err := s.repo.Files().Update(ctx, canonical); err != nil {
+		return nil, fmt.Errorf("failed to update canonical file: %w", err)
+	}
+
+	result := &MergeResult{Canonical: canonical, MergedFields: merged}
+	for _, duplicate := range duplicates {
+		priorPaths := s.files.PriorVersionPaths(ctx, duplicate)
+		if err := s.files.removeFile(ctx, duplicate); err != nil {
+			log.Printf("Failed to remove duplicate file %d merged into %d: %v", duplicate.ID, canonical.ID, err)
+			continue
+		}
+		s.releaseObjects(ctx, duplicate, canonical)
+		for _, path := range priorPaths {
+			prior := *duplicate
+			prior.Path = path
+			s.releaseObjects(ctx, &prior, canonical)
+		}
+		result.RemovedIDs = append(result.RemovedIDs, duplicate.ID)
+	}
+
+	// Remaining linked duplicates follow the canonical file
+	if err := s.repo.Files().LinkDuplicates(ctx, canonical.Name, canonical.ID); err != nil {
+		log.Printf("Failed to relink duplicates of file %d: %v", canonical.ID, err)
+	}
+
+	log.Printf("Merged %d duplicate(s) into file %d by %s", len(result.RemovedIDs), canonical.ID, username)
+	return result, nil
+}
+
+// releaseObjects deletes a removed duplicate's stored original and preview unless the canonical
+// file uses the same object; content-addressed objects are only removed with their last reference
+func (s *DuplicateService) releaseObjects(ctx context.Context, duplicate, canonical *models.Files) {
+	if s.storage == nil || duplicate.Path == canonical.Path {
+		return
+	}
+	releaseStoredObjects(ctx, s.storage, duplicate)
+}
+
+// mergeMetadata copies the duplicates' metadata into canonical and returns the changed fields
+func mergeMetadata(canonical *models.Files, duplicates []*models.Files) ([]string, error) {
+	var merged []string
+
+	catalog := map[string]interface{}{}
+	if strings.TrimSpace(canonical.CatalogInfo) != "" {
+		if err := json.Unmarshal([]byte(canonical.CatalogInfo), &catalog); err != nil {
+			return nil, fmt.Errorf("canonical file has invalid catalog info: %w", err)
+		}
+	}
+	catalogChanged := false
+	for _, duplicate := range duplicates {
+		if canonical.Title == "" && duplicate.Title != "" {
+			canonical.Title = duplicate.Title
+			merged = append(merged, "title")
+		}
+
+		if strings.TrimSpace(duplicate.CatalogInfo) != "" {
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(duplicate.CatalogInfo), &fields); err != nil {
+				log.Printf("Skipping invalid catalog info of duplicate file %d: %v", duplicate.ID, err)
+			} else {
+				for key, value := range fields {
+					if isEmptyCatalogValue(catalog[key]) && !isEmptyCatalogValue(value) {
+						catalog[key] = value
+						catalogChanged = true
+					}
+				}
+			}
+		}
+
+		if duplicate.Level < canonical.Level {
+			canonical.Level = duplicate.Level
+			merged = append(merged, "level")
+		}
+		if groups := mergeGroups(canonical.Groups, duplicate.Groups); groups != canonical.Groups {
+			canonical.Groups = groups
+			merged = append(merged, "groups")
+		}
+	}
+
+	if catalogChanged {
+		encoded, err := json.Marshal(catalog)
+		if err != nil {
+			return nil, err
+		}
+		canonical.CatalogInfo = string(encoded)
+		merged = append(merged, "catalog_info")
+	}
+	return uniqueStrings(merged), nil
+}
+
+// mergeGroups unions two group lists, where "all" covers every group
+func mergeGroups(a, b string) string {
+	if a == "all" || b == "all" || a == "" || b == "" {
+		return "all"
+	}
+
+	seen := map[int]bool{}
+	var ids []int
+	added := false
+	for i, list := range []string{a, b} {
+		for _, part := range strings.Split(list, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+			added = added || i == 1
+		}
+	}
+	if !added {
+		return a
+	}
+	sort.Ints(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// isEmptyCatalogValue reports whether a catalog field is missing or blank
+func isEmptyCatalogValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// uniqueStrings removes repeated values, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
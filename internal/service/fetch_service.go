@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/fetch"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// FetchQueueName is the queue consumed by workers for server-side URL fetches
+const FetchQueueName = "openwan_fetch_jobs"
+
+// ErrFetchDisabled is returned when no fetch hosts are configured
+var ErrFetchDisabled = errors.New("server-side fetch is not enabled")
+
+// FetchRequest describes a remote file to fetch and register as a new asset
+type FetchRequest struct {
+	URL         string
+	Checksum    string // Optional expected digest, algorithm:hex
+	CategoryID  int
+	Title       string // "" = source filename
+	Type        int    // 0 = detect from extension
+	Level       int
+	Groups      string
+	CatalogInfo string
+	Username    string
+}
+
+// FetchService downloads remote files on allowed hosts and passes them through the upload path
+type FetchService struct {
+	repo    repository.Repository
+	uploads *UploadService
+	fetcher *fetch.Fetcher
+	queue   queue.QueueService
+}
+
+// NewFetchService creates a new fetch service. fetcher may be nil, which disables fetching.
+func NewFetchService(repo repository.Repository, uploads *UploadService, fetcher *fetch.Fetcher) *FetchService {
+	return &FetchService{
+		repo:    repo,
+		uploads: uploads,
+		fetcher: fetcher,
+	}
+}
+
+// SetQueue sets the queue fetch jobs are published to; without one, jobs run in-process
+func (s *FetchService) SetQueue(queueService queue.QueueService) {
+	s.queue = queueService
+}
+
+// Enabled reports whether fetch hosts are configured
+func (s *FetchService) Enabled() bool {
+	return s.fetcher != nil
+}
+
+// Submit validates the request, records a queued job and schedules it
+func (s *FetchService) Submit(ctx context.Context, req *FetchRequest) (*models.FetchJob, error) {
+	if !s.Enabled() {
+		return nil, ErrFetchDisabled
+	}
+	if _, err := s.fetcher.Validate(req.URL); err != nil {
+		return nil, &UploadValidationError{Message: err.Error()}
+	}
+	checksum, err := fetch.ParseChecksum(req.Checksum)
+	if err != nil {
+		return nil, &UploadValidationError{Message: err.Error()}
+	}
+	if req.CategoryID <= 0 {
+		return nil, &UploadValidationError{Message: "category_id is required"}
+	}
+
+	job := &models.FetchJob{
+		SourceURL:   strings.TrimSpace(req.URL),
+		CategoryID:  req.CategoryID,
+		Title:       req.Title,
+		Type:        req.Type,
+		Level:       req.Level,
+		Groups:      req.Groups,
+		CatalogInfo: req.CatalogInfo,
+		Username:    req.Username,
+		Status:      models.FetchStatusQueued,
+		Created:     int(time.Now().Unix()),
+	}
+	if checksum != nil {
+		job.Checksum = checksum.String()
+	}
+	if err := s.repo.FetchJobs().Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create fetch job: %w", err)
+	}
+
+	if err := s.publish(ctx, job); err != nil {
+		// Fetch in-process rather than leaving the job queued forever
+		fmt.Printf("⚠ Queue unavailable for fetch job %d, fetching in-process: %v\n", job.ID, err)
+		go s.Run(context.Background(), job.ID)
+	}
+	return redactFetchJob(job), nil
+}
+
+// publish sends the job to the fetch queue
+func (s *FetchService) publish(ctx context.Context, job *models.FetchJob) error {
+	if s.queue == nil {
+		return fmt.Errorf("no queue service configured")
+	}
+	body, err := json.Marshal(queue.FetchJob{JobID: job.ID})
+	if err != nil {
+		return err
+	}
+	return s.queue.Publish(ctx, FetchQueueName, &queue.Message{
+		ID:        fmt.Sprintf("fetch-%d", job.ID),
+		Body:      string(body),
+		Timestamp: time.Now(),
+		Attributes: map[string]string{
+			"job_id":   strconv.FormatUint(job.ID, 10),
+			"username": job.Username,
+		},
+	})
+}
+
+// HandleJob runs a queued fetch job. Failures are recorded on the job, so the message is only
+// retried when the job record itself cannot be read.
+func (s *FetchService) HandleJob(ctx context.Context, message *queue.Message) error {
+	var job queue.FetchJob
+	if err := json.Unmarshal([]byte(message.Body), &job); err != nil {
+		return fmt.Errorf("failed to parse fetch job: %w", err)
+	}
+	return s.Run(ctx, job.JobID)
+}
+
+// Run fetches the source of a queued job into a temporary file, verifies it and uploads it.
+// Jobs that are no longer queued are skipped.
+func (s *FetchService) Run(ctx context.Context, jobID uint64) error {
+	if !s.Enabled() {
+		return ErrFetchDisabled
+	}
+	started, err := s.repo.FetchJobs().MarkRunning(ctx, jobID, int(time.Now().Unix()))
+	if err != nil {
+		return fmt.Errorf("failed to start fetch job %d: %w", jobID, err)
+	}
+	if !started {
+		return nil
+	}
+	job, err := s.repo.FetchJobs().FindByID(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to load fetch job %d: %w", jobID, err)
+	}
+
+	file, err := s.fetchAndUpload(ctx, job)
+	job.FinishedAt = int(time.Now().Unix())
+	job.Status = models.FetchStatusCompleted
+	if file != nil {
+		job.FileID = file.ID
+		job.ExistingFileID = file.DuplicateOf
+	}
+	if err != nil {
+		job.Status = models.FetchStatusFailed
+		job.Error = truncate(err.Error(), 1024)
+		var duplicate *DuplicateFileError
+		if errors.As(err, &duplicate) && duplicate.ExistingFile != nil {
+			job.ExistingFileID = duplicate.ExistingFile.ID
+		}
+		log.Printf("Fetch job %d failed: %v", job.ID, err)
+	} else {
+		log.Printf("Fetch job %d completed: file %d, %d bytes", job.ID, job.FileID, job.Bytes)
+	}
+
+	if updateErr := s.repo.FetchJobs().Update(context.Background(), job); updateErr != nil {
+		return fmt.Errorf("failed to update fetch job %d: %w", job.ID, updateErr)
+	}
+	return nil
+}
+
+// fetchAndUpload downloads the job's source and passes it through the upload path. Bytes
+// received are recorded on the job.
+func (s *FetchService) fetchAndUpload(ctx context.Context, job *models.FetchJob) (*models.Files, error) {
+	u, err := s.fetcher.Validate(job.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := fetch.ParseChecksum(job.Checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, s.fetcher.Timeout())
+	defer cancel()
+	source, err := s.fetcher.Open(fetchCtx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "openwan-fetch-*")
+	if err != nil {
+		source.Body.Close()
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var dst io.Writer = tmp
+	var digest hash.Hash
+	if checksum != nil {
+		digest = checksum.New()
+		dst = io.MultiWriter(tmp, digest)
+	}
+	written, err := s.fetcher.Copy(dst, source)
+	closeErr := source.Body.Close()
+	job.Bytes = written
+	if err != nil {
+		return nil, fmt.Errorf("transfer failed: %w", err)
+	}
+	if closeErr != nil {
+		return nil, fmt.Errorf("transfer failed: %w", closeErr)
+	}
+	if checksum != nil {
+		if err := checksum.Verify(digest.Sum(nil)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	filename := source.Filename
+	if filename == "" || filename == "." || filename == "/" {
+		filename = path.Base(u.Path)
+	}
+	title := job.Title
+	if title == "" {
+		title = strings.TrimSuffix(filename, path.Ext(filename))
+	}
+
+	file, err := s.uploads.Upload(ctx, &UploadRequest{
+		Filename:    filename,
+		Content:     tmp,
+		Size:        written,
+		ContentType: source.ContentType,
+		CategoryID:  job.CategoryID,
+		Title:       title,
+		Type:        job.Type,
+		Level:       job.Level,
+		Groups:      job.Groups,
+		CatalogInfo: job.CatalogInfo,
+		Username:    job.Username,
+		Metadata:    map[string]string{"source-url": redactURL(job.SourceURL)},
+	})
+	return file, err
+}
+
+// GetJob returns a job with credentials removed from its URL
+func (s *FetchService) GetJob(ctx context.Context, id uint64) (*models.FetchJob, error) {
+	job, err := s.repo.FetchJobs().FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return redactFetchJob(job), nil
+}
+
+// ListJobs returns jobs newest first; an empty username lists every user's jobs
+func (s *FetchService) ListJobs(ctx context.Context, username string, limit, offset int) ([]*models.FetchJob, int64, error) {
+	jobs, total, err := s.repo.FetchJobs().FindAll(ctx, username, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, job := range jobs {
+		jobs[i] = redactFetchJob(job)
+	}
+	return jobs, total, nil
+}
+
+// redactFetchJob returns a copy of the job with any password in the URL masked
+func redactFetchJob(job *models.FetchJob) *models.FetchJob {
+	redacted := *job
+	redacted.SourceURL = redactURL(job.SourceURL)
+	return &redacted
+}
+
+// redactURL masks the password of a URL
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
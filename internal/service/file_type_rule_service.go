@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/cache"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// DefaultMaxUploadSize is the size limit of the built-in rules
+const DefaultMaxUploadSize = 500 * 1024 * 1024 // 500MB
+
+// ErrInvalidFileTypeRule is returned when a file type rule definition is rejected
+var ErrInvalidFileTypeRule = errors.New("invalid file type rule")
+
+// defaultFileTypeRules are seeded into an empty rule table and used when no rules are stored
+var defaultFileTypeRules = []struct {
+	fileType   int
+	extensions map[string]string // extension -> MIME types
+}{
+	{models.FileTypeVideo, map[string]string{
+		".mp4": "video/mp4", ".avi": "video/x-msvideo,video/avi", ".mov": "video/quicktime",
+		".wmv": "video/x-ms-wmv", ".flv": "video/x-flv", ".mkv": "video/x-matroska",
+		".mpg": "video/mpeg", ".mpeg": "video/mpeg", ".asf": "video/x-ms-asf",
+	}},
+	{models.FileTypeAudio, map[string]string{
+		".mp3": "audio/mpeg", ".wav": "audio/wav,audio/x-wav,audio/vnd.wave", ".wma": "audio/x-ms-wma",
+		".aac": "audio/aac,audio/x-aac", ".flac": "audio/flac,audio/x-flac", ".ogg": "audio/ogg,application/ogg",
+		".m4a": "audio/mp4,audio/x-m4a",
+	}},
+	{models.FileTypeImage, map[string]string{
+		".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".gif": "image/gif",
+		".bmp": "image/bmp,image/x-ms-bmp", ".tif": "image/tiff", ".tiff": "image/tiff",
+		".svg": "image/svg+xml", ".webp": "image/webp",
+	}},
+	{models.FileTypeRichMedia, map[string]string{
+		".swf": "application/x-shockwave-flash", ".pdf": "application/pdf",
+		".doc": "application/msword", ".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xls": "application/vnd.ms-excel", ".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".ppt": "application/vnd.ms-powerpoint", ".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		".html": "text/html", ".htm": "text/html", ".zip": "application/zip,application/x-zip-compressed",
+		".txt": "text/plain", ".lrc": "text/plain,application/octet-stream",
+	}},
+}
+
+// FileTypeRuleService manages the upload rules for extensions and is the single place where
+// uploads are checked against them
+type FileTypeRuleService struct {
+	repo  repository.Repository
+	cache cache.CacheService
+}
+
+// NewFileTypeRuleService creates a new file type rule service; cacheService may be nil
+func NewFileTypeRuleService(repo repository.Repository, cacheService cache.CacheService) *FileTypeRuleService {
+	return &FileTypeRuleService{
+		repo:  repo,
+		cache: cacheService,
+	}
+}
+
+// ListRules returns all stored rules, including disabled rules and category overrides
+func (s *FileTypeRuleService) ListRules(ctx context.Context) ([]*models.FileTypeRule, error) {
+	return s.repo.FileTypeRules().FindAll(ctx)
+}
+
+// GetRule returns a rule by ID
+func (s *FileTypeRuleService) GetRule(ctx context.Context, id int) (*models.FileTypeRule, error) {
+	return s.repo.FileTypeRules().FindByID(ctx, id)
+}
+
+// CreateRule validates and stores a new rule
+func (s *FileTypeRuleService) CreateRule(ctx context.Context, rule *models.FileTypeRule) error {
+	if err := validateFileTypeRule(rule); err != nil {
+		return err
+	}
+	now := int(time.Now().Unix())
+	rule.Created = now
+	rule.Updated = now
+	if err := s.repo.FileTypeRules().Create(ctx, rule); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+// UpdateRule validates and stores changes to a rule
+func (s *FileTypeRuleService) UpdateRule(ctx context.Context, rule *models.FileTypeRule) error {
+	if err := validateFileTypeRule(rule); err != nil {
+		return err
+	}
+	rule.Updated = int(time.Now().Unix())
+	if err := s.repo.FileTypeRules().Update(ctx, rule); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+// DeleteRule deletes a rule
+func (s *FileTypeRuleService) DeleteRule(ctx context.Context, id int) error {
+	if err := s.repo.FileTypeRules().Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+// EnsureDefaults stores the built-in rules if the rule table is empty
+func (s *FileTypeRuleService) EnsureDefaults(ctx context.Context) error {
+	rules, err := s.repo.FileTypeRules().FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		return nil
+	}
+
+	if err := s.repo.FileTypeRules().CreateBatch(ctx, defaultRules()); err != nil {
+		return fmt.Errorf("failed to seed file type rules: %w", err)
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+// EffectiveRules returns the enabled rules that apply to uploads into a category (0 = no category),
+// sorted by extension
+func (s *FileTypeRuleService) EffectiveRules(ctx context.Context, categoryID int) ([]*models.FileTypeRule, error) {
+	byExt, err := s.resolve(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*models.FileTypeRule, 0, len(byExt))
+	for _, rule := range byExt {
+		if rule.Enabled {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Type != rules[j].Type {
+			return rules[i].Type < rules[j].Type
+		}
+		return rules[i].Ext < rules[j].Ext
+	})
+	return rules, nil
+}
+
+// DetectType returns the file type for a filename in a category, or 0 if the extension is not allowed
+func (s *FileTypeRuleService) DetectType(ctx context.Context, categoryID int, filename string) int {
+	byExt, err := s.resolve(ctx, categoryID)
+	if err != nil {
+		return 0
+	}
+	rule, ok := byExt[strings.ToLower(filepath.Ext(filename))]
+	if !ok || !rule.Enabled {
+		return 0
+	}
+	return rule.Type
+}
+
+// Check validates an upload against the rules for its category and returns the file type.
+// declaredType 0 accepts the rule's type; contentType is the MIME type detected from the content
+// and is skipped when empty. Rejections are returned as *UploadValidationError.
+func (s *FileTypeRuleService) Check(ctx context.Context, categoryID int, filename, contentType string, declaredType int, size int64) (int, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return 0, &UploadValidationError{Message: "File must have an extension"}
+	}
+
+	byExt, err := s.resolve(ctx, categoryID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load file type rules: %w", err)
+	}
+	rule, ok := byExt[ext]
+	if !ok || !rule.Enabled {
+		return 0, &UploadValidationError{Message: fmt.Sprintf("Unsupported file type: %s", ext)}
+	}
+	if declaredType != 0 && declaredType != rule.Type {
+		return 0, &UploadValidationError{Message: fmt.Sprintf("File extension %s not allowed for type %d", ext, declaredType)}
+	}
+	if rule.MaxSize > 0 && size > rule.MaxSize {
+		return 0, &UploadValidationError{Message: fmt.Sprintf("File size exceeds maximum allowed size of %d MB for %s files", rule.MaxSize/(1024*1024), ext)}
+	}
+
+	if mimeType := normalizeMimeType(contentType); mimeType != "" {
+		if allowed := rule.MimeTypeList(); len(allowed) > 0 && !containsString(allowed, mimeType) {
+			return 0, &UploadValidationError{Message: fmt.Sprintf("File content (%s) does not match the %s extension", mimeType, ext)}
+		}
+	}
+
+	return rule.Type, nil
+}
+
+// resolve returns the rules by extension with category overrides applied, from the nearest
+// ancestor category down to the category itself
+func (s *FileTypeRuleService) resolve(ctx context.Context, categoryID int) (map[string]*models.FileTypeRule, error) {
+	rules, err := s.loadRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byExt := make(map[string]*models.FileTypeRule)
+	overrides := make(map[int][]*models.FileTypeRule)
+	for _, rule := range rules {
+		if rule.CategoryID == 0 {
+			byExt[rule.Ext] = rule
+		} else {
+			overrides[rule.CategoryID] = append(overrides[rule.CategoryID], rule)
+		}
+	}
+	if categoryID <= 0 || len(overrides) == 0 {
+		return byExt, nil
+	}
+
+	ancestors, err := categoryAncestors(ctx, s.repo.Category(), categoryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ancestors {
+		for _, rule := range overrides[id] {
+			byExt[rule.Ext] = rule
+		}
+	}
+	return byExt, nil
+}
+
+// loadRules returns all rules from the cache or the database, falling back to the built-in rules
+// when none are stored
+func (s *FileTypeRuleService) loadRules(ctx context.Context) ([]*models.FileTypeRule, error) {
+	key := cache.GenerateKey(cache.KeyTypeFileTypeRules)
+	if s.cache != nil {
+		if cached, err := s.cache.Get(ctx, key); err == nil {
+			if data, ok := cached.(string); ok {
+				var rules []*models.FileTypeRule
+				if err := json.Unmarshal([]byte(data), &rules); err == nil {
+					return rules, nil
+				}
+			}
+		}
+	}
+
+	rules, err := s.repo.FileTypeRules().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		rules = defaultRules()
+	}
+
+	if s.cache != nil {
+		if data, err := json.Marshal(rules); err == nil {
+			if err := s.cache.Set(ctx, key, string(data), cache.TTLFileTypeRules); err != nil {
+				log.Printf("Failed to cache file type rules: %v", err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// invalidate drops the cached rules after a change
+func (s *FileTypeRuleService) invalidate(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, cache.GenerateKey(cache.KeyTypeFileTypeRules)); err != nil {
+		log.Printf("Failed to invalidate cached file type rules: %v", err)
+	}
+}
+
+// validateFileTypeRule normalizes a rule and checks its fields
+func validateFileTypeRule(rule *models.FileTypeRule) error {
+	rule.Ext = strings.ToLower(strings.TrimSpace(rule.Ext))
+	if rule.Ext != "" && !strings.HasPrefix(rule.Ext, ".") {
+		rule.Ext = "." + rule.Ext
+	}
+	if len(rule.Ext) < 2 || len(rule.Ext) > 16 || strings.ContainsAny(rule.Ext[1:], "./\\ ") {
+		return fmt.Errorf("%w: invalid extension %q", ErrInvalidFileTypeRule, rule.Ext)
+	}
+	switch rule.Type {
+	case models.FileTypeVideo, models.FileTypeAudio, models.FileTypeImage, models.FileTypeRichMedia:
+	default:
+		return fmt.Errorf("%w: invalid file type %d", ErrInvalidFileTypeRule, rule.Type)
+	}
+	if rule.MaxSize < 0 {
+		return fmt.Errorf("%w: max_size must not be negative", ErrInvalidFileTypeRule)
+	}
+	if rule.CategoryID < 0 {
+		return fmt.Errorf("%w: invalid category ID", ErrInvalidFileTypeRule)
+	}
+
+	types := rule.MimeTypeList()
+	for i, mimeType := range types {
+		if types[i] = normalizeMimeType(mimeType); types[i] == "" {
+			return fmt.Errorf("%w: invalid MIME type %q", ErrInvalidFileTypeRule, mimeType)
+		}
+	}
+	rule.MimeTypes = strings.Join(types, ",")
+	return nil
+}
+
+// defaultRules returns the built-in global rules
+func defaultRules() []*models.FileTypeRule {
+	now := int(time.Now().Unix())
+	var rules []*models.FileTypeRule
+	for _, group := range defaultFileTypeRules {
+		for ext, mimeTypes := range group.extensions {
+			rules = append(rules, &models.FileTypeRule{
+				Ext:       ext,
+				MimeTypes: mimeTypes,
+				Type:      group.fileType,
+				MaxSize:   DefaultMaxUploadSize,
+				Enabled:   true,
+				Created:   now,
+				Updated:   now,
+			})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Ext < rules[j].Ext })
+	return rules
+}
+
+// normalizeMimeType lowercases a MIME type and strips parameters such as charset
+func normalizeMimeType(value string) string {
+	if value == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
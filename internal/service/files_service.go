@@ -2,12 +2,33 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/openwan/media-asset-management/internal/models"
 	"github.com/openwan/media-asset-management/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFileQuarantined is returned for workflow actions on files flagged by the malware scanner
+	ErrFileQuarantined = errors.New("file is quarantined by the malware scanner")
+	// ErrStatusConflict is returned when the file's status changed while a transition was applied
+	ErrStatusConflict = errors.New("file status was changed concurrently, retry")
+	// ErrInvalidHistoryDate is returned for history date filters that are not YYYY-MM-DD
+	ErrInvalidHistoryDate = errors.New("invalid date")
+	// ErrFileInTrash is returned when deleting a file that is already in the trash
+	ErrFileInTrash = errors.New("file is already in the trash")
+)
+
+const (
+	// SystemActor is recorded as the actor of status changes made by background jobs
+	SystemActor = "system"
+	// EmbargoPermission lets users see files outside their publication window, e.g. to review them
+	EmbargoPermission = "files.workflow.publish"
 )
 
 // DuplicateFileError represents a duplicate file error with existing file information
@@ -22,7 +43,11 @@ func (e *DuplicateFileError) Error() string {
 
 // FilesService handles file-related business logic
 type FilesService struct {
-	repo repository.Repository
+	repo        repository.Repository
+	quota       *QuotaService
+	workflows   *WorkflowService
+	holds       *LegalHoldService
+	assignments *ReviewAssignmentService
 }
 
 // FileService is an alias for FilesService for handler compatibility
@@ -30,7 +55,19 @@ type FileService = FilesService
 
 // NewFilesService creates a new files service
 func NewFilesService(repo repository.Repository) *FilesService {
-	return &FilesService{repo: repo}
+	return &FilesService{
+		repo:        repo,
+		quota:       NewQuotaService(repo),
+		workflows:   NewWorkflowService(repo),
+		holds:       NewLegalHoldService(repo),
+		assignments: NewReviewAssignmentService(repo, NewNotificationService(repo)),
+	}
+}
+
+// SetReviewAssignments sets the service that assigns reviewers to files entering pending review,
+// so its notifications and escalation role are shared with the API
+func (s *FilesService) SetReviewAssignments(assignments *ReviewAssignmentService) {
+	s.assignments = assignments
 }
 
 // NewFileService creates a new file service (alias)
@@ -40,24 +77,136 @@ func NewFileService(repo repository.Repository) *FileService {
 
 // CreateFile creates a new file record
 func (s *FilesService) CreateFile(ctx context.Context, file *models.Files) error {
-	// Validate file type based on extension
-	if err := s.ValidateFileType(file.Ext, file.Type); err != nil {
+	// Apply the category's duplicate policy - return existing file info if the upload is rejected
+	if file.DuplicateOf == 0 {
+		existing, err := s.CheckDuplicate(ctx, file.CategoryID, file.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			file.DuplicateOf = existing.ID
+		}
+	}
+
+	// Reserve the file's usage against the uploader's storage quota
+	if err := s.quota.ReserveUpload(ctx, file); err != nil {
 		return err
 	}
 
-	// Check for MD5 collision - return existing file info if duplicate
-	existing, err := s.repo.Files().FindByMD5(ctx, file.Name)
+	if err := s.repo.Files().Create(ctx, file); err != nil {
+		if releaseErr := s.quota.ReleaseUpload(ctx, file); releaseErr != nil {
+			log.Printf("Failed to release storage usage reserved for %s: %v", file.Name, releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CheckDuplicate applies the category's duplicate policy to content with the given MD5. It returns
+// *DuplicateFileError under the reject policy, and the existing file to link to under the warn policy.
+func (s *FilesService) CheckDuplicate(ctx context.Context, categoryID int, md5 string) (*models.Files, error) {
+	existing, err := s.repo.Files().FindByMD5(ctx, md5)
 	if err != nil {
-		return fmt.Errorf("failed to check MD5: %w", err)
+		return nil, fmt.Errorf("failed to check MD5: %w", err)
+	}
+	if existing == nil {
+		return nil, nil
 	}
-	if existing != nil {
-		return &DuplicateFileError{
+
+	switch s.DuplicatePolicy(ctx, categoryID) {
+	case models.DuplicatePolicyAllow:
+		return nil, nil
+	case models.DuplicatePolicyWarn:
+		return existing, nil
+	default:
+		return nil, &DuplicateFileError{
 			Message:      "文件已存在，这是重复文件",
 			ExistingFile: existing,
 		}
 	}
+}
+
+// DuplicatePolicy returns the duplicate policy of the category or its nearest ancestor that sets
+// one, defaulting to reject
+func (s *FilesService) DuplicatePolicy(ctx context.Context, categoryID int) string {
+	ancestors, err := categoryAncestors(ctx, s.repo.Category(), categoryID)
+	if err != nil {
+		return models.DuplicatePolicyReject
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		category, err := s.repo.Category().FindByID(ctx, ancestors[i])
+		if err != nil {
+			continue
+		}
+		if category.DuplicatePolicy != "" {
+			return category.DuplicatePolicy
+		}
+	}
+	return models.DuplicatePolicyReject
+}
+
+// CanViewFile reports whether a user may see a file, used to decide how much of an existing
+// duplicate is revealed to the uploader
+func (s *FilesService) CanViewFile(ctx context.Context, userID int, isAdmin bool, fileID uint64) bool {
+	if isAdmin {
+		return true
+	}
+	canAccess, err := s.repo.ACL().CanAccessFile(ctx, userID, fileID)
+	return err == nil && canAccess
+}
+
+// CanSeeEmbargoed reports whether a user may see files outside their publication window:
+// administrators, users who may publish and the file's assigned reviewers. Without a file only
+// the first two are checked.
+func (s *FilesService) CanSeeEmbargoed(ctx context.Context, userID int, isAdmin bool, file *models.Files) bool {
+	if isAdmin {
+		return true
+	}
+	parts := strings.Split(EmbargoPermission, ".")
+	if allowed, err := s.repo.ACL().HasPermission(ctx, userID, parts[0], parts[1], parts[2]); err == nil && allowed {
+		return true
+	}
+	if file == nil {
+		return false
+	}
+	open, err := s.repo.Reviews().FindOpenByFileID(ctx, file.ID)
+	if err != nil || open == nil {
+		return false
+	}
+	task, err := s.repo.Reviews().FindTask(ctx, open.ID) // With its assignees
+	if err != nil {
+		return false
+	}
+	user, err := s.repo.Users().FindByID(ctx, userID)
+	return err == nil && isReviewer(task, user)
+}
+
+// CreateFiles creates file records in a single transaction, for bulk imports. Callers check
+// file type rules and duplicate policies beforehand; quotas are not enforced but usage is recorded after the commit.
+func (s *FilesService) CreateFiles(ctx context.Context, files []*models.Files) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		return tx.WithContext(ctx).Create(files).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := s.quota.RecordUpload(ctx, file); err != nil {
+			log.Printf("Failed to record storage usage for file %d: %v", file.ID, err)
+		}
+	}
+	return nil
+}
 
-	return s.repo.Files().Create(ctx, file)
+// CheckQuota verifies that a user can upload size more bytes without exceeding the storage quota
+func (s *FilesService) CheckQuota(ctx context.Context, username string, size int64) error {
+	return s.quota.CheckUpload(ctx, username, size)
 }
 
 // GetFile retrieves a file by ID with access control
@@ -95,21 +244,22 @@ func (s *FilesService) GetFileByID(ctx context.Context, fileID uint) (*models.Fi
 }
 
 // UpdateFile updates file information
-func (s *FilesService) UpdateFile(ctx context.Context, fileID uint, updates map[string]interface{}) error {
+func (s *FilesService) UpdateFile(ctx context.Context, fileID uint, updates map[string]interface{}, username string) error {
 	// Get existing file
 	file, err := s.repo.Files().FindByID(ctx, uint64(fileID))
 	if err != nil {
 		return err
 	}
+	if err := s.holds.Check(ctx, file, HoldOperationUpdate, username); err != nil {
+		return err
+	}
 	
 	// Apply updates
 	// This is a simplified version - in production you'd use reflection or a proper update method
 	if title, ok := updates["title"].(string); ok {
 		file.Title = title
 	}
-	if status, ok := updates["status"].(int); ok {
-		file.Status = status
-	}
+	// Status changes go through ChangeStatus so the category's workflow applies
 	if level, ok := updates["level"].(int); ok {
 		file.Level = level
 	}
@@ -128,81 +278,388 @@ func (s *FilesService) UpdateFile(ctx context.Context, fileID uint, updates map[
 
 // UpdateFileMetadata updates file metadata
 func (s *FilesService) UpdateFileMetadata(ctx context.Context, file *models.Files) error {
+	if err := s.holds.Check(ctx, file, HoldOperationUpdate, ""); err != nil {
+		return err
+	}
 	return s.repo.Files().Update(ctx, file)
 }
 
-// SubmitForReview changes file status from new to pending
+// SubmitForReview changes file status to pending
 func (s *FilesService) SubmitForReview(ctx context.Context, fileID uint64, username string) error {
-	return s.repo.Files().UpdateStatus(ctx, fileID, models.FileStatusPending, username)
+	return s.ChangeStatus(ctx, fileID, models.FileStatusPending, username, "")
 }
 
 // PublishFile changes file status to published
 func (s *FilesService) PublishFile(ctx context.Context, fileID uint64, username string) error {
-	return s.repo.Files().UpdateStatus(ctx, fileID, models.FileStatusPublished, username)
+	return s.ChangeStatus(ctx, fileID, models.FileStatusPublished, username, "")
 }
 
-// RejectFile changes file status to rejected
-func (s *FilesService) RejectFile(ctx context.Context, fileID uint64, username string) error {
-	return s.repo.Files().UpdateStatus(ctx, fileID, models.FileStatusRejected, username)
+// RejectFile changes file status to rejected, recording the reason in the file's history
+func (s *FilesService) RejectFile(ctx context.Context, fileID uint64, username, reason string) error {
+	return s.ChangeStatus(ctx, fileID, models.FileStatusRejected, username, reason)
 }
 
-// DeleteFile soft deletes a file
-func (s *FilesService) DeleteFile(ctx context.Context, fileID uint64, username string) error {
-	// Hard delete - permanently remove from database
-	return s.repo.Files().Delete(ctx, fileID)
+// ChangeStatus moves a file to another status through its category's workflow. It returns
+// ErrFileQuarantined, *WorkflowTransitionError, ErrWorkflowPermission, *WorkflowGuardError,
+// ErrReviewPending or ErrUnresolvedComments when the change is not allowed, and *LegalHoldError
+// when deleting a held file.
+// Moving a file to the deleted status moves it to the trash.
+// The change and the comment are recorded in the file's workflow history.
+func (s *FilesService) ChangeStatus(ctx context.Context, fileID uint64, to int, username, comment string) error {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	return s.changeStatus(ctx, file, to, username, comment, false)
 }
 
-// ValidateFileType validates file extension against file type
-func (s *FilesService) ValidateFileType(ext string, fileType int) error {
-	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
-	
-	videoExts := []string{"mp4", "avi", "flv", "wmv", "mov", "mkv", "mpg", "mpeg", "asf"}
-	audioExts := []string{"mp3", "wav", "wma", "aac", "flac", "ogg", "m4a"}
-	imageExts := []string{"jpg", "jpeg", "png", "gif", "bmp", "tif", "tiff", "svg", "webp"}
-	richExts := []string{"swf", "html", "htm", "zip", "doc", "docx", "xls", "xlsx", "ppt", "pptx", "pdf", "txt", "lrc"}
-
-	var validExts []string
-	switch fileType {
-	case models.FileTypeVideo:
-		validExts = videoExts
-	case models.FileTypeAudio:
-		validExts = audioExts
-	case models.FileTypeImage:
-		validExts = imageExts
-	case models.FileTypeRichMedia:
-		validExts = richExts
-	default:
-		return fmt.Errorf("invalid file type: %d", fileType)
+// transitionAuth is what authorises a status change
+type transitionAuth int
+
+const (
+	authUser   transitionAuth = iota // The transition's permission, held by the acting user
+	authReview                       // The votes of a review stage
+	authSystem                       // A background job such as the publication scheduler
+)
+
+// reviewAuth returns the authorisation of a change made by a user or decided by review votes
+func reviewAuth(reviewed bool) transitionAuth {
+	if reviewed {
+		return authReview
+	}
+	return authUser
+}
+
+// changeStatus applies a status change. Changes decided by review votes (reviewed) are
+// authorised by the votes rather than the transition's permission.
+func (s *FilesService) changeStatus(ctx context.Context, file *models.Files, to int, username, comment string, reviewed bool) error {
+	return s.applyStatus(ctx, file, to, username, comment, reviewAuth(reviewed))
+}
+
+// changeStatusAsSystem applies a status change made by a background job, recorded as
+// SystemActor. It needs no permission, but publishing a file in review is left to the reviewers.
+func (s *FilesService) changeStatusAsSystem(ctx context.Context, file *models.Files, to int, comment string) error {
+	return s.applyStatus(ctx, file, to, SystemActor, comment, authSystem)
+}
+
+// applyStatus applies a status change authorised by auth. Entering pending review opens the
+// workflow's first review stage and leaving it cancels the open one.
+func (s *FilesService) applyStatus(ctx context.Context, file *models.Files, to int, username, comment string, auth transitionAuth) error {
+	if isQuarantined(file) {
+		return ErrFileQuarantined
+	}
+	from := file.Status
+	workflow, transition, err := s.authorizeTransition(ctx, file, from, to, username, auth)
+	if errors.Is(err, ErrReviewPending) {
+		// Files submitted before the workflow had review stages start their review now
+		if open, findErr := s.repo.Reviews().FindOpenByFileID(ctx, file.ID); findErr == nil && open == nil {
+			if startErr := startReview(ctx, s.repo, file, workflow); startErr != nil {
+				log.Printf("Failed to start review of file %d: %v", file.ID, startErr)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if to == models.FileStatusDeleted {
+		if err := s.holds.Check(ctx, file, HoldOperationDelete, username); err != nil {
+			return err
+		}
+	}
+
+	if from == models.FileStatusPending || to == models.FileStatusDeleted {
+		if err := s.repo.Reviews().CancelOpen(ctx, file.ID, int(time.Now().Unix())); err != nil {
+			log.Printf("Failed to cancel review of file %d: %v", file.ID, err)
+		}
+		if err := s.assignments.close(ctx, file.ID); err != nil {
+			log.Printf("Failed to close review assignment of file %d: %v", file.ID, err)
+		}
+	}
+	actor := username
+	if actor == "" {
+		actor = SystemActor
+	}
+	event := &models.WorkflowEvent{
+		FileID:     file.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Transition: transition.Name,
+		Username:   actor,
+		Comment:    comment,
+		Created:    int(time.Now().Unix()),
+	}
+	changed, err := s.repo.Files().TransitionStatus(ctx, file.ID, from, to, actor, event)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrStatusConflict
+	}
+	file.Status = to
+
+	if to == models.FileStatusPending {
+		if err := startReview(ctx, s.repo, file, workflow); err != nil {
+			log.Printf("Failed to start review of file %d: %v", file.ID, err)
+		}
+		if err := s.assignments.start(ctx, file, actor); err != nil {
+			log.Printf("Failed to assign a reviewer to file %d: %v", file.ID, err)
+		}
+	}
+	return nil
+}
+
+// CheckTransition reports whether a user may move the file to a status, without changing it
+func (s *FilesService) CheckTransition(ctx context.Context, file *models.Files, to int, username string) error {
+	if isQuarantined(file) {
+		return ErrFileQuarantined
+	}
+	_, _, err := s.checkTransition(ctx, file, file.Status, to, username, false)
+	return err
+}
+
+// checkTransition checks a change from the given status against the file's workflow and returns
+// the workflow and the matching transition. Publishing a pending file whose workflow has review
+// stages is left to the votes, and files with unresolved review comments are not published.
+func (s *FilesService) checkTransition(ctx context.Context, file *models.Files, from, to int, username string, reviewed bool) (*models.Workflow, *models.WorkflowTransition, error) {
+	return s.authorizeTransition(ctx, file, from, to, username, reviewAuth(reviewed))
+}
+
+// authorizeTransition is checkTransition for a change authorised by auth
+func (s *FilesService) authorizeTransition(ctx context.Context, file *models.Files, from, to int, username string, auth transitionAuth) (*models.Workflow, *models.WorkflowTransition, error) {
+	workflow, err := s.workflows.Resolve(ctx, file.CategoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	transition, err := findTransition(workflow, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if transition == nil {
+		return workflow, nil, &WorkflowTransitionError{Workflow: workflow.Name, From: from, To: to}
+	}
+
+	if auth != authReview && from == models.FileStatusPending && to == models.FileStatusPublished && hasReviewStages(workflow) {
+		return workflow, transition, ErrReviewPending
+	}
+	if auth == authUser {
+		permitted, err := s.workflows.canPerform(ctx, transition, username)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !permitted {
+			return workflow, transition, fmt.Errorf("%w: %s requires %s", ErrWorkflowPermission, transition.Name, transition.Permission)
+		}
+	}
+	if missing := missingFields(file, transition.RequiredFields); len(missing) > 0 {
+		return workflow, transition, &WorkflowGuardError{Transition: transition.Name, Missing: missing}
+	}
+	if to == models.FileStatusPublished {
+		if err := checkComments(ctx, s.repo, file); err != nil {
+			return workflow, transition, err
+		}
+	}
+	return workflow, transition, nil
+}
+
+// AvailableTransitions returns the file's workflow and the transitions from its current status.
+// Quarantined files have none.
+func (s *FilesService) AvailableTransitions(ctx context.Context, file *models.Files, username string) (*models.Workflow, []AvailableTransition, error) {
+	workflow, err := s.workflows.Resolve(ctx, file.CategoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	available := []AvailableTransition{}
+	if isQuarantined(file) {
+		return workflow, available, nil
+	}
+
+	states, err := workflow.GetStates()
+	if err != nil {
+		return nil, nil, err
+	}
+	transitions, err := workflow.GetTransitions()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range transitions {
+		transition := &transitions[i]
+		if !transition.Allows(file.Status, transition.To) {
+			continue
+		}
+		permitted, err := s.workflows.canPerform(ctx, transition, username)
+		if err != nil {
+			return nil, nil, err
+		}
+		if file.Status == models.FileStatusPending && transition.To == models.FileStatusPublished && hasReviewStages(workflow) {
+			permitted = false // Published by the last review stage
+		}
+		item := AvailableTransition{
+			Name:      transition.Name,
+			To:        transition.To,
+			Permitted: permitted,
+			Missing:   missingFields(file, transition.RequiredFields),
+		}
+		for _, state := range states {
+			if state.Status == transition.To {
+				item.ToName = state.Name
+			}
+		}
+		available = append(available, item)
+	}
+	return workflow, available, nil
+}
+
+// HistoryParams filters workflow history. Dates are YYYY-MM-DD in server time and inclusive.
+type HistoryParams struct {
+	FileID   uint64
+	Username string
+	DateFrom string
+	DateTo   string
+}
+
+// WorkflowHistory returns the workflow events matching the parameters, newest first
+func (s *FilesService) WorkflowHistory(ctx context.Context, params HistoryParams, limit, offset int) ([]*models.WorkflowEvent, int64, error) {
+	filter := repository.WorkflowEventFilter{
+		FileID:   params.FileID,
+		Username: params.Username,
+	}
+	if params.DateFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", params.DateFrom, time.Local)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: date_from must be YYYY-MM-DD", ErrInvalidHistoryDate)
+		}
+		filter.From = int(from.Unix())
+	}
+	if params.DateTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", params.DateTo, time.Local)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: date_to must be YYYY-MM-DD", ErrInvalidHistoryDate)
+		}
+		filter.To = int(to.AddDate(0, 0, 1).Unix()) - 1
 	}
+	return s.repo.WorkflowEvents().FindAll(ctx, filter, limit, offset)
+}
+
+// isQuarantined reports whether the malware scanner flagged the file; such files stay out of the
+// review workflow
+func isQuarantined(file *models.Files) bool {
+	return file.Status == models.FileStatusFlagged || file.ScanStatus == models.ScanStatusInfected
+}
 
-	for _, validExt := range validExts {
-		if ext == validExt {
-			return nil
+// DeleteFile moves a file to the trash. Its record, versions and stored objects are kept until it is
+// restored or purged; open reviews are cancelled. Files under legal hold return *LegalHoldError.
+func (s *FilesService) DeleteFile(ctx context.Context, fileID uint64, username string) error {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if file.Status == models.FileStatusDeleted {
+		return ErrFileInTrash
+	}
+	if err := s.holds.Check(ctx, file, HoldOperationDelete, username); err != nil {
+		return err
+	}
+
+	now := int(time.Now().Unix())
+	if file.Status == models.FileStatusPending {
+		if err := s.repo.Reviews().CancelOpen(ctx, file.ID, now); err != nil {
+			log.Printf("Failed to cancel review of file %d: %v", file.ID, err)
 		}
+		if err := s.assignments.close(ctx, file.ID); err != nil {
+			log.Printf("Failed to close review assignment of file %d: %v", file.ID, err)
+		}
+	}
+	actor := username
+	if actor == "" {
+		actor = SystemActor
+	}
+	event := &models.WorkflowEvent{
+		FileID:     file.ID,
+		FromStatus: file.Status,
+		ToStatus:   models.FileStatusDeleted,
+		Transition: "delete",
+		Username:   actor,
+		Created:    now,
 	}
+	changed, err := s.repo.Files().TransitionStatus(ctx, file.ID, file.Status, models.FileStatusDeleted, actor, event)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrStatusConflict
+	}
+	return nil
+}
 
-	return fmt.Errorf("extension %s is not valid for file type %d", ext, fileType)
+// removeFile permanently removes a file's record with its versions and fingerprint, and releases
+// its storage usage. Stored objects are left to the caller.
+func (s *FilesService) removeFile(ctx context.Context, file *models.Files) error {
+	if err := s.repo.Files().Delete(ctx, file.ID); err != nil {
+		return err
+	}
+	s.releaseRecords(ctx, file)
+	return nil
 }
 
-// DetectFileType detects file type from extension
-func (s *FilesService) DetectFileType(filename string) int {
-	ext := strings.ToLower(filepath.Ext(filename))
-	ext = strings.TrimPrefix(ext, ".")
+// releaseRecords removes what belongs to a file whose record was deleted
+func (s *FilesService) releaseRecords(ctx context.Context, file *models.Files) {
+	if err := s.quota.RecordDelete(ctx, file); err != nil {
+		log.Printf("Failed to release storage usage for file %d: %v", file.ID, err)
+	}
+	if err := s.repo.Fingerprints().DeleteByFileID(ctx, file.ID); err != nil {
+		log.Printf("Failed to delete fingerprint of file %d: %v", file.ID, err)
+	}
+	if err := s.repo.FileVersions().DeleteByFileID(ctx, file.ID); err != nil {
+		log.Printf("Failed to delete versions of file %d: %v", file.ID, err)
+	}
+}
 
-	videoExts := map[string]bool{"mp4": true, "avi": true, "flv": true, "wmv": true, "mov": true, "mkv": true}
-	audioExts := map[string]bool{"mp3": true, "wav": true, "wma": true, "aac": true, "flac": true}
-	imageExts := map[string]bool{"jpg": true, "jpeg": true, "png": true, "gif": true, "bmp": true}
+// PriorVersionPaths returns the stored objects of a file's earlier versions that the current
+// version does not use, for releasing them when the file is removed. Callers must read them
+// before the version records are removed.
+func (s *FilesService) PriorVersionPaths(ctx context.Context, file *models.Files) []string {
+	versions, err := s.repo.FileVersions().FindByFileID(ctx, file.ID)
+	if err != nil {
+		log.Printf("Failed to load versions of file %d: %v", file.ID, err)
+		return nil
+	}
+
+	var paths []string
+	seen := map[string]bool{file.Path: true}
+	for _, version := range versions {
+		if !seen[version.Path] {
+			seen[version.Path] = true
+			paths = append(paths, version.Path)
+		}
+	}
+	return paths
+}
 
-	if videoExts[ext] {
-		return models.FileTypeVideo
+// FileAtVersion returns a copy of the file describing the given version's content, or the file
+// itself for its current version
+func (s *FilesService) FileAtVersion(ctx context.Context, file *models.Files, version int) (*models.Files, error) {
+	if version == file.Version {
+		return file, nil
 	}
-	if audioExts[ext] {
-		return models.FileTypeAudio
+	v, err := s.repo.FileVersions().FindVersion(ctx, file.ID, version)
+	if err != nil {
+		return nil, err
 	}
-	if imageExts[ext] {
-		return models.FileTypeImage
+	if v == nil {
+		return nil, ErrVersionNotFound
 	}
-	return models.FileTypeRichMedia
+
+	at := *file
+	at.Version = v.Version
+	at.Name = v.Name
+	at.ContentHash = v.ContentHash
+	at.Ext = v.Ext
+	at.MimeType = v.MimeType
+	at.Size = v.Size
+	at.Path = v.Path
+	at.ScanStatus = v.ScanStatus
+	at.ScanResult = v.ScanResult
+	return &at, nil
 }
 
 // FileStats represents file statistics
@@ -219,46 +676,34 @@ type FileStats struct {
 
 // GetStats returns file statistics
 func (s *FilesService) GetStats() (*FileStats, error) {
-	stats := &FileStats{}
-	
-	// Use Files() to get FilesRepository, which has access to db
-	// We need to use the repository pattern correctly
-	// Let's create a custom query in the repository
-	
-	// For now, use a simple approach - count through list operations
-	// This is not optimal but works without modifying repository interface
-	ctx := context.Background()
-	
-	// Get total count by fetching all with high limit
-	allFiles, total, err := s.repo.Files().FindAll(ctx, map[string]interface{}{}, 100000, 0)
+	counts, err := s.repo.Stats().CountFiles(context.Background(), repository.StatsFilter{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to count total files: %w", err)
+		return nil, fmt.Errorf("failed to count files: %w", err)
 	}
-	stats.Total = total
-	
-	// Count by type and status from the fetched files
-	for _, file := range allFiles {
-		switch file.Type {
+
+	stats := &FileStats{Total: counts.Total}
+	for _, row := range counts.ByType {
+		switch row.Type {
 		case models.FileTypeVideo:
-			stats.Video++
+			stats.Video = row.Count
 		case models.FileTypeAudio:
-			stats.Audio++
+			stats.Audio = row.Count
 		case models.FileTypeImage:
-			stats.Image++
+			stats.Image = row.Count
 		case models.FileTypeRichMedia:
-			stats.RichMedia++
+			stats.RichMedia = row.Count
 		}
-		
-		switch file.Status {
+	}
+	for _, row := range counts.ByStatus {
+		switch row.Status {
 		case models.FileStatusNew:
-			stats.NewCount++
+			stats.NewCount = row.Count
 		case models.FileStatusPublished:
-			stats.Published++
+			stats.Published = row.Count
 		case models.FileStatusPending:
-			stats.Pending++
+			stats.Pending = row.Count
 		}
 	}
-	
 	return stats, nil
 }
 
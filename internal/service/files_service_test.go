@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+func TestCreateFileEnforcesQuota(t *testing.T) {
+	f := newTestFixture(t)
+	user := f.user("uploader")
+	if err := f.db.Model(&models.Groups{}).Where("id = ?", user.GroupID).Update("quota", 1).Error; err != nil {
+		t.Fatalf("failed to set group quota: %v", err)
+	}
+	service := NewFilesService(f.repo)
+	existing := f.file("existing", models.FileStatusNew, "other")
+
+	usedBytes := func() int64 {
+		t.Helper()
+		usage, err := f.repo.StorageUsage().Find(f.ctx, models.UsageScopeUser, user.ID)
+		if err != nil {
+			t.Fatalf("failed to read usage: %v", err)
+		}
+		return usage.UsedBytes
+	}
+	newFile := func(name string, size int64) *models.Files {
+		return &models.Files{Name: name, Title: name, Ext: ".mp4", Type: models.FileTypeVideo, Path: "videos/" + name + ".mp4", Size: size, UploadUsername: user.Username}
+	}
+
+	if err := service.CreateFile(f.ctx, newFile("first", 768*1024)); err != nil {
+		t.Fatalf("CreateFile() within the quota error = %v", err)
+	}
+	if used := usedBytes(); used != 768*1024 {
+		t.Fatalf("used bytes = %d, want %d", used, 768*1024)
+	}
+
+	var exceeded *QuotaExceededError
+	err := service.CreateFile(f.ctx, newFile("second", 512*1024))
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("CreateFile() past the quota error = %v, want *QuotaExceededError", err)
+	}
+	if exceeded.QuotaBytes != 1024*1024 || exceeded.UsedBytes != 768*1024 {
+		t.Errorf("QuotaExceededError = %+v, want quota 1048576 and usage 786432", exceeded)
+	}
+
+	// A record that cannot be stored gives its reservation back
+	failing := newFile("failing", 128*1024)
+	failing.ID = existing.ID
+	if err := service.CreateFile(f.ctx, failing); err == nil {
+		t.Fatal("CreateFile() with a taken ID succeeded")
+	}
+	if used := usedBytes(); used != 768*1024 {
+		t.Errorf("used bytes after the failed create = %d, want %d", used, 768*1024)
+	}
+}
+
+func TestChangeStatusRequiresTransitionPermission(t *testing.T) {
+	f := newTestFixture(t)
+	f.user("editor", "files.workflow.submit")
+	f.user("viewer", "files.browse.view")
+	service := NewFilesService(f.repo)
+
+	tests := []struct {
+		name       string
+		username   string
+		wantErr    error
+		want       int
+		wantEvents int
+	}{
+		{"without permission", "viewer", ErrWorkflowPermission, models.FileStatusNew, 0},
+		{"anonymous", "", ErrWorkflowPermission, models.FileStatusNew, 0},
+		{"with permission", "editor", nil, models.FileStatusPending, 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := f.file(fmt.Sprintf("file-%d", i), models.FileStatusNew, "uploader")
+			err := service.ChangeStatus(f.ctx, file.ID, models.FileStatusPending, tt.username, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangeStatus() error = %v, want %v", err, tt.wantErr)
+			}
+			if status := f.reload(file).Status; status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+			events, _, err := f.repo.WorkflowEvents().FindAll(f.ctx, repository.WorkflowEventFilter{FileID: file.ID}, 10, 0)
+			if err != nil {
+				t.Fatalf("failed to list events: %v", err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Errorf("got %d events, want %d", len(events), tt.wantEvents)
+			}
+		})
+	}
+}
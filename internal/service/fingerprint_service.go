@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Register decoders for the image formats hashed without ffmpeg
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/fingerprint"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// FingerprintQueueName is the queue consumed by workers for perceptual hashing
+const FingerprintQueueName = "openwan_fingerprint_jobs"
+
+// Similarity thresholds, in differing bits out of 64
+const (
+	DefaultSimilarityDistance = 10
+	MaxSimilarityDistance     = 32 // Beyond this, hashes are no more alike than random ones
+)
+
+// fingerprintFrames is the number of frames sampled from a video
+const fingerprintFrames = 16
+
+var (
+	// ErrNotFingerprintable is returned for files that are not clean images or videos
+	ErrNotFingerprintable = errors.New("only clean images and videos are fingerprinted")
+	// ErrFingerprintNotFound is returned when a file has not been fingerprinted yet
+	ErrFingerprintNotFound = errors.New("file has not been fingerprinted yet")
+)
+
+// FrameExtractor decodes scaled grayscale frames; implemented by *transcoding.FFmpegWrapper
+type FrameExtractor interface {
+	ExtractGrayFrames(ctx context.Context, inputPath string, count int, duration float64, size int) ([][]byte, error)
+}
+
+// SimilarFile is a file that looks like the searched one
+type SimilarFile struct {
+	File           *models.Files `json:"file"`
+	Distance       float64       `json:"distance"`   // Differing hash bits; the mean over sampled frames for videos
+	Similarity     float64       `json:"similarity"` // 1 = identical
+	ExactDuplicate bool          `json:"exact_duplicate"`
+}
+
+// SimilarPair is two files that look alike
+type SimilarPair struct {
+	File           *models.Files `json:"file"`
+	Other          *models.Files `json:"other"`
+	Kind           string        `json:"kind"`
+	Distance       float64       `json:"distance"`
+	Similarity     float64       `json:"similarity"`
+	ExactDuplicate bool          `json:"exact_duplicate"`
+}
+
+// FingerprintService computes perceptual hashes of images and videos and finds near-duplicates
+type FingerprintService struct {
+	repo    repository.Repository
+	storage storage.StorageService
+	frames  FrameExtractor
+	prober  MediaProber
+	queue   queue.QueueService
+}
+
+// NewFingerprintService creates a new fingerprint service. frames may be nil where ffmpeg is not
+// installed, such as in the API; hashes are then computed only for images Go can decode.
+func NewFingerprintService(repo repository.Repository, storageService storage.StorageService, frames FrameExtractor) *FingerprintService {
+	return &FingerprintService{
+		repo:    repo,
+		storage: storageService,
+		frames:  frames,
+		prober:  defaultMediaProber(),
+	}
+}
+
+// SetQueue sets the queue fingerprint jobs are published to
+func (s *FingerprintService) SetQueue(queueService queue.QueueService) {
+	s.queue = queueService
+}
+
+// Fingerprintable reports whether a file is hashed: images and videos that passed the malware scan
+func Fingerprintable(file *models.Files) bool {
+	return (file.Type == models.FileTypeImage || file.Type == models.FileTypeVideo) &&
+		file.Status != models.FileStatusDeleted && file.Downloadable()
+}
+
+// Enqueue publishes a fingerprint job for the file; files that are not fingerprinted are skipped
+func (s *FingerprintService) Enqueue(ctx context.Context, file *models.Files) error {
+	if !Fingerprintable(file) {
+		return nil
+	}
+	if s.queue == nil {
+		return fmt.Errorf("no queue service configured")
+	}
+
+	body, err := json.Marshal(queue.FingerprintJob{FileID: file.ID})
+	if err != nil {
+		return err
+	}
+	return s.queue.Publish(ctx, FingerprintQueueName, &queue.Message{
+		ID:        fmt.Sprintf("fingerprint-%d-%d", file.ID, time.Now().Unix()),
+		Body:      string(body),
+		Timestamp: time.Now(),
+		Attributes: map[string]string{
+			"file_id":   strconv.FormatUint(file.ID, 10),
+			"file_type": strconv.Itoa(file.Type),
+		},
+	})
+}
+
+// Backfill queues fingerprint jobs for up to limit images and videos that have none, returning
+// how many were queued
+func (s *FingerprintService) Backfill(ctx context.Context, limit int) (int, error) {
+	if s.queue == nil {
+		return 0, fmt.Errorf("no queue service configured")
+	}
+
+	types := []int{models.FileTypeImage, models.FileTypeVideo}
+	queued := 0
+	var afterID uint64
+	for queued < limit {
+		files, err := s.repo.Fingerprints().FindUnfingerprinted(ctx, types, afterID, min(500, limit-queued))
+		if err != nil {
+			return queued, err
+		}
+		if len(files) == 0 {
+			break
+		}
+		for _, file := range files {
+			if err := s.Enqueue(ctx, file); err != nil {
+				return queued, err
+			}
+			queued++
+			afterID = file.ID
+		}
+	}
+	return queued, nil
+}
+
+// HandleJob computes the fingerprint for a queued job. Files that can no longer be fingerprinted
+// are acknowledged without a fingerprint.
+func (s *FingerprintService) HandleJob(ctx context.Context, message *queue.Message) error {
+	var job queue.FingerprintJob
+	if err := json.Unmarshal([]byte(message.Body), &job); err != nil {
+		return fmt.Errorf("failed to parse fingerprint job: %w", err)
+	}
+
+	fp, err := s.Compute(ctx, job.FileID)
+	if errors.Is(err, ErrNotFingerprintable) {
+		log.Printf("Skipping fingerprint of file %d: %v", job.FileID, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Fingerprinted file %d (%s, %d frame(s))", job.FileID, fp.Kind, fp.Frames)
+	return nil
+}
+
+// Compute hashes a stored image or sampled video frames and saves the fingerprint
+func (s *FingerprintService) Compute(ctx context.Context, fileID uint64) (*models.FileFingerprint, error) {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if !Fingerprintable(file) {
+		return nil, ErrNotFingerprintable
+	}
+
+	path, cleanup, err := s.download(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	fp := &models.FileFingerprint{
+		FileID:  file.ID,
+		Created: int(time.Now().Unix()),
+	}
+	if file.Type == models.FileTypeImage {
+		gray, err := s.imageFrame(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if fp.PHash, err = fingerprint.PHash(gray); err != nil {
+			return nil, err
+		}
+		if fp.DHash, err = fingerprint.DHash(gray); err != nil {
+			return nil, err
+		}
+		fp.Kind = models.FingerprintKindImage
+		fp.Frames = 1
+	} else {
+		if err := s.hashVideo(ctx, path, fp); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Fingerprints().Save(ctx, fp); err != nil {
+		return nil, fmt.Errorf("failed to save fingerprint: %w", err)
+	}
+	return fp, nil
+}
+
+// Similar returns files that look like the given one, closest first, with at most maxDistance
+// differing bits
+func (s *FingerprintService) Similar(ctx context.Context, fileID uint64, maxDistance, limit int) ([]*SimilarFile, error) {
+	source, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	fp, err := s.repo.Fingerprints().FindByFileID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if fp == nil {
+		return nil, ErrFingerprintNotFound
+	}
+
+	matches, err := s.repo.Fingerprints().FindWithinDistance(ctx, fp.Kind, fp.PHash, maxDistance, fileID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]*SimilarFile, 0, len(matches))
+	for _, match := range matches {
+		distance := s.distance(fp, &match.FileFingerprint, match.Distance)
+		if distance > float64(maxDistance) {
+			continue
+		}
+		file, err := s.repo.Files().FindByID(ctx, match.FileID)
+		if err != nil || file.Status == models.FileStatusDeleted {
+			continue
+		}
+		similar = append(similar, &SimilarFile{
+			File:           file,
+			Distance:       distance,
+			Similarity:     fingerprint.Similarity(distance),
+			ExactDuplicate: file.Name == source.Name,
+		})
+	}
+	return similar, nil
+}
+
+// Report returns pairs of likely near-duplicates across the archive, closest first. Video pairs
+// whose sampled frames differ more than their summary hashes suggest are dropped, so a page may
+// hold fewer than limit pairs.
+func (s *FingerprintService) Report(ctx context.Context, maxDistance, limit, offset int) ([]*SimilarPair, int64, error) {
+	candidates, total, err := s.repo.Fingerprints().FindPairsWithinDistance(ctx, maxDistance, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pairs := make([]*SimilarPair, 0, len(candidates))
+	for _, candidate := range candidates {
+		distance := float64(candidate.Distance)
+		if candidate.Kind == models.FingerprintKindVideo {
+			a, errA := s.repo.Fingerprints().FindByFileID(ctx, candidate.FileID)
+			b, errB := s.repo.Fingerprints().FindByFileID(ctx, candidate.OtherFileID)
+			if errA != nil || errB != nil || a == nil || b == nil {
+				continue
+			}
+			if distance = s.distance(a, b, candidate.Distance); distance > float64(maxDistance) {
+				continue
+			}
+		}
+
+		file, err := s.repo.Files().FindByID(ctx, candidate.FileID)
+		if err != nil || file.Status == models.FileStatusDeleted {
+			continue
+		}
+		other, err := s.repo.Files().FindByID(ctx, candidate.OtherFileID)
+		if err != nil || other.Status == models.FileStatusDeleted {
+			continue
+		}
+		pairs = append(pairs, &SimilarPair{
+			File:           file,
+			Other:          other,
+			Kind:           candidate.Kind,
+			Distance:       distance,
+			Similarity:     fingerprint.Similarity(distance),
+			ExactDuplicate: file.Name == other.Name,
+		})
+	}
+	return pairs, total, nil
+}
+
+// distance refines the summary hash distance of two videos with their frame sequences
+func (s *FingerprintService) distance(a, b *models.FileFingerprint, hashDistance int) float64 {
+	if a.Kind != models.FingerprintKindVideo {
+		return float64(hashDistance)
+	}
+	framesA, framesB := fingerprint.ParseHashes(a.FrameHashes), fingerprint.ParseHashes(b.FrameHashes)
+	if len(framesA) == 0 || len(framesB) == 0 {
+		return float64(hashDistance)
+	}
+	return fingerprint.SequenceDistance(framesA, framesB)
+}
+
+// imageFrame decodes an image with Go's decoders, falling back to ffmpeg for other formats
+func (s *FingerprintService) imageFrame(ctx context.Context, path string) (fingerprint.Gray, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	img, _, decodeErr := image.Decode(f)
+	f.Close()
+	if decodeErr == nil {
+		return fingerprint.FromImage(img), nil
+	}
+
+	if s.frames == nil {
+		return nil, fmt.Errorf("failed to decode image: %w", decodeErr)
+	}
+	frames, err := s.frames.ExtractGrayFrames(ctx, path, 1, 0, fingerprint.GridSize)
+	if err != nil {
+		return nil, err
+	}
+	return fingerprint.Gray(frames[0]), nil
+}
+
+// hashVideo samples frames evenly across the video and hashes each of them
+func (s *FingerprintService) hashVideo(ctx context.Context, path string, fp *models.FileFingerprint) error {
+	if s.frames == nil {
+		return fmt.Errorf("ffmpeg is required to fingerprint videos")
+	}
+
+	duration := 0.0
+	if s.prober != nil {
+		if info, err := s.prober.ProbeStreams(ctx, path); err == nil {
+			duration = info.Duration
+		}
+	}
+	if duration <= 0 {
+		duration = fingerprintFrames // Unknown duration: one frame per second from the start
+	}
+
+	frames, err := s.frames.ExtractGrayFrames(ctx, path, fingerprintFrames, duration, fingerprint.GridSize)
+	if err != nil {
+		return err
+	}
+
+	hashes := make([]uint64, len(frames))
+	for i, frame := range frames {
+		if hashes[i], err = fingerprint.PHash(frame); err != nil {
+			return err
+		}
+	}
+	middle := frames[len(frames)/2]
+	if fp.DHash, err = fingerprint.DHash(middle); err != nil {
+		return err
+	}
+
+	fp.Kind = models.FingerprintKindVideo
+	fp.PHash = fingerprint.Combine(hashes)
+	fp.FrameHashes = fingerprint.FormatHashes(hashes)
+	fp.Frames = len(hashes)
+	return nil
+}
+
+// download copies a stored file to a temporary file, keeping its extension for ffmpeg
+func (s *FingerprintService) download(ctx context.Context, file *models.Files) (string, func(), error) {
+	reader, err := s.storage.Download(ctx, file.Path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read stored file: %w", err)
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "openwan-fingerprint-*"+filepath.Ext(file.Path))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+	_, err = io.Copy(tmp, reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to copy stored file: %w", err)
+	}
+	return tmp.Name(), cleanup, nil
+}
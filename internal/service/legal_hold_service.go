@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+var (
+	// ErrInvalidLegalHold is returned for holds without a case reference or without exactly one target
+	ErrInvalidLegalHold = errors.New("a legal hold needs a case reference and exactly one of file_id or category_id")
+	// ErrLegalHoldTarget is returned when the file or category to hold does not exist
+	ErrLegalHoldTarget = errors.New("file or category to hold does not exist")
+	// ErrLegalHoldReleased is returned when releasing a hold that was already released
+	ErrLegalHoldReleased = errors.New("legal hold is already released")
+)
+
+// Operations blocked by legal holds, recorded in the hold log
+const (
+	HoldOperationDelete  = "delete"
+	HoldOperationUpdate  = "update"
+	HoldOperationVersion = "version"
+	HoldOperationPurge   = "purge"
+	HoldOperationMerge   = "merge"
+	HoldOperationMove    = "storage_move"
+)
+
+// LegalHoldError is returned for operations that would delete or alter a file under legal hold
+type LegalHoldError struct {
+	FileID    uint64
+	Operation string
+	Holds     []*models.LegalHold
+}
+
+func (e *LegalHoldError) Error() string {
+	cases := make([]string, 0, len(e.Holds))
+	for _, hold := range e.Holds {
+		cases = append(cases, hold.CaseReference)
+	}
+	return fmt.Sprintf("file %d is under legal hold (%s), %s is not allowed", e.FileID, strings.Join(cases, ", "), e.Operation)
+}
+
+// LegalHoldRequest describes a hold to apply to a file or to a category and its subcategories
+type LegalHoldRequest struct {
+	FileID        uint64 `json:"file_id"`
+	CategoryID    int    `json:"category_id"`
+	CaseReference string `json:"case_reference"`
+	Reason        string `json:"reason"`
+}
+
+// LegalHoldService applies and releases legal holds and checks them before files are deleted or
+// altered. Every hold applied or released and every operation a hold blocks is logged.
+type LegalHoldService struct {
+	repo repository.Repository
+}
+
+// NewLegalHoldService creates a new legal hold service
+func NewLegalHoldService(repo repository.Repository) *LegalHoldService {
+	return &LegalHoldService{repo: repo}
+}
+
+// Apply places a hold on a file or on a category and its subcategories
+func (s *LegalHoldService) Apply(ctx context.Context, req *LegalHoldRequest, username string) (*models.LegalHold, error) {
+	req.CaseReference = strings.TrimSpace(req.CaseReference)
+	if req.CaseReference == "" || (req.FileID > 0) == (req.CategoryID > 0) || req.CategoryID < 0 {
+		return nil, ErrInvalidLegalHold
+	}
+	if req.FileID > 0 {
+		if _, err := s.repo.Files().FindByID(ctx, req.FileID); err != nil {
+			return nil, ErrLegalHoldTarget
+		}
+	} else if _, err := s.repo.Category().FindByID(ctx, req.CategoryID); err != nil {
+		return nil, ErrLegalHoldTarget
+	}
+
+	hold := &models.LegalHold{
+		FileID:        req.FileID,
+		CategoryID:    req.CategoryID,
+		CaseReference: req.CaseReference,
+		Reason:        req.Reason,
+		CreatedBy:     username,
+		Created:       int(time.Now().Unix()),
+	}
+	if err := s.repo.LegalHolds().Create(ctx, hold); err != nil {
+		return nil, err
+	}
+	log.Printf("Legal hold %d (%s) applied by %s to file %d / category %d", hold.ID, hold.CaseReference, username, hold.FileID, hold.CategoryID)
+	return hold, nil
+}
+
+// Release ends an active hold
+func (s *LegalHoldService) Release(ctx context.Context, id uint64, username, reason string) (*models.LegalHold, error) {
+	hold, err := s.repo.LegalHolds().FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	released, err := s.repo.LegalHolds().Release(ctx, id, username, reason, int(time.Now().Unix()))
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		return nil, ErrLegalHoldReleased
+	}
+	log.Printf("Legal hold %d (%s) released by %s", hold.ID, hold.CaseReference, username)
+	return s.repo.LegalHolds().FindByID(ctx, id)
+}
+
+// Get returns a hold
+func (s *LegalHoldService) Get(ctx context.Context, id uint64) (*models.LegalHold, error) {
+	return s.repo.LegalHolds().FindByID(ctx, id)
+}
+
+// List returns the holds matching the filter, newest first
+func (s *LegalHoldService) List(ctx context.Context, filter repository.LegalHoldFilter, limit, offset int) ([]*models.LegalHold, int64, error) {
+	return s.repo.LegalHolds().FindAll(ctx, filter, limit, offset)
+}
+
+// Events returns the hold log entries matching the filter, newest first
+func (s *LegalHoldService) Events(ctx context.Context, filter repository.LegalHoldEventFilter, limit, offset int) ([]*models.LegalHoldEvent, int64, error) {
+	return s.repo.LegalHolds().FindEvents(ctx, filter, limit, offset)
+}
+
+// HoldsOn returns the active holds on a file, directly or through its category or an ancestor
+func (s *LegalHoldService) HoldsOn(ctx context.Context, file *models.Files) ([]*models.LegalHold, error) {
+	categories, err := categoryAncestors(ctx, s.repo.Category(), file.CategoryID)
+	if err != nil {
+		// The category is gone; holds on the file itself still apply
+		categories = []int{file.CategoryID}
+	}
+	return s.repo.LegalHolds().FindActive(ctx, file.ID, categories)
+}
+
+// Check returns *LegalHoldError, and logs the blocked operation, if the file is under an active
+// hold. Holds that cannot be checked block the operation.
+func (s *LegalHoldService) Check(ctx context.Context, file *models.Files, operation, username string) error {
+	holds, err := s.HoldsOn(ctx, file)
+	if err != nil {
+		return fmt.Errorf("failed to check legal holds: %w", err)
+	}
+	if len(holds) == 0 {
+		return nil
+	}
+
+	if username == "" {
+		username = SystemActor
+	}
+	now := int(time.Now().Unix())
+	for _, hold := range holds {
+		event := &models.LegalHoldEvent{
+			HoldID:   hold.ID,
+			Action:   models.LegalHoldActionBlocked,
+			FileID:   file.ID,
+			Username: username,
+			Detail:   operation,
+			Created:  now,
+		}
+		if err := s.repo.LegalHolds().CreateEvent(ctx, event); err != nil {
+			log.Printf("Failed to log %s of file %d blocked by legal hold %d: %v", operation, file.ID, hold.ID, err)
+		}
+	}
+	return &LegalHoldError{FileID: file.ID, Operation: operation, Holds: holds}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// NotificationQueueName is the queue notifications are published to for delivery by other
+// channels, e.g. a mail gateway
+const NotificationQueueName = "openwan_notifications"
+
+// NotificationService stores notifications in the recipients' inboxes and publishes them to the
+// notification queue when one is set
+type NotificationService struct {
+	repo  repository.Repository
+	queue queue.QueueService
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(repo repository.Repository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// SetQueue sets the queue notifications are published to; without one they are only stored
+func (s *NotificationService) SetQueue(queueService queue.QueueService) {
+	s.queue = queueService
+}
+
+// Notify sends a message to each user
+func (s *NotificationService) Notify(ctx context.Context, userIDs []int, kind string, fileID uint64, message string) error {
+	now := int(time.Now().Unix())
+	notifications := make([]*models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, &models.Notification{
+			UserID:  userID,
+			Kind:    kind,
+			FileID:  fileID,
+			Message: truncate(message, 1024),
+			Created: now,
+		})
+	}
+	if err := s.repo.Notifications().Create(ctx, notifications); err != nil {
+		return fmt.Errorf("failed to store notifications: %w", err)
+	}
+
+	if s.queue == nil {
+		return nil
+	}
+	for _, notification := range notifications {
+		if err := s.publish(ctx, notification); err != nil {
+			// Stored in the inbox either way
+			log.Printf("Failed to publish notification %d: %v", notification.ID, err)
+		}
+	}
+	return nil
+}
+
+// List returns a user's notifications, newest first
+func (s *NotificationService) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*models.Notification, int64, error) {
+	return s.repo.Notifications().FindByUserID(ctx, userID, unreadOnly, limit, offset)
+}
+
+// MarkRead marks one of a user's notifications as read, returning false if the user has no such
+// notification
+func (s *NotificationService) MarkRead(ctx context.Context, id uint64, userID int) (bool, error) {
+	return s.repo.Notifications().MarkRead(ctx, id, userID, int(time.Now().Unix()))
+}
+
+// publish sends a stored notification to the notification queue
+func (s *NotificationService) publish(ctx context.Context, notification *models.Notification) error {
+	body, err := json.Marshal(queue.NotificationJob{
+		NotificationID: notification.ID,
+		UserID:         notification.UserID,
+		Kind:           notification.Kind,
+		FileID:         notification.FileID,
+		Message:        notification.Message,
+	})
+	if err != nil {
+		return err
+	}
+	return s.queue.Publish(ctx, NotificationQueueName, &queue.Message{
+		ID:        fmt.Sprintf("notification-%d", notification.ID),
+		Body:      string(body),
+		Timestamp: time.Now(),
+		Attributes: map[string]string{
+			"kind":    notification.Kind,
+			"user_id": strconv.Itoa(notification.UserID),
+		},
+	})
+}
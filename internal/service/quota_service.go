@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"gorm.io/gorm"
+)
+
+// QuotaExceededError is returned when an upload would exceed the uploader's storage quota
+type QuotaExceededError struct {
+	Username   string
+	GroupID    int
+	QuotaBytes int64
+	UsedBytes  int64
+	Requested  int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("storage quota exceeded for %s: %d of %d bytes used, %d bytes requested",
+		e.Username, e.UsedBytes, e.QuotaBytes, e.Requested)
+}
+
+// QuotaService handles per-user and per-group storage accounting and quota enforcement.
+// The quota is configured per group (Groups.Quota, in MB) and applies to each member of the group;
+// a quota of 0 or less means unlimited.
+type QuotaService struct {
+	repo repository.Repository
+}
+
+// NewQuotaService creates a new quota service
+func NewQuotaService(repo repository.Repository) *QuotaService {
+	return &QuotaService{repo: repo}
+}
+
+// CheckUpload verifies that the user can store additional bytes without exceeding the quota. It lets
+// uploads fail before their content is stored; ReserveUpload makes the binding check.
+func (s *QuotaService) CheckUpload(ctx context.Context, username string, size int64) error {
+	user, quotaBytes, err := s.uploaderQuota(ctx, username)
+	if err != nil || user == nil || quotaBytes <= 0 {
+		return err
+	}
+
+	usage, err := s.repo.StorageUsage().Find(ctx, models.UsageScopeUser, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load storage usage: %w", err)
+	}
+
+	if usage.UsedBytes+size > quotaBytes {
+		return &QuotaExceededError{
+			Username:   username,
+			GroupID:    user.GroupID,
+			QuotaBytes: quotaBytes,
+			UsedBytes:  usage.UsedBytes,
+			Requested:  size,
+		}
+	}
+
+	return nil
+}
+
+// ReserveUpload adds a new file to the uploader's and group's usage, returning *QuotaExceededError
+// instead when it would exceed the uploader's quota. The check and the increment are a single
+// update, so concurrent uploads cannot overrun the quota together.
+func (s *QuotaService) ReserveUpload(ctx context.Context, file *models.Files) error {
+	return s.reserve(ctx, file.UploadUsername, file.Size+file.DerivativeSize, 1)
+}
+
+// ReleaseUpload gives back the usage reserved for a file that was not created
+func (s *QuotaService) ReleaseUpload(ctx context.Context, file *models.Files) error {
+	return s.adjust(ctx, file.UploadUsername, -(file.Size + file.DerivativeSize), -1)
+}
+
+// RecordUpload adds a newly created file to the uploader's and group's usage
+func (s *QuotaService) RecordUpload(ctx context.Context, file *models.Files) error {
+	return s.adjust(ctx, file.UploadUsername, file.Size+file.DerivativeSize, 1)
+}
+
+// RecordDelete removes a deleted file from the uploader's and group's usage
+func (s *QuotaService) RecordDelete(ctx context.Context, file *models.Files) error {
+	return s.adjust(ctx, file.UploadUsername, -(file.Size + file.DerivativeSize + file.VersionSize), -1)
+}
+
+// RecordDerivative adds the size of a generated derivative (e.g. preview) to the file and its owner's usage
+func (s *QuotaService) RecordDerivative(ctx context.Context, fileID uint64, bytes int64) error {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Files().AddDerivativeSize(ctx, fileID, bytes); err != nil {
+		return fmt.Errorf("failed to update derivative size: %w", err)
+	}
+
+	return s.adjust(ctx, file.UploadUsername, bytes, 0)
+}
+
+// GetUserUsage returns the usage and quota for a single user
+func (s *QuotaService) GetUserUsage(ctx context.Context, userID int) (*UserQuota, error) {
+	user, err := s.repo.Users().FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.repo.StorageUsage().Find(ctx, models.UsageScopeUser, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &UserQuota{
+		UserID:    user.ID,
+		Username:  user.Username,
+		GroupID:   user.GroupID,
+		UsedBytes: usage.UsedBytes,
+		FileCount: usage.FileCount,
+	}
+	if group, err := s.repo.Groups().FindByID(ctx, user.GroupID); err == nil && group != nil && group.Quota > 0 {
+		quota.QuotaBytes = int64(group.Quota) * 1024 * 1024
+	}
+	quota.calculatePercent()
+
+	return quota, nil
+}
+
+// GetUsageReport returns storage usage per group with the usage of each member
+func (s *QuotaService) GetUsageReport(ctx context.Context, groupID int) ([]*GroupUsageReport, error) {
+	groups, err := s.repo.Groups().FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load groups: %w", err)
+	}
+
+	groupUsages, err := s.repo.StorageUsage().FindByScope(ctx, models.UsageScopeGroup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load group usage: %w", err)
+	}
+	usageByGroup := make(map[int]*models.StorageUsage, len(groupUsages))
+	for _, usage := range groupUsages {
+		usageByGroup[usage.ScopeID] = usage
+	}
+
+	userUsages, err := s.repo.StorageUsage().ListUserUsage(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user usage: %w", err)
+	}
+
+	reports := make([]*GroupUsageReport, 0, len(groups))
+	reportByGroup := make(map[int]*GroupUsageReport, len(groups))
+	for _, group := range groups {
+		if groupID > 0 && group.ID != groupID {
+			continue
+		}
+		report := &GroupUsageReport{
+			GroupID:   group.ID,
+			GroupName: group.Name,
+			QuotaMB:   group.Quota,
+			Users:     []*UserQuota{},
+		}
+		if usage, ok := usageByGroup[group.ID]; ok {
+			report.UsedBytes = usage.UsedBytes
+			report.FileCount = usage.FileCount
+		}
+		reports = append(reports, report)
+		reportByGroup[group.ID] = report
+	}
+
+	for _, usage := range userUsages {
+		report, ok := reportByGroup[usage.GroupID]
+		if !ok {
+			continue
+		}
+		quota := &UserQuota{
+			UserID:    usage.UserID,
+			Username:  usage.Username,
+			GroupID:   usage.GroupID,
+			UsedBytes: usage.UsedBytes,
+			FileCount: usage.FileCount,
+		}
+		if report.QuotaMB > 0 {
+			quota.QuotaBytes = int64(report.QuotaMB) * 1024 * 1024
+		}
+		quota.calculatePercent()
+		if quota.Exceeded {
+			report.UsersOverQuota++
+		}
+		report.Users = append(report.Users, quota)
+	}
+
+	return reports, nil
+}
+
+// Recalculate rebuilds all usage counters from the files table
+func (s *QuotaService) Recalculate(ctx context.Context) error {
+	return s.repo.StorageUsage().Recalculate(ctx)
+}
+
+// reserve applies a usage delta like adjust, but fails with *QuotaExceededError when an increase
+// would take the user over the quota
+func (s *QuotaService) reserve(ctx context.Context, username string, bytes, files int64) error {
+	user, quotaBytes, err := s.uploaderQuota(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil || quotaBytes <= 0 || bytes <= 0 {
+		return s.adjust(ctx, username, bytes, files)
+	}
+
+	reserved, err := s.repo.StorageUsage().Reserve(ctx, models.UsageScopeUser, user.ID, bytes, files, quotaBytes)
+	if err != nil {
+		return fmt.Errorf("failed to reserve storage usage: %w", err)
+	}
+	if !reserved {
+		exceeded := &QuotaExceededError{
+			Username:   username,
+			GroupID:    user.GroupID,
+			QuotaBytes: quotaBytes,
+			Requested:  bytes,
+		}
+		if usage, err := s.repo.StorageUsage().Find(ctx, models.UsageScopeUser, user.ID); err == nil {
+			exceeded.UsedBytes = usage.UsedBytes
+		}
+		return exceeded
+	}
+
+	// The group counter has no limit of its own; its quota applies to each member
+	if err := s.repo.StorageUsage().Increment(ctx, models.UsageScopeGroup, user.GroupID, bytes, files); err != nil {
+		if releaseErr := s.repo.StorageUsage().Increment(ctx, models.UsageScopeUser, user.ID, -bytes, -files); releaseErr != nil {
+			log.Printf("Failed to release storage usage of %s: %v", username, releaseErr)
+		}
+		return fmt.Errorf("failed to update group usage: %w", err)
+	}
+	return nil
+}
+
+// uploaderQuota returns the user with the given name and the user's quota in bytes (0 = unlimited).
+// Unknown users (e.g. system ingest) are not subject to quotas and yield a nil user.
+func (s *QuotaService) uploaderQuota(ctx context.Context, username string) (*models.Users, int64, error) {
+	user, err := s.findUploader(ctx, username)
+	if err != nil || user == nil {
+		return nil, 0, err
+	}
+
+	group, err := s.repo.Groups().FindByID(ctx, user.GroupID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load group: %w", err)
+	}
+	if group.Quota <= 0 {
+		return user, 0, nil
+	}
+	return user, int64(group.Quota) * 1024 * 1024, nil
+}
+
+// findUploader returns the user with the given name, or nil if there is none
+func (s *QuotaService) findUploader(ctx context.Context, username string) (*models.Users, error) {
+	user, err := s.repo.Users().FindByUsername(ctx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return user, nil
+}
+
+// adjust applies a usage delta to a user and the user's group
+func (s *QuotaService) adjust(ctx context.Context, username string, bytes, files int64) error {
+	user, err := s.findUploader(ctx, username)
+	if err != nil || user == nil {
+		// Files uploaded by unknown users are only counted by Recalculate
+		return err
+	}
+
+	if err := s.repo.StorageUsage().Increment(ctx, models.UsageScopeUser, user.ID, bytes, files); err != nil {
+		return fmt.Errorf("failed to update user usage: %w", err)
+	}
+	if err := s.repo.StorageUsage().Increment(ctx, models.UsageScopeGroup, user.GroupID, bytes, files); err != nil {
+		return fmt.Errorf("failed to update group usage: %w", err)
+	}
+	return nil
+}
+
+// UserQuota represents a user's storage usage against the quota
+type UserQuota struct {
+	UserID      int     `json:"user_id"`
+	Username    string  `json:"username"`
+	GroupID     int     `json:"group_id"`
+	UsedBytes   int64   `json:"used_bytes"`
+	FileCount   int64   `json:"file_count"`
+	QuotaBytes  int64   `json:"quota_bytes"` // 0 = unlimited
+	PercentUsed float64 `json:"percent_used"`
+	Exceeded    bool    `json:"exceeded"`
+}
+
+func (q *UserQuota) calculatePercent() {
+	if q.QuotaBytes <= 0 {
+		return
+	}
+	q.PercentUsed = float64(q.UsedBytes) * 100 / float64(q.QuotaBytes)
+	q.Exceeded = q.UsedBytes > q.QuotaBytes
+}
+
+// GroupUsageReport represents storage usage of a group and its members
+type GroupUsageReport struct {
+	GroupID        int          `json:"group_id"`
+	GroupName      string       `json:"group_name"`
+	QuotaMB        int          `json:"quota_mb"` // Per-member quota, 0 = unlimited
+	UsedBytes      int64        `json:"used_bytes"`
+	FileCount      int64        `json:"file_count"`
+	UsersOverQuota int          `json:"users_over_quota"`
+	Users          []*UserQuota `json:"users"`
+}
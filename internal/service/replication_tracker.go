@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// ReplicationTracker persists storage replication state in the database.
+// It implements storage.ReplicationRecorder.
+type ReplicationTracker struct {
+	repo repository.Repository
+}
+
+// NewReplicationTracker creates a new replication tracker
+func NewReplicationTracker(repo repository.Repository) *ReplicationTracker {
+	return &ReplicationTracker{repo: repo}
+}
+
+// RecordReplication stores the outcome of a replication attempt
+func (t *ReplicationTracker) RecordReplication(ctx context.Context, record storage.ReplicationRecord) error {
+	return t.repo.StorageReplicas().Upsert(ctx, &models.StorageReplica{
+		PrimaryPath:  record.PrimaryPath,
+		Replica:      record.Replica,
+		ReplicaPath:  record.ReplicaPath,
+		Status:       record.Status,
+		LastError:    record.Error,
+		QueuedAt:     record.QueuedAt,
+		ReplicatedAt: record.ReplicatedAt,
+	})
+}
+
+// FindReplicaPath returns the path of an object on a replica, or "" if unknown
+func (t *ReplicationTracker) FindReplicaPath(ctx context.Context, primaryPath, replica string) (string, error) {
+	record, err := t.repo.StorageReplicas().FindByPath(ctx, primaryPath, replica)
+	if err != nil || record == nil {
+		return "", err
+	}
+	return record.ReplicaPath, nil
+}
+
+// DeleteReplication removes all replication records for an object
+func (t *ReplicationTracker) DeleteReplication(ctx context.Context, primaryPath string) error {
+	return t.repo.StorageReplicas().DeleteByPath(ctx, primaryPath)
+}
+
+// IsReplicated reports whether an object has been successfully copied to a replica
+func (t *ReplicationTracker) IsReplicated(ctx context.Context, primaryPath, replica string) (bool, error) {
+	record, err := t.repo.StorageReplicas().FindByPath(ctx, primaryPath, replica)
+	if err != nil || record == nil {
+		return false, err
+	}
+	return record.Status == models.ReplicaStatusReplicated, nil
+}
+
+// GetStats returns replication counts and lag per replica and status
+func (t *ReplicationTracker) GetStats(ctx context.Context) ([]*repository.ReplicationStats, error) {
+	return t.repo.StorageReplicas().GetStats(ctx)
+}
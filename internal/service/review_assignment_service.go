@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// DefaultEscalationRole is the role notified of review SLA breaches unless another is set
+const DefaultEscalationRole = "supervisor"
+
+// escalationBatchSize is the number of overdue assignments the escalation job loads at a time
+const escalationBatchSize = 100
+
+var (
+	// ErrFileNotPending is returned when assigning a reviewer to a file that is not pending review
+	ErrFileNotPending = errors.New("file is not pending review")
+	// ErrInvalidReviewer is returned for reviewers that do not exist or are disabled
+	ErrInvalidReviewer = errors.New("reviewer does not exist or is disabled")
+	// ErrNoReviewGroup is returned when assigning in turn a file whose category has no review group
+	// with enabled members
+	ErrNoReviewGroup = errors.New("the file's category has no review group with enabled members")
+	// ErrAssignmentConflict is returned when the file's assignment changed while it was reassigned
+	ErrAssignmentConflict = errors.New("review assignment was changed concurrently, retry")
+)
+
+// ReviewSettings are the review SLA and review group that apply to a category
+type ReviewSettings struct {
+	SLAHours int `json:"sla_hours"` // 0 without an SLA
+	GroupID  int `json:"group_id"`  // 0 without automatic assignment
+}
+
+// ReviewAssignmentService makes reviewers responsible for pending files. Files entering pending
+// review are assigned in turn to the members of their category's review group and are due once the
+// category's review SLA has passed; overdue files are escalated to the users holding the escalation
+// role.
+type ReviewAssignmentService struct {
+	repo           repository.Repository
+	notifications  *NotificationService
+	escalationRole string
+}
+
+// NewReviewAssignmentService creates a new review assignment service
+func NewReviewAssignmentService(repo repository.Repository, notifications *NotificationService) *ReviewAssignmentService {
+	return &ReviewAssignmentService{
+		repo:           repo,
+		notifications:  notifications,
+		escalationRole: DefaultEscalationRole,
+	}
+}
+
+// SetEscalationRole sets the name of the role notified of SLA breaches
+func (s *ReviewAssignmentService) SetEscalationRole(role string) {
+	if role != "" {
+		s.escalationRole = role
+	}
+}
+
+// Settings returns the review SLA and review group of the category or its nearest ancestor that
+// sets each of them
+func (s *ReviewAssignmentService) Settings(ctx context.Context, categoryID int) ReviewSettings {
+	var settings ReviewSettings
+	ancestors, err := categoryAncestors(ctx, s.repo.Category(), categoryID)
+	if err != nil {
+		return settings
+	}
+	slaSet, groupSet := false, false
+	for i := len(ancestors) - 1; i >= 0 && !(slaSet && groupSet); i-- {
+		category, err := s.repo.Category().FindByID(ctx, ancestors[i])
+		if err != nil {
+			continue
+		}
+		if !slaSet && category.ReviewSLAHours != 0 {
+			slaSet = true
+			if category.ReviewSLAHours > 0 {
+				settings.SLAHours = category.ReviewSLAHours
+			}
+		}
+		if !groupSet && category.ReviewGroupID != 0 {
+			groupSet = true
+			if category.ReviewGroupID > 0 {
+				settings.GroupID = category.ReviewGroupID
+			}
+		}
+	}
+	return settings
+}
+
+// Assign makes a user the reviewer of a pending file, replacing its current assignment. The due
+// date of the file's review is kept.
+func (s *ReviewAssignmentService) Assign(ctx context.Context, fileID uint64, reviewerID int, assignedBy string) (*models.ReviewAssignment, error) {
+	file, err := s.pendingFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := s.repo.Users().FindByID(ctx, reviewerID)
+	if err != nil || !reviewer.Enabled {
+		return nil, ErrInvalidReviewer
+	}
+	return s.assign(ctx, file, reviewer, 0, assignedBy)
+}
+
+// AssignInTurn assigns a pending file to the next member of its category's review group
+func (s *ReviewAssignmentService) AssignInTurn(ctx context.Context, fileID uint64, assignedBy string) (*models.ReviewAssignment, error) {
+	file, err := s.pendingFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	groupID := s.Settings(ctx, file.CategoryID).GroupID
+	if groupID == 0 {
+		return nil, ErrNoReviewGroup
+	}
+	reviewer, err := s.nextReviewer(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer == nil {
+		return nil, ErrNoReviewGroup
+	}
+	return s.assign(ctx, file, reviewer, groupID, assignedBy)
+}
+
+// FileAssignments returns a file's assignments, oldest first
+func (s *ReviewAssignmentService) FileAssignments(ctx context.Context, fileID uint64) ([]*models.ReviewAssignment, error) {
+	return s.repo.ReviewAssignments().FindByFileID(ctx, fileID)
+}
+
+// List returns the assignments matching the filter with their files
+func (s *ReviewAssignmentService) List(ctx context.Context, filter repository.ReviewAssignmentFilter, limit, offset int) ([]*models.ReviewAssignment, int64, error) {
+	assignments, total, err := s.repo.ReviewAssignments().FindAll(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, assignment := range assignments {
+		if file, err := s.repo.Files().FindByID(ctx, assignment.FileID); err == nil {
+			assignment.File = file
+		}
+	}
+	return assignments, total, nil
+}
+
+// Overdue returns the open assignments past their due date matching the filter, most overdue first
+func (s *ReviewAssignmentService) Overdue(ctx context.Context, filter repository.ReviewAssignmentFilter, limit, offset int) ([]*models.ReviewAssignment, int64, error) {
+	filter.DueBefore = int(time.Now().Unix())
+	return s.List(ctx, filter, limit, offset)
+}
+
+// EscalateOverdue notifies the users holding the escalation role of each assignment that passed
+// its due date since the last run, and returns how many were escalated. Assignments are escalated
+// once.
+func (s *ReviewAssignmentService) EscalateOverdue(ctx context.Context) (int, error) {
+	supervisors, err := s.repo.Users().FindEnabledByRoleName(ctx, s.escalationRole)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load users with role %q: %w", s.escalationRole, err)
+	}
+	recipients := make([]int, 0, len(supervisors))
+	for _, user := range supervisors {
+		recipients = append(recipients, user.ID)
+	}
+	if len(recipients) == 0 {
+		log.Printf("⚠ Warning: No enabled users have the %q role, overdue reviews are escalated without notification", s.escalationRole)
+	}
+
+	escalated := 0
+	for {
+		now := int(time.Now().Unix())
+		assignments, err := s.repo.ReviewAssignments().FindUnescalated(ctx, now, escalationBatchSize)
+		if err != nil {
+			return escalated, err
+		}
+		for _, assignment := range assignments {
+			marked, err := s.repo.ReviewAssignments().MarkEscalated(ctx, assignment.ID, now)
+			if err != nil {
+				return escalated, err
+			}
+			if !marked {
+				continue
+			}
+			escalated++
+			if len(recipients) == 0 {
+				continue
+			}
+			if err := s.notifications.Notify(ctx, recipients, models.NotificationReviewEscalated, assignment.FileID, s.escalationMessage(ctx, assignment)); err != nil {
+				log.Printf("Failed to escalate overdue review of file %d: %v", assignment.FileID, err)
+			}
+		}
+		if len(assignments) < escalationBatchSize {
+			return escalated, nil
+		}
+	}
+}
+
+// start opens the assignment of a file that entered pending review, if its category has a review
+// SLA or review group
+func (s *ReviewAssignmentService) start(ctx context.Context, file *models.Files, actor string) error {
+	settings := s.Settings(ctx, file.CategoryID)
+	if settings.SLAHours == 0 && settings.GroupID == 0 {
+		return nil
+	}
+	now := int(time.Now().Unix())
+	if err := s.repo.ReviewAssignments().CloseOpen(ctx, file.ID, now); err != nil {
+		return err
+	}
+
+	assignment := &models.ReviewAssignment{
+		FileID:     file.ID,
+		CategoryID: file.CategoryID,
+		AssignedBy: actor,
+		Assigned:   now,
+		Submitted:  now,
+		DueAt:      dueAt(now, settings.SLAHours),
+		Status:     models.ReviewAssignmentOpen,
+	}
+	if settings.GroupID > 0 {
+		reviewer, err := s.nextReviewer(ctx, settings.GroupID)
+		if err != nil {
+			log.Printf("Failed to pick a reviewer of file %d from group %d: %v", file.ID, settings.GroupID, err)
+		} else if reviewer != nil {
+			assignment.ReviewerID = reviewer.ID
+			assignment.Reviewer = reviewer.Username
+			assignment.GroupID = settings.GroupID
+		}
+	}
+	if err := s.repo.ReviewAssignments().Create(ctx, assignment); err != nil {
+		return err
+	}
+	s.notifyReviewer(ctx, file, assignment)
+	return nil
+}
+
+// close closes the open assignment of a file that left pending review
+func (s *ReviewAssignmentService) close(ctx context.Context, fileID uint64) error {
+	return s.repo.ReviewAssignments().CloseOpen(ctx, fileID, int(time.Now().Unix()))
+}
+
+// assign replaces a pending file's open assignment, or opens one, with the reviewer
+func (s *ReviewAssignmentService) assign(ctx context.Context, file *models.Files, reviewer *models.Users, groupID int, assignedBy string) (*models.ReviewAssignment, error) {
+	now := int(time.Now().Unix())
+	next := &models.ReviewAssignment{
+		FileID:     file.ID,
+		CategoryID: file.CategoryID,
+		ReviewerID: reviewer.ID,
+		Reviewer:   reviewer.Username,
+		GroupID:    groupID,
+		AssignedBy: assignedBy,
+		Assigned:   now,
+		Status:     models.ReviewAssignmentOpen,
+	}
+
+	open, err := s.repo.ReviewAssignments().FindOpenByFileID(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		// The review stays due when it was first due and is not escalated again
+		next.Submitted = open.Submitted
+		next.DueAt = open.DueAt
+		next.EscalatedAt = open.EscalatedAt
+		replaced, err := s.repo.ReviewAssignments().Reassign(ctx, open, next)
+		if err != nil {
+			return nil, err
+		}
+		if !replaced {
+			return nil, ErrAssignmentConflict
+		}
+	} else {
+		next.Submitted = now
+		next.DueAt = dueAt(now, s.Settings(ctx, file.CategoryID).SLAHours)
+		if err := s.repo.ReviewAssignments().Create(ctx, next); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("File %d assigned to reviewer %s by %s", file.ID, reviewer.Username, assignedBy)
+	s.notifyReviewer(ctx, file, next)
+	return next, nil
+}
+
+// pendingFile returns a file if it is pending review
+func (s *ReviewAssignmentService) pendingFile(ctx context.Context, fileID uint64) (*models.Files, error) {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Status != models.FileStatusPending {
+		return nil, ErrFileNotPending
+	}
+	return file, nil
+}
+
+// nextReviewer returns the enabled member of the group after the one assigned in turn last, or
+// nil if the group has no enabled members
+func (s *ReviewAssignmentService) nextReviewer(ctx context.Context, groupID int) (*models.Users, error) {
+	members, err := s.repo.Users().FindEnabledByGroupID(ctx, groupID)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	last, err := s.repo.ReviewAssignments().LastRotationReviewer(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if member.ID > last {
+			return member, nil
+		}
+	}
+	return members[0], nil
+}
+
+// notifyReviewer tells the reviewer of an assignment about it
+func (s *ReviewAssignmentService) notifyReviewer(ctx context.Context, file *models.Files, assignment *models.ReviewAssignment) {
+	if assignment.ReviewerID == 0 {
+		return
+	}
+	message := fmt.Sprintf("You were assigned to review file %d %q", file.ID, file.Title)
+	if assignment.DueAt > 0 {
+		message += fmt.Sprintf(", due %s", time.Unix(int64(assignment.DueAt), 0).Format(time.RFC3339))
+	}
+	if err := s.notifications.Notify(ctx, []int{assignment.ReviewerID}, models.NotificationReviewAssigned, file.ID, message); err != nil {
+		log.Printf("Failed to notify reviewer of file %d: %v", file.ID, err)
+	}
+}
+
+// escalationMessage describes an overdue assignment
+func (s *ReviewAssignmentService) escalationMessage(ctx context.Context, assignment *models.ReviewAssignment) string {
+	title := ""
+	if file, err := s.repo.Files().FindByID(ctx, assignment.FileID); err == nil {
+		title = fmt.Sprintf(" %q", file.Title)
+	}
+	reviewer := assignment.Reviewer
+	if reviewer == "" {
+		reviewer = "nobody"
+	}
+	return fmt.Sprintf("Review of file %d%s is overdue: it was due %s and is assigned to %s",
+		assignment.FileID, title, time.Unix(int64(assignment.DueAt), 0).Format(time.RFC3339), reviewer)
+}
+
+// dueAt returns when a review submitted at the given time is due under an SLA in hours, or 0
+func dueAt(submitted, slaHours int) int {
+	if slaHours <= 0 {
+		return 0
+	}
+	return submitted + slaHours*60*60
+}
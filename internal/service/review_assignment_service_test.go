@@ -0,0 +1,72 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+)
+
+func TestEscalateOverdueNotifiesOnce(t *testing.T) {
+	f := newTestFixture(t)
+	reviewer := f.user("reviewer")
+	supervisor := f.user("supervisor")
+	service := NewReviewAssignmentService(f.repo, NewNotificationService(f.repo))
+	service.SetEscalationRole("supervisor-role")
+
+	category := &models.Category{Name: "News", Path: "-1,", Enabled: true, ReviewSLAHours: 2, ReviewGroupID: reviewer.GroupID}
+	f.create(category)
+	submit := func(name string) *models.Files {
+		file := f.file(name, models.FileStatusPending, "uploader")
+		if err := f.db.Model(file).Update("category_id", category.ID).Error; err != nil {
+			t.Fatalf("failed to move file: %v", err)
+		}
+		file = f.reload(file)
+		if err := service.start(f.ctx, file, "uploader"); err != nil {
+			t.Fatalf("start() error = %v", err)
+		}
+		return file
+	}
+	// The SLA of late and done passed an hour ago; done has since left review
+	late, fresh, done := submit("late"), submit("fresh"), submit("done")
+	overdue := int(time.Now().Add(-time.Hour).Unix())
+	if err := f.db.Model(&models.ReviewAssignment{}).Where("file_id IN ?", []uint64{late.ID, done.ID}).Update("due_at", overdue).Error; err != nil {
+		t.Fatalf("failed to backdate due dates: %v", err)
+	}
+	if err := service.close(f.ctx, done.ID); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	escalated, err := service.EscalateOverdue(f.ctx)
+	if err != nil {
+		t.Fatalf("EscalateOverdue() error = %v", err)
+	}
+	if escalated != 1 {
+		t.Errorf("EscalateOverdue() = %d, want 1", escalated)
+	}
+	notifications, total, err := f.repo.Notifications().FindByUserID(f.ctx, supervisor.ID, false, 10, 0)
+	if err != nil {
+		t.Fatalf("FindByUserID() error = %v", err)
+	}
+	if total != 1 || notifications[0].Kind != models.NotificationReviewEscalated || notifications[0].FileID != late.ID {
+		t.Fatalf("supervisor got %d notifications %+v, want one escalation of file %d", total, notifications, late.ID)
+	}
+	if message := notifications[0].Message; !strings.Contains(message, `"late" is overdue`) || !strings.HasSuffix(message, "assigned to reviewer") {
+		t.Errorf("escalation message = %q", message)
+	}
+	for _, file := range []*models.Files{fresh, done} {
+		assignments, err := service.FileAssignments(f.ctx, file.ID)
+		if err != nil || len(assignments) != 1 || assignments[0].EscalatedAt != 0 {
+			t.Errorf("assignments of %s = %+v, %v, want one not escalated", file.Name, assignments, err)
+		}
+	}
+
+	// Escalated assignments are not escalated again
+	if escalated, err := service.EscalateOverdue(f.ctx); err != nil || escalated != 0 {
+		t.Errorf("second EscalateOverdue() = %d, %v, want 0", escalated, err)
+	}
+	if _, total, _ := f.repo.Notifications().FindByUserID(f.ctx, supervisor.ID, false, 10, 0); total != 1 {
+		t.Errorf("supervisor has %d notifications after the second run, want 1", total)
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReviewNotFound is returned for review tasks that do not exist
+	ErrReviewNotFound = errors.New("review task not found")
+	// ErrReviewPending is returned when publishing a file whose review stages have not passed
+	ErrReviewPending = errors.New("file must pass its review stages before it is published")
+	// ErrReviewClosed is returned for votes on tasks that are no longer open
+	ErrReviewClosed = errors.New("review task is closed")
+	// ErrReviewNotAssigned is returned for votes by users who are not reviewers of the task
+	ErrReviewNotAssigned = errors.New("not a reviewer of this task")
+	// ErrReviewAlreadyVoted is returned when a reviewer votes twice on a task
+	ErrReviewAlreadyVoted = errors.New("already voted on this review task")
+	// ErrReviewConflict is returned when another vote changed the task while this one was recorded
+	ErrReviewConflict = errors.New("review task was changed by another vote, retry")
+	// ErrInvalidReviewDecision is returned for decisions other than approve or reject
+	ErrInvalidReviewDecision = errors.New("decision must be approve or reject")
+)
+
+// ReviewService records review votes and moves files through their workflow's review stages.
+// A stage passes when its quorum of reviewers approves; the last stage publishes the file and any
+// rejection rejects it.
+type ReviewService struct {
+	repo  repository.Repository
+	files *FilesService
+}
+
+// NewReviewService creates a new review service
+func NewReviewService(repo repository.Repository, files *FilesService) *ReviewService {
+	return &ReviewService{
+		repo:  repo,
+		files: files,
+	}
+}
+
+// FileReviews returns a file's review tasks with their votes, oldest first
+func (s *ReviewService) FileReviews(ctx context.Context, fileID uint64) ([]*models.ReviewTask, error) {
+	return s.repo.Reviews().FindByFileID(ctx, fileID)
+}
+
+// Queue returns the open review tasks a user can still vote on, with their files
+func (s *ReviewService) Queue(ctx context.Context, userID int, limit, offset int) ([]*models.ReviewTask, int64, error) {
+	user, err := s.repo.Users().FindByID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	tasks, total, err := s.repo.Reviews().FindQueue(ctx, userID, user.GroupID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, task := range tasks {
+		if file, err := s.repo.Files().FindByID(ctx, task.FileID); err == nil {
+			task.File = file
+		}
+	}
+	return tasks, total, nil
+}
+
+// Vote records a reviewer's decision on an open task. Administrators may vote on any task.
+func (s *ReviewService) Vote(ctx context.Context, taskID uint64, userID int, username string, isAdmin bool, decision, comment string) (*models.ReviewTask, error) {
+	if decision != models.ReviewDecisionApprove && decision != models.ReviewDecisionReject {
+		return nil, ErrInvalidReviewDecision
+	}
+	task, err := s.repo.Reviews().FindTask(ctx, taskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != models.ReviewStatusOpen {
+		return nil, ErrReviewClosed
+	}
+	user, err := s.repo.Users().FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && !isReviewer(task, user) {
+		return nil, ErrReviewNotAssigned
+	}
+	for _, vote := range task.Votes {
+		if vote.UserID == userID {
+			return nil, ErrReviewAlreadyVoted
+		}
+	}
+	file, err := s.repo.Files().FindByID(ctx, task.FileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Status != models.FileStatusPending {
+		// The file left review without the task being closed
+		s.repo.Reviews().CancelOpen(ctx, file.ID, int(time.Now().Unix()))
+		return nil, ErrReviewClosed
+	}
+
+	// Work out what the vote decides before recording it
+	now := int(time.Now().Unix())
+	approvals := task.Approvals
+	status := models.ReviewStatusOpen
+	fileStatus := -1
+	var next *models.ReviewTask
+	switch {
+	case decision == models.ReviewDecisionReject:
+		status = models.ReviewStatusRejected
+		fileStatus = models.FileStatusRejected
+	case approvals+1 >= task.Quorum:
+		approvals++
+		status = models.ReviewStatusApproved
+		workflow, stages, err := s.taskWorkflow(ctx, task, file)
+		if err != nil {
+			return nil, err
+		}
+		if task.Stage+1 < len(stages) {
+			next = newReviewTask(file, workflow, task.Stage+1, &stages[task.Stage+1], now)
+		} else {
+			fileStatus = models.FileStatusPublished
+		}
+	default:
+		approvals++
+	}
+	if fileStatus >= 0 {
+		if _, _, err := s.files.checkTransition(ctx, file, file.Status, fileStatus, username, true); err != nil {
+			return nil, err
+		}
+	}
+
+	vote := &models.ReviewVote{
+		TaskID:   task.ID,
+		FileID:   task.FileID,
+		UserID:   userID,
+		Username: username,
+		Decision: decision,
+		Comment:  comment,
+		Created:  now,
+	}
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"approvals": approvals,
+			"status":    status,
+		}
+		if status != models.ReviewStatusOpen {
+			updates["closed"] = now
+		}
+		result := tx.WithContext(ctx).Model(&models.ReviewTask{}).
+			Where("id = ? AND status = ? AND approvals = ?", task.ID, models.ReviewStatusOpen, task.Approvals).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewConflict
+		}
+		if err := tx.WithContext(ctx).Create(vote).Error; err != nil {
+			return err
+		}
+		if next != nil {
+			return tx.WithContext(ctx).Create(next).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Review task %d of file %d: %s by %s (%d/%d approvals)", task.ID, file.ID, decision, username, approvals, task.Quorum)
+
+	if fileStatus >= 0 {
+		if err := s.files.changeStatus(ctx, file, fileStatus, username, comment, true); err != nil {
+			return nil, fmt.Errorf("vote recorded but the file status could not be changed: %w", err)
+		}
+	}
+	return s.repo.Reviews().FindTask(ctx, task.ID)
+}
+
+// taskWorkflow returns the workflow a task was started from and its review stages, falling back
+// to the file's current workflow if it was deleted
+func (s *ReviewService) taskWorkflow(ctx context.Context, task *models.ReviewTask, file *models.Files) (*models.Workflow, []models.WorkflowReviewStage, error) {
+	var workflow *models.Workflow
+	if task.WorkflowID > 0 {
+		workflow, _ = s.repo.Workflows().FindByID(ctx, task.WorkflowID)
+	}
+	if workflow == nil {
+		resolved, err := s.files.workflows.Resolve(ctx, file.CategoryID)
+		if err != nil {
+			return nil, nil, err
+		}
+		workflow = resolved
+	}
+	stages, err := workflow.GetReviewStages()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode review stages of workflow %q: %w", workflow.Name, err)
+	}
+	return workflow, stages, nil
+}
+
+// isReviewer reports whether the user is assigned to the task directly or through their group
+func isReviewer(task *models.ReviewTask, user *models.Users) bool {
+	for _, assignee := range task.Assignees {
+		if assignee.UserID == user.ID || (assignee.GroupID > 0 && assignee.GroupID == user.GroupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// newReviewTask creates the task of a review stage with the stage's reviewers
+func newReviewTask(file *models.Files, workflow *models.Workflow, index int, stage *models.WorkflowReviewStage, now int) *models.ReviewTask {
+	task := &models.ReviewTask{
+		FileID:     file.ID,
+		WorkflowID: workflow.ID,
+		Stage:      index,
+		StageName:  stage.Name,
+		Quorum:     stage.Quorum,
+		Status:     models.ReviewStatusOpen,
+		Created:    now,
+	}
+	for _, userID := range stage.Users {
+		task.Assignees = append(task.Assignees, models.ReviewAssignee{UserID: userID})
+	}
+	for _, groupID := range stage.Groups {
+		task.Assignees = append(task.Assignees, models.ReviewAssignee{GroupID: groupID})
+	}
+	return task
+}
+
+// startReview cancels a file's open review and opens the first stage of its workflow, if the
+// workflow has review stages
+func startReview(ctx context.Context, repo repository.Repository, file *models.Files, workflow *models.Workflow) error {
+	now := int(time.Now().Unix())
+	if err := repo.Reviews().CancelOpen(ctx, file.ID, now); err != nil {
+		return err
+	}
+	stages, err := workflow.GetReviewStages()
+	if err != nil || len(stages) == 0 {
+		return err
+	}
+	return repo.Reviews().CreateTask(ctx, newReviewTask(file, workflow, 0, &stages[0], now))
+}
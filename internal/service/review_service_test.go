@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/models"
+)
+
+// reviewFixture is a pending file in a category whose workflow has a legal stage needing two of
+// three reviewers and an editorial stage needing one member of the editors' group
+type reviewFixture struct {
+	*testFixture
+	service  *ReviewService
+	file     *models.Files
+	lawyers  []*models.Users
+	editor   *models.Users
+	outsider *models.Users
+}
+
+func newReviewFixture(t *testing.T) *reviewFixture {
+	f := &reviewFixture{testFixture: newTestFixture(t)}
+	for _, name := range []string{"lawyer1", "lawyer2", "lawyer3"} {
+		f.lawyers = append(f.lawyers, f.user(name))
+	}
+	f.editor = f.user("editor")
+	f.outsider = f.user("outsider")
+
+	workflow := defaultWorkflow()
+	workflow.Name = "two-stage"
+	workflow.IsDefault = false
+	workflow.SetReviewStages([]models.WorkflowReviewStage{
+		{Name: "legal", Users: []int{f.lawyers[0].ID, f.lawyers[1].ID, f.lawyers[2].ID}, Quorum: 2},
+		{Name: "editorial", Groups: []int{f.editor.GroupID}, Quorum: 1},
+	})
+	if err := NewWorkflowService(f.repo).CreateWorkflow(f.ctx, workflow); err != nil {
+		t.Fatalf("CreateWorkflow() error = %v", err)
+	}
+	category := &models.Category{Name: "Legal", Path: "-1,", WorkflowID: workflow.ID, Enabled: true}
+	f.create(category)
+
+	f.file = f.testFixture.file("contract", models.FileStatusPending, "uploader")
+	if err := f.db.Model(f.file).Update("category_id", category.ID).Error; err != nil {
+		t.Fatalf("failed to move file: %v", err)
+	}
+	f.file = f.reload(f.file)
+	if err := startReview(f.ctx, f.repo, f.file, workflow); err != nil {
+		t.Fatalf("startReview() error = %v", err)
+	}
+	f.service = NewReviewService(f.repo, NewFilesService(f.repo))
+	return f
+}
+
+// openTask returns the file's open review task
+func (f *reviewFixture) openTask() *models.ReviewTask {
+	f.t.Helper()
+	task, err := f.repo.Reviews().FindOpenByFileID(f.ctx, f.file.ID)
+	if err != nil || task == nil {
+		f.t.Fatalf("FindOpenByFileID() = %v, %v, want an open task", task, err)
+	}
+	return task
+}
+
+// vote records a user's decision on the open task
+func (f *reviewFixture) vote(user *models.Users, decision string) (*models.ReviewTask, error) {
+	return f.service.Vote(f.ctx, f.openTask().ID, user.ID, user.Username, false, decision, "")
+}
+
+func TestReviewVotesReachQuorumStageByStage(t *testing.T) {
+	f := newReviewFixture(t)
+	legal := f.openTask()
+
+	task, err := f.vote(f.lawyers[0], models.ReviewDecisionApprove)
+	if err != nil {
+		t.Fatalf("first approval error = %v", err)
+	}
+	if task.Status != models.ReviewStatusOpen || task.Approvals != 1 {
+		t.Errorf("after one approval the stage is %s with %d approvals, want open with 1", task.Status, task.Approvals)
+	}
+	if _, err := f.vote(f.lawyers[0], models.ReviewDecisionApprove); !errors.Is(err, ErrReviewAlreadyVoted) {
+		t.Errorf("second vote of the same reviewer error = %v, want %v", err, ErrReviewAlreadyVoted)
+	}
+	if _, err := f.vote(f.outsider, models.ReviewDecisionApprove); !errors.Is(err, ErrReviewNotAssigned) {
+		t.Errorf("vote of an unassigned user error = %v, want %v", err, ErrReviewNotAssigned)
+	}
+
+	task, err = f.vote(f.lawyers[1], models.ReviewDecisionApprove)
+	if err != nil {
+		t.Fatalf("second approval error = %v", err)
+	}
+	if task.ID != legal.ID || task.Status != models.ReviewStatusApproved || task.Approvals != 2 {
+		t.Errorf("after the quorum the legal stage is %s with %d approvals, want approved with 2", task.Status, task.Approvals)
+	}
+	editorial := f.openTask()
+	if editorial.StageName != "editorial" || editorial.Quorum != 1 {
+		t.Errorf("next stage = %s with quorum %d, want editorial with quorum 1", editorial.StageName, editorial.Quorum)
+	}
+	if status := f.reload(f.file).Status; status != models.FileStatusPending {
+		t.Fatalf("status after the first stage = %d, want pending", status)
+	}
+
+	// Any member of the editors' group completes the last stage
+	if _, err := f.vote(f.editor, models.ReviewDecisionApprove); err != nil {
+		t.Fatalf("editorial approval error = %v", err)
+	}
+	if status := f.reload(f.file).Status; status != models.FileStatusPublished {
+		t.Errorf("status after the last stage = %d, want published", status)
+	}
+}
+
+func TestReviewRejectionEndsReview(t *testing.T) {
+	f := newReviewFixture(t)
+
+	if _, err := f.vote(f.lawyers[0], models.ReviewDecisionApprove); err != nil {
+		t.Fatalf("approval error = %v", err)
+	}
+	task, err := f.vote(f.lawyers[2], models.ReviewDecisionReject)
+	if err != nil {
+		t.Fatalf("rejection error = %v", err)
+	}
+	if task.Status != models.ReviewStatusRejected || len(task.Votes) != 2 {
+		t.Errorf("stage = %s with %d votes, want rejected with 2", task.Status, len(task.Votes))
+	}
+	if status := f.reload(f.file).Status; status != models.FileStatusRejected {
+		t.Errorf("status = %d, want rejected", status)
+	}
+	if open, err := f.repo.Reviews().FindOpenByFileID(f.ctx, f.file.ID); err != nil || open != nil {
+		t.Errorf("FindOpenByFileID() = %v, %v, want no open task", open, err)
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/antivirus"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// MalwareScanner scans content for malware; implemented by *antivirus.ClamdScanner
+type MalwareScanner interface {
+	Scan(ctx context.Context, content io.Reader) (*antivirus.Result, error)
+}
+
+// DefaultMalwareScanner returns the clamd scanner configured by CLAMD_ADDRESS, or nil when
+// scanning is not configured
+func DefaultMalwareScanner() MalwareScanner {
+	scanner, err := antivirus.NewClamdScannerFromEnv()
+	if err != nil {
+		log.Printf("Malware scanning disabled: %v", err)
+		return nil
+	}
+	if scanner == nil {
+		return nil
+	}
+	return scanner
+}
+
+// MalwareDetectedError is returned when an upload is infected. The file record is still created,
+// flagged and pointing at the quarantined object, so administrators can review it.
+type MalwareDetectedError struct {
+	File      *models.Files
+	Signature string
+}
+
+func (e *MalwareDetectedError) Error() string {
+	return fmt.Sprintf("malware detected: %s", e.Signature)
+}
+
+// ScanService scans stored files for malware and quarantines infected ones
+type ScanService struct {
+	repo    repository.Repository
+	storage storage.StorageService
+	scanner MalwareScanner
+}
+
+// NewScanService creates a new scan service; scanner may be nil when scanning is not configured
+func NewScanService(repo repository.Repository, storageService storage.StorageService, scanner MalwareScanner) *ScanService {
+	return &ScanService{
+		repo:    repo,
+		storage: storageService,
+		scanner: scanner,
+	}
+}
+
+// Enabled reports whether a scanner is configured
+func (s *ScanService) Enabled() bool {
+	return s.scanner != nil
+}
+
+// ScanContent scans content and rewinds it, returning the scan status and the detected signature
+// or scanner error. Scanner failures leave the file pending so it stays blocked until rescanned.
+func (s *ScanService) ScanContent(ctx context.Context, content io.ReadSeeker) (string, string) {
+	if s.scanner == nil {
+		return models.ScanStatusSkipped, ""
+	}
+
+	result, err := s.scanner.Scan(ctx, content)
+	if _, seekErr := content.Seek(0, io.SeekStart); seekErr != nil && err == nil {
+		err = fmt.Errorf("failed to rewind content: %w", seekErr)
+	}
+	if err != nil {
+		log.Printf("Malware scan failed, file stays pending: %v", err)
+		return models.ScanStatusPending, truncate(err.Error(), 255)
+	}
+	if result.Infected {
+		return models.ScanStatusInfected, truncate(result.Signature, 255)
+	}
+	return models.ScanStatusClean, ""
+}
+
+// ListFiles returns files with the given scan status, newest first
+func (s *ScanService) ListFiles(ctx context.Context, status string, limit, offset int) ([]*models.Files, int64, error) {
+	return s.repo.Files().FindAll(ctx, map[string]interface{}{"scan_status": status}, limit, offset)
+}
+
+// Rescan scans a stored file again, quarantining it if it is infected
+func (s *ScanService) Rescan(ctx context.Context, fileID uint64) (*models.Files, error) {
+	if s.scanner == nil {
+		return nil, fmt.Errorf("malware scanning is not configured")
+	}
+
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.ScanStatus == models.ScanStatusInfected {
+		return file, nil // Already quarantined
+	}
+
+	reader, err := s.storage.Download(ctx, file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored file: %w", err)
+	}
+	result, scanErr := s.scanner.Scan(ctx, reader)
+	reader.Close()
+
+	file.ScannedAt = int(time.Now().Unix())
+	switch {
+	case scanErr != nil:
+		file.ScanStatus = models.ScanStatusPending
+		file.ScanResult = truncate(scanErr.Error(), 255)
+	case result.Infected:
+		if err := s.quarantine(ctx, file, result.Signature); err != nil {
+			return nil, err
+		}
+		return file, nil
+	default:
+		file.ScanStatus = models.ScanStatusClean
+		file.ScanResult = ""
+	}
+
+	if err := s.repo.Files().Update(ctx, file); err != nil {
+		return nil, err
+	}
+	return file, scanErr
+}
+
+// RescanPending rescans up to limit pending files and returns how many were scanned and infected
+func (s *ScanService) RescanPending(ctx context.Context, limit int) (int, int, error) {
+	files, _, err := s.ListFiles(ctx, models.ScanStatusPending, limit, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	scanned, infected := 0, 0
+	for _, file := range files {
+		updated, err := s.Rescan(ctx, file.ID)
+		if err != nil {
+			log.Printf("Rescan of file %d failed: %v", file.ID, err)
+			continue
+		}
+		scanned++
+		if updated.ScanStatus == models.ScanStatusInfected {
+			infected++
+		}
+	}
+	return scanned, infected, nil
+}
+
+// quarantine quarantines an infected file and every other file stored as the same
+// content-addressed blob, since they all serve the infected content
+func (s *ScanService) quarantine(ctx context.Context, file *models.Files, signature string) error {
+	contentHash := file.ContentHash
+	if err := s.quarantineFile(ctx, file, signature); err != nil {
+		return err
+	}
+	if contentHash == "" {
+		return nil
+	}
+
+	copies, err := s.repo.Files().FindAllByContentHash(ctx, contentHash)
+	if err != nil {
+		log.Printf("Failed to find copies of quarantined file %d: %v", file.ID, err)
+		return nil
+	}
+	for _, other := range copies {
+		other.ScannedAt = file.ScannedAt
+		if err := s.quarantineFile(ctx, other, signature); err != nil {
+			log.Printf("Failed to quarantine file %d, a copy of file %d: %v", other.ID, file.ID, err)
+		}
+	}
+	return nil
+}
+
+// quarantineFile moves a stored file below the quarantine prefix and flags it, recording the
+// change in its workflow history and closing its open reviews. The original and its preview
+// are released once the flagged record is saved.
+func (s *ScanService) quarantineFile(ctx context.Context, file *models.Files, signature string) error {
+	reader, err := s.storage.Download(ctx, file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read stored file: %w", err)
+	}
+	// Files sharing a blob share its path, so the file ID keeps their quarantined copies apart
+	quarantinePath, err := storage.PutQuarantined(ctx, s.storage, fmt.Sprintf("%d/%s", file.ID, file.Path), reader, map[string]string{
+		"scan-signature": signature,
+	})
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to quarantine file: %w", err)
+	}
+
+	stored := *file
+	if file.Status != models.FileStatusFlagged {
+		now := int(time.Now().Unix())
+		event := &models.WorkflowEvent{
+			FileID:     file.ID,
+			FromStatus: file.Status,
+			ToStatus:   models.FileStatusFlagged,
+			Transition: "quarantine",
+			Username:   SystemActor,
+			Comment:    truncate("Malware detected: "+signature, 1024),
+			Created:    now,
+		}
+		changed, err := s.repo.Files().TransitionStatus(ctx, file.ID, file.Status, models.FileStatusFlagged, SystemActor, event)
+		if err == nil && !changed {
+			err = ErrStatusConflict
+		}
+		if err != nil {
+			if delErr := s.storage.Delete(ctx, quarantinePath); delErr != nil {
+				log.Printf("Failed to delete quarantined copy %s of file %d: %v", quarantinePath, file.ID, delErr)
+			}
+			return err
+		}
+
+		if err := s.repo.Reviews().CancelOpen(ctx, file.ID, now); err != nil {
+			log.Printf("Failed to cancel reviews of quarantined file %d: %v", file.ID, err)
+		}
+		if err := s.repo.ReviewAssignments().CloseOpen(ctx, file.ID, now); err != nil {
+			log.Printf("Failed to close review assignment of quarantined file %d: %v", file.ID, err)
+		}
+	}
+
+	file.Path = quarantinePath
+	file.ContentHash = ""
+	file.Status = models.FileStatusFlagged
+	file.DeletedAt = 0
+	file.DeletedBy = ""
+	file.IsDownload = false
+	file.ScanStatus = models.ScanStatusInfected
+	file.ScanResult = truncate(signature, 255)
+	if err := s.repo.Files().Update(ctx, file); err != nil {
+		return err
+	}
+
+	releaseStoredObjects(ctx, s.storage, &stored)
+	return nil
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
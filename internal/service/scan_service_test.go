@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/antivirus"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// infectedScanner reports every scanned content as infected
+type infectedScanner struct{}
+
+func (infectedScanner) Scan(ctx context.Context, content io.Reader) (*antivirus.Result, error) {
+	io.Copy(io.Discard, content)
+	return &antivirus.Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+}
+
+func TestRescanQuarantinesSharedBlob(t *testing.T) {
+	f := newTestFixture(t)
+	backend := storage.NewMemoryStorage()
+	store, err := storage.NewContentAddressedStorage(backend)
+	if err != nil {
+		t.Fatalf("NewContentAddressedStorage() error = %v", err)
+	}
+	store.SetIndex(NewBlobIndex(f.repo))
+
+	// Two uploads of the same content share one blob and its preview
+	var files []*models.Files
+	for _, name := range []string{"first", "second"} {
+		key, err := store.Upload(f.ctx, name+".mp4", strings.NewReader("infected content"), nil)
+		if err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		file := f.file(name, models.FileStatusPending, "uploader")
+		file.Path = key
+		file.ContentHash = storage.ContentHashFromPath(key)
+		if err := f.repo.Files().Update(f.ctx, file); err != nil {
+			t.Fatalf("failed to update file: %v", err)
+		}
+		f.create(&models.ReviewTask{FileID: file.ID, StageName: "Review", Quorum: 1, Status: models.ReviewStatusOpen})
+		files = append(files, file)
+	}
+	blobPath := files[0].Path
+	previewPath := strings.TrimSuffix(blobPath, ".mp4") + "-preview.flv"
+	if _, err := store.Upload(f.ctx, previewPath, strings.NewReader("preview"), nil); err != nil {
+		t.Fatalf("Upload() preview error = %v", err)
+	}
+
+	scanned, err := NewScanService(f.repo, store, infectedScanner{}).Rescan(f.ctx, files[0].ID)
+	if err != nil {
+		t.Fatalf("Rescan() error = %v", err)
+	}
+	if scanned.ScanStatus != models.ScanStatusInfected {
+		t.Errorf("Rescan() scan status = %q, want %q", scanned.ScanStatus, models.ScanStatusInfected)
+	}
+
+	for _, file := range files {
+		stored := f.reload(file)
+		if stored.Status != models.FileStatusFlagged || stored.ScanStatus != models.ScanStatusInfected {
+			t.Errorf("file %d status = %d, scan status = %q, want flagged and infected", file.ID, stored.Status, stored.ScanStatus)
+		}
+		if !strings.HasPrefix(stored.Path, storage.QuarantinePrefix) || stored.ContentHash != "" {
+			t.Errorf("file %d path = %q, content hash = %q, want a quarantined object", file.ID, stored.Path, stored.ContentHash)
+		}
+		if exists, _ := backend.Exists(f.ctx, stored.Path); !exists {
+			t.Errorf("quarantined object %s of file %d is missing", stored.Path, file.ID)
+		}
+
+		events, _, err := f.repo.WorkflowEvents().FindAll(f.ctx, repository.WorkflowEventFilter{FileID: file.ID}, 10, 0)
+		if err != nil {
+			t.Fatalf("failed to list events: %v", err)
+		}
+		if len(events) != 1 || events[0].Transition != "quarantine" || events[0].ToStatus != models.FileStatusFlagged {
+			t.Errorf("file %d events = %+v, want one quarantine event", file.ID, events)
+		}
+
+		tasks, err := f.repo.Reviews().FindByFileID(f.ctx, file.ID)
+		if err != nil {
+			t.Fatalf("failed to list review tasks: %v", err)
+		}
+		for _, task := range tasks {
+			if task.Status != models.ReviewStatusCancelled {
+				t.Errorf("file %d review task status = %q, want %q", file.ID, task.Status, models.ReviewStatusCancelled)
+			}
+		}
+	}
+
+	for _, key := range []string{blobPath, previewPath} {
+		if exists, _ := backend.Exists(f.ctx, key); exists {
+			t.Errorf("object %s still exists after quarantine", key)
+		}
+	}
+}
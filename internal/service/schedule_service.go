@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// ErrInvalidSchedule is returned for publication windows that end before they start
+var ErrInvalidSchedule = errors.New("unpublish_at must be after publish_at")
+
+// scheduleBatchSize is the number of due files handled per run and direction
+const scheduleBatchSize = 100
+
+// ScheduleService manages publication windows. Files are embargoed before publish_at and after
+// unpublish_at; RunDue publishes pending files at publish_at and withdraws published files at
+// unpublish_at through their workflow's transitions, so workflows without a transition from
+// published to new keep expired files published (but hidden).
+type ScheduleService struct {
+	repo     repository.Repository
+	files    *FilesService
+	mu       sync.Mutex
+	reported map[uint64]string // Failures logged by the last run, by file ID
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(repo repository.Repository, files *FilesService) *ScheduleService {
+	return &ScheduleService{
+		repo:  repo,
+		files: files,
+	}
+}
+
+// SetSchedule sets a file's publication window; 0 clears either end. Files under legal hold return
+// *LegalHoldError. The old and new window are recorded in the file's history.
+func (s *ScheduleService) SetSchedule(ctx context.Context, fileID uint64, publishAt, unpublishAt int, username string) (*models.Files, error) {
+	if publishAt < 0 || unpublishAt < 0 || (unpublishAt > 0 && unpublishAt <= publishAt) {
+		return nil, ErrInvalidSchedule
+	}
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.files.holds.Check(ctx, file, HoldOperationUpdate, username); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Files().UpdateSchedule(ctx, fileID, publishAt, unpublishAt); err != nil {
+		return nil, err
+	}
+
+	event := &models.WorkflowEvent{
+		FileID:     file.ID,
+		FromStatus: file.Status,
+		ToStatus:   file.Status,
+		Transition: "schedule",
+		Username:   username,
+		Comment: fmt.Sprintf("Publish at %s, unpublish at %s (was %s, %s)",
+			scheduleTime(publishAt), scheduleTime(unpublishAt), scheduleTime(file.PublishAt), scheduleTime(file.UnpublishAt)),
+		Created: int(time.Now().Unix()),
+	}
+	if err := s.repo.WorkflowEvents().Create(ctx, event); err != nil {
+		log.Printf("Failed to record schedule change of file %d in its history: %v", fileID, err)
+	}
+	file.PublishAt = publishAt
+	file.UnpublishAt = unpublishAt
+	log.Printf("Schedule of file %d set by %s: publish at %d, unpublish at %d", fileID, username, publishAt, unpublishAt)
+	return file, nil
+}
+
+// ListScheduled returns files with a publication or withdrawal still to come
+func (s *ScheduleService) ListScheduled(ctx context.Context, limit, offset int) ([]*models.Files, int64, error) {
+	return s.repo.Files().FindScheduled(ctx, int(time.Now().Unix()), limit, offset)
+}
+
+// RunDue publishes and withdraws the files whose scheduled time has passed, in batches over all
+// due files. Files that cannot change status are left for the next run without holding up the
+// others; pending files of workflows with review stages are published by their reviewers instead.
+func (s *ScheduleService) RunDue(ctx context.Context) (published, unpublished int, err error) {
+	now := int(time.Now().Unix())
+	failures := make(map[uint64]string)
+	defer s.keepFailures(failures)
+
+	afterTime, afterID := 0, uint64(0)
+	for {
+		due, err := s.repo.Files().FindDueForPublish(ctx, now, afterTime, afterID, scheduleBatchSize)
+		if err != nil {
+			return published, 0, err
+		}
+		for _, file := range due {
+			comment := fmt.Sprintf("Scheduled publication at %s", time.Unix(int64(file.PublishAt), 0).Format("2006-01-02 15:04:05"))
+			err := s.files.changeStatusAsSystem(ctx, file, models.FileStatusPublished, comment)
+			switch {
+			case err == nil:
+				published++
+			case errors.Is(err, ErrReviewPending):
+				// Still in review; the embargo applies once the reviewers publish it
+			case errors.Is(err, ErrUnresolvedComments):
+				// Published by a later run once its review comments are resolved
+			default:
+				s.reportFailure(failures, file.ID, "Scheduled publication", err)
+			}
+		}
+		if len(due) < scheduleBatchSize {
+			break
+		}
+		afterTime, afterID = due[len(due)-1].PublishAt, due[len(due)-1].ID
+	}
+
+	afterTime, afterID = 0, 0
+	for {
+		due, err := s.repo.Files().FindDueForUnpublish(ctx, now, afterTime, afterID, scheduleBatchSize)
+		if err != nil {
+			return published, unpublished, err
+		}
+		for _, file := range due {
+			comment := fmt.Sprintf("Scheduled withdrawal at %s", time.Unix(int64(file.UnpublishAt), 0).Format("2006-01-02 15:04:05"))
+			if err := s.files.changeStatusAsSystem(ctx, file, models.FileStatusNew, comment); err != nil {
+				s.reportFailure(failures, file.ID, "Scheduled withdrawal", err)
+				continue
+			}
+			// A withdrawal happens once; publishing the file again must not hide it straight away
+			if err := s.repo.Files().UpdateSchedule(ctx, file.ID, file.PublishAt, 0); err != nil {
+				log.Printf("Failed to clear withdrawal time of file %d: %v", file.ID, err)
+			}
+			unpublished++
+		}
+		if len(due) < scheduleBatchSize {
+			break
+		}
+		afterTime, afterID = due[len(due)-1].UnpublishAt, due[len(due)-1].ID
+	}
+	return published, unpublished, nil
+}
+
+// reportFailure logs why a due file could not change status. A failure already logged by the
+// previous run for the same reason is not logged again.
+func (s *ScheduleService) reportFailure(failures map[uint64]string, fileID uint64, action string, err error) {
+	message := fmt.Sprintf("%s of file %d failed: %v", action, fileID, err)
+	failures[fileID] = message
+
+	s.mu.Lock()
+	reported := s.reported[fileID] == message
+	s.mu.Unlock()
+	if !reported {
+		log.Print(message)
+	}
+}
+
+// keepFailures remembers the failures of a run, so files that keep failing are logged once
+func (s *ScheduleService) keepFailures(failures map[uint64]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reported = failures
+}
+
+// scheduleTime formats one end of a publication window for the file's history
+func scheduleTime(at int) string {
+	if at == 0 {
+		return "none"
+	}
+	return time.Unix(int64(at), 0).Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+func TestRunDueWithdrawsUnderDefaultWorkflow(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		stored bool // A default workflow seeded before files could be withdrawn is stored
+	}{
+		{name: "built-in"},
+		{name: "seeded earlier", stored: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestFixture(t)
+			if tc.stored {
+				workflows := NewWorkflowService(f.repo)
+				if err := workflows.CreateWorkflow(f.ctx, seededDefaultWorkflow(t)); err != nil {
+					t.Fatalf("CreateWorkflow returned error: %v", err)
+				}
+				if err := workflows.EnsureDefaults(f.ctx); err != nil {
+					t.Fatalf("EnsureDefaults returned error: %v", err)
+				}
+			}
+			schedule := NewScheduleService(f.repo, NewFilesService(f.repo))
+
+			expired := f.file("expired", models.FileStatusPublished, "editor")
+			if err := f.repo.Files().UpdateSchedule(f.ctx, expired.ID, 0, int(time.Now().Unix())-60); err != nil {
+				t.Fatalf("UpdateSchedule returned error: %v", err)
+			}
+
+			published, unpublished, err := schedule.RunDue(f.ctx)
+			if err != nil {
+				t.Fatalf("RunDue returned error: %v", err)
+			}
+			if published != 0 || unpublished != 1 {
+				t.Fatalf("RunDue = %d published, %d unpublished, want 0 and 1", published, unpublished)
+			}
+
+			stored := f.reload(expired)
+			if stored.Status != models.FileStatusNew || stored.UnpublishAt != 0 {
+				t.Errorf("withdrawn file has status %d and unpublish_at %d, want %d and 0", stored.Status, stored.UnpublishAt, models.FileStatusNew)
+			}
+			events, _, err := f.repo.WorkflowEvents().FindAll(f.ctx, repository.WorkflowEventFilter{FileID: expired.ID}, 10, 0)
+			if err != nil {
+				t.Fatalf("FindAll returned error: %v", err)
+			}
+			if len(events) != 1 || events[0].Transition != "unpublish" || events[0].Username != SystemActor {
+				t.Errorf("history = %+v, want one unpublish event by %s", events, SystemActor)
+			}
+		})
+	}
+}
+
+func TestRunDuePagesThroughBatches(t *testing.T) {
+	f := newTestFixture(t)
+	schedule := NewScheduleService(f.repo, NewFilesService(f.repo))
+
+	// More files than one batch; withdrawals share one time so paging relies on the ID
+	count := scheduleBatchSize + 50
+	past := int(time.Now().Unix()) - 3600
+	var due []*models.Files
+	for i := 0; i < count; i++ {
+		launch := f.file(fmt.Sprintf("launch-%d", i), models.FileStatusPending, "editor")
+		if err := f.repo.Files().UpdateSchedule(f.ctx, launch.ID, past+i, 0); err != nil {
+			t.Fatalf("UpdateSchedule returned error: %v", err)
+		}
+		expired := f.file(fmt.Sprintf("expired-%d", i), models.FileStatusPublished, "editor")
+		if err := f.repo.Files().UpdateSchedule(f.ctx, expired.ID, 0, past); err != nil {
+			t.Fatalf("UpdateSchedule returned error: %v", err)
+		}
+		due = append(due, launch, expired)
+	}
+
+	published, unpublished, err := schedule.RunDue(f.ctx)
+	if err != nil {
+		t.Fatalf("RunDue returned error: %v", err)
+	}
+	if published != count || unpublished != count {
+		t.Fatalf("RunDue = %d published, %d unpublished, want %d and %d", published, unpublished, count, count)
+	}
+	for i, file := range due {
+		want := models.FileStatusPublished
+		if i%2 == 1 {
+			want = models.FileStatusNew
+		}
+		if stored := f.reload(file); stored.Status != want {
+			t.Errorf("file %s has status %d, want %d", file.Name, stored.Status, want)
+		}
+	}
+}
+
+func TestSetScheduleRecordsHistory(t *testing.T) {
+	f := newTestFixture(t)
+	schedule := NewScheduleService(f.repo, NewFilesService(f.repo))
+	file := f.file("launch", models.FileStatusPending, "editor")
+
+	publishAt := int(time.Date(2030, 1, 2, 9, 0, 0, 0, time.Local).Unix())
+	if _, err := schedule.SetSchedule(f.ctx, file.ID, publishAt, 0, "editor"); err != nil {
+		t.Fatalf("SetSchedule returned error: %v", err)
+	}
+	if stored := f.reload(file); stored.PublishAt != publishAt || stored.UnpublishAt != 0 {
+		t.Fatalf("stored window is %d-%d, want %d-0", stored.PublishAt, stored.UnpublishAt, publishAt)
+	}
+
+	events, _, err := f.repo.WorkflowEvents().FindAll(f.ctx, repository.WorkflowEventFilter{FileID: file.ID}, 10, 0)
+	if err != nil {
+		t.Fatalf("FindAll returned error: %v", err)
+	}
+	want := "Publish at 2030-01-02 09:00:00, unpublish at none (was none, none)"
+	if len(events) != 1 || events[0].Transition != "schedule" || events[0].Username != "editor" || events[0].Comment != want {
+		t.Fatalf("history = %+v, want one schedule event by editor with comment %q", events, want)
+	}
+	if events[0].FromStatus != models.FileStatusPending || events[0].ToStatus != models.FileStatusPending {
+		t.Errorf("schedule event changes status %d to %d", events[0].FromStatus, events[0].ToStatus)
+	}
+}
+
+func TestSetScheduleRefusesHeldFile(t *testing.T) {
+	f := newTestFixture(t)
+	schedule := NewScheduleService(f.repo, NewFilesService(f.repo))
+	file := f.file("evidence", models.FileStatusPublished, "editor")
+	f.hold(file)
+
+	_, err := schedule.SetSchedule(f.ctx, file.ID, 0, int(time.Now().Unix())+3600, "editor")
+	var holdErr *LegalHoldError
+	if !errors.As(err, &holdErr) {
+		t.Fatalf("SetSchedule returned %v, want *LegalHoldError", err)
+	}
+	if stored := f.reload(file); stored.UnpublishAt != 0 {
+		t.Errorf("held file got unpublish_at %d", stored.UnpublishAt)
+	}
+}
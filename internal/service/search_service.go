@@ -82,6 +82,8 @@ type SearchParams struct {
 	Page       int
 	PageSize   int
 	SortBy     string
+
+	IncludeEmbargoed bool // Also find files outside their publication window, for reviewers
 }
 
 // Search performs full-text search using Sphinx
@@ -123,6 +125,10 @@ func (s *SearchService) Search(ctx context.Context, params SearchParams) ([]Sear
 		SortBy:     params.SortBy,
 	}
 
+	if !params.IncludeEmbargoed {
+		repoParams.VisibleAt = time.Now()
+	}
+
 	// Execute search
 	rows, err := s.searchRepo.ExecuteSearch(ctx, repoParams)
 	if err != nil {
@@ -158,7 +164,11 @@ func (s *SearchService) Search(ctx context.Context, params SearchParams) ([]Sear
 		}
 
 		// Get full file details from database
-		if file, err := s.filesRepo.FindByID(ctx, row.ID); err == nil {
+		file, err := s.filesRepo.FindByID(ctx, row.ID)
+		if err == nil && !params.IncludeEmbargoed && file.Embargoed(time.Now().Unix()) {
+			continue // Schedule changed after the file was indexed
+		}
+		if err == nil {
 			result.Description = file.Title // Or extract from catalog_info
 			result.Size = file.Size
 			result.Ext = file.Ext
@@ -249,6 +259,9 @@ func (s *SearchService) fallbackSearch(ctx context.Context, params SearchParams)
 	if params.DateTo != "" {
 		filters["upload_date_to"] = params.DateTo
 	}
+	if !params.IncludeEmbargoed {
+		filters["visible_at"] = time.Now().Unix()
+	}
 	
 	// Calculate offset
 	offset := (params.Page - 1) * params.PageSize
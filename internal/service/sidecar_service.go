@@ -0,0 +1,522 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Sidecar mapping targets that set Files columns; catalog_info keys use CatalogTargetPrefix
+const (
+	SidecarTargetTitle  = "title"
+	SidecarTargetLevel  = "level"
+	SidecarTargetGroups = "groups"
+	CatalogTargetPrefix = "catalog."
+)
+
+// ErrInvalidSidecarTemplate is returned when a sidecar template definition is rejected
+var ErrInvalidSidecarTemplate = errors.New("invalid sidecar template")
+
+// sidecarExtensions maps sidecar file extensions to formats
+var sidecarExtensions = map[string]string{
+	".xml":  models.SidecarFormatXML,
+	".json": models.SidecarFormatJSON,
+	".csv":  models.SidecarFormatCSV,
+}
+
+// SidecarInput is a metadata sidecar delivered with an uploaded file
+type SidecarInput struct {
+	Filename string
+	Content  []byte
+	Template string // Mapping template name
+}
+
+// SidecarFieldError describes one sidecar field that could not be applied
+type SidecarFieldError struct {
+	Field   string `json:"field"`            // Mapping target
+	Source  string `json:"source,omitempty"` // Sidecar field path
+	Message string `json:"message"`
+}
+
+// SidecarValidationError is returned when a sidecar cannot be parsed or fails validation
+type SidecarValidationError struct {
+	Sidecar string
+	Errors  []SidecarFieldError
+}
+
+func (e *SidecarValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		if fieldErr.Field != "" {
+			messages = append(messages, fieldErr.Field+": "+fieldErr.Message)
+		} else {
+			messages = append(messages, fieldErr.Message)
+		}
+	}
+	return fmt.Sprintf("invalid sidecar %s: %s", e.Sidecar, strings.Join(messages, "; "))
+}
+
+// SidecarResult holds the file attributes mapped from a sidecar; empty values were not mapped
+type SidecarResult struct {
+	Template    string            `json:"template"`
+	Title       string            `json:"title,omitempty"`
+	Level       int               `json:"level,omitempty"`
+	Groups      string            `json:"groups,omitempty"`
+	CatalogInfo map[string]string `json:"catalog_info"`
+}
+
+// SidecarService manages sidecar mapping templates and applies them to delivered sidecars
+type SidecarService struct {
+	repo repository.Repository
+}
+
+// NewSidecarService creates a new sidecar service
+func NewSidecarService(repo repository.Repository) *SidecarService {
+	return &SidecarService{repo: repo}
+}
+
+// ListTemplates returns all sidecar templates
+func (s *SidecarService) ListTemplates(ctx context.Context) ([]*models.SidecarTemplate, error) {
+	return s.repo.SidecarTemplates().FindAll(ctx)
+}
+
+// GetTemplate returns a sidecar template by ID
+func (s *SidecarService) GetTemplate(ctx context.Context, id int) (*models.SidecarTemplate, error) {
+	return s.repo.SidecarTemplates().FindByID(ctx, id)
+}
+
+// CreateTemplate validates and stores a new sidecar template
+func (s *SidecarService) CreateTemplate(ctx context.Context, template *models.SidecarTemplate) error {
+	if err := s.validateTemplate(ctx, template); err != nil {
+		return err
+	}
+	now := int(time.Now().Unix())
+	template.Created = now
+	template.Updated = now
+	return s.repo.SidecarTemplates().Create(ctx, template)
+}
+
+// UpdateTemplate validates and stores changes to an existing sidecar template
+func (s *SidecarService) UpdateTemplate(ctx context.Context, template *models.SidecarTemplate) error {
+	if err := s.validateTemplate(ctx, template); err != nil {
+		return err
+	}
+	template.Updated = int(time.Now().Unix())
+	return s.repo.SidecarTemplates().Update(ctx, template)
+}
+
+// DeleteTemplate deletes a sidecar template
+func (s *SidecarService) DeleteTemplate(ctx context.Context, id int) error {
+	return s.repo.SidecarTemplates().Delete(ctx, id)
+}
+
+// Apply parses a sidecar with the named template and returns the mapped file attributes.
+// Parse and validation failures are returned as *SidecarValidationError.
+func (s *SidecarService) Apply(ctx context.Context, input *SidecarInput) (*SidecarResult, error) {
+	if input.Template == "" {
+		return nil, sidecarError(input.Filename, "", "", "no sidecar template specified")
+	}
+
+	template, err := s.repo.SidecarTemplates().FindByName(ctx, input.Template)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, sidecarError(input.Filename, "", "", fmt.Sprintf("unknown sidecar template: %s", input.Template))
+		}
+		return nil, fmt.Errorf("failed to load sidecar template: %w", err)
+	}
+	if !template.Enabled {
+		return nil, sidecarError(input.Filename, "", "", fmt.Sprintf("sidecar template %s is disabled", template.Name))
+	}
+
+	return s.ApplyTemplate(ctx, template, input.Filename, input.Content)
+}
+
+// ApplyTemplate parses a sidecar with the given template and returns the mapped file attributes
+func (s *SidecarService) ApplyTemplate(ctx context.Context, template *models.SidecarTemplate, filename string, content []byte) (*SidecarResult, error) {
+	if format, ok := sidecarExtensions[strings.ToLower(filepath.Ext(filename))]; ok && format != template.Format {
+		return nil, sidecarError(filename, "", "", fmt.Sprintf("template %s expects a %s sidecar", template.Name, template.Format))
+	}
+
+	document, err := parseSidecar(template.Format, content)
+	if err != nil {
+		return nil, sidecarError(filename, "", "", err.Error())
+	}
+
+	mappings, err := template.GetMappings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mappings of sidecar template %s: %w", template.Name, err)
+	}
+	catalogs, err := s.catalogFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SidecarResult{
+		Template:    template.Name,
+		CatalogInfo: make(map[string]string),
+	}
+	validation := &SidecarValidationError{Sidecar: filename}
+
+	for _, mapping := range mappings {
+		value := strings.Join(lookupSidecarPath(document, splitSidecarPath(mapping.Source)), ", ")
+		if value == "" {
+			value = mapping.Default
+		}
+		if value == "" {
+			if mapping.Required {
+				validation.Errors = append(validation.Errors, SidecarFieldError{
+					Field:   mapping.Target,
+					Source:  mapping.Source,
+					Message: "required field is missing",
+				})
+			}
+			continue
+		}
+
+		if message := applySidecarValue(result, catalogs, mapping.Target, value); message != "" {
+			validation.Errors = append(validation.Errors, SidecarFieldError{
+				Field:   mapping.Target,
+				Source:  mapping.Source,
+				Message: message,
+			})
+		}
+	}
+
+	if len(validation.Errors) > 0 {
+		return nil, validation
+	}
+	return result, nil
+}
+
+// MergeCatalogInfo merges sidecar values over an existing catalog_info JSON document
+func (r *SidecarResult) MergeCatalogInfo(catalogInfo string) (string, error) {
+	if len(r.CatalogInfo) == 0 {
+		return catalogInfo, nil
+	}
+
+	merged := make(map[string]string)
+	if catalogInfo != "" {
+		if err := json.Unmarshal([]byte(catalogInfo), &merged); err != nil {
+			return "", fmt.Errorf("invalid catalog metadata: %w", err)
+		}
+	}
+	for key, value := range r.CatalogInfo {
+		merged[key] = value
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// IsSidecarFile reports whether a filename has a sidecar extension (.xml, .json, .csv)
+func IsSidecarFile(filename string) bool {
+	_, ok := sidecarExtensions[strings.ToLower(filepath.Ext(filename))]
+	return ok
+}
+
+// validateTemplate checks the template format and that every mapping targets a known field
+func (s *SidecarService) validateTemplate(ctx context.Context, template *models.SidecarTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	template.Format = strings.ToLower(strings.TrimSpace(template.Format))
+	if template.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSidecarTemplate)
+	}
+	switch template.Format {
+	case models.SidecarFormatXML, models.SidecarFormatJSON, models.SidecarFormatCSV:
+	default:
+		return fmt.Errorf("%w: unsupported format %q (expected xml, json or csv)", ErrInvalidSidecarTemplate, template.Format)
+	}
+
+	mappings, err := template.GetMappings()
+	if err != nil {
+		return fmt.Errorf("%w: mappings must be a JSON array: %v", ErrInvalidSidecarTemplate, err)
+	}
+	if len(mappings) == 0 {
+		return fmt.Errorf("%w: at least one mapping is required", ErrInvalidSidecarTemplate)
+	}
+
+	catalogs, err := s.catalogFields(ctx)
+	if err != nil {
+		return err
+	}
+
+	targets := make(map[string]bool, len(mappings))
+	for i, mapping := range mappings {
+		if strings.TrimSpace(mapping.Source) == "" {
+			return fmt.Errorf("%w: mapping %d has no source", ErrInvalidSidecarTemplate, i+1)
+		}
+		if targets[mapping.Target] {
+			return fmt.Errorf("%w: target %s is mapped more than once", ErrInvalidSidecarTemplate, mapping.Target)
+		}
+		targets[mapping.Target] = true
+
+		switch mapping.Target {
+		case SidecarTargetTitle, SidecarTargetLevel, SidecarTargetGroups:
+		default:
+			key := strings.TrimPrefix(mapping.Target, CatalogTargetPrefix)
+			if !strings.HasPrefix(mapping.Target, CatalogTargetPrefix) || key == "" {
+				return fmt.Errorf("%w: unknown target %q (expected title, level, groups or catalog.<name>)", ErrInvalidSidecarTemplate, mapping.Target)
+			}
+			if _, ok := catalogs[key]; !ok {
+				return fmt.Errorf("%w: %s is not an enabled catalog field", ErrInvalidSidecarTemplate, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// catalogFields returns the enabled catalog fields by name
+func (s *SidecarService) catalogFields(ctx context.Context) (map[string]*models.Catalog, error) {
+	catalogs, err := s.repo.Catalog().BuildTree(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog fields: %w", err)
+	}
+
+	fields := make(map[string]*models.Catalog, len(catalogs))
+	for _, catalog := range catalogs {
+		if _, exists := fields[catalog.Name]; !exists {
+			fields[catalog.Name] = catalog
+		}
+	}
+	return fields, nil
+}
+
+// applySidecarValue validates a mapped value and stores it in the result, returning an error message
+func applySidecarValue(result *SidecarResult, catalogs map[string]*models.Catalog, target, value string) string {
+	switch target {
+	case SidecarTargetTitle:
+		if len(value) > 255 {
+			return "title exceeds 255 characters"
+		}
+		result.Title = value
+	case SidecarTargetLevel:
+		level, err := strconv.Atoi(value)
+		if err != nil || level <= 0 {
+			return fmt.Sprintf("level must be a positive integer, got %q", value)
+		}
+		result.Level = level
+	case SidecarTargetGroups:
+		if value != "all" {
+			for _, part := range strings.Split(value, ",") {
+				if _, err := strconv.Atoi(strings.TrimSpace(part)); err != nil {
+					return fmt.Sprintf("groups must be \"all\" or comma-separated group IDs, got %q", value)
+				}
+			}
+		}
+		result.Groups = value
+	default:
+		key := strings.TrimPrefix(target, CatalogTargetPrefix)
+		catalog, ok := catalogs[key]
+		if !ok {
+			return fmt.Sprintf("%s is not an enabled catalog field", key)
+		}
+		if message := validateCatalogValue(catalog, value); message != "" {
+			return message
+		}
+		result.CatalogInfo[key] = value
+	}
+	return ""
+}
+
+// validateCatalogValue checks a value against the catalog field type
+func validateCatalogValue(catalog *models.Catalog, value string) string {
+	switch catalog.FieldType {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("%s must be a number, got %q", catalog.Name, value)
+		}
+	case "date":
+		for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+			if _, err := time.Parse(layout, value); err == nil {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s must be a date (YYYY-MM-DD), got %q", catalog.Name, value)
+	case "select":
+		var options []string
+		if catalog.Options == "" || json.Unmarshal([]byte(catalog.Options), &options) != nil {
+			return ""
+		}
+		for _, option := range options {
+			if option == value {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s must be one of %s, got %q", catalog.Name, strings.Join(options, ", "), value)
+	}
+	return ""
+}
+
+// sidecarError builds a validation error with a single message
+func sidecarError(sidecar, field, source, message string) *SidecarValidationError {
+	return &SidecarValidationError{
+		Sidecar: sidecar,
+		Errors:  []SidecarFieldError{{Field: field, Source: source, Message: message}},
+	}
+}
+
+// parseSidecar decodes a sidecar into nested maps and slices addressed by lookupSidecarPath
+func parseSidecar(format string, content []byte) (interface{}, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+
+	switch format {
+	case models.SidecarFormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return document, nil
+	case models.SidecarFormatXML:
+		return parseXMLSidecar(content)
+	case models.SidecarFormatCSV:
+		return parseCSVSidecar(content)
+	default:
+		return nil, fmt.Errorf("unsupported sidecar format: %s", format)
+	}
+}
+
+// parseXMLSidecar converts an XML document into maps keyed by element name. Attributes are keyed
+// "@name", repeated elements become slices, and the root element itself is not part of the path.
+func parseXMLSidecar(content []byte) (interface{}, error) {
+	type element struct {
+		fields map[string]interface{}
+		text   strings.Builder
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var stack []*element
+	var root interface{}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &element{fields: make(map[string]interface{})}
+			for _, attr := range t.Attr {
+				el.fields["@"+attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, el)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			el := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			var value interface{}
+			text := strings.TrimSpace(el.text.String())
+			if len(el.fields) == 0 {
+				value = text
+			} else {
+				if text != "" {
+					el.fields["#text"] = text
+				}
+				value = el.fields
+			}
+
+			if len(stack) == 0 {
+				root = value
+				continue
+			}
+			parent := stack[len(stack)-1].fields
+			name := t.Name.Local
+			switch existing := parent[name].(type) {
+			case nil:
+				parent[name] = value
+			case []interface{}:
+				parent[name] = append(existing, value)
+			default:
+				parent[name] = []interface{}{existing, value}
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("invalid XML: no root element")
+	}
+	return root, nil
+}
+
+// parseCSVSidecar reads a header row and a single data row into a map keyed by column name
+func parseCSVSidecar(content []byte) (interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) != 2 {
+		return nil, fmt.Errorf("CSV sidecar must contain a header row and exactly one data row, got %d rows", len(records))
+	}
+
+	document := make(map[string]interface{}, len(records[0]))
+	for i, column := range records[0] {
+		document[strings.TrimSpace(column)] = records[1][i]
+	}
+	return document, nil
+}
+
+// splitSidecarPath splits a dotted field path; "/" is accepted as separator for XML-style paths
+func splitSidecarPath(path string) []string {
+	return strings.FieldsFunc(strings.TrimSpace(path), func(r rune) bool {
+		return r == '.' || r == '/'
+	})
+}
+
+// lookupSidecarPath returns the non-empty values at path; lists are searched element by element
+func lookupSidecarPath(value interface{}, path []string) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, lookupSidecarPath(item, path)...)
+		}
+		return values
+	case map[string]interface{}:
+		if len(path) == 0 {
+			if text, ok := v["#text"]; ok {
+				return lookupSidecarPath(text, nil)
+			}
+			return nil
+		}
+		return lookupSidecarPath(v[path[0]], path[1:])
+	case nil:
+		return nil
+	default:
+		if len(path) > 0 {
+			return nil
+		}
+		text := strings.TrimSpace(fmt.Sprint(v))
+		if text == "" {
+			return nil
+		}
+		return []string{text}
+	}
+}
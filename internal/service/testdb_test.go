@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDriver is a SQLite driver with the MySQL functions the repositories use
+const testDriver = "sqlite3_openwan"
+
+var registerTestDriver sync.Once
+
+// newTestDB returns a fresh SQLite database with all tables migrated
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	registerTestDriver.Do(func() {
+		sql.Register(testDriver, &sqlite3.SQLiteDriver{ConnectHook: registerMySQLFunctions})
+	})
+
+	dsn := filepath.Join(t.TempDir(), "openwan.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Dialector{DriverName: testDriver, DSN: dsn}, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.Files{}, &models.Catalog{}, &models.Category{}, &models.Users{}, &models.Groups{},
+		&models.Roles{}, &models.Permissions{}, &models.Levels{}, &models.GroupsHasCategory{},
+		&models.GroupsHasRoles{}, &models.RolesHasPermissions{}, &models.StorageReplica{},
+		&models.StorageUsage{}, &models.Blob{}, &models.FileVersion{}, &models.Workflow{},
+		&models.WorkflowEvent{}, &models.ReviewTask{}, &models.ReviewAssignee{}, &models.ReviewVote{},
+		&models.LegalHold{}, &models.LegalHoldEvent{}, &models.BulkJob{}, &models.BulkJobItem{},
+		&models.ReviewAssignment{}, &models.Notification{}, &models.ReviewComment{},
+	)
+	if err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// registerMySQLFunctions adds the MySQL functions used in queries to a SQLite connection
+func registerMySQLFunctions(conn *sqlite3.SQLiteConn) error {
+	functions := map[string]any{
+		"UNIX_TIMESTAMP": func() int64 { return time.Now().Unix() },
+		"NOW":            func() string { return time.Now().Format("2006-01-02 15:04:05") },
+		"GREATEST":       func(a, b int64) int64 { return max(a, b) },
+		"IF": func(condition bool, then, otherwise int64) int64 {
+			if condition {
+				return then
+			}
+			return otherwise
+		},
+		"FIND_IN_SET": func(needle, set string) int64 {
+			for i, item := range strings.Split(set, ",") {
+				if item == needle {
+					return int64(i + 1)
+				}
+			}
+			return 0
+		},
+	}
+	for name, function := range functions {
+		if err := conn.RegisterFunc(name, function, name != "UNIX_TIMESTAMP" && name != "NOW"); err != nil {
+			return fmt.Errorf("failed to register %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// testFixture creates the records a test needs and fails the test on errors
+type testFixture struct {
+	t    *testing.T
+	ctx  context.Context
+	db   *gorm.DB
+	repo repository.Repository
+}
+
+func newTestFixture(t *testing.T) *testFixture {
+	db := newTestDB(t)
+	return &testFixture{t: t, ctx: context.Background(), db: db, repo: repository.NewRepository(db)}
+}
+
+// create stores records the repositories cannot create, such as relationship rows
+func (f *testFixture) create(value interface{}) {
+	f.t.Helper()
+	if err := f.db.Create(value).Error; err != nil {
+		f.t.Fatalf("failed to create %T: %v", value, err)
+	}
+}
+
+// user creates a user in a new group whose role has the given permissions
+func (f *testFixture) user(username string, permissions ...string) *models.Users {
+	f.t.Helper()
+	role := &models.Roles{Name: username + "-role"}
+	if err := f.repo.Roles().Create(f.ctx, role); err != nil {
+		f.t.Fatalf("failed to create role: %v", err)
+	}
+	for _, name := range permissions {
+		f.create(&models.RolesHasPermissions{RoleID: role.ID, PermissionID: f.permission(name).ID})
+	}
+	group := &models.Groups{Name: username + "-group"}
+	if err := f.repo.Groups().Create(f.ctx, group); err != nil {
+		f.t.Fatalf("failed to create group: %v", err)
+	}
+	f.create(&models.GroupsHasRoles{GroupID: group.ID, RoleID: role.ID})
+	user := &models.Users{Username: username, GroupID: group.ID, Enabled: true}
+	if err := f.repo.Users().Create(f.ctx, user); err != nil {
+		f.t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// permission creates a permission from a namespace.controller.action name
+func (f *testFixture) permission(name string) *models.Permissions {
+	f.t.Helper()
+	parts := strings.SplitN(name, ".", 3)
+	if len(parts) != 3 {
+		f.t.Fatalf("invalid permission name %q", name)
+	}
+	permission := &models.Permissions{Namespace: parts[0], Controller: parts[1], Action: parts[2], Aliasname: name}
+	if err := f.repo.Permissions().Create(f.ctx, permission); err != nil {
+		f.t.Fatalf("failed to create permission %s: %v", name, err)
+	}
+	return permission
+}
+
+// file creates a file with the given status, uploaded by username
+func (f *testFixture) file(name string, status int, username string) *models.Files {
+	f.t.Helper()
+	file := &models.Files{
+		Name:           name,
+		Title:          name,
+		Ext:            ".mp4",
+		Type:           models.FileTypeVideo,
+		Path:           "videos/" + name + ".mp4",
+		Size:           1024,
+		Status:         status,
+		UploadUsername: username,
+		UploadAt:       int(time.Now().Unix()),
+	}
+	if err := f.repo.Files().Create(f.ctx, file); err != nil {
+		f.t.Fatalf("failed to create file %s: %v", name, err)
+	}
+	return file
+}
+
+// reload returns the stored state of a file
+func (f *testFixture) reload(file *models.Files) *models.Files {
+	f.t.Helper()
+	stored, err := f.repo.Files().FindByID(f.ctx, file.ID)
+	if err != nil {
+		f.t.Fatalf("failed to reload file %d: %v", file.ID, err)
+	}
+	return stored
+}
+
+// hold places a legal hold on a file
+func (f *testFixture) hold(file *models.Files) *models.LegalHold {
+	f.t.Helper()
+	hold, err := NewLegalHoldService(f.repo).Apply(f.ctx, &LegalHoldRequest{FileID: file.ID, CaseReference: "CASE-1"}, "counsel")
+	if err != nil {
+		f.t.Fatalf("failed to place legal hold on file %d: %v", file.ID, err)
+	}
+	return hold
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// ErrFileNotInTrash is returned when restoring or purging a file that is not in the trash
+var ErrFileNotInTrash = errors.New("file is not in the trash")
+
+// trashBatchSize is the number of trashed files the purger loads at a time
+const trashBatchSize = 100
+
+// TrashedFile is a file in the trash with the time the purger removes it
+type TrashedFile struct {
+	*models.Files
+	PurgeAt int `json:"purge_at"` // Unix timestamp; 0 when the category keeps deleted files forever
+}
+
+// TrashService manages deleted files. DeleteFile moves files to the trash; they can be restored
+// through the workflow until they are purged by hand or once their category's retention passed.
+// Purging removes the record with its versions, stored objects and derivatives.
+type TrashService struct {
+	repo    repository.Repository
+	files   *FilesService
+	storage storage.StorageService
+}
+
+// NewTrashService creates a new trash service
+func NewTrashService(repo repository.Repository, files *FilesService, storageService storage.StorageService) *TrashService {
+	return &TrashService{
+		repo:    repo,
+		files:   files,
+		storage: storageService,
+	}
+}
+
+// List returns the files in the trash, most recently deleted first
+func (s *TrashService) List(ctx context.Context, filter repository.TrashFilter, limit, offset int) ([]*TrashedFile, int64, error) {
+	files, total, err := s.repo.Files().FindTrashed(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	retentions := map[int]int{}
+	trashed := make([]*TrashedFile, 0, len(files))
+	for _, file := range files {
+		trashed = append(trashed, &TrashedFile{
+			Files:   file,
+			PurgeAt: purgeAt(file, s.cachedRetention(ctx, retentions, file.CategoryID)),
+		})
+	}
+	return trashed, total, nil
+}
+
+// Restore takes a file out of the trash through its workflow's transition from deleted to pending
+// review. It returns the errors of FilesService.ChangeStatus.
+func (s *TrashService) Restore(ctx context.Context, fileID uint64, username string) (*models.Files, error) {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Status != models.FileStatusDeleted {
+		return nil, ErrFileNotInTrash
+	}
+	if err := s.files.changeStatus(ctx, file, models.FileStatusPending, username, "Restored from trash", false); err != nil {
+		return nil, err
+	}
+	file.DeletedAt = 0
+	file.DeletedBy = ""
+	return file, nil
+}
+
+// Purge permanently removes a file in the trash with its versions, stored objects and derivatives.
+// Files under legal hold return *LegalHoldError.
+func (s *TrashService) Purge(ctx context.Context, fileID uint64, username string) error {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if file.Status != models.FileStatusDeleted {
+		return ErrFileNotInTrash
+	}
+	if err := s.files.holds.Check(ctx, file, HoldOperationPurge, username); err != nil {
+		return err
+	}
+	return s.purge(ctx, file, username, "")
+}
+
+// PurgeExpired purges the files whose category's trash retention has passed and returns how many
+// were removed. Files under legal hold are kept.
+func (s *TrashService) PurgeExpired(ctx context.Context) (int, error) {
+	if err := s.checkStorage(); err != nil {
+		return 0, err
+	}
+	now := int(time.Now().Unix())
+	// No retention is shorter than a day
+	deletedBefore := now - 24*60*60
+
+	retentions := map[int]int{}
+	purged := 0
+	var lastID uint64
+	for {
+		files, err := s.repo.Files().FindTrashedAfterID(ctx, lastID, deletedBefore, trashBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, file := range files {
+			lastID = file.ID
+			at := purgeAt(file, s.cachedRetention(ctx, retentions, file.CategoryID))
+			if at == 0 || at > now {
+				continue
+			}
+			// Held files stay in the trash until their holds are released
+			if holds, err := s.files.holds.HoldsOn(ctx, file); err != nil || len(holds) > 0 {
+				continue
+			}
+			if err := s.purge(ctx, file, SystemActor, "Trash retention expired"); err != nil {
+				log.Printf("Failed to purge file %d from the trash: %v", file.ID, err)
+				continue
+			}
+			purged++
+		}
+		if len(files) < trashBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Retention returns the trash retention in days of the category or its nearest ancestor that
+// sets one, or TrashRetentionForever
+func (s *TrashService) Retention(ctx context.Context, categoryID int) int {
+	ancestors, err := categoryAncestors(ctx, s.repo.Category(), categoryID)
+	if err != nil {
+		return models.DefaultTrashRetentionDays
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		category, err := s.repo.Category().FindByID(ctx, ancestors[i])
+		if err != nil {
+			continue
+		}
+		if category.TrashRetentionDays != 0 {
+			return category.TrashRetentionDays
+		}
+	}
+	return models.DefaultTrashRetentionDays
+}
+
+func (s *TrashService) cachedRetention(ctx context.Context, retentions map[int]int, categoryID int) int {
+	days, ok := retentions[categoryID]
+	if !ok {
+		days = s.Retention(ctx, categoryID)
+		retentions[categoryID] = days
+	}
+	return days
+}
+
+// purge removes a trashed file's record unless it was restored meanwhile, then its stored objects,
+// and records the purge in the file's history
+func (s *TrashService) purge(ctx context.Context, file *models.Files, username, comment string) error {
+	if err := s.checkStorage(); err != nil {
+		return err
+	}
+
+	// Earlier versions' objects are released with the file
+	priorPaths := s.files.PriorVersionPaths(ctx, file)
+
+	removed, err := s.repo.Files().DeleteTrashed(ctx, file.ID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrFileNotInTrash
+	}
+	s.files.releaseRecords(ctx, file)
+
+	if s.storage != nil {
+		releaseStoredObjects(ctx, s.storage, file)
+		for _, path := range priorPaths {
+			prior := *file
+			prior.Path = path
+			releaseStoredObjects(ctx, s.storage, &prior)
+		}
+	}
+
+	event := &models.WorkflowEvent{
+		FileID:     file.ID,
+		FromStatus: models.FileStatusDeleted,
+		ToStatus:   models.FileStatusDeleted,
+		Transition: "purge",
+		Username:   username,
+		Comment:    comment,
+		Created:    int(time.Now().Unix()),
+	}
+	if err := s.repo.WorkflowEvents().Create(ctx, event); err != nil {
+		log.Printf("Failed to record purge of file %d in its history: %v", file.ID, err)
+	}
+	log.Printf("Purged file %d from the trash by %s", file.ID, username)
+	return nil
+}
+
+// checkStorage refuses purging from content-addressed storage that cannot count references in the
+// database: the record would be removed while the shared objects it used could not be released
+func (s *TrashService) checkStorage() error {
+	if casStorage, ok := s.storage.(*storage.ContentAddressedStorage); ok && !casStorage.HasIndex() {
+		return storage.ErrNoBlobIndex
+	}
+	return nil
+}
+
+// purgeAt returns when a trashed file is purged under a retention in days, or 0 for never
+func purgeAt(file *models.Files, retentionDays int) int {
+	if retentionDays <= 0 {
+		return 0
+	}
+	return file.DeletedAt + retentionDays*24*60*60
+}
+
+// releaseStoredObjects deletes the original and preview objects of a removed file. The original is
+// released first so a shared preview is kept while other files still reference the content;
+// content-addressed objects are only removed with their last reference.
+func releaseStoredObjects(ctx context.Context, store storage.StorageService, file *models.Files) {
+	if err := store.Delete(ctx, file.Path); err != nil {
+		log.Printf("Failed to delete stored object %s of file %d: %v", file.Path, file.ID, err)
+	}
+	if file.Type == models.FileTypeVideo || file.Type == models.FileTypeAudio {
+		previewPath := strings.TrimSuffix(file.Path, filepath.Ext(file.Path)) + "-preview.flv"
+		if exists, err := store.Exists(ctx, previewPath); err == nil && exists {
+			if err := store.Delete(ctx, previewPath); err != nil {
+				log.Printf("Failed to delete preview %s of file %d: %v", previewPath, file.ID, err)
+			}
+		}
+	}
+}
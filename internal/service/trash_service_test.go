@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+func TestTrashKeepsHeldFiles(t *testing.T) {
+	f := newTestFixture(t)
+	store := storage.NewMemoryStorage()
+	service := NewTrashService(f.repo, NewFilesService(f.repo), store)
+
+	// Both files were deleted long past the default retention
+	deletedAt := int(time.Now().AddDate(0, 0, -2*models.DefaultTrashRetentionDays).Unix())
+	trashed := func(name string) *models.Files {
+		file := f.file(name, models.FileStatusDeleted, "uploader")
+		if err := f.db.Model(file).Update("deleted_at", deletedAt).Error; err != nil {
+			t.Fatalf("failed to backdate deletion: %v", err)
+		}
+		if _, err := store.Upload(f.ctx, file.Path, strings.NewReader(name), nil); err != nil {
+			t.Fatalf("failed to store %s: %v", file.Path, err)
+		}
+		return file
+	}
+	held := trashed("held")
+	expired := trashed("expired")
+	f.hold(held)
+
+	var holdErr *LegalHoldError
+	if err := service.Purge(f.ctx, held.ID, "admin"); !errors.As(err, &holdErr) {
+		t.Fatalf("Purge() of a held file error = %v, want *LegalHoldError", err)
+	}
+
+	purged, err := service.PurgeExpired(f.ctx)
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeExpired() = %d, want 1", purged)
+	}
+
+	if stored := f.reload(held); stored.Status != models.FileStatusDeleted {
+		t.Errorf("held file status = %d, want it kept in the trash", stored.Status)
+	}
+	if exists, _ := store.Exists(f.ctx, held.Path); !exists {
+		t.Errorf("object of the held file was deleted")
+	}
+	if _, err := f.repo.Files().FindByID(f.ctx, expired.ID); err == nil {
+		t.Errorf("expired file %d was not purged", expired.ID)
+	}
+	if exists, _ := store.Exists(f.ctx, expired.Path); exists {
+		t.Errorf("object of the expired file was kept")
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// TranscodeQueueName is the queue consumed by transcoding workers
+const TranscodeQueueName = "openwan_transcoding_jobs"
+
+// UploadRequest describes a file to be stored and registered as a new asset
+type UploadRequest struct {
+	Filename    string // Original filename; its extension selects the file type rule
+	Content     io.ReadSeeker
+	Size        int64
+	ContentType string // Client-supplied; the recorded MIME type is detected from the content
+	CategoryID  int
+	Title       string
+	Type        int    // 0 = detect from extension
+	Level       int    // 0 = default level 1
+	Groups      string // "" = all groups
+	CatalogInfo string // JSON catalog metadata
+	Username    string
+	Metadata    map[string]string // Additional storage metadata
+	Sidecar     *SidecarInput     // Optional metadata sidecar; mapped values override the fields above
+
+	SkipQuotaCheck bool // Administrative imports are not limited by quotas; usage is still recorded
+}
+
+// UploadValidationError is returned when an upload is rejected before it reaches storage
+type UploadValidationError struct {
+	Message string
+}
+
+func (e *UploadValidationError) Error() string {
+	return e.Message
+}
+
+// TranscodeFallback transcodes a file in-process when the transcoding queue is unavailable
+type TranscodeFallback func(file *models.Files, storageType string)
+
+// UploadService implements the upload path shared by the API and the ingest tools:
+// validation, quota, storage, duplicate detection, file record, transcode and fingerprint jobs.
+type UploadService struct {
+	files        *FilesService
+	storage      storage.StorageService
+	queue        queue.QueueService
+	sidecars     *SidecarService
+	rules        *FileTypeRuleService
+	sniffer      *ContentSniffer
+	scans        *ScanService
+	fingerprints *FingerprintService
+	transcode    TranscodeFallback
+}
+
+// NewUploadService creates a new upload service
+func NewUploadService(files *FilesService, storageService storage.StorageService, queueService queue.QueueService) *UploadService {
+	fingerprints := NewFingerprintService(files.repo, storageService, nil)
+	fingerprints.SetQueue(queueService)
+	return &UploadService{
+		files:        files,
+		storage:      storageService,
+		queue:        queueService,
+		sidecars:     NewSidecarService(files.repo),
+		rules:        NewFileTypeRuleService(files.repo, nil),
+		sniffer:      NewContentSniffer(defaultMediaProber()),
+		scans:        NewScanService(files.repo, storageService, DefaultMalwareScanner()),
+		fingerprints: fingerprints,
+	}
+}
+
+// SetFileTypeRules sets the file type rule service, so uploads share its cache
+func (s *UploadService) SetFileTypeRules(rules *FileTypeRuleService) {
+	s.rules = rules
+}
+
+// FileTypeRules returns the file type rules uploads are checked against
+func (s *UploadService) FileTypeRules() *FileTypeRuleService {
+	return s.rules
+}
+
+// SetTranscodeFallback sets the in-process transcoder used when the queue is unavailable
+func (s *UploadService) SetTranscodeFallback(fallback TranscodeFallback) {
+	s.transcode = fallback
+}
+
+// Upload validates and stores the content, creates the file record and triggers transcoding.
+// Duplicates accepted under the warn policy are returned with DuplicateOf set. It returns *UploadValidationError, *SidecarValidationError, *QuotaExceededError or
+// *DuplicateFileError for rejected uploads, and the flagged record with *MalwareDetectedError
+// for infected uploads.
+func (s *UploadService) Upload(ctx context.Context, req *UploadRequest) (*models.Files, error) {
+	file, err := s.Store(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.files.CreateFile(ctx, file); err != nil {
+		// Roll back by releasing the uploaded object; a duplicate keeps the existing file's own object
+		s.storage.Delete(ctx, file.Path)
+		if _, ok := err.(*DuplicateFileError); ok {
+			return nil, err
+		}
+		if _, ok := err.(*QuotaExceededError); ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save file record: %w", err)
+	}
+
+	if file.ScanStatus == models.ScanStatusInfected {
+		return file, &MalwareDetectedError{File: file, Signature: file.ScanResult}
+	}
+
+	s.TriggerTranscode(file)
+	s.TriggerFingerprint(file)
+
+	return file, nil
+}
+
+// Store validates the request and writes the content to storage, returning the file record
+// without saving it. Callers that create records themselves (bulk import) must delete the stored
+// object if the record is not created.
+func (s *UploadService) Store(ctx context.Context, req *UploadRequest) (*models.Files, error) {
+	ext := strings.ToLower(filepath.Ext(req.Filename))
+
+	// Detect the real content type; the extension and client Content-Type are not trusted
+	sniffed, err := s.sniffer.Sniff(req.Filename, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	if sniffed.Executable {
+		return nil, &UploadValidationError{Message: "Executable files cannot be uploaded"}
+	}
+
+	// Check extension, type, size and detected content type against the category's rules
+	fileType, err := s.rules.Check(ctx, req.CategoryID, req.Filename, sniffed.MimeType, req.Type, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	fileType, mimeType, err := s.sniffer.Verify(ctx, req.Content, sniffed, fileType, req.Type == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	title, level, groups, catalogInfo := req.Title, req.Level, req.Groups, req.CatalogInfo
+	if req.Sidecar != nil {
+		mapped, err := s.sidecars.Apply(ctx, req.Sidecar)
+		if err != nil {
+			return nil, err
+		}
+		if mapped.Title != "" {
+			title = mapped.Title
+		}
+		if mapped.Level > 0 {
+			level = mapped.Level
+		}
+		if mapped.Groups != "" {
+			groups = mapped.Groups
+		}
+		if catalogInfo, err = mapped.MergeCatalogInfo(catalogInfo); err != nil {
+			return nil, &UploadValidationError{Message: err.Error()}
+		}
+	}
+	if title == "" {
+		return nil, &UploadValidationError{Message: "Title is required"}
+	}
+
+	// Reject uploads that would exceed the uploader's storage quota before writing to storage
+	if req.Username != "" && !req.SkipQuotaCheck {
+		if err := s.files.CheckQuota(ctx, req.Username, req.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	// Calculate MD5 hash of file content
+	hash := md5.New()
+	if _, err := io.Copy(hash, req.Content); err != nil {
+		return nil, fmt.Errorf("failed to process file: %w", err)
+	}
+	md5Hash := fmt.Sprintf("%x", hash.Sum(nil))
+	if _, err := req.Content.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to process file: %w", err)
+	}
+
+	// Skip the storage write for duplicates the category's policy rejects
+	duplicateOf, err := s.files.CheckDuplicate(ctx, req.CategoryID, md5Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate storage path (MD5-based directory structure)
+	timestamp := time.Now().Unix()
+	dirHash := md5.Sum([]byte(fmt.Sprintf("%s_%d", req.Filename, timestamp)))
+	storagePath := filepath.Join(fmt.Sprintf("%x", dirHash), md5Hash+ext)
+
+	metadata := map[string]string{
+		"original-filename": req.Filename,
+		"content-type":      mimeType,
+		"title":             title,
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+
+	// Infected content goes to the quarantine prefix, never to the regular layout
+	scanStatus, scanResult := s.scans.ScanContent(ctx, req.Content)
+	var uploadedPath string
+	if scanStatus == models.ScanStatusInfected {
+		metadata["scan-signature"] = scanResult
+		uploadedPath, err = storage.PutQuarantined(ctx, s.storage, storagePath, req.Content, metadata)
+	} else {
+		uploadedPath, err = s.storage.Upload(ctx, storagePath, req.Content, metadata)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file to storage: %w", err)
+	}
+
+	username := req.Username
+	if username == "" {
+		username = "anonymous" // Default username if not authenticated
+	}
+	if level <= 0 {
+		level = 1 // Default level
+	}
+	if groups == "" {
+		groups = "all" // Default to all groups
+	}
+
+	file := &models.Files{
+		CategoryID:     req.CategoryID,
+		Type:           fileType,
+		Title:          title,
+		Name:           md5Hash,
+		ContentHash:    storage.ContentHashFromPath(uploadedPath),
+		Ext:            ext,
+		MimeType:       mimeType,
+		Size:           req.Size,
+		Path:           uploadedPath,
+		Status:         models.FileStatusNew,
+		Level:          level,
+		Groups:         groups,
+		CatalogInfo:    catalogInfo,
+		UploadUsername: username,
+		UploadAt:       int(time.Now().Unix()),
+		ScanStatus:     scanStatus,
+		ScanResult:     scanResult,
+	}
+	if duplicateOf != nil {
+		file.DuplicateOf = duplicateOf.ID // Accepted under the warn policy
+	}
+	if scanStatus != models.ScanStatusSkipped {
+		file.ScannedAt = file.UploadAt
+	}
+	if scanStatus == models.ScanStatusInfected {
+		file.Status = models.FileStatusFlagged // Downloads are blocked by the scan status
+	}
+
+	return file, nil
+}
+
+// TriggerTranscode publishes a transcode job for video and audio files, transcoding in-process
+// through the fallback when no queue is available
+func (s *UploadService) TriggerTranscode(file *models.Files) {
+	if file.Type != models.FileTypeVideo && file.Type != models.FileTypeAudio {
+		return
+	}
+
+	if s.queue == nil {
+		if s.transcode != nil {
+			// No queue service, do sync transcode for video/audio
+			fmt.Printf("⚠ No queue service available, using sync transcode for file %d\n", file.ID)
+			go s.transcode(file, "s3") // Assuming S3 for now
+		}
+		return
+	}
+
+	storageType := s.storageType()
+
+	// Publish without blocking the caller
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.PublishTranscode(ctx, file); err != nil {
+			if s.transcode == nil {
+				fmt.Printf("⚠ Queue unavailable for file %d, transcode not scheduled: %v\n", file.ID, err)
+				return
+			}
+			fmt.Printf("⚠ Queue unavailable for file %d, using sync transcode: %v\n", file.ID, err)
+			s.transcode(file, storageType)
+		} else {
+			fmt.Printf("✓ Transcode job published for file %d (type %d)\n", file.ID, file.Type)
+		}
+	}()
+}
+
+// TriggerFingerprint publishes a perceptual hashing job for images and videos without blocking
+// the caller; without a queue, files are picked up by the fingerprint backfill
+func (s *UploadService) TriggerFingerprint(file *models.Files) {
+	if s.queue == nil || !Fingerprintable(file) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.fingerprints.Enqueue(ctx, file); err != nil {
+			fmt.Printf("⚠ Fingerprint job not published for file %d: %v\n", file.ID, err)
+		}
+	}()
+}
+
+// PublishTranscode publishes the transcode job for a video or audio file and waits for the queue
+func (s *UploadService) PublishTranscode(ctx context.Context, file *models.Files) error {
+	if file.Type != models.FileTypeVideo && file.Type != models.FileTypeAudio {
+		return nil
+	}
+	if s.queue == nil {
+		return fmt.Errorf("no queue service configured")
+	}
+
+	transcodeJob := queue.TranscodeJob{
+		FileID:      file.ID,
+		InputPath:   file.Path,
+		OutputPath:  strings.TrimSuffix(file.Path, filepath.Ext(file.Path)) + "-preview.flv",
+		Parameters:  "-y -ab 56 -ar 22050 -r 15 -b 500 -s 320x240",
+		StorageType: s.storageType(),
+	}
+	jobData, err := json.Marshal(transcodeJob)
+	if err != nil {
+		return err
+	}
+
+	message := &queue.Message{
+		ID:        fmt.Sprintf("transcode-%d-%d", file.ID, time.Now().Unix()),
+		Body:      string(jobData),
+		Timestamp: time.Now(),
+		Attributes: map[string]string{
+			"file_id":   strconv.FormatUint(file.ID, 10),
+			"file_type": strconv.Itoa(file.Type),
+		},
+	}
+
+	return s.queue.Publish(ctx, TranscodeQueueName, message)
+}
+
+// storageType returns the storage type passed to transcoders
+func (s *UploadService) storageType() string {
+	if s.storage != nil {
+		return "s3" // TODO: get from config
+	}
+	return "local"
+}
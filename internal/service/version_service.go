@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrVersionNotFound is returned for versions a file does not have
+	ErrVersionNotFound = errors.New("file version not found")
+	// ErrVersionUnchanged is returned when a new version has the current version's content
+	ErrVersionUnchanged = errors.New("content is identical to the current version")
+	// ErrVersionConflict is returned when the file got another version while this one was stored
+	ErrVersionConflict = errors.New("file was revised by another request, retry")
+)
+
+// VersionRequest describes new content for an existing file
+type VersionRequest struct {
+	Filename      string // Its extension must belong to the file's type
+	Content       io.ReadSeeker
+	Size          int64
+	ContentType   string
+	Comment       string
+	Username      string
+	ResetWorkflow bool // Send the file back to pending review
+}
+
+// VersionChange is a field that differs between two versions
+type VersionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// VersionDiff compares two versions of a file
+type VersionDiff struct {
+	From      *models.FileVersion `json:"from"`
+	To        *models.FileVersion `json:"to"`
+	Identical bool                `json:"identical"` // Same content
+	SizeDelta int64               `json:"size_delta"`
+	Changes   []VersionChange     `json:"changes"`
+}
+
+// VersionService stores new revisions of files and restores earlier ones. The file record always
+// describes the current version, so links, metadata and workflow history stay with the file ID.
+type VersionService struct {
+	repo    repository.Repository
+	files   *FilesService
+	uploads *UploadService
+	storage storage.StorageService
+}
+
+// NewVersionService creates a new version service; new content goes through the upload path's
+// validation, scanning and storage
+func NewVersionService(repo repository.Repository, uploads *UploadService, storageService storage.StorageService) *VersionService {
+	return &VersionService{
+		repo:    repo,
+		files:   uploads.files,
+		uploads: uploads,
+		storage: storageService,
+	}
+}
+
+// AddVersion stores new content as the file's next version and regenerates its derivatives.
+// Storage used by versions is charged to the file's uploader. It returns the same errors as
+// UploadService.Upload, and the new version with *MalwareDetectedError for infected content.
+func (s *VersionService) AddVersion(ctx context.Context, fileID uint64, req *VersionRequest) (*models.FileVersion, *models.Files, error) {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Status == models.FileStatusDeleted {
+		return nil, nil, &UploadValidationError{Message: "Deleted files cannot be revised"}
+	}
+	if err := s.files.holds.Check(ctx, file, HoldOperationVersion, req.Username); err != nil {
+		return nil, nil, err
+	}
+	if req.ResetWorkflow {
+		if err := s.checkReset(ctx, file, req.Username); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.files.CheckQuota(ctx, file.UploadUsername, req.Size); err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := s.uploads.Store(ctx, &UploadRequest{
+		Filename:    req.Filename,
+		Content:     req.Content,
+		Size:        req.Size,
+		ContentType: req.ContentType,
+		CategoryID:  file.CategoryID,
+		Title:       file.Title,
+		Type:        file.Type, // A revision keeps the file's type
+		Username:    req.Username,
+		Metadata: map[string]string{
+			"file-id": strconv.FormatUint(file.ID, 10),
+			"version": strconv.Itoa(file.Version + 1),
+		},
+		SkipQuotaCheck: true,
+	})
+	if err != nil {
+		var duplicate *DuplicateFileError
+		if errors.As(err, &duplicate) && duplicate.ExistingFile != nil && duplicate.ExistingFile.ID == file.ID {
+			return nil, nil, ErrVersionUnchanged
+		}
+		return nil, nil, err
+	}
+	if stored.Name == file.Name {
+		s.storage.Delete(ctx, stored.Path)
+		return nil, nil, ErrVersionUnchanged
+	}
+
+	version := &models.FileVersion{
+		FileID:      file.ID,
+		Version:     file.Version + 1,
+		Name:        stored.Name,
+		ContentHash: stored.ContentHash,
+		Ext:         stored.Ext,
+		MimeType:    stored.MimeType,
+		Size:        stored.Size,
+		Path:        stored.Path,
+		ScanStatus:  stored.ScanStatus,
+		ScanResult:  stored.ScanResult,
+		Comment:     req.Comment,
+		Username:    stored.UploadUsername,
+		Created:     int(time.Now().Unix()),
+	}
+	if err := s.switchTo(ctx, file, version, stored.DuplicateOf, req.ResetWorkflow); err != nil {
+		s.storage.Delete(ctx, stored.Path)
+		return nil, nil, err
+	}
+	log.Printf("File %d revised to version %d by %s", file.ID, version.Version, version.Username)
+
+	if file.ScanStatus == models.ScanStatusInfected {
+		s.repo.Fingerprints().DeleteByFileID(ctx, file.ID)
+		return version, file, &MalwareDetectedError{File: file, Signature: file.ScanResult}
+	}
+	s.uploads.TriggerTranscode(file)
+	s.uploads.TriggerFingerprint(file)
+	return version, file, nil
+}
+
+// Restore makes an earlier version current again by adding a new version with its content; the
+// stored object is reused
+func (s *VersionService) Restore(ctx context.Context, fileID uint64, number int, username, comment string, resetWorkflow bool) (*models.FileVersion, *models.Files, error) {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Status == models.FileStatusDeleted {
+		return nil, nil, &UploadValidationError{Message: "Deleted files cannot be revised"}
+	}
+	if err := s.files.holds.Check(ctx, file, HoldOperationVersion, username); err != nil {
+		return nil, nil, err
+	}
+	if number == file.Version {
+		return nil, nil, &UploadValidationError{Message: fmt.Sprintf("Version %d is already current", number)}
+	}
+	target, err := s.repo.FileVersions().FindVersion(ctx, fileID, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target == nil {
+		return nil, nil, ErrVersionNotFound
+	}
+	if !target.Downloadable() {
+		return nil, nil, ErrFileQuarantined
+	}
+	if resetWorkflow {
+		if err := s.checkReset(ctx, file, username); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	version := *target
+	version.ID = 0
+	version.Version = file.Version + 1
+	version.RestoredFrom = target.Version
+	version.Comment = comment
+	version.Username = username
+	version.Created = int(time.Now().Unix())
+	if err := s.switchTo(ctx, file, &version, 0, resetWorkflow); err != nil {
+		return nil, nil, err
+	}
+	log.Printf("File %d restored to version %d as version %d by %s", file.ID, target.Version, version.Version, username)
+
+	// The preview of the restored content normally still exists
+	previewPath := strings.TrimSuffix(file.Path, filepath.Ext(file.Path)) + "-preview.flv"
+	if exists, err := s.storage.Exists(ctx, previewPath); err != nil || !exists {
+		s.uploads.TriggerTranscode(file)
+	}
+	s.uploads.TriggerFingerprint(file)
+	return &version, file, nil
+}
+
+// checkReset checks that the file's workflow lets the user send it back to pending review. A
+// quarantined file whose content is replaced starts over as new.
+func (s *VersionService) checkReset(ctx context.Context, file *models.Files, username string) error {
+	from := file.Status
+	if from == models.FileStatusFlagged {
+		from = models.FileStatusNew
+	}
+	if from == models.FileStatusPending {
+		return nil
+	}
+	_, _, err := s.files.checkTransition(ctx, file, from, models.FileStatusPending, username, false)
+	return err
+}
+
+// switchTo records the version and points the file at its content in one transaction. Files that
+// were never revised get their original content recorded as version 1 first.
+func (s *VersionService) switchTo(ctx context.Context, file *models.Files, version *models.FileVersion, duplicateOf uint64, resetWorkflow bool) error {
+	versions, err := s.repo.FileVersions().FindByFileID(ctx, file.ID)
+	if err != nil {
+		return err
+	}
+	created := []*models.FileVersion{version}
+	if len(versions) == 0 {
+		initial := initialVersion(file)
+		created = []*models.FileVersion{initial, version}
+		versions = []*models.FileVersion{initial}
+	}
+
+	previous := *file
+	file.Version = version.Version
+	file.Name = version.Name
+	file.ContentHash = version.ContentHash
+	file.Ext = version.Ext
+	file.MimeType = version.MimeType
+	file.Size = version.Size
+	file.Path = version.Path
+	file.ScanStatus = version.ScanStatus
+	file.ScanResult = version.ScanResult
+	file.ScannedAt = 0
+	if version.ScanStatus != models.ScanStatusSkipped {
+		file.ScannedAt = version.Created
+	}
+	file.DuplicateOf = duplicateOf
+	file.VersionSize = priorVersionSize(versions, file.Path)
+	switch {
+	case file.ScanStatus == models.ScanStatusInfected:
+		file.Status = models.FileStatusFlagged
+	case resetWorkflow:
+		file.Status = models.FileStatusPending
+	case previous.Status == models.FileStatusFlagged:
+		file.Status = models.FileStatusNew // Clean content replaced the quarantined version
+	}
+
+	// Reserve the growth against the uploader's quota before the switch; it is given back if the switch fails
+	delta := (file.Size + file.VersionSize) - (previous.Size + previous.VersionSize)
+	if err := s.files.quota.reserve(ctx, file.UploadUsername, delta, 0); err != nil {
+		*file = previous
+		return err
+	}
+
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		// Updating the file first locks its row; a concurrent revision then finds the version changed
+		result := tx.WithContext(ctx).Model(&models.Files{}).
+			Where("id = ? AND version = ?", file.ID, previous.Version).
+			Updates(map[string]interface{}{
+				"version":      file.Version,
+				"version_size": file.VersionSize,
+				"name":         file.Name,
+				"content_hash": file.ContentHash,
+				"ext":          file.Ext,
+				"mime_type":    file.MimeType,
+				"size":         file.Size,
+				"path":         file.Path,
+				"scan_status":  file.ScanStatus,
+				"scan_result":  file.ScanResult,
+				"scanned_at":   file.ScannedAt,
+				"duplicate_of": file.DuplicateOf,
+				"status":       file.Status,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		if err := tx.WithContext(ctx).Create(created).Error; err != nil {
+			return err
+		}
+		if file.Status == previous.Status {
+			return nil
+		}
+		transition := "new_version"
+		if version.RestoredFrom > 0 {
+			transition = "restore_version"
+		}
+		return tx.WithContext(ctx).Create(&models.WorkflowEvent{
+			FileID:     file.ID,
+			FromStatus: previous.Status,
+			ToStatus:   file.Status,
+			Transition: transition,
+			Username:   version.Username,
+			Comment:    version.Comment,
+			Created:    version.Created,
+		}).Error
+	})
+	if err != nil {
+		if releaseErr := s.files.quota.adjust(ctx, previous.UploadUsername, -delta, 0); releaseErr != nil {
+			log.Printf("Failed to release storage usage reserved for file %d: %v", file.ID, releaseErr)
+		}
+		*file = previous
+		return err
+	}
+
+	// Content sent back to review goes through the review stages again, with a new reviewer
+	// assignment and due date, like a file entering review through changeStatus
+	switch {
+	case resetWorkflow && file.Status == models.FileStatusPending:
+		workflow, err := s.files.workflows.Resolve(ctx, file.CategoryID)
+		if err == nil {
+			err = startReview(ctx, s.repo, file, workflow)
+		}
+		if err != nil {
+			log.Printf("Failed to restart review of file %d: %v", file.ID, err)
+		}
+		if err := s.files.assignments.start(ctx, file, version.Username); err != nil {
+			log.Printf("Failed to assign a reviewer to file %d: %v", file.ID, err)
+		}
+	case previous.Status == models.FileStatusPending && file.Status != models.FileStatusPending:
+		if err := s.repo.Reviews().CancelOpen(ctx, file.ID, int(time.Now().Unix())); err != nil {
+			log.Printf("Failed to cancel review of file %d: %v", file.ID, err)
+		}
+		if err := s.files.assignments.close(ctx, file.ID); err != nil {
+			log.Printf("Failed to close review assignment of file %d: %v", file.ID, err)
+		}
+	}
+	return nil
+}
+
+// ListVersions returns a file's versions, newest first. Files that were never revised have a
+// single version 1 describing their content.
+func (s *VersionService) ListVersions(ctx context.Context, fileID uint64) ([]*models.FileVersion, error) {
+	file, err := s.repo.Files().FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.FileVersions().FindByFileID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		versions = []*models.FileVersion{initialVersion(file)}
+	}
+	return versions, nil
+}
+
+// GetVersion returns one version of a file
+func (s *VersionService) GetVersion(ctx context.Context, fileID uint64, number int) (*models.FileVersion, error) {
+	versions, err := s.ListVersions(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if version.Version == number {
+			return version, nil
+		}
+	}
+	return nil, ErrVersionNotFound
+}
+
+// Diff compares the content and technical metadata of two versions
+func (s *VersionService) Diff(ctx context.Context, fileID uint64, from, to int) (*VersionDiff, error) {
+	fromVersion, err := s.GetVersion(ctx, fileID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.GetVersion(ctx, fileID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &VersionDiff{
+		From:      fromVersion,
+		To:        toVersion,
+		Identical: fromVersion.Name == toVersion.Name,
+		SizeDelta: toVersion.Size - fromVersion.Size,
+		Changes:   []VersionChange{},
+	}
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"md5", fromVersion.Name, toVersion.Name},
+		{"content_hash", fromVersion.ContentHash, toVersion.ContentHash},
+		{"ext", fromVersion.Ext, toVersion.Ext},
+		{"mime_type", fromVersion.MimeType, toVersion.MimeType},
+		{"size", fromVersion.Size, toVersion.Size},
+		{"scan_status", fromVersion.ScanStatus, toVersion.ScanStatus},
+	}
+	for _, field := range fields {
+		if field.from != field.to {
+			diff.Changes = append(diff.Changes, VersionChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	return diff, nil
+}
+
+// initialVersion describes a file's original content as version 1
+func initialVersion(file *models.Files) *models.FileVersion {
+	return &models.FileVersion{
+		FileID:      file.ID,
+		Version:     file.Version,
+		Name:        file.Name,
+		ContentHash: file.ContentHash,
+		Ext:         file.Ext,
+		MimeType:    file.MimeType,
+		Size:        file.Size,
+		Path:        file.Path,
+		ScanStatus:  file.ScanStatus,
+		ScanResult:  file.ScanResult,
+		Username:    file.UploadUsername,
+		Created:     file.UploadAt,
+	}
+}
+
+// priorVersionSize sums the sizes of the distinct stored objects of earlier versions, leaving out
+// the current object
+func priorVersionSize(versions []*models.FileVersion, currentPath string) int64 {
+	var size int64
+	seen := map[string]bool{currentPath: true}
+	for _, version := range versions {
+		if !seen[version.Path] {
+			seen[version.Path] = true
+			size += version.Size
+		}
+	}
+	return size
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// revisedFile creates a published file at version 2 whose version 1 had other content
+func (f *testFixture) revisedFile(name string) *models.Files {
+	f.t.Helper()
+	file := f.file(name, models.FileStatusPublished, "uploader")
+	first := initialVersion(f.reload(file))
+	first.Version = 1
+	f.create(first)
+	second := &models.FileVersion{
+		FileID: file.ID, Version: 2, Name: name + "-v2", Ext: ".mp4", Size: 2048,
+		Path: "videos/" + name + "-v2.mp4", ScanStatus: models.ScanStatusSkipped, Username: "editor",
+	}
+	f.create(second)
+	err := f.db.Model(file).Updates(map[string]interface{}{
+		"version": 2, "name": second.Name, "size": second.Size, "path": second.Path, "version_size": first.Size,
+	}).Error
+	if err != nil {
+		f.t.Fatalf("failed to revise file: %v", err)
+	}
+	return f.reload(file)
+}
+
+func TestVersionRestore(t *testing.T) {
+	f := newTestFixture(t)
+	store := storage.NewMemoryStorage()
+	service := NewVersionService(f.repo, NewUploadService(NewFilesService(f.repo), store, nil), store)
+
+	file := f.revisedFile("master")
+	original, err := f.repo.FileVersions().FindVersion(f.ctx, file.ID, 1)
+	if err != nil || original == nil {
+		t.Fatalf("FindVersion(1) = %v, %v", original, err)
+	}
+
+	version, restored, err := service.Restore(f.ctx, file.ID, 1, "editor", "Back to the original", false)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if version.Version != 3 || version.RestoredFrom != 1 || version.Path != original.Path {
+		t.Errorf("Restore() version = %d restored from %d at %s, want 3 restored from 1 at %s", version.Version, version.RestoredFrom, version.Path, original.Path)
+	}
+
+	stored := f.reload(restored)
+	if stored.Version != 3 || stored.Path != original.Path || stored.Name != original.Name || stored.Size != original.Size {
+		t.Errorf("file = version %d at %s (%s, %d bytes), want version 3 with the original content", stored.Version, stored.Path, stored.Name, stored.Size)
+	}
+	if stored.VersionSize != 2048 {
+		t.Errorf("version size = %d, want the 2048 bytes of version 2", stored.VersionSize)
+	}
+	if stored.Status != models.FileStatusPublished {
+		t.Errorf("status = %d, want the file to stay published", stored.Status)
+	}
+	versions, err := service.ListVersions(f.ctx, file.ID)
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("ListVersions() = %d versions, %v, want 3", len(versions), err)
+	}
+	events, _, err := f.repo.WorkflowEvents().FindAll(f.ctx, repository.WorkflowEventFilter{FileID: file.ID}, 10, 0)
+	if err != nil || len(events) != 0 {
+		t.Errorf("restore without a workflow reset recorded %d events, %v, want none", len(events), err)
+	}
+}
+
+func TestVersionRestoreRefuses(t *testing.T) {
+	f := newTestFixture(t)
+	store := storage.NewMemoryStorage()
+	service := NewVersionService(f.repo, NewUploadService(NewFilesService(f.repo), store, nil), store)
+
+	file := f.revisedFile("master")
+	held := f.revisedFile("held")
+	f.hold(held)
+	infected := f.revisedFile("infected")
+	if err := f.db.Model(&models.FileVersion{}).Where("file_id = ? AND version = 1", infected.ID).Update("scan_status", models.ScanStatusInfected).Error; err != nil {
+		t.Fatalf("failed to flag version: %v", err)
+	}
+
+	var validation *UploadValidationError
+	var holdErr *LegalHoldError
+	tests := []struct {
+		name    string
+		file    *models.Files
+		number  int
+		wantErr func(error) bool
+	}{
+		{"current version", file, 2, func(err error) bool { return errors.As(err, &validation) }},
+		{"unknown version", file, 7, func(err error) bool { return errors.Is(err, ErrVersionNotFound) }},
+		{"held file", held, 1, func(err error) bool { return errors.As(err, &holdErr) }},
+		{"infected version", infected, 1, func(err error) bool { return errors.Is(err, ErrFileQuarantined) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.Restore(f.ctx, tt.file.ID, tt.number, "editor", "", false)
+			if !tt.wantErr(err) {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if stored := f.reload(tt.file); stored.Version != 2 || stored.Path != tt.file.Path {
+				t.Errorf("file changed to version %d at %s", stored.Version, stored.Path)
+			}
+		})
+	}
+}
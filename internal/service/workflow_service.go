@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/models"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// DefaultWorkflowName is the name of the seeded five-state workflow
+const DefaultWorkflowName = "default"
+
+var (
+	// ErrInvalidWorkflow is returned when a workflow definition is rejected
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+	// ErrWorkflowInUse is returned when deleting the default workflow or one assigned to categories
+	ErrWorkflowInUse = errors.New("workflow is the default or assigned to categories")
+	// ErrWorkflowPermission is returned when the user lacks the permission a transition requires
+	ErrWorkflowPermission = errors.New("permission denied for workflow transition")
+)
+
+// WorkflowTransitionError is returned for status changes the file's workflow does not define
+type WorkflowTransitionError struct {
+	Workflow string
+	From     int
+	To       int
+}
+
+func (e *WorkflowTransitionError) Error() string {
+	return fmt.Sprintf("workflow %q does not allow changing status %d to %d", e.Workflow, e.From, e.To)
+}
+
+// WorkflowGuardError is returned when a transition requires metadata the file does not have
+type WorkflowGuardError struct {
+	Transition string
+	Missing    []string
+}
+
+func (e *WorkflowGuardError) Error() string {
+	return fmt.Sprintf("transition %q requires %s", e.Transition, strings.Join(e.Missing, ", "))
+}
+
+// AvailableTransition is a transition from a file's current status, as seen by one user
+type AvailableTransition struct {
+	Name      string   `json:"name"`
+	To        int      `json:"to"`
+	ToName    string   `json:"to_name"`
+	Permitted bool     `json:"permitted"`                // The user has the required permission
+	Missing   []string `json:"missing_fields,omitempty"` // Unmet guards
+}
+
+// defaultWorkflow returns the built-in five-state workflow: new, pending, published and rejected
+// files can be deleted, all but pending ones (re)submitted for review, and published ones withdrawn
+// back to new
+func defaultWorkflow() *models.Workflow {
+	workflow := &models.Workflow{
+		Name:        DefaultWorkflowName,
+		Description: "Submit, review and publish",
+		IsDefault:   true,
+	}
+	workflow.SetStates([]models.WorkflowState{
+		{Status: models.FileStatusNew, Name: "new", Label: "New"},
+		{Status: models.FileStatusPending, Name: "pending", Label: "Pending review"},
+		{Status: models.FileStatusPublished, Name: "published", Label: "Published"},
+		{Status: models.FileStatusRejected, Name: "rejected", Label: "Rejected"},
+		{Status: models.FileStatusDeleted, Name: "deleted", Label: "Deleted"},
+	})
+	workflow.SetTransitions([]models.WorkflowTransition{
+		{Name: "submit", From: []int{models.FileStatusNew, models.FileStatusPublished, models.FileStatusRejected, models.FileStatusDeleted}, To: models.FileStatusPending, Permission: "files.workflow.submit"},
+		{Name: "publish", From: []int{models.FileStatusPending}, To: models.FileStatusPublished, Permission: "files.workflow.publish"},
+		{Name: "reject", From: []int{models.FileStatusPending}, To: models.FileStatusRejected, Permission: "files.workflow.reject"},
+		{Name: "unpublish", From: []int{models.FileStatusPublished}, To: models.FileStatusNew, Permission: "files.workflow.publish"},
+		{Name: "delete", From: []int{models.FileStatusNew, models.FileStatusPending, models.FileStatusPublished, models.FileStatusRejected}, To: models.FileStatusDeleted, Permission: "files.edit.delete"},
+	})
+	return workflow
+}
+
+// WorkflowService manages workflow definitions and decides which status changes a file allows
+type WorkflowService struct {
+	repo repository.Repository
+}
+
+// NewWorkflowService creates a new workflow service
+func NewWorkflowService(repo repository.Repository) *WorkflowService {
+	return &WorkflowService{
+		repo: repo,
+	}
+}
+
+// ListWorkflows returns all workflows
+func (s *WorkflowService) ListWorkflows(ctx context.Context) ([]*models.Workflow, error) {
+	return s.repo.Workflows().FindAll(ctx)
+}
+
+// GetWorkflow returns a workflow by ID
+func (s *WorkflowService) GetWorkflow(ctx context.Context, id int) (*models.Workflow, error) {
+	return s.repo.Workflows().FindByID(ctx, id)
+}
+
+// CreateWorkflow validates and stores a new workflow
+func (s *WorkflowService) CreateWorkflow(ctx context.Context, workflow *models.Workflow) error {
+	if err := validateWorkflow(workflow); err != nil {
+		return err
+	}
+	now := int(time.Now().Unix())
+	workflow.Created = now
+	workflow.Updated = now
+	return s.repo.Workflows().Create(ctx, workflow)
+}
+
+// UpdateWorkflow validates and stores changes to a workflow. Files in states the workflow no
+// longer defines keep their status but can only leave it through transitions from any state.
+func (s *WorkflowService) UpdateWorkflow(ctx context.Context, workflow *models.Workflow) error {
+	if err := validateWorkflow(workflow); err != nil {
+		return err
+	}
+	workflow.Updated = int(time.Now().Unix())
+	return s.repo.Workflows().Update(ctx, workflow)
+}
+
+// DeleteWorkflow deletes a workflow that is neither the default nor assigned to a category
+func (s *WorkflowService) DeleteWorkflow(ctx context.Context, id int) error {
+	workflow, err := s.repo.Workflows().FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if workflow.IsDefault {
+		return ErrWorkflowInUse
+	}
+	count, err := s.repo.Workflows().CountCategories(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrWorkflowInUse
+	}
+	return s.repo.Workflows().Delete(ctx, id)
+}
+
+// EnsureDefaults stores the built-in workflow as the default if no workflows exist, and upgrades
+// a stored default workflow that was seeded by an earlier version and never edited
+func (s *WorkflowService) EnsureDefaults(ctx context.Context) error {
+	workflows, err := s.repo.Workflows().FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	if len(workflows) > 0 {
+		return s.upgradeDefault(ctx)
+	}
+
+	if err := s.CreateWorkflow(ctx, defaultWorkflow()); err != nil {
+		return fmt.Errorf("failed to seed default workflow: %w", err)
+	}
+	return nil
+}
+
+// upgradeDefault adds the unpublish transition, which the scheduler needs to withdraw files, to
+// a default workflow seeded before scheduled withdrawal existed. Edited workflows are left alone:
+// without a transition from published to new their expired files stay published but hidden.
+func (s *WorkflowService) upgradeDefault(ctx context.Context) error {
+	workflow, err := s.repo.Workflows().FindDefault(ctx)
+	if err != nil || workflow == nil || workflow.Name != DefaultWorkflowName {
+		return err
+	}
+	transitions, err := workflow.GetTransitions()
+	if err != nil {
+		return fmt.Errorf("failed to decode transitions of the default workflow: %w", err)
+	}
+
+	builtIn := defaultWorkflow()
+	current, _ := builtIn.GetTransitions()
+	var seeded []models.WorkflowTransition
+	for _, transition := range current {
+		if transition.Name != "unpublish" {
+			seeded = append(seeded, transition)
+		}
+	}
+	if !reflect.DeepEqual(transitions, seeded) {
+		return nil
+	}
+
+	workflow.Transitions = builtIn.Transitions
+	if err := s.UpdateWorkflow(ctx, workflow); err != nil {
+		return fmt.Errorf("failed to upgrade default workflow: %w", err)
+	}
+	log.Printf("Added the unpublish transition to the default workflow")
+	return nil
+}
+
+// Resolve returns the workflow of the category or its nearest ancestor that sets one, then the
+// default workflow, then the built-in workflow
+func (s *WorkflowService) Resolve(ctx context.Context, categoryID int) (*models.Workflow, error) {
+	ancestors, err := categoryAncestors(ctx, s.repo.Category(), categoryID)
+	if err != nil {
+		ancestors = nil // Files of removed categories follow the default workflow
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		category, err := s.repo.Category().FindByID(ctx, ancestors[i])
+		if err != nil || category.WorkflowID == 0 {
+			continue
+		}
+		workflow, err := s.repo.Workflows().FindByID(ctx, category.WorkflowID)
+		if err != nil {
+			log.Printf("Workflow %d of category %d not found: %v", category.WorkflowID, category.ID, err)
+			continue
+		}
+		return workflow, nil
+	}
+
+	workflow, err := s.repo.Workflows().FindDefault(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if workflow == nil {
+		workflow = defaultWorkflow()
+	}
+	return workflow, nil
+}
+
+// WorkflowExists reports whether a workflow can be assigned to a category
+func (s *WorkflowService) WorkflowExists(ctx context.Context, id int) bool {
+	_, err := s.repo.Workflows().FindByID(ctx, id)
+	return err == nil
+}
+
+// findTransition returns the first transition of the workflow that leads from one status to
+// another, or nil
+func findTransition(workflow *models.Workflow, from, to int) (*models.WorkflowTransition, error) {
+	transitions, err := workflow.GetTransitions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transitions of workflow %q: %w", workflow.Name, err)
+	}
+	for i := range transitions {
+		if transitions[i].Allows(from, to) {
+			return &transitions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// hasReviewStages reports whether pending files of the workflow are published by review votes
+func hasReviewStages(workflow *models.Workflow) bool {
+	stages, err := workflow.GetReviewStages()
+	return err == nil && len(stages) > 0
+}
+
+// canPerform reports whether a user holds the permission a transition requires. Administrators
+// may perform every transition; without a username only transitions open to everyone are allowed.
+func (s *WorkflowService) canPerform(ctx context.Context, transition *models.WorkflowTransition, username string) (bool, error) {
+	if transition.Permission == "" {
+		return true, nil
+	}
+	if username == "" {
+		return false, nil
+	}
+	user, err := s.repo.Users().FindByUsername(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	isAdmin, err := s.repo.ACL().IsAdmin(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if isAdmin {
+		return true, nil
+	}
+	parts := strings.Split(transition.Permission, ".")
+	return s.repo.ACL().HasPermission(ctx, user.ID, parts[0], parts[1], parts[2])
+}
+
+// missingFields returns the required fields the file does not have
+func missingFields(file *models.Files, required []string) []string {
+	var catalog map[string]interface{}
+	var missing []string
+	for _, field := range required {
+		if field == "title" {
+			if strings.TrimSpace(file.Title) == "" {
+				missing = append(missing, field)
+			}
+			continue
+		}
+
+		key := strings.TrimPrefix(field, "catalog.")
+		if catalog == nil {
+			catalog = map[string]interface{}{}
+			if file.CatalogInfo != "" {
+				json.Unmarshal([]byte(file.CatalogInfo), &catalog)
+			}
+		}
+		value, ok := catalog[key]
+		if !ok || value == nil || strings.TrimSpace(fmt.Sprint(value)) == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// validateWorkflow checks a workflow definition. Every workflow must contain the new state that
+// uploads start in; the quarantine status is managed by the malware scanner and cannot be used.
+func validateWorkflow(workflow *models.Workflow) error {
+	workflow.Name = strings.TrimSpace(workflow.Name)
+	if workflow.Name == "" || len(workflow.Name) > 64 {
+		return fmt.Errorf("%w: name is required and must be at most 64 characters", ErrInvalidWorkflow)
+	}
+
+	states, err := workflow.GetStates()
+	if err != nil {
+		return fmt.Errorf("%w: states must be a JSON array: %v", ErrInvalidWorkflow, err)
+	}
+	statuses := map[int]bool{}
+	names := map[string]bool{}
+	for _, state := range states {
+		if state.Status < 0 || state.Status == models.FileStatusFlagged {
+			return fmt.Errorf("%w: invalid state status %d", ErrInvalidWorkflow, state.Status)
+		}
+		if state.Name == "" {
+			return fmt.Errorf("%w: state %d has no name", ErrInvalidWorkflow, state.Status)
+		}
+		if statuses[state.Status] || names[state.Name] {
+			return fmt.Errorf("%w: duplicate state %d (%s)", ErrInvalidWorkflow, state.Status, state.Name)
+		}
+		statuses[state.Status] = true
+		names[state.Name] = true
+	}
+	if !statuses[models.FileStatusNew] {
+		return fmt.Errorf("%w: the new state (status 0) is required", ErrInvalidWorkflow)
+	}
+
+	transitions, err := workflow.GetTransitions()
+	if err != nil {
+		return fmt.Errorf("%w: transitions must be a JSON array: %v", ErrInvalidWorkflow, err)
+	}
+	names = map[string]bool{}
+	for _, transition := range transitions {
+		if transition.Name == "" || names[transition.Name] {
+			return fmt.Errorf("%w: transition names must be unique and non-empty", ErrInvalidWorkflow)
+		}
+		names[transition.Name] = true
+		if !statuses[transition.To] {
+			return fmt.Errorf("%w: transition %q leads to undefined state %d", ErrInvalidWorkflow, transition.Name, transition.To)
+		}
+		for _, from := range transition.From {
+			if !statuses[from] {
+				return fmt.Errorf("%w: transition %q starts from undefined state %d", ErrInvalidWorkflow, transition.Name, from)
+			}
+		}
+		if transition.Permission != "" {
+			parts := strings.Split(transition.Permission, ".")
+			if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+				return fmt.Errorf("%w: transition %q permission must be namespace.controller.action", ErrInvalidWorkflow, transition.Name)
+			}
+		}
+		for _, field := range transition.RequiredFields {
+			if field != "title" && (!strings.HasPrefix(field, "catalog.") || field == "catalog.") {
+				return fmt.Errorf("%w: transition %q requires unknown field %q (use title or catalog.<key>)", ErrInvalidWorkflow, transition.Name, field)
+			}
+		}
+	}
+	return validateReviewStages(workflow)
+}
+
+// validateReviewStages checks the review stages of a workflow. Stages decide between publishing
+// and rejecting pending files, so the workflow must define both transitions.
+func validateReviewStages(workflow *models.Workflow) error {
+	stages, err := workflow.GetReviewStages()
+	if err != nil {
+		return fmt.Errorf("%w: review_stages must be a JSON array: %v", ErrInvalidWorkflow, err)
+	}
+	if len(stages) == 0 {
+		return nil
+	}
+	for _, to := range []int{models.FileStatusPublished, models.FileStatusRejected} {
+		if transition, _ := findTransition(workflow, models.FileStatusPending, to); transition == nil {
+			return fmt.Errorf("%w: review stages need a transition from pending (1) to %d", ErrInvalidWorkflow, to)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, stage := range stages {
+		if stage.Name == "" || names[stage.Name] {
+			return fmt.Errorf("%w: review stage names must be unique and non-empty", ErrInvalidWorkflow)
+		}
+		names[stage.Name] = true
+		if len(stage.Users) == 0 && len(stage.Groups) == 0 {
+			return fmt.Errorf("%w: review stage %q has no reviewers", ErrInvalidWorkflow, stage.Name)
+		}
+		for _, id := range append(append([]int{}, stage.Users...), stage.Groups...) {
+			if id <= 0 {
+				return fmt.Errorf("%w: review stage %q has an invalid user or group ID", ErrInvalidWorkflow, stage.Name)
+			}
+		}
+		if stage.Quorum < 1 || (len(stage.Groups) == 0 && stage.Quorum > len(stage.Users)) {
+			return fmt.Errorf("%w: review stage %q quorum must be between 1 and its number of reviewers", ErrInvalidWorkflow, stage.Name)
+		}
+	}
+	return nil
+}
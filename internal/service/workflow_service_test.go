@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/models"
+)
+
+// seededDefaultWorkflow returns the default workflow as seeded before files could be withdrawn
+func seededDefaultWorkflow(t *testing.T) *models.Workflow {
+	t.Helper()
+	workflow := defaultWorkflow()
+	transitions, err := workflow.GetTransitions()
+	if err != nil {
+		t.Fatalf("GetTransitions returned error: %v", err)
+	}
+	var seeded []models.WorkflowTransition
+	for _, transition := range transitions {
+		if transition.Name != "unpublish" {
+			seeded = append(seeded, transition)
+		}
+	}
+	workflow.SetTransitions(seeded)
+	return workflow
+}
+
+func TestEnsureDefaultsUpgradesSeededWorkflow(t *testing.T) {
+	f := newTestFixture(t)
+	workflows := NewWorkflowService(f.repo)
+
+	seeded := seededDefaultWorkflow(t)
+	if err := workflows.CreateWorkflow(f.ctx, seeded); err != nil {
+		t.Fatalf("CreateWorkflow returned error: %v", err)
+	}
+	if err := workflows.EnsureDefaults(f.ctx); err != nil {
+		t.Fatalf("EnsureDefaults returned error: %v", err)
+	}
+
+	stored, err := workflows.GetWorkflow(f.ctx, seeded.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow returned error: %v", err)
+	}
+	transition, err := findTransition(stored, models.FileStatusPublished, models.FileStatusNew)
+	if err != nil || transition == nil || transition.Name != "unpublish" {
+		t.Fatalf("upgraded default workflow withdraws with %+v (%v), want unpublish", transition, err)
+	}
+}
+
+func TestEnsureDefaultsKeepsEditedWorkflow(t *testing.T) {
+	f := newTestFixture(t)
+	workflows := NewWorkflowService(f.repo)
+
+	// An administrator removed the reject transition; the workflow is no longer the seeded one
+	edited := seededDefaultWorkflow(t)
+	transitions, _ := edited.GetTransitions()
+	var kept []models.WorkflowTransition
+	for _, transition := range transitions {
+		if transition.Name != "reject" {
+			kept = append(kept, transition)
+		}
+	}
+	edited.SetTransitions(kept)
+	if err := workflows.CreateWorkflow(f.ctx, edited); err != nil {
+		t.Fatalf("CreateWorkflow returned error: %v", err)
+	}
+	if err := workflows.EnsureDefaults(f.ctx); err != nil {
+		t.Fatalf("EnsureDefaults returned error: %v", err)
+	}
+
+	stored, err := workflows.GetWorkflow(f.ctx, edited.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow returned error: %v", err)
+	}
+	if stored.Transitions != edited.Transitions {
+		t.Errorf("edited default workflow changed to %s", stored.Transitions)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/cache"
+	"github.com/openwan/media-asset-management/internal/repository"
+)
+
+// ErrInvalidStatsRange is returned for statistics date filters that are malformed or too wide
+var ErrInvalidStatsRange = errors.New("invalid statistics date range")
+
+const (
+	// defaultStatsDays is the time-series range when no dates are given
+	defaultStatsDays = 30
+	// maxStatsDays is the widest time-series range
+	maxStatsDays = 366
+)
+
+// WorkflowStatsParams selects workflow statistics. Dates are YYYY-MM-DD in server time and
+// inclusive; the category includes its descendants.
+type WorkflowStatsParams struct {
+	CategoryID int
+	DateFrom   string
+	DateTo     string
+}
+
+// ReviewTimeStats summarizes how long files stayed in pending review
+type ReviewTimeStats struct {
+	Reviews        int   `json:"reviews"` // Files that left review in the range
+	MedianSeconds  int64 `json:"median_seconds"`
+	AverageSeconds int64 `json:"average_seconds"`
+	P90Seconds     int64 `json:"p90_seconds"`
+}
+
+// WorkflowStats are file counts and review throughput. Files are counted by upload date when a
+// range is given; the daily series and review times cover the range or the last 30 days.
+type WorkflowStats struct {
+	CategoryID  int                            `json:"category_id,omitempty"`
+	From        string                         `json:"from"`
+	To          string                         `json:"to"`
+	Files       *repository.FileCounts         `json:"files"`
+	Daily       []*repository.DailyTransitions `json:"daily"`
+	ReviewTime  ReviewTimeStats                `json:"review_time"`
+	GeneratedAt int64                          `json:"generated_at"` // Unix timestamp; results are cached briefly
+}
+
+// WorkflowStatsService computes workflow statistics with aggregate queries and caches them briefly
+type WorkflowStatsService struct {
+	repo  repository.Repository
+	cache cache.CacheService
+}
+
+// NewWorkflowStatsService creates a new workflow statistics service. The cache may be nil.
+func NewWorkflowStatsService(repo repository.Repository, cacheService cache.CacheService) *WorkflowStatsService {
+	return &WorkflowStatsService{
+		repo:  repo,
+		cache: cacheService,
+	}
+}
+
+// Stats returns the statistics for the parameters, from the cache when they were computed recently
+func (s *WorkflowStatsService) Stats(ctx context.Context, params WorkflowStatsParams) (*WorkflowStats, error) {
+	files, series, err := statsFilters(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	key := cache.GenerateKey(cache.KeyTypeWorkflowStats, fmt.Sprintf("%d:%s:%s", params.CategoryID, params.DateFrom, params.DateTo))
+	if s.cache != nil {
+		if cached, err := s.cache.Get(ctx, key); err == nil {
+			if data, ok := cached.(string); ok {
+				var stats WorkflowStats
+				if err := json.Unmarshal([]byte(data), &stats); err == nil {
+					return &stats, nil
+				}
+			}
+		}
+	}
+
+	stats := &WorkflowStats{
+		CategoryID:  params.CategoryID,
+		From:        time.Unix(int64(series.From), 0).Format("2006-01-02"),
+		To:          time.Unix(int64(series.To), 0).Format("2006-01-02"),
+		GeneratedAt: time.Now().Unix(),
+	}
+	if stats.Files, err = s.repo.Stats().CountFiles(ctx, files); err != nil {
+		return nil, fmt.Errorf("failed to count files: %w", err)
+	}
+	if stats.Daily, err = s.repo.Stats().DailyTransitions(ctx, series); err != nil {
+		return nil, fmt.Errorf("failed to count transitions: %w", err)
+	}
+	durations, err := s.repo.Stats().ReviewDurations(ctx, series)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure review times: %w", err)
+	}
+	stats.ReviewTime = summarizeDurations(durations)
+
+	if s.cache != nil {
+		if data, err := json.Marshal(stats); err == nil {
+			if err := s.cache.Set(ctx, key, string(data), cache.TTLWorkflowStats); err != nil {
+				log.Printf("Failed to cache workflow statistics: %v", err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// statsFilters returns the filter for counting files, which only has a range if one was given,
+// and the filter for the time series
+func statsFilters(params WorkflowStatsParams, now time.Time) (repository.StatsFilter, repository.StatsFilter, error) {
+	files := repository.StatsFilter{CategoryID: params.CategoryID}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from := today.AddDate(0, 0, 1-defaultStatsDays)
+	to := today
+	if params.DateFrom != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", params.DateFrom, time.Local)
+		if err != nil {
+			return files, files, fmt.Errorf("%w: date_from must be YYYY-MM-DD", ErrInvalidStatsRange)
+		}
+		from = parsed
+		files.From = int(from.Unix())
+	}
+	if params.DateTo != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", params.DateTo, time.Local)
+		if err != nil {
+			return files, files, fmt.Errorf("%w: date_to must be YYYY-MM-DD", ErrInvalidStatsRange)
+		}
+		to = parsed
+		files.To = int(to.AddDate(0, 0, 1).Unix()) - 1
+		if params.DateFrom == "" {
+			from = to.AddDate(0, 0, 1-defaultStatsDays)
+		}
+	}
+	if to.Before(from) {
+		return files, files, fmt.Errorf("%w: date_to is before date_from", ErrInvalidStatsRange)
+	}
+	if to.After(from.AddDate(0, 0, maxStatsDays-1)) { // Both days are included
+		return files, files, fmt.Errorf("%w: at most %d days", ErrInvalidStatsRange, maxStatsDays)
+	}
+
+	series := repository.StatsFilter{
+		CategoryID: params.CategoryID,
+		From:       int(from.Unix()),
+		To:         int(to.AddDate(0, 0, 1).Unix()) - 1,
+	}
+	return files, series, nil
+}
+
+// summarizeDurations returns the count, median, mean and 90th percentile of review durations
+func summarizeDurations(durations []int64) ReviewTimeStats {
+	summary := ReviewTimeStats{Reviews: len(durations)}
+	if len(durations) == 0 {
+		return summary
+	}
+	sorted := append([]int64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum int64
+	for _, d := range sorted {
+		sum += d
+	}
+	summary.AverageSeconds = sum / int64(len(sorted))
+	mid := len(sorted) / 2
+	summary.MedianSeconds = sorted[mid]
+	if len(sorted)%2 == 0 {
+		summary.MedianSeconds = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	summary.P90Seconds = sorted[(len(sorted)*9+9)/10-1]
+	return summary
+}
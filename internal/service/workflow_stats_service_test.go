@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatsFilters(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	day := func(month time.Month, d int) int {
+		return int(time.Date(2024, month, d, 0, 0, 0, 0, time.Local).Unix())
+	}
+	endOf := func(month time.Month, d int) int { return day(month, d+1) - 1 }
+
+	tests := []struct {
+		name       string
+		params     WorkflowStatsParams
+		filesFrom  int
+		filesTo    int
+		seriesFrom int
+		seriesTo   int
+		wantErr    bool
+	}{
+		{
+			name:       "last 30 days by default",
+			params:     WorkflowStatsParams{},
+			seriesFrom: day(time.February, 15),
+			seriesTo:   endOf(time.March, 15),
+		},
+		{
+			name:       "from only",
+			params:     WorkflowStatsParams{DateFrom: "2024-03-01"},
+			filesFrom:  day(time.March, 1),
+			seriesFrom: day(time.March, 1),
+			seriesTo:   endOf(time.March, 15),
+		},
+		{
+			name:       "to only",
+			params:     WorkflowStatsParams{DateTo: "2024-01-31"},
+			filesTo:    endOf(time.January, 31),
+			seriesFrom: day(time.January, 2),
+			seriesTo:   endOf(time.January, 31),
+		},
+		{
+			name:       "single day",
+			params:     WorkflowStatsParams{DateFrom: "2024-03-10", DateTo: "2024-03-10"},
+			filesFrom:  day(time.March, 10),
+			filesTo:    endOf(time.March, 10),
+			seriesFrom: day(time.March, 10),
+			seriesTo:   endOf(time.March, 10),
+		},
+		{
+			name:       "widest range",
+			params:     WorkflowStatsParams{DateFrom: "2023-01-01", DateTo: "2024-01-01"}, // 366 days
+			filesFrom:  int(time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local).Unix()),
+			filesTo:    endOf(time.January, 1),
+			seriesFrom: int(time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local).Unix()),
+			seriesTo:   endOf(time.January, 1),
+		},
+		{name: "too wide", params: WorkflowStatsParams{DateFrom: "2023-01-01", DateTo: "2024-01-02"}, wantErr: true},
+		{name: "to before from", params: WorkflowStatsParams{DateFrom: "2024-03-02", DateTo: "2024-03-01"}, wantErr: true},
+		{name: "future from", params: WorkflowStatsParams{DateFrom: "2024-04-01"}, wantErr: true},
+		{name: "malformed from", params: WorkflowStatsParams{DateFrom: "2024/03/01"}, wantErr: true},
+		{name: "malformed to", params: WorkflowStatsParams{DateTo: "2024-02-30"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, series, err := statsFilters(tt.params, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStatsRange) {
+					t.Fatalf("statsFilters error = %v, want ErrInvalidStatsRange", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("statsFilters returned error: %v", err)
+			}
+			if files.From != tt.filesFrom || files.To != tt.filesTo {
+				t.Errorf("files range = %d..%d, want %d..%d", files.From, files.To, tt.filesFrom, tt.filesTo)
+			}
+			if series.From != tt.seriesFrom || series.To != tt.seriesTo {
+				t.Errorf("series range = %d..%d, want %d..%d", series.From, series.To, tt.seriesFrom, tt.seriesTo)
+			}
+		})
+	}
+}
+
+func TestSummarizeDurations(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []int64
+		want      ReviewTimeStats
+	}{
+		{"none", nil, ReviewTimeStats{}},
+		{"one", []int64{42}, ReviewTimeStats{Reviews: 1, MedianSeconds: 42, AverageSeconds: 42, P90Seconds: 42}},
+		{"two", []int64{30, 10}, ReviewTimeStats{Reviews: 2, MedianSeconds: 20, AverageSeconds: 20, P90Seconds: 30}},
+		{"odd count", []int64{5, 1, 3}, ReviewTimeStats{Reviews: 3, MedianSeconds: 3, AverageSeconds: 3, P90Seconds: 5}},
+		{
+			name:      "ten",
+			durations: []int64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			want:      ReviewTimeStats{Reviews: 10, MedianSeconds: 5, AverageSeconds: 5, P90Seconds: 9},
+		},
+		{
+			name:      "eleven",
+			durations: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 100},
+			want:      ReviewTimeStats{Reviews: 11, MedianSeconds: 6, AverageSeconds: 14, P90Seconds: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			durations := append([]int64(nil), tt.durations...)
+			if got := summarizeDurations(durations); got != tt.want {
+				t.Errorf("summarizeDurations = %+v, want %+v", got, tt.want)
+			}
+			for i := range durations {
+				if durations[i] != tt.durations[i] {
+					t.Fatalf("summarizeDurations reordered its input: %v", durations)
+				}
+			}
+		})
+	}
+}
@@ -7,8 +7,9 @@ import (
 
 // Config holds storage configuration
 type Config struct {
-	Type            string // "local" or "s3"
+	Type            string // "local", "s3" or "memory"
 	LocalPath       string
+	SnapshotPath    string // Directory preloaded into memory storage (tests and demos)
 	S3Bucket        string
 	S3Region        string
 	S3AccessKey     string
@@ -16,13 +17,80 @@ type Config struct {
 	S3Prefix        string
 	S3CDNURL        string
 	S3UseIAMRole    bool
+
+	// S3-compatible endpoint settings (MinIO, Ceph RGW, ...)
+	S3Endpoint             string
+	S3UsePathStyle         bool
+	S3InsecureSkipVerify   bool
+	S3CACertFile           string
+	S3ChecksumCalculation  string // when_supported or when_required
+	S3ChecksumValidation   string // when_supported or when_required
+	S3ChecksumAlgorithm    string // CRC32, CRC32C, SHA1, SHA256 or empty
+	S3ServerSideEncryption string // AES256, aws:kms or none
+
+	// Replication: every write to this storage is mirrored to the replicas
+	Replicas        []ReplicaConfig
+	ReplicationMode string // "sync" or "async"
+
+	// Layout selects how objects are keyed: "default" or "cas" (content-addressed, deduplicated)
+	Layout string
+}
+
+// ReplicaConfig holds configuration for a named storage replica
+type ReplicaConfig struct {
+	Name   string
+	Config Config
 }
 
 // NewStorageFromConfig creates a storage service based on configuration
 func NewStorageFromConfig(cfg Config) (StorageService, error) {
+	primary, err := newBackendFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Replicas) == 0 {
+		return withLayout(primary, cfg.Layout)
+	}
+
+	replicas := make([]Replica, 0, len(cfg.Replicas))
+	for i, replicaCfg := range cfg.Replicas {
+		service, err := newBackendFromConfig(replicaCfg.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create replica %q: %w", replicaCfg.Name, err)
+		}
+		name := replicaCfg.Name
+		if name == "" {
+			name = fmt.Sprintf("replica%d", i+1)
+		}
+		replicas = append(replicas, Replica{Name: name, Service: service})
+	}
+
+	return withLayout(NewReplicatedStorage(primary, replicas, cfg.ReplicationMode), cfg.Layout)
+}
+
+// withLayout wraps the storage service for the configured storage layout
+func withLayout(service StorageService, layout string) (StorageService, error) {
+	switch layout {
+	case "", LayoutDefault:
+		return service, nil
+	case LayoutContentAddressed:
+		return NewContentAddressedStorage(service)
+	default:
+		return nil, fmt.Errorf("unsupported storage layout: %s", layout)
+	}
+}
+
+// newBackendFromConfig creates a single (non-replicated) storage backend
+func newBackendFromConfig(cfg Config) (StorageService, error) {
 	switch cfg.Type {
 	case "local":
 		return NewLocalStorage(cfg.LocalPath)
+	case "memory":
+		if cfg.SnapshotPath != "" {
+			return NewMemoryStorageFromSnapshot(cfg.SnapshotPath)
+		}
+		return NewMemoryStorage(), nil
 	case "s3":
 		s3cfg := S3Config{
 			Bucket:          cfg.S3Bucket,
@@ -32,6 +100,15 @@ func NewStorageFromConfig(cfg Config) (StorageService, error) {
 			Prefix:          cfg.S3Prefix,
 			CDNURL:          cfg.S3CDNURL,
 			UseIAMRole:      cfg.S3UseIAMRole,
+
+			Endpoint:             cfg.S3Endpoint,
+			UsePathStyle:         cfg.S3UsePathStyle,
+			InsecureSkipVerify:   cfg.S3InsecureSkipVerify,
+			CACertFile:           cfg.S3CACertFile,
+			ChecksumCalculation:  cfg.S3ChecksumCalculation,
+			ChecksumValidation:   cfg.S3ChecksumValidation,
+			ChecksumAlgorithm:    cfg.S3ChecksumAlgorithm,
+			ServerSideEncryption: cfg.S3ServerSideEncryption,
 		}
 		return NewS3Storage(s3cfg)
 	default:
@@ -43,9 +120,10 @@ func NewStorageFromConfig(cfg Config) (StorageService, error) {
 func LoadConfigFromEnv() Config {
 	storageType := getEnv("STORAGE_TYPE", "local")
 	
-	return Config{
+	cfg := Config{
 		Type:         storageType,
 		LocalPath:    getEnv("LOCAL_STORAGE_PATH", "./storage"),
+		SnapshotPath: getEnv("MEMORY_STORAGE_SNAPSHOT", ""),
 		S3Bucket:     getEnv("S3_BUCKET", ""),
 		S3Region:     getEnv("S3_REGION", "us-east-1"),
 		S3AccessKey:  getEnv("S3_ACCESS_KEY_ID", ""),
@@ -53,7 +131,44 @@ func LoadConfigFromEnv() Config {
 		S3Prefix:     getEnv("S3_PREFIX", "openwan/"),
 		S3CDNURL:     getEnv("S3_CDN_URL", ""),
 		S3UseIAMRole: getEnv("S3_USE_IAM_ROLE", "false") == "true",
+
+		S3Endpoint:             getEnv("S3_ENDPOINT", ""),
+		S3UsePathStyle:         getEnv("S3_USE_PATH_STYLE", "false") == "true",
+		S3InsecureSkipVerify:   getEnv("S3_INSECURE_SKIP_VERIFY", "false") == "true",
+		S3CACertFile:           getEnv("S3_CA_CERT_FILE", ""),
+		S3ChecksumCalculation:  getEnv("S3_CHECKSUM_CALCULATION", ""),
+		S3ChecksumValidation:   getEnv("S3_CHECKSUM_VALIDATION", ""),
+		S3ChecksumAlgorithm:    getEnv("S3_CHECKSUM_ALGORITHM", ""),
+		S3ServerSideEncryption: getEnv("S3_SERVER_SIDE_ENCRYPTION", ""),
+
+		ReplicationMode: getEnv("STORAGE_REPLICATION_MODE", ReplicationModeSync),
+		Layout:          getEnv("STORAGE_LAYOUT", LayoutDefault),
+	}
+
+	// Optional single replica configured through REPLICA_* variables
+	if replicaType := os.Getenv("REPLICA_STORAGE_TYPE"); replicaType != "" {
+		cfg.Replicas = append(cfg.Replicas, ReplicaConfig{
+			Name: getEnv("REPLICA_NAME", "dr"),
+			Config: Config{
+				Type:         replicaType,
+				LocalPath:    getEnv("REPLICA_LOCAL_STORAGE_PATH", "./storage-replica"),
+				S3Bucket:     getEnv("REPLICA_S3_BUCKET", ""),
+				S3Region:     getEnv("REPLICA_S3_REGION", cfg.S3Region),
+				S3AccessKey:  getEnv("REPLICA_S3_ACCESS_KEY_ID", cfg.S3AccessKey),
+				S3SecretKey:  getEnv("REPLICA_S3_SECRET_ACCESS_KEY", cfg.S3SecretKey),
+				S3Prefix:     getEnv("REPLICA_S3_PREFIX", cfg.S3Prefix),
+				S3UseIAMRole: getEnv("REPLICA_S3_USE_IAM_ROLE", "false") == "true",
+
+				S3Endpoint:             getEnv("REPLICA_S3_ENDPOINT", ""),
+				S3UsePathStyle:         getEnv("REPLICA_S3_USE_PATH_STYLE", "false") == "true",
+				S3InsecureSkipVerify:   getEnv("REPLICA_S3_INSECURE_SKIP_VERIFY", "false") == "true",
+				S3CACertFile:           getEnv("REPLICA_S3_CA_CERT_FILE", ""),
+				S3ServerSideEncryption: getEnv("REPLICA_S3_SERVER_SIDE_ENCRYPTION", ""),
+			},
+		})
 	}
+
+	return cfg
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Storage layouts
+const (
+	LayoutDefault          = "default" // Backend-generated paths, one object per upload
+	LayoutContentAddressed = "cas"     // Objects keyed by SHA-256 and shared between uploads
+)
+
+// ContentAddressedPrefix is the key prefix of content-addressed objects
+const ContentAddressedPrefix = "cas/"
+
+// ErrNoBlobIndex is returned when content-addressed objects would be stored or deleted without a
+// reference index; the references of shared objects must never be counted per process
+var ErrNoBlobIndex = errors.New("storage: content-addressed layout has no reference index")
+
+// BlobIndex tracks how many files reference each content-addressed object
+type BlobIndex interface {
+	// AddReference records one more reference to the object at path and returns the new count.
+	// It waits while RemoveUnreferenced removes the object.
+	AddReference(ctx context.Context, hash, path string, size int64) (int64, error)
+	// ReleaseReference removes one reference to the object at path and returns the remaining count
+	ReleaseReference(ctx context.Context, path string) (int64, error)
+	// CountReferences returns the number of references to objects with the given content hash
+	CountReferences(ctx context.Context, hash string) (int64, error)
+	// RemoveUnreferenced calls remove if the object at path has no references, keeping references
+	// from being added until it returns, and then forgets the object. It reports whether remove
+	// was called.
+	RemoveUnreferenced(ctx context.Context, path string, remove func() error) (bool, error)
+}
+
+// ContentAddressedStorage implements StorageService by storing each distinct content once under
+// cas/<aa>/<bb>/<sha256><ext>. Uploading identical content again only adds a reference, and Delete
+// removes the object when its last reference is released.
+//
+// Keys already below ContentAddressedPrefix (e.g. "<hash>-preview.flv" derivatives) are written as-is
+// and are only deleted once no file references the content they were derived from.
+type ContentAddressedStorage struct {
+	backend StorageService
+	writer  KeyedWriter
+	index   BlobIndex
+}
+
+// NewContentAddressedStorage creates a content-addressed decorator around backend.
+// The backend must support writing to a fixed key. Storing and deleting content fails with
+// ErrNoBlobIndex until SetIndex has been called.
+func NewContentAddressedStorage(backend StorageService) (*ContentAddressedStorage, error) {
+	writer, ok := backend.(KeyedWriter)
+	if !ok {
+		return nil, fmt.Errorf("storage backend %T does not support content-addressed layout", backend)
+	}
+
+	return &ContentAddressedStorage{
+		backend: backend,
+		writer:  writer,
+	}, nil
+}
+
+// SetIndex sets the persistent index used for reference counting
+func (s *ContentAddressedStorage) SetIndex(index BlobIndex) {
+	s.index = index
+}
+
+// HasIndex reports whether a reference index has been set
+func (s *ContentAddressedStorage) HasIndex() bool {
+	return s.index != nil
+}
+
+// Backend returns the wrapped storage service
+func (s *ContentAddressedStorage) Backend() StorageService {
+	return s.backend
+}
+
+// Upload stores the content under its SHA-256 key, reusing an existing object with the same content
+func (s *ContentAddressedStorage) Upload(ctx context.Context, filename string, content io.Reader, metadata map[string]string) (string, error) {
+	if strings.HasPrefix(filename, QuarantinePrefix) {
+		// Quarantined objects are never shared with clean uploads
+		if err := s.writer.Put(ctx, filename, content, metadata); err != nil {
+			return "", err
+		}
+		return filename, nil
+	}
+	if strings.HasPrefix(filename, ContentAddressedPrefix) {
+		// Derivative written next to its source object
+		if err := s.writer.Put(ctx, filename, content, metadata); err != nil {
+			return "", err
+		}
+		return filename, nil
+	}
+
+	if s.index == nil {
+		return "", ErrNoBlobIndex
+	}
+
+	// Spool the content to hash it before choosing the key
+	spool, err := os.CreateTemp("", "openwan-cas-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload spool: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, hasher), content)
+	if err != nil {
+		return "", fmt.Errorf("failed to read content: %w", err)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	key := ContentAddressedKey(hash, path.Ext(filename))
+
+	// The reference is taken before writing, so a concurrent Delete either sees it and keeps the
+	// object or has removed the object before it is counted here and written again
+	count, err := s.index.AddReference(ctx, hash, key, size)
+	if err != nil {
+		return "", fmt.Errorf("failed to record object reference: %w", err)
+	}
+	exists := false
+	if count > 1 {
+		// Written by an earlier upload unless its write failed or is still in progress
+		if exists, err = s.backend.Exists(ctx, key); err != nil {
+			s.Delete(ctx, key)
+			return "", fmt.Errorf("failed to check for existing object: %w", err)
+		}
+	}
+	if !exists {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			s.Delete(ctx, key)
+			return "", fmt.Errorf("failed to rewind upload spool: %w", err)
+		}
+		if err := s.writer.Put(ctx, key, spool, metadata); err != nil {
+			s.Delete(ctx, key)
+			return "", err
+		}
+	}
+
+	return key, nil
+}
+
+// Download retrieves a file from the backend
+func (s *ContentAddressedStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	return s.backend.Download(ctx, path)
+}
+
+// Delete releases one reference to the object and removes it when no references remain.
+// Paths outside the content-addressed layout are deleted directly.
+func (s *ContentAddressedStorage) Delete(ctx context.Context, path string) error {
+	hash, isBlob := parseContentAddressedKey(path)
+	if hash == "" {
+		return s.backend.Delete(ctx, path)
+	}
+	if s.index == nil {
+		return ErrNoBlobIndex
+	}
+
+	if !isBlob {
+		remaining, err := s.index.CountReferences(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to count object references: %w", err)
+		}
+		if remaining > 0 {
+			return nil
+		}
+		return s.backend.Delete(ctx, path)
+	}
+
+	remaining, err := s.index.ReleaseReference(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to release object reference: %w", err)
+	}
+	if remaining > 0 {
+		return nil
+	}
+	// An upload may have taken a new reference since; the index checks again while removing
+	_, err = s.index.RemoveUnreferenced(ctx, path, func() error {
+		return s.backend.Delete(ctx, path)
+	})
+	return err
+}
+
+// Exists checks if a file exists in the backend
+func (s *ContentAddressedStorage) Exists(ctx context.Context, path string) (bool, error) {
+	return s.backend.Exists(ctx, path)
+}
+
+// GetURL returns the backend URL for the file
+func (s *ContentAddressedStorage) GetURL(ctx context.Context, path string) (string, error) {
+	return s.backend.GetURL(ctx, path)
+}
+
+// GetMetadata returns the metadata stored with a file when the backend persists metadata
+func (s *ContentAddressedStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	reader, ok := s.backend.(MetadataReader)
+	if !ok {
+		return nil, ErrMetadataUnsupported
+	}
+	return reader.GetMetadata(ctx, path)
+}
+
+// ContentAddressedKey returns the storage key for content with the given SHA-256 hash
+func ContentAddressedKey(hash, ext string) string {
+	return fmt.Sprintf("%s%s/%s/%s%s", ContentAddressedPrefix, hash[0:2], hash[2:4], hash, strings.ToLower(ext))
+}
+
+// ContentHashFromPath returns the SHA-256 hash of a content-addressed object, or "" for other paths
+func ContentHashFromPath(p string) string {
+	hash, isBlob := parseContentAddressedKey(p)
+	if !isBlob {
+		return ""
+	}
+	return hash
+}
+
+// parseContentAddressedKey extracts the content hash from a content-addressed key and reports whether
+// the key is the object itself (true) or a derivative stored next to it (false)
+func parseContentAddressedKey(p string) (string, bool) {
+	if !strings.HasPrefix(p, ContentAddressedPrefix) {
+		return "", false
+	}
+
+	base := path.Base(p)
+	if len(base) < sha256.Size*2 {
+		return "", false
+	}
+	hash := base[:sha256.Size*2]
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+
+	rest := base[sha256.Size*2:]
+	return hash, rest == "" || (strings.HasPrefix(rest, ".") && !strings.Contains(rest[1:], "."))
+}
@@ -66,12 +66,46 @@ func (s *LocalStorage) Upload(ctx context.Context, filename string, content io.R
 	return relativePath, nil
 }
 
+// Put writes content to basePath/key
+func (s *LocalStorage) Put(ctx context.Context, key string, content io.Reader, metadata map[string]string) error {
+	fullPath := filepath.Join(s.basePath, key)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	
+	// Write to a temporary file first so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	
+	return nil
+}
+
 // Download retrieves a file from local storage
 func (s *LocalStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
 	fullPath := filepath.Join(s.basePath, path)
 	
 	file, err := os.Open(fullPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	
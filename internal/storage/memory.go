@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryObject is a file held by MemoryStorage
+type memoryObject struct {
+	data       []byte
+	metadata   map[string]string
+	modifiedAt time.Time
+}
+
+// MemoryStorage implements StorageService in memory. It is intended for tests and demos;
+// its contents can be saved to and loaded from a directory snapshot.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+	baseURL string
+}
+
+// NewMemoryStorage creates an empty in-memory storage service
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		objects: make(map[string]*memoryObject),
+		baseURL: "memory://",
+	}
+}
+
+// NewMemoryStorageFromSnapshot creates an in-memory storage service preloaded from a snapshot directory
+func NewMemoryStorageFromSnapshot(dir string) (*MemoryStorage, error) {
+	s := NewMemoryStorage()
+	if err := s.LoadSnapshot(dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetBaseURL sets the prefix used by GetURL (e.g. "/storage/" when served by the API for demos)
+func (s *MemoryStorage) SetBaseURL(baseURL string) {
+	s.baseURL = baseURL
+}
+
+// Upload stores the content under a date-based path, or under filename if it already contains a directory
+func (s *MemoryStorage) Upload(ctx context.Context, filename string, content io.Reader, metadata map[string]string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to read content: %w", err)
+	}
+
+	key := s.NormalizePath(filename)
+	if !strings.Contains(key, "/") {
+		contentHash := fmt.Sprintf("%x", md5.Sum(data))
+		key = path.Join(time.Now().Format("2006/01/02"), contentHash+path.Ext(key))
+	}
+
+	s.store(key, data, metadata)
+	return key, nil
+}
+
+// Put stores content under the exact key
+func (s *MemoryStorage) Put(ctx context.Context, key string, content io.Reader, metadata map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("failed to read content: %w", err)
+	}
+
+	s.store(s.NormalizePath(key), data, metadata)
+	return nil
+}
+
+// Download returns a reader over a copy of the stored content
+func (s *MemoryStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	object, ok := s.get(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	return io.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+// Delete removes a file; deleting a missing file is not an error
+func (s *MemoryStorage) Delete(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.objects, s.NormalizePath(path))
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Exists checks if a file exists
+func (s *MemoryStorage) Exists(ctx context.Context, path string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	_, ok := s.get(path)
+	return ok, nil
+}
+
+// GetURL returns a URL for the file using the configured base URL
+func (s *MemoryStorage) GetURL(ctx context.Context, path string) (string, error) {
+	return s.baseURL + s.NormalizePath(path), nil
+}
+
+// GetMetadata returns a copy of the metadata stored with a file
+func (s *MemoryStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	object, ok := s.get(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	metadata := make(map[string]string, len(object.metadata))
+	for k, v := range object.metadata {
+		metadata[k] = v
+	}
+	return metadata, nil
+}
+
+// List returns all stored paths in sorted order
+func (s *MemoryStorage) List() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	paths := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		paths = append(paths, key)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Reset removes all stored files
+func (s *MemoryStorage) Reset() {
+	s.mu.Lock()
+	s.objects = make(map[string]*memoryObject)
+	s.mu.Unlock()
+}
+
+// snapshotMetadataFile holds the metadata of every file in a snapshot directory
+const snapshotMetadataFile = ".metadata.json"
+
+// SaveSnapshot writes every stored file to dir, keeping the storage paths, plus a metadata index
+func (s *MemoryStorage) SaveSnapshot(dir string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index := make(map[string]map[string]string, len(s.objects))
+	for key, object := range s.objects {
+		target := filepath.Join(dir, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := os.WriteFile(target, object.data, 0644); err != nil {
+			return fmt.Errorf("failed to write snapshot file %s: %w", key, err)
+		}
+		if err := os.Chtimes(target, object.modifiedAt, object.modifiedAt); err != nil {
+			return fmt.Errorf("failed to set snapshot file time %s: %w", key, err)
+		}
+		index[key] = object.metadata
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotMetadataFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot metadata: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot adds every file below dir to the storage, using the relative path as storage path.
+// Any directory tree can be loaded; metadata is restored when the directory was written by SaveSnapshot.
+func (s *MemoryStorage) LoadSnapshot(dir string) error {
+	index := make(map[string]map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, snapshotMetadataFile)); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("failed to decode snapshot metadata: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read snapshot metadata: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filepath.WalkDir(dir, func(file string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || entry.Name() == snapshotMetadataFile {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot file %s: %w", rel, err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		metadata := index[key]
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		s.objects[key] = &memoryObject{
+			data:       data,
+			metadata:   metadata,
+			modifiedAt: info.ModTime(),
+		}
+		return nil
+	})
+}
+
+// NormalizePath converts a path to the slash-separated form used as key
+func (s *MemoryStorage) NormalizePath(p string) string {
+	return strings.TrimPrefix(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+}
+
+// store saves data and a copy of metadata under key
+func (s *MemoryStorage) store(key string, data []byte, metadata map[string]string) {
+	object := &memoryObject{
+		data:       data,
+		metadata:   make(map[string]string, len(metadata)),
+		modifiedAt: time.Now(),
+	}
+	for k, v := range metadata {
+		object.metadata[k] = v
+	}
+
+	s.mu.Lock()
+	s.objects[key] = object
+	s.mu.Unlock()
+}
+
+// get looks up a stored file
+func (s *MemoryStorage) get(p string) (*memoryObject, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, ok := s.objects[s.NormalizePath(p)]
+	return object, ok
+}
@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/queue"
+)
+
+// Replication modes
+const (
+	ReplicationModeSync  = "sync"  // Write replicas before Upload returns
+	ReplicationModeAsync = "async" // Enqueue replication jobs after the primary write
+)
+
+// Replication status values stored by the ReplicationRecorder
+const (
+	ReplicationStatusPending    = "pending"
+	ReplicationStatusReplicated = "replicated"
+	ReplicationStatusFailed     = "failed"
+)
+
+// ReplicationQueueName is the queue consumed by workers for async replication
+const ReplicationQueueName = "openwan_replication_jobs"
+
+// Replica is a named secondary storage that mirrors the primary
+type Replica struct {
+	Name    string
+	Service StorageService
+}
+
+// ReplicationRecord describes the replication state of one object on one replica
+type ReplicationRecord struct {
+	PrimaryPath  string
+	Replica      string
+	ReplicaPath  string
+	Status       string
+	Error        string
+	QueuedAt     time.Time
+	ReplicatedAt *time.Time
+}
+
+// ReplicationRecorder persists replication state so lag and failures can be tracked
+type ReplicationRecorder interface {
+	RecordReplication(ctx context.Context, record ReplicationRecord) error
+	FindReplicaPath(ctx context.Context, primaryPath, replica string) (string, error)
+	DeleteReplication(ctx context.Context, primaryPath string) error
+}
+
+// ReplicatedStorage implements StorageService by writing to a primary and one or more replicas.
+// Reads are served by the primary and fall back to replicas when the primary fails.
+type ReplicatedStorage struct {
+	primary  StorageService
+	replicas []Replica
+	mode     string
+	queue    queue.QueueService
+	recorder ReplicationRecorder
+}
+
+// NewReplicatedStorage creates a new replicating storage decorator
+func NewReplicatedStorage(primary StorageService, replicas []Replica, mode string) *ReplicatedStorage {
+	if mode != ReplicationModeAsync {
+		mode = ReplicationModeSync
+	}
+
+	return &ReplicatedStorage{
+		primary:  primary,
+		replicas: replicas,
+		mode:     mode,
+	}
+}
+
+// AsReplicated returns the ReplicatedStorage behind service, looking through the content-addressed layout
+func AsReplicated(service StorageService) (*ReplicatedStorage, bool) {
+	if cas, ok := service.(*ContentAddressedStorage); ok {
+		service = cas.Backend()
+	}
+	replicated, ok := service.(*ReplicatedStorage)
+	return replicated, ok
+}
+
+// SetQueue sets the queue used for async replication jobs
+func (s *ReplicatedStorage) SetQueue(q queue.QueueService) {
+	s.queue = q
+}
+
+// SetRecorder sets the recorder used to track replication state
+func (s *ReplicatedStorage) SetRecorder(recorder ReplicationRecorder) {
+	s.recorder = recorder
+}
+
+// Primary returns the primary storage service
+func (s *ReplicatedStorage) Primary() StorageService {
+	return s.primary
+}
+
+// Replicas returns the configured replicas
+func (s *ReplicatedStorage) Replicas() []Replica {
+	return s.replicas
+}
+
+// Mode returns the replication mode (sync or async)
+func (s *ReplicatedStorage) Mode() string {
+	return s.mode
+}
+
+// Upload writes the file to the primary and then replicates it
+func (s *ReplicatedStorage) Upload(ctx context.Context, filename string, content io.Reader, metadata map[string]string) (string, error) {
+	if s.mode == ReplicationModeAsync {
+		path, err := s.primary.Upload(ctx, filename, content, metadata)
+		if err != nil {
+			return "", err
+		}
+		for _, replica := range s.replicas {
+			s.enqueue(ctx, path, replica.Name, metadata)
+		}
+		return path, nil
+	}
+
+	// Sync mode: spool the content so it can be written to every replica
+	spool, err := os.CreateTemp("", "openwan-replicate-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create replication spool: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	path, err := s.primary.Upload(ctx, filename, io.TeeReader(content, spool), metadata)
+	if err != nil {
+		return "", err
+	}
+
+	for _, replica := range s.replicas {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to rewind replication spool: %w", err)
+		}
+		queuedAt := time.Now()
+		replicaPath, err := replica.Service.Upload(ctx, path, spool, metadata)
+		s.record(ctx, path, replica.Name, replicaPath, queuedAt, err)
+		if err != nil {
+			// The primary write succeeded; a failed replica is tracked and repaired by backfill
+			log.Printf("Replication of %s to %s failed: %v", path, replica.Name, err)
+		}
+	}
+
+	return path, nil
+}
+
+// Put writes the file to the primary under the exact key and then replicates it
+func (s *ReplicatedStorage) Put(ctx context.Context, key string, content io.Reader, metadata map[string]string) error {
+	writer, ok := s.primary.(KeyedWriter)
+	if !ok {
+		return fmt.Errorf("primary storage does not support writing to a fixed key")
+	}
+	if err := writer.Put(ctx, key, content, metadata); err != nil {
+		return err
+	}
+
+	for _, replica := range s.replicas {
+		if s.mode == ReplicationModeAsync {
+			s.enqueue(ctx, key, replica.Name, metadata)
+			continue
+		}
+		if err := s.ReplicateObject(ctx, key, replica.Name, metadata); err != nil {
+			// The primary write succeeded; a failed replica is tracked and repaired by backfill
+			log.Printf("Replication of %s to %s failed: %v", key, replica.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Download retrieves a file from the primary, falling back to replicas on error
+func (s *ReplicatedStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	reader, primaryErr := s.primary.Download(ctx, path)
+	if primaryErr == nil {
+		return reader, nil
+	}
+
+	for _, replica := range s.replicas {
+		reader, err := replica.Service.Download(ctx, s.replicaPath(ctx, path, replica.Name))
+		if err == nil {
+			log.Printf("Primary download of %s failed, served from replica %s: %v", path, replica.Name, primaryErr)
+			return reader, nil
+		}
+	}
+
+	return nil, primaryErr
+}
+
+// Delete removes a file from the primary and all replicas
+func (s *ReplicatedStorage) Delete(ctx context.Context, path string) error {
+	if err := s.primary.Delete(ctx, path); err != nil {
+		return err
+	}
+
+	for _, replica := range s.replicas {
+		if err := replica.Service.Delete(ctx, s.replicaPath(ctx, path, replica.Name)); err != nil {
+			log.Printf("Failed to delete %s from replica %s: %v", path, replica.Name, err)
+		}
+	}
+
+	if s.recorder != nil {
+		if err := s.recorder.DeleteReplication(ctx, path); err != nil {
+			log.Printf("Failed to clear replication records for %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Exists checks the primary, falling back to replicas on error
+func (s *ReplicatedStorage) Exists(ctx context.Context, path string) (bool, error) {
+	exists, primaryErr := s.primary.Exists(ctx, path)
+	if primaryErr == nil {
+		return exists, nil
+	}
+
+	for _, replica := range s.replicas {
+		exists, err := replica.Service.Exists(ctx, s.replicaPath(ctx, path, replica.Name))
+		if err == nil {
+			return exists, nil
+		}
+	}
+
+	return false, primaryErr
+}
+
+// GetURL returns the primary URL for the file
+func (s *ReplicatedStorage) GetURL(ctx context.Context, path string) (string, error) {
+	return s.primary.GetURL(ctx, path)
+}
+
+// GetMetadata returns the metadata stored with a file on the primary
+func (s *ReplicatedStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	reader, ok := s.primary.(MetadataReader)
+	if !ok {
+		return nil, ErrMetadataUnsupported
+	}
+	return reader.GetMetadata(ctx, path)
+}
+
+// ReplicateObject copies an object from the primary to the named replica.
+// It is used by async replication workers and by the backfill command.
+func (s *ReplicatedStorage) ReplicateObject(ctx context.Context, path, replicaName string, metadata map[string]string) error {
+	replica, ok := s.findReplica(replicaName)
+	if !ok {
+		return fmt.Errorf("unknown replica: %s", replicaName)
+	}
+
+	queuedAt := time.Now()
+	reader, err := s.primary.Download(ctx, path)
+	if err != nil {
+		s.record(ctx, path, replica.Name, "", queuedAt, err)
+		return fmt.Errorf("failed to read %s from primary: %w", path, err)
+	}
+	defer reader.Close()
+
+	replicaPath, err := replica.Service.Upload(ctx, path, reader, metadata)
+	s.record(ctx, path, replica.Name, replicaPath, queuedAt, err)
+	if err != nil {
+		return fmt.Errorf("failed to write %s to replica %s: %w", path, replica.Name, err)
+	}
+
+	return nil
+}
+
+// HandleReplicationJob processes a replication job message from the queue
+func (s *ReplicatedStorage) HandleReplicationJob(ctx context.Context, message *queue.Message) error {
+	var job queue.ReplicationJob
+	if err := json.Unmarshal([]byte(message.Body), &job); err != nil {
+		return fmt.Errorf("failed to unmarshal replication job: %w", err)
+	}
+
+	return s.ReplicateObject(ctx, job.Path, job.Replica, job.Metadata)
+}
+
+// enqueue publishes an async replication job, replicating in the background if no queue is available
+func (s *ReplicatedStorage) enqueue(ctx context.Context, path, replicaName string, metadata map[string]string) {
+	if s.recorder != nil {
+		record := ReplicationRecord{
+			PrimaryPath: path,
+			Replica:     replicaName,
+			Status:      ReplicationStatusPending,
+			QueuedAt:    time.Now(),
+		}
+		if err := s.recorder.RecordReplication(ctx, record); err != nil {
+			log.Printf("Failed to record pending replication of %s: %v", path, err)
+		}
+	}
+
+	if s.queue != nil {
+		job := queue.ReplicationJob{
+			Path:     path,
+			Replica:  replicaName,
+			Metadata: metadata,
+		}
+		body, err := json.Marshal(job)
+		if err == nil {
+			message := &queue.Message{
+				ID:        fmt.Sprintf("replicate-%s-%d", replicaName, time.Now().UnixNano()),
+				Body:      string(body),
+				Timestamp: time.Now(),
+				Attributes: map[string]string{
+					"path":    path,
+					"replica": replicaName,
+				},
+			}
+			if err = s.queue.Publish(ctx, ReplicationQueueName, message); err == nil {
+				return
+			}
+		}
+		log.Printf("⚠ Queue unavailable for replication of %s, replicating in background: %v", path, err)
+	}
+
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		if err := s.ReplicateObject(bgCtx, path, replicaName, metadata); err != nil {
+			log.Printf("Background replication failed: %v", err)
+		}
+	}()
+}
+
+// record stores the outcome of a replication attempt
+func (s *ReplicatedStorage) record(ctx context.Context, path, replicaName, replicaPath string, queuedAt time.Time, replicationErr error) {
+	if s.recorder == nil {
+		return
+	}
+
+	record := ReplicationRecord{
+		PrimaryPath: path,
+		Replica:     replicaName,
+		ReplicaPath: replicaPath,
+		QueuedAt:    queuedAt,
+	}
+	if replicationErr != nil {
+		record.Status = ReplicationStatusFailed
+		record.Error = replicationErr.Error()
+	} else {
+		now := time.Now()
+		record.Status = ReplicationStatusReplicated
+		record.ReplicatedAt = &now
+	}
+
+	if err := s.recorder.RecordReplication(ctx, record); err != nil {
+		log.Printf("Failed to record replication of %s to %s: %v", path, replicaName, err)
+	}
+}
+
+// replicaPath resolves the path of an object on a replica, defaulting to the primary path
+func (s *ReplicatedStorage) replicaPath(ctx context.Context, path, replicaName string) string {
+	if s.recorder == nil {
+		return path
+	}
+
+	replicaPath, err := s.recorder.FindReplicaPath(ctx, path, replicaName)
+	if err != nil || replicaPath == "" {
+		return path
+	}
+	return replicaPath
+}
+
+// findReplica looks up a replica by name
+func (s *ReplicatedStorage) findReplica(name string) (Replica, bool) {
+	for _, replica := range s.replicas {
+		if replica.Name == name {
+			return replica, true
+		}
+	}
+	return Replica{}, false
+}
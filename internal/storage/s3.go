@@ -2,27 +2,39 @@ package storage
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 )
 
 // S3Storage implements StorageService for AWS S3
 type S3Storage struct {
-	client       *s3.Client
-	uploader     *manager.Uploader
-	bucket       string
-	region       string
-	prefix       string
-	cdnURL       string
+	client            *s3.Client
+	uploader          *manager.Uploader
+	bucket            string
+	region            string
+	prefix            string
+	cdnURL            string
+	endpoint          string
+	usePathStyle      bool
+	sse               string
+	checksumAlgorithm string
 }
 
 // S3Config holds S3 configuration
@@ -34,6 +46,18 @@ type S3Config struct {
 	Prefix          string
 	CDNURL          string
 	UseIAMRole      bool
+
+	// S3-compatible endpoints (MinIO, Ceph RGW, ...)
+	Endpoint           string // Custom endpoint URL, e.g. http://minio:9000
+	UsePathStyle       bool   // Address buckets as endpoint/bucket/key instead of bucket.endpoint/key
+	InsecureSkipVerify bool   // Skip TLS certificate verification (self-signed test setups only)
+	CACertFile         string // PEM bundle of additional CAs trusted for the endpoint
+
+	// Checksums and encryption
+	ChecksumCalculation  string // "when_supported" (default) or "when_required"
+	ChecksumValidation   string // "when_supported" (default) or "when_required"
+	ChecksumAlgorithm    string // Per-request checksum for uploads: CRC32, CRC32C, SHA1, SHA256 or empty
+	ServerSideEncryption string // "AES256" (default), "aws:kms" or "none"
 }
 
 // NewS3Storage creates a new S3 storage service
@@ -43,41 +67,125 @@ func NewS3Storage(cfg S3Config) (*S3Storage, error) {
 	var awsCfg aws.Config
 	var err error
 	
+	loadOptions := []func(*config.LoadOptions) error{
+		config.WithRegion(cfg.Region),
+	}
+	
 	// Check if static credentials are provided and not empty
 	hasStaticCredentials := cfg.AccessKeyID != "" && cfg.SecretAccessKey != ""
 	
-	if cfg.UseIAMRole || !hasStaticCredentials {
-		// Use IAM role credentials or default credential chain (includes ~/.aws/credentials)
-		awsCfg, err = config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
-	} else {
-		// Use provided static credentials
-		awsCfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(cfg.Region),
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-				cfg.AccessKeyID,
-				cfg.SecretAccessKey,
-				"",
-			)),
-		)
+	if !cfg.UseIAMRole && hasStaticCredentials {
+		// Use provided static credentials; otherwise IAM role or default credential chain (includes ~/.aws/credentials)
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			cfg.AccessKeyID,
+			cfg.SecretAccessKey,
+			"",
+		)))
 	}
 	
+	// Custom TLS settings for on-prem endpoints
+	if cfg.InsecureSkipVerify || cfg.CACertFile != "" {
+		tlsConfig, err := buildTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+			tr.TLSClientConfig = tlsConfig
+		})
+		loadOptions = append(loadOptions, config.WithHTTPClient(httpClient))
+	}
+	
+	awsCfg, err = config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 	
-	client := s3.NewFromConfig(awsCfg)
+	calculation, err := parseChecksumCalculation(cfg.ChecksumCalculation)
+	if err != nil {
+		return nil, err
+	}
+	validation, err := parseChecksumValidation(cfg.ChecksumValidation)
+	if err != nil {
+		return nil, err
+	}
+	
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+		o.RequestChecksumCalculation = calculation
+		o.ResponseChecksumValidation = validation
+	})
 	uploader := manager.NewUploader(client)
 	
+	sse := cfg.ServerSideEncryption
+	if sse == "" {
+		sse = string(types.ServerSideEncryptionAes256)
+	}
+	
 	return &S3Storage{
-		client:   client,
-		uploader: uploader,
-		bucket:   cfg.Bucket,
-		region:   cfg.Region,
-		prefix:   cfg.Prefix,
-		cdnURL:   cfg.CDNURL,
+		client:            client,
+		uploader:          uploader,
+		bucket:            cfg.Bucket,
+		region:            cfg.Region,
+		prefix:            cfg.Prefix,
+		cdnURL:            cfg.CDNURL,
+		endpoint:          strings.TrimSuffix(cfg.Endpoint, "/"),
+		usePathStyle:      cfg.UsePathStyle,
+		sse:               sse,
+		checksumAlgorithm: strings.ToUpper(cfg.ChecksumAlgorithm),
 	}, nil
 }
 
+// buildTLSConfig creates the TLS configuration for custom endpoints
+func buildTLSConfig(cfg S3Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	
+	if cfg.CACertFile != "" {
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	
+	return tlsConfig, nil
+}
+
+// parseChecksumCalculation maps a config value to the SDK request checksum mode
+func parseChecksumCalculation(value string) (aws.RequestChecksumCalculation, error) {
+	switch strings.ToLower(value) {
+	case "", "when_supported":
+		return aws.RequestChecksumCalculationWhenSupported, nil
+	case "when_required":
+		return aws.RequestChecksumCalculationWhenRequired, nil
+	default:
+		return 0, fmt.Errorf("invalid S3 checksum calculation mode: %s", value)
+	}
+}
+
+// parseChecksumValidation maps a config value to the SDK response checksum mode
+func parseChecksumValidation(value string) (aws.ResponseChecksumValidation, error) {
+	switch strings.ToLower(value) {
+	case "", "when_supported":
+		return aws.ResponseChecksumValidationWhenSupported, nil
+	case "when_required":
+		return aws.ResponseChecksumValidationWhenRequired, nil
+	default:
+		return 0, fmt.Errorf("invalid S3 checksum validation mode: %s", value)
+	}
+}
+
 // Upload uploads a file to S3
 func (s *S3Storage) Upload(ctx context.Context, filename string, content io.Reader, metadata map[string]string) (string, error) {
 	// Check if filename already contains date path structure (e.g., starts with prefix/YYYY/MM/DD/)
@@ -91,6 +199,15 @@ func (s *S3Storage) Upload(ctx context.Context, filename string, content io.Read
 		key = s.generateS3Key(filename)
 	}
 	
+	if err := s.Put(ctx, key, content, metadata); err != nil {
+		return "", err
+	}
+	
+	return key, nil
+}
+
+// Put uploads content to S3 under the exact key
+func (s *S3Storage) Put(ctx context.Context, key string, content io.Reader, metadata map[string]string) error {
 	// Prepare S3 metadata
 	s3Metadata := make(map[string]string)
 	for k, v := range metadata {
@@ -103,7 +220,14 @@ func (s *S3Storage) Upload(ctx context.Context, filename string, content io.Read
 		Key:                  aws.String(key),
 		Body:                 content,
 		Metadata:             s3Metadata,
-		ServerSideEncryption: "AES256",
+	}
+	
+	// S3-compatible stores without a KMS reject SSE headers, so encryption can be disabled
+	if s.sse != "none" {
+		input.ServerSideEncryption = types.ServerSideEncryption(s.sse)
+	}
+	if s.checksumAlgorithm != "" {
+		input.ChecksumAlgorithm = types.ChecksumAlgorithm(s.checksumAlgorithm)
 	}
 	
 	if contentType, ok := metadata[MetadataContentType]; ok {
@@ -112,10 +236,10 @@ func (s *S3Storage) Upload(ctx context.Context, filename string, content io.Read
 	
 	_, err := s.uploader.Upload(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload to S3: %w", err)
+		return fmt.Errorf("failed to upload to S3: %w", err)
 	}
 	
-	return key, nil
+	return nil
 }
 
 // Download retrieves a file from S3
@@ -127,12 +251,38 @@ func (s *S3Storage) Download(ctx context.Context, path string) (io.ReadCloser, e
 	
 	result, err := s.client.GetObject(ctx, input)
 	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
 		return nil, fmt.Errorf("failed to download from S3: %w", err)
 	}
 	
 	return result.Body, nil
 }
 
+// GetMetadata returns the user metadata stored with an S3 object
+func (s *S3Storage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+	}
+	
+	result, err := s.client.HeadObject(ctx, input)
+	if err != nil {
+		if strings.Contains(err.Error(), "NotFound") {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return nil, fmt.Errorf("failed to read S3 metadata: %w", err)
+	}
+	
+	metadata := make(map[string]string, len(result.Metadata))
+	for k, v := range result.Metadata {
+		metadata[strings.ToLower(k)] = v
+	}
+	return metadata, nil
+}
+
 // Delete removes a file from S3
 func (s *S3Storage) Delete(ctx context.Context, path string) error {
 	input := &s3.DeleteObjectInput{
@@ -173,10 +323,41 @@ func (s *S3Storage) GetURL(ctx context.Context, path string) (string, error) {
 		return fmt.Sprintf("%s/%s", strings.TrimSuffix(s.cdnURL, "/"), path), nil
 	}
 	
+	// Custom endpoint (MinIO, Ceph RGW, ...)
+	if s.endpoint != "" {
+		if s.usePathStyle {
+			return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, path), nil
+		}
+		endpointURL, err := url.Parse(s.endpoint)
+		if err != nil {
+			return "", fmt.Errorf("invalid S3 endpoint: %w", err)
+		}
+		return fmt.Sprintf("%s://%s.%s/%s", endpointURL.Scheme, s.bucket, endpointURL.Host, path), nil
+	}
+	
 	// Otherwise, return S3 URL
 	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, path), nil
 }
 
+// EnsureBucket creates the bucket if it does not exist (useful for local MinIO test setups)
+func (s *S3Storage) EnsureBucket(ctx context.Context) error {
+	if _, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.bucket)}); err == nil {
+		return nil
+	}
+	
+	input := &s3.CreateBucketInput{Bucket: aws.String(s.bucket)}
+	if s.region != "" && s.region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(s.region),
+		}
+	}
+	
+	if _, err := s.client.CreateBucket(ctx, input); err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", s.bucket, err)
+	}
+	return nil
+}
+
 // generateS3Key generates S3 object key with prefix
 func (s *S3Storage) generateS3Key(filename string) string {
 	// Use timestamp-based path structure
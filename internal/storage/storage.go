@@ -2,9 +2,22 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
 )
 
+// ErrNotFound is returned (wrapped) by Download when the requested path does not exist
+var ErrNotFound = errors.New("storage: file not found")
+
+// ErrMetadataUnsupported is returned by decorators whose backend does not persist metadata
+var ErrMetadataUnsupported = errors.New("storage: backend does not store metadata")
+
+// QuarantinePrefix is the key prefix for content flagged by the malware scanner. Objects below it
+// are stored under their exact key by every layout.
+const QuarantinePrefix = "quarantine/"
+
 // StorageService defines the interface for file storage operations
 type StorageService interface {
 	// Upload uploads a file and returns the storage path
@@ -23,6 +36,19 @@ type StorageService interface {
 	GetURL(ctx context.Context, path string) (string, error)
 }
 
+// MetadataReader is implemented by backends that persist upload metadata
+type MetadataReader interface {
+	// GetMetadata returns the metadata stored with a file
+	GetMetadata(ctx context.Context, path string) (map[string]string, error)
+}
+
+// KeyedWriter is implemented by backends that can store a file under an exact key
+// instead of generating their own path
+type KeyedWriter interface {
+	// Put stores content under key, replacing any existing file
+	Put(ctx context.Context, key string, content io.Reader, metadata map[string]string) error
+}
+
 // Metadata keys
 const (
 	MetadataContentType    = "content-type"
@@ -31,3 +57,20 @@ const (
 	MetadataCategoryID     = "category-id"
 	MetadataFileType       = "file-type"
 )
+
+// PutQuarantined stores content under QuarantinePrefix+name, bypassing path generation and
+// content-addressed sharing, and returns the key
+func PutQuarantined(ctx context.Context, service StorageService, name string, content io.Reader, metadata map[string]string) (string, error) {
+	key := QuarantinePrefix + strings.TrimPrefix(name, "/")
+	if cas, ok := service.(*ContentAddressedStorage); ok {
+		return cas.Upload(ctx, key, content, metadata)
+	}
+	writer, ok := service.(KeyedWriter)
+	if !ok {
+		return "", fmt.Errorf("storage backend %T does not support quarantine", service)
+	}
+	if err := writer.Put(ctx, key, content, metadata); err != nil {
+		return "", err
+	}
+	return key, nil
+}
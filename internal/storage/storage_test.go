@@ -0,0 +1,416 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openwan/media-asset-management/internal/queue"
+	"github.com/openwan/media-asset-management/internal/storage"
+	"github.com/openwan/media-asset-management/internal/storage/storagetest"
+)
+
+func TestMemoryStorageConformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.StorageService {
+		return storage.NewMemoryStorage()
+	})
+}
+
+func TestLocalStorageConformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.StorageService {
+		s, err := storage.NewLocalStorage(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create local storage: %v", err)
+		}
+		return s
+	})
+}
+
+func TestReplicatedStorageConformance(t *testing.T) {
+	for _, mode := range []string{storage.ReplicationModeSync, storage.ReplicationModeAsync} {
+		t.Run(mode, func(t *testing.T) {
+			storagetest.RunConformance(t, func(t *testing.T) storage.StorageService {
+				replicas := []storage.Replica{{Name: "dr", Service: storage.NewMemoryStorage()}}
+				return storage.NewReplicatedStorage(storage.NewMemoryStorage(), replicas, mode)
+			})
+		})
+	}
+}
+
+func TestContentAddressedStorageConformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.StorageService {
+		local, err := storage.NewLocalStorage(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create local storage: %v", err)
+		}
+		s, err := storage.NewContentAddressedStorage(local)
+		if err != nil {
+			t.Fatalf("failed to create content-addressed storage: %v", err)
+		}
+		s.SetIndex(storagetest.NewMemoryBlobIndex())
+		return s
+	})
+}
+
+func TestContentAddressedStorageDeduplicates(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewMemoryStorage()
+	s, err := storage.NewContentAddressedStorage(backend)
+	if err != nil {
+		t.Fatalf("failed to create content-addressed storage: %v", err)
+	}
+	s.SetIndex(storagetest.NewMemoryBlobIndex())
+
+	first, err := s.Upload(ctx, "master.mov", bytes.NewReader([]byte("same master")), nil)
+	if err != nil {
+		t.Fatalf("first Upload returned error: %v", err)
+	}
+	second, err := s.Upload(ctx, "copy.mov", bytes.NewReader([]byte("same master")), nil)
+	if err != nil {
+		t.Fatalf("second Upload returned error: %v", err)
+	}
+	if first != second {
+		t.Fatalf("identical content stored at %q and %q", first, second)
+	}
+	if storage.ContentHashFromPath(first) == "" {
+		t.Errorf("ContentHashFromPath(%q) returned no hash", first)
+	}
+
+	// A derivative stored next to the object survives while any reference remains
+	preview := first[:len(first)-len(".mov")] + "-preview.flv"
+	if _, err := s.Upload(ctx, preview, bytes.NewReader([]byte("preview")), nil); err != nil {
+		t.Fatalf("preview Upload returned error: %v", err)
+	}
+
+	if err := s.Delete(ctx, first); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := s.Delete(ctx, preview); err != nil {
+		t.Fatalf("Delete of preview returned error: %v", err)
+	}
+	for _, path := range []string{first, preview} {
+		if exists, _ := s.Exists(ctx, path); !exists {
+			t.Fatalf("%s deleted while still referenced", path)
+		}
+	}
+
+	if err := s.Delete(ctx, second); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := s.Delete(ctx, preview); err != nil {
+		t.Fatalf("Delete of preview returned error: %v", err)
+	}
+	if paths := backend.List(); len(paths) != 0 {
+		t.Errorf("objects left after last reference was released: %v", paths)
+	}
+}
+
+// blockingDeleteStorage is a memory backend whose Delete waits for release after signalling deleting
+type blockingDeleteStorage struct {
+	*storage.MemoryStorage
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (s *blockingDeleteStorage) Delete(ctx context.Context, path string) error {
+	close(s.deleting)
+	<-s.release
+	return s.MemoryStorage.Delete(ctx, path)
+}
+
+func TestContentAddressedStorageUploadDuringDelete(t *testing.T) {
+	ctx := context.Background()
+	backend := &blockingDeleteStorage{
+		MemoryStorage: storage.NewMemoryStorage(),
+		deleting:      make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	s, err := storage.NewContentAddressedStorage(backend)
+	if err != nil {
+		t.Fatalf("failed to create content-addressed storage: %v", err)
+	}
+	index := storagetest.NewMemoryBlobIndex()
+	s.SetIndex(index)
+
+	key, err := s.Upload(ctx, "master.mov", bytes.NewReader([]byte("shared master")), nil)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+
+	// The last reference is released and the delete stalls while removing the object
+	deleted := make(chan error, 1)
+	go func() { deleted <- s.Delete(ctx, key) }()
+	<-backend.deleting
+
+	// Identical content arrives while the object is being removed
+	uploaded := make(chan error, 1)
+	go func() {
+		again, err := s.Upload(ctx, "copy.mov", bytes.NewReader([]byte("shared master")), nil)
+		if err == nil && again != key {
+			err = fmt.Errorf("stored at %q, want %q", again, key)
+		}
+		uploaded <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+
+	if err := <-deleted; err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := <-uploaded; err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if exists, _ := s.Exists(ctx, key); !exists {
+		t.Fatalf("%s missing although the second upload references it", key)
+	}
+	if count, _ := index.CountReferences(ctx, storage.ContentHashFromPath(key)); count != 1 {
+		t.Errorf("CountReferences = %d, want 1", count)
+	}
+}
+
+func TestContentAddressedStorageRequiresIndex(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewMemoryStorage()
+	s, err := storage.NewContentAddressedStorage(backend)
+	if err != nil {
+		t.Fatalf("failed to create content-addressed storage: %v", err)
+	}
+
+	if _, err := s.Upload(ctx, "master.mov", bytes.NewReader([]byte("master")), nil); !errors.Is(err, storage.ErrNoBlobIndex) {
+		t.Fatalf("Upload without index returned %v, want ErrNoBlobIndex", err)
+	}
+
+	// An object written by another process must survive a delete that cannot count its references
+	key := storage.ContentAddressedKey(strings.Repeat("ab", 32), ".mov")
+	if _, err := backend.Upload(ctx, key, bytes.NewReader([]byte("shared")), nil); err != nil {
+		t.Fatalf("failed to seed object: %v", err)
+	}
+	if err := s.Delete(ctx, key); !errors.Is(err, storage.ErrNoBlobIndex) {
+		t.Fatalf("Delete without index returned %v, want ErrNoBlobIndex", err)
+	}
+	if exists, _ := s.Exists(ctx, key); !exists {
+		t.Errorf("%s deleted without a reference index", key)
+	}
+}
+
+// TestS3StorageConformance runs against a real bucket when S3_TEST_BUCKET is set
+// (e.g. the MinIO service from docker-compose with S3_ENDPOINT and S3_USE_PATH_STYLE=true).
+func TestS3StorageConformance(t *testing.T) {
+	bucket := os.Getenv("S3_TEST_BUCKET")
+	if bucket == "" {
+		t.Skip("S3_TEST_BUCKET not set")
+	}
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.StorageService {
+		cfg := storage.LoadConfigFromEnv()
+		cfg.Type = "s3"
+		cfg.S3Bucket = bucket
+		cfg.S3Prefix = "conformance/"
+		cfg.Replicas = nil
+
+		s, err := storage.NewStorageFromConfig(cfg)
+		if err != nil {
+			t.Fatalf("failed to create S3 storage: %v", err)
+		}
+		return s
+	})
+}
+
+func TestReplicatedStorageFallsBackToReplica(t *testing.T) {
+	ctx := context.Background()
+	primary := storage.NewMemoryStorage()
+	replica := storage.NewMemoryStorage()
+	s := storage.NewReplicatedStorage(primary, []storage.Replica{{Name: "dr", Service: replica}}, storage.ReplicationModeSync)
+
+	path, err := s.Upload(ctx, "fallback.txt", bytes.NewReader([]byte("replicated")), nil)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+
+	// Simulate losing the object on the primary
+	if err := primary.Delete(ctx, path); err != nil {
+		t.Fatalf("failed to delete from primary: %v", err)
+	}
+
+	reader, err := s.Download(ctx, path)
+	if err != nil {
+		t.Fatalf("Download did not fall back to replica: %v", err)
+	}
+	defer reader.Close()
+
+	data, _ := io.ReadAll(reader)
+	if string(data) != "replicated" {
+		t.Errorf("Download returned %q, want %q", data, "replicated")
+	}
+}
+
+// memoryRecorder keeps the latest replication record per object and replica
+type memoryRecorder struct {
+	records map[string]storage.ReplicationRecord
+}
+
+func newMemoryRecorder() *memoryRecorder {
+	return &memoryRecorder{records: map[string]storage.ReplicationRecord{}}
+}
+
+func (r *memoryRecorder) RecordReplication(ctx context.Context, record storage.ReplicationRecord) error {
+	r.records[record.PrimaryPath+"@"+record.Replica] = record
+	return nil
+}
+
+func (r *memoryRecorder) FindReplicaPath(ctx context.Context, primaryPath, replica string) (string, error) {
+	return r.records[primaryPath+"@"+replica].ReplicaPath, nil
+}
+
+func (r *memoryRecorder) DeleteReplication(ctx context.Context, primaryPath string) error {
+	for key, record := range r.records {
+		if record.PrimaryPath == primaryPath {
+			delete(r.records, key)
+		}
+	}
+	return nil
+}
+
+// failingUploadStorage is a memory backend that refuses writes
+type failingUploadStorage struct {
+	*storage.MemoryStorage
+}
+
+func (s *failingUploadStorage) Upload(ctx context.Context, filename string, content io.Reader, metadata map[string]string) (string, error) {
+	return "", errors.New("replica offline")
+}
+
+// recordingQueue records published messages instead of delivering them
+type recordingQueue struct {
+	messages []*queue.Message
+}
+
+func (q *recordingQueue) Publish(ctx context.Context, queueName string, message *queue.Message) error {
+	q.messages = append(q.messages, message)
+	return nil
+}
+
+func (q *recordingQueue) Subscribe(ctx context.Context, queueName string, handler func(*queue.Message) error) error {
+	return nil
+}
+
+func (q *recordingQueue) Close() error {
+	return nil
+}
+
+func TestReplicatedStorageSyncReplication(t *testing.T) {
+	ctx := context.Background()
+	replica := storage.NewMemoryStorage()
+	s := storage.NewReplicatedStorage(storage.NewMemoryStorage(), []storage.Replica{
+		{Name: "dr", Service: replica},
+		{Name: "offline", Service: &failingUploadStorage{storage.NewMemoryStorage()}},
+	}, storage.ReplicationModeSync)
+	recorder := newMemoryRecorder()
+	s.SetRecorder(recorder)
+
+	// A failing replica does not fail the upload; it is recorded for the backfill
+	path, err := s.Upload(ctx, "videos/sync.mp4", bytes.NewReader([]byte("sync copy")), nil)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if exists, _ := replica.Exists(ctx, path); !exists {
+		t.Errorf("replica does not have %s when Upload returns", path)
+	}
+
+	tests := []struct {
+		replica string
+		status  string
+	}{
+		{"dr", storage.ReplicationStatusReplicated},
+		{"offline", storage.ReplicationStatusFailed},
+	}
+	for _, tt := range tests {
+		record := recorder.records[path+"@"+tt.replica]
+		if record.Status != tt.status {
+			t.Errorf("replication to %s status = %q, want %q", tt.replica, record.Status, tt.status)
+		}
+	}
+}
+
+func TestReplicatedStorageAsyncReplication(t *testing.T) {
+	ctx := context.Background()
+	replica := storage.NewMemoryStorage()
+	s := storage.NewReplicatedStorage(storage.NewMemoryStorage(), []storage.Replica{{Name: "dr", Service: replica}}, storage.ReplicationModeAsync)
+	jobs := &recordingQueue{}
+	s.SetQueue(jobs)
+	recorder := newMemoryRecorder()
+	s.SetRecorder(recorder)
+
+	path, err := s.Upload(ctx, "videos/async.mp4", bytes.NewReader([]byte("async copy")), nil)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if exists, _ := replica.Exists(ctx, path); exists {
+		t.Errorf("replica has %s before the job ran", path)
+	}
+	if status := recorder.records[path+"@dr"].Status; status != storage.ReplicationStatusPending {
+		t.Errorf("replication status before the job = %q, want %q", status, storage.ReplicationStatusPending)
+	}
+	if len(jobs.messages) != 1 {
+		t.Fatalf("published %d replication jobs, want 1", len(jobs.messages))
+	}
+
+	if err := s.HandleReplicationJob(ctx, jobs.messages[0]); err != nil {
+		t.Fatalf("HandleReplicationJob returned error: %v", err)
+	}
+	reader, err := replica.Download(ctx, path)
+	if err != nil {
+		t.Fatalf("replica does not have %s after the job: %v", path, err)
+	}
+	defer reader.Close()
+	if data, _ := io.ReadAll(reader); string(data) != "async copy" {
+		t.Errorf("replica content = %q, want %q", data, "async copy")
+	}
+	if status := recorder.records[path+"@dr"].Status; status != storage.ReplicationStatusReplicated {
+		t.Errorf("replication status after the job = %q, want %q", status, storage.ReplicationStatusReplicated)
+	}
+}
+
+func TestMemoryStorageSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	original := storage.NewMemoryStorage()
+	metadata := map[string]string{storage.MetadataOriginalName: "demo.mp4"}
+	path, err := original.Upload(ctx, "demo.mp4", bytes.NewReader([]byte("demo video")), metadata)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if err := original.SaveSnapshot(dir); err != nil {
+		t.Fatalf("SaveSnapshot returned error: %v", err)
+	}
+
+	restored, err := storage.NewMemoryStorageFromSnapshot(dir)
+	if err != nil {
+		t.Fatalf("NewMemoryStorageFromSnapshot returned error: %v", err)
+	}
+
+	reader, err := restored.Download(ctx, path)
+	if err != nil {
+		t.Fatalf("Download from restored snapshot returned error: %v", err)
+	}
+	defer reader.Close()
+	data, _ := io.ReadAll(reader)
+	if string(data) != "demo video" {
+		t.Errorf("restored content = %q, want %q", data, "demo video")
+	}
+
+	stored, err := restored.GetMetadata(ctx, path)
+	if err != nil {
+		t.Fatalf("GetMetadata returned error: %v", err)
+	}
+	if stored[storage.MetadataOriginalName] != "demo.mp4" {
+		t.Errorf("restored metadata = %v, want original name demo.mp4", stored)
+	}
+}
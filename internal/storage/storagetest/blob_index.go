@@ -0,0 +1,75 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBlobIndex is a process-local storage.BlobIndex for tests of the content-addressed layout
+type MemoryBlobIndex struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	hashes map[string]string
+}
+
+// NewMemoryBlobIndex creates an empty in-memory blob index
+func NewMemoryBlobIndex() *MemoryBlobIndex {
+	return &MemoryBlobIndex{
+		counts: make(map[string]int64),
+		hashes: make(map[string]string),
+	}
+}
+
+// AddReference records one more reference to the object at path
+func (i *MemoryBlobIndex) AddReference(ctx context.Context, hash, path string, size int64) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.counts[path]++
+	i.hashes[path] = hash
+	return i.counts[path], nil
+}
+
+// ReleaseReference removes one reference to the object at path
+func (i *MemoryBlobIndex) ReleaseReference(ctx context.Context, path string) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.counts[path] <= 1 {
+		delete(i.counts, path)
+		delete(i.hashes, path)
+		return 0, nil
+	}
+	i.counts[path]--
+	return i.counts[path], nil
+}
+
+// CountReferences returns the number of references to objects with the given content hash
+func (i *MemoryBlobIndex) CountReferences(ctx context.Context, hash string) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var total int64
+	for path, count := range i.counts {
+		if i.hashes[path] == hash {
+			total += count
+		}
+	}
+	return total, nil
+}
+
+// RemoveUnreferenced calls remove while no reference can be added if the object has none
+func (i *MemoryBlobIndex) RemoveUnreferenced(ctx context.Context, path string, remove func() error) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.counts[path] > 0 {
+		return false, nil
+	}
+	if err := remove(); err != nil {
+		return true, err
+	}
+	delete(i.counts, path)
+	delete(i.hashes, path)
+	return true, nil
+}
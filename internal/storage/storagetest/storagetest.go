@@ -0,0 +1,270 @@
+// Package storagetest provides a conformance test suite for storage.StorageService implementations.
+//
+// Every backend (and every decorator such as ReplicatedStorage) should pass RunConformance:
+//
+//	func TestMyStorageConformance(t *testing.T) {
+//		storagetest.RunConformance(t, func(t *testing.T) storage.StorageService {
+//			return newMyStorage(t)
+//		})
+//	}
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openwan/media-asset-management/internal/storage"
+)
+
+// Factory creates a fresh, empty storage service for a single test
+type Factory func(t *testing.T) storage.StorageService
+
+// uploadCounter keeps uploaded filenames unique across tests sharing a backend (e.g. one S3 bucket)
+var uploadCounter atomic.Int64
+
+// RunConformance runs the shared StorageService behaviour tests against the backend created by newStorage
+func RunConformance(t *testing.T, newStorage Factory) {
+	t.Run("UploadDownloadRoundTrip", func(t *testing.T) {
+		s := newStorage(t)
+		content := []byte("openwan conformance content")
+		path := upload(t, s, "clip.mp4", content, nil)
+		assertContent(t, s, path, content)
+	})
+
+	t.Run("BinaryContent", func(t *testing.T) {
+		s := newStorage(t)
+		content := make([]byte, 1024*1024+17)
+		if _, err := rand.Read(content); err != nil {
+			t.Fatalf("failed to generate content: %v", err)
+		}
+		path := upload(t, s, "binary.bin", content, nil)
+		assertContent(t, s, path, content)
+	})
+
+	t.Run("EmptyFile", func(t *testing.T) {
+		s := newStorage(t)
+		path := upload(t, s, "empty.txt", []byte{}, nil)
+		assertContent(t, s, path, []byte{})
+	})
+
+	t.Run("PathKeepsExtension", func(t *testing.T) {
+		s := newStorage(t)
+		path := upload(t, s, "document.pdf", []byte("%PDF-1.4"), nil)
+		if !strings.HasSuffix(path, ".pdf") {
+			t.Errorf("storage path %q lost the file extension", path)
+		}
+	})
+
+	t.Run("DistinctUploadsDoNotCollide", func(t *testing.T) {
+		s := newStorage(t)
+		first := upload(t, s, "a.txt", []byte("first"), nil)
+		second := upload(t, s, "b.txt", []byte("second"), nil)
+		if first == second {
+			t.Fatalf("different uploads returned the same path %q", first)
+		}
+		assertContent(t, s, first, []byte("first"))
+		assertContent(t, s, second, []byte("second"))
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		s := newStorage(t)
+		path := upload(t, s, "exists.txt", []byte("x"), nil)
+
+		exists, err := s.Exists(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Exists(%q) returned error: %v", path, err)
+		}
+		if !exists {
+			t.Errorf("Exists(%q) = false after upload", path)
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		s := newStorage(t)
+		path := missingPath()
+
+		exists, err := s.Exists(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Exists(%q) returned error for missing file: %v", path, err)
+		}
+		if exists {
+			t.Errorf("Exists(%q) = true for missing file", path)
+		}
+
+		reader, err := s.Download(context.Background(), path)
+		if err == nil {
+			reader.Close()
+			t.Fatalf("Download(%q) succeeded for missing file", path)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Download(%q) error = %v, want storage.ErrNotFound", path, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStorage(t)
+		path := upload(t, s, "delete.txt", []byte("to be deleted"), nil)
+
+		if err := s.Delete(context.Background(), path); err != nil {
+			t.Fatalf("Delete(%q) returned error: %v", path, err)
+		}
+
+		exists, err := s.Exists(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Exists(%q) returned error after delete: %v", path, err)
+		}
+		if exists {
+			t.Errorf("Exists(%q) = true after delete", path)
+		}
+
+		reader, err := s.Download(context.Background(), path)
+		if err == nil {
+			reader.Close()
+			t.Fatalf("Download(%q) succeeded after delete", path)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Download(%q) after delete error = %v, want storage.ErrNotFound", path, err)
+		}
+	})
+
+	t.Run("DeleteLeavesOtherFiles", func(t *testing.T) {
+		s := newStorage(t)
+		keep := upload(t, s, "keep.txt", []byte("keep"), nil)
+		remove := upload(t, s, "remove.txt", []byte("remove"), nil)
+
+		if err := s.Delete(context.Background(), remove); err != nil {
+			t.Fatalf("Delete(%q) returned error: %v", remove, err)
+		}
+		assertContent(t, s, keep, []byte("keep"))
+	})
+
+	t.Run("GetURL", func(t *testing.T) {
+		s := newStorage(t)
+		path := upload(t, s, "url.jpg", []byte("jpeg"), nil)
+
+		url, err := s.GetURL(context.Background(), path)
+		if err != nil {
+			t.Fatalf("GetURL(%q) returned error: %v", path, err)
+		}
+		if !strings.Contains(url, path) {
+			t.Errorf("GetURL(%q) = %q, want URL containing the storage path", path, url)
+		}
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		s := newStorage(t)
+		metadata := map[string]string{
+			storage.MetadataContentType:  "text/plain",
+			storage.MetadataOriginalName: "notes.txt",
+		}
+		path := upload(t, s, "notes.txt", []byte("notes"), metadata)
+
+		// Metadata must never be a reason for an upload to fail; it is only read back when supported
+		reader, ok := s.(storage.MetadataReader)
+		if !ok {
+			t.Skip("backend does not persist metadata")
+		}
+
+		stored, err := reader.GetMetadata(context.Background(), path)
+		if errors.Is(err, storage.ErrMetadataUnsupported) {
+			t.Skip("wrapped backend does not persist metadata")
+		}
+		if err != nil {
+			t.Fatalf("GetMetadata(%q) returned error: %v", path, err)
+		}
+		if got := stored[storage.MetadataOriginalName]; got != "notes.txt" {
+			t.Errorf("metadata %s = %q, want %q", storage.MetadataOriginalName, got, "notes.txt")
+		}
+
+		// Callers must not be able to change stored metadata through the map they passed in
+		metadata[storage.MetadataOriginalName] = "changed.txt"
+		stored, err = reader.GetMetadata(context.Background(), path)
+		if err != nil {
+			t.Fatalf("GetMetadata(%q) returned error: %v", path, err)
+		}
+		if got := stored[storage.MetadataOriginalName]; got != "notes.txt" {
+			t.Errorf("metadata changed through caller map: got %q", got)
+		}
+	})
+
+	t.Run("ConcurrentUploads", func(t *testing.T) {
+		s := newStorage(t)
+
+		const workers = 8
+		paths := make([]string, workers)
+		errs := make([]error, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				content := []byte(fmt.Sprintf("concurrent upload %d", i))
+				paths[i], errs[i] = s.Upload(context.Background(), uniqueName("concurrent.txt"), bytes.NewReader(content), nil)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < workers; i++ {
+			if errs[i] != nil {
+				t.Fatalf("concurrent upload %d failed: %v", i, errs[i])
+			}
+			assertContent(t, s, paths[i], []byte(fmt.Sprintf("concurrent upload %d", i)))
+		}
+	})
+}
+
+// upload stores content under a unique filename and fails the test on error
+func upload(t *testing.T, s storage.StorageService, filename string, content []byte, metadata map[string]string) string {
+	t.Helper()
+
+	path, err := s.Upload(context.Background(), uniqueName(filename), bytes.NewReader(content), metadata)
+	if err != nil {
+		t.Fatalf("Upload(%q) returned error: %v", filename, err)
+	}
+	if path == "" {
+		t.Fatalf("Upload(%q) returned an empty path", filename)
+	}
+
+	t.Cleanup(func() {
+		s.Delete(context.Background(), path)
+	})
+
+	return path
+}
+
+// assertContent downloads path and compares it with want
+func assertContent(t *testing.T, s storage.StorageService, path string, want []byte) {
+	t.Helper()
+
+	reader, err := s.Download(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Download(%q) returned error: %v", path, err)
+	}
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read %q: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Download(%q) returned %d bytes, want %d bytes with the uploaded content", path, len(got), len(want))
+	}
+}
+
+// uniqueName prefixes filename with a counter so repeated uploads never share a name
+func uniqueName(filename string) string {
+	return fmt.Sprintf("conformance-%d-%s", uploadCounter.Add(1), filename)
+}
+
+// missingPath returns a path that was never uploaded
+func missingPath() string {
+	return fmt.Sprintf("conformance/2000/01/01/missing-%d.txt", uploadCounter.Add(1))
+}
@@ -221,6 +221,49 @@ func (f *FFmpegWrapper) parseProgress(stderr io.Reader, callback func(float64),
 	return scanner.Err()
 }
 
+// ExtractGrayFrames decodes count frames spread evenly over duration seconds, scaled to
+// size×size 8-bit grayscale. A duration of 0 decodes the first frame only, for still images.
+func (f *FFmpegWrapper) ExtractGrayFrames(ctx context.Context, inputPath string, count int, duration float64, size int) ([][]byte, error) {
+	filter := fmt.Sprintf("scale=%d:%d,format=gray", size, size)
+	if duration > 0 && count > 1 {
+		// One frame per 1/count of the duration
+		filter = fmt.Sprintf("fps=%f,", float64(count)/duration) + filter
+	} else {
+		count = 1
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	args := []string{
+		"-v", "error",
+		"-i", inputPath,
+		"-an",
+		"-vf", filter,
+		"-frames:v", strconv.Itoa(count),
+		"-f", "rawvideo",
+		"-pix_fmt", "gray",
+		"pipe:1",
+	}
+	cmd := exec.CommandContext(timeoutCtx, f.binaryPath, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("FFmpeg frame extraction failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	frameSize := size * size
+	var frames [][]byte
+	for offset := 0; offset+frameSize <= len(output); offset += frameSize {
+		frames = append(frames, output[offset:offset+frameSize])
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames decoded from %s", inputPath)
+	}
+	return frames, nil
+}
+
 // GetVersion returns FFmpeg version information
 func (f *FFmpegWrapper) GetVersion(ctx context.Context) (string, error) {
 	cmd := exec.CommandContext(ctx, f.binaryPath, "-version")
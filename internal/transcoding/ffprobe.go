@@ -0,0 +1,97 @@
+package transcoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// FFprobe wraps ffprobe for inspecting media containers
+type FFprobe struct {
+	binaryPath string
+	timeout    time.Duration
+}
+
+// StreamInfo summarizes the streams ffprobe found in a container
+type StreamInfo struct {
+	FormatName   string // Comma-separated demuxer names, "mov,mp4,m4a,3gp,3g2,mj2"
+	VideoStreams int    // Excluding attached pictures such as cover art
+	AudioStreams int
+	Duration     float64 // Seconds
+}
+
+// NewFFprobe creates a new ffprobe wrapper
+func NewFFprobe(binaryPath string) *FFprobe {
+	return &FFprobe{
+		binaryPath: binaryPath,
+		timeout:    30 * time.Second,
+	}
+}
+
+// LookupFFprobe returns an ffprobe wrapper for FFPROBE_PATH or the ffprobe found in PATH,
+// or nil if ffprobe is not installed
+func LookupFFprobe() *FFprobe {
+	path := os.Getenv("FFPROBE_PATH")
+	if path == "" {
+		found, err := exec.LookPath("ffprobe")
+		if err != nil {
+			return nil
+		}
+		path = found
+	}
+	return NewFFprobe(path)
+}
+
+// ProbeStreams reads the container format and stream types of a local file
+func (f *FFprobe) ProbeStreams(ctx context.Context, filePath string) (*StreamInfo, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(timeoutCtx, f.binaryPath,
+		"-v", "error",
+		"-show_entries", "format=format_name,duration:stream=codec_type:stream_disposition=attached_pic",
+		"-of", "json",
+		filePath,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("ffprobe failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var probe struct {
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType   string `json:"codec_type"`
+			Disposition struct {
+				AttachedPic int `json:"attached_pic"`
+			} `json:"disposition"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &StreamInfo{FormatName: probe.Format.FormatName}
+	fmt.Sscanf(probe.Format.Duration, "%g", &info.Duration)
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if stream.Disposition.AttachedPic == 0 {
+				info.VideoStreams++
+			}
+		case "audio":
+			info.AudioStreams++
+		}
+	}
+	return info, nil
+}
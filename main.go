@@ -12,7 +12,9 @@ import (
 	"gorm.io/gorm/logger"
 	
 	"github.com/openwan/media-asset-management/internal/api"
+	"github.com/openwan/media-asset-management/internal/cache"
 	"github.com/openwan/media-asset-management/internal/database"
+	"github.com/openwan/media-asset-management/internal/fetch"
 	"github.com/openwan/media-asset-management/internal/repository"
 	"github.com/openwan/media-asset-management/internal/service"
 	"github.com/openwan/media-asset-management/internal/session"
@@ -85,6 +87,14 @@ func main() {
 		fmt.Println("✓ Redis session store connected")
 	}
 
+	// Shared Redis cache for configuration lookups; services fall back to the database without it
+	var cacheService cache.CacheService
+	if redisCache, err := cache.NewRedisCache(redisAddr, "", 0); err != nil {
+		log.Printf("Warning: Failed to connect to Redis cache: %v", err)
+	} else {
+		cacheService = redisCache
+	}
+
 	// Initialize storage service
 	fmt.Println("Initializing storage service...")
 	storageConfig := storage.LoadConfigFromEnv()
@@ -104,6 +114,16 @@ func main() {
 	levelsRepo := repository.NewLevelsRepository(db)
 	fmt.Println("✓ Repositories initialized")
 
+	// Persist reference counts of deduplicated objects
+	if casStorage, ok := storageService.(*storage.ContentAddressedStorage); ok {
+		casStorage.SetIndex(service.NewBlobIndex(mainRepo))
+	}
+
+	// Track replication state when storage replicas are configured
+	if replicatedStorage, ok := storage.AsReplicated(storageService); ok {
+		replicatedStorage.SetRecorder(service.NewReplicationTracker(mainRepo))
+	}
+
 	// Initialize services
 	fmt.Println("Initializing services...")
 	aclService := service.NewACLService(mainRepo)
@@ -116,22 +136,77 @@ func main() {
 	roleService := service.NewRoleService(mainRepo)
 	permissionService := service.NewPermissionService(mainRepo)
 	levelsService := service.NewLevelsService(levelsRepo)
+	quotaService := service.NewQuotaService(mainRepo)
+	sidecarService := service.NewSidecarService(mainRepo)
+	fileTypeRuleService := service.NewFileTypeRuleService(mainRepo, cacheService)
+	if err := fileTypeRuleService.EnsureDefaults(context.Background()); err != nil {
+		log.Printf("Warning: Failed to seed file type rules: %v", err)
+	}
+	workflowService := service.NewWorkflowService(mainRepo)
+	if err := workflowService.EnsureDefaults(context.Background()); err != nil {
+		log.Printf("Warning: Failed to seed default workflow: %v", err)
+	}
+	categoryService.SetWorkflowService(workflowService)
+	scanService := service.NewScanService(mainRepo, storageService, service.DefaultMalwareScanner())
+	if scanService.Enabled() {
+		fmt.Println("✓ Malware scanning enabled")
+	} else {
+		log.Println("Warning: CLAMD_ADDRESS not set, uploads are not scanned for malware")
+	}
+	duplicateService := service.NewDuplicateService(mainRepo, fileService, storageService)
+	fingerprintService := service.NewFingerprintService(mainRepo, storageService, nil)
+	fetcher, err := fetch.NewFetcherFromEnv()
+	if err != nil {
+		log.Printf("Warning: Server-side fetch disabled: %v", err)
+	}
+	uploadService := service.NewUploadService(fileService, storageService, nil)
+	uploadService.SetFileTypeRules(fileTypeRuleService)
+	fetchService := service.NewFetchService(mainRepo, uploadService, fetcher)
+	versionService := service.NewVersionService(mainRepo, uploadService, storageService)
+	reviewService := service.NewReviewService(mainRepo, fileService)
+	statsService := service.NewWorkflowStatsService(mainRepo, cacheService)
+	scheduleService := service.NewScheduleService(mainRepo, fileService)
+	trashService := service.NewTrashService(mainRepo, fileService, storageService)
+	legalHoldService := service.NewLegalHoldService(mainRepo)
+	bulkService := service.NewBulkService(mainRepo, fileService)
+	notificationService := service.NewNotificationService(mainRepo)
+	assignmentService := service.NewReviewAssignmentService(mainRepo, notificationService)
+	fileService.SetReviewAssignments(assignmentService)
+	commentService := service.NewCommentService(mainRepo)
 	fmt.Println("✓ Services initialized")
 
 	// Setup router dependencies
 	deps := &api.RouterDependencies{
-		SessionStore:      sessionStore,
-		ACLService:        aclService,
-		UsersService:      usersService,
-		FileService:       fileService,
-		CategoryService:   categoryService,
-		CatalogService:    catalogService,
-		SearchService:     searchService,
-		GroupService:      groupService,
-		RoleService:       roleService,
-		PermissionService: permissionService,
-		LevelsService:     levelsService,
-		StorageService:    storageService,
+		SessionStore:        sessionStore,
+		ACLService:          aclService,
+		UsersService:        usersService,
+		FileService:         fileService,
+		CategoryService:     categoryService,
+		CatalogService:      catalogService,
+		SearchService:       searchService,
+		GroupService:        groupService,
+		RoleService:         roleService,
+		PermissionService:   permissionService,
+		LevelsService:       levelsService,
+		QuotaService:        quotaService,
+		SidecarService:      sidecarService,
+		FileTypeRuleService: fileTypeRuleService,
+		ScanService:         scanService,
+		DuplicateService:    duplicateService,
+		FingerprintService:  fingerprintService,
+		FetchService:        fetchService,
+		VersionService:      versionService,
+		WorkflowService:     workflowService,
+		ReviewService:       reviewService,
+		StatsService:        statsService,
+		ScheduleService:     scheduleService,
+		TrashService:        trashService,
+		LegalHoldService:    legalHoldService,
+		BulkService:         bulkService,
+		AssignmentService:   assignmentService,
+		NotificationService: notificationService,
+		CommentService:      commentService,
+		StorageService:      storageService,
 	}
 
 	// Setup router
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `ow_storage_replicas`;
@@ -0,0 +1,16 @@
+-- Track replication of stored objects to secondary storage (DR copies)
+CREATE TABLE IF NOT EXISTS `ow_storage_replicas` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `primary_path` varchar(255) NOT NULL COMMENT 'Object path on primary storage',
+  `replica` varchar(64) NOT NULL COMMENT 'Replica name',
+  `replica_path` varchar(255) NOT NULL DEFAULT '' COMMENT 'Object path on replica storage',
+  `status` varchar(16) NOT NULL COMMENT 'Status (pending, replicated, failed)',
+  `attempts` int(11) NOT NULL DEFAULT '0' COMMENT 'Replication attempts',
+  `last_error` text COMMENT 'Last replication error',
+  `queued_at` datetime NOT NULL COMMENT 'Time replication was requested',
+  `replicated_at` datetime DEFAULT NULL COMMENT 'Time replication completed',
+  `updated_at` datetime NOT NULL COMMENT 'Last update time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_path_replica` (`primary_path`, `replica`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Storage replication tracking';
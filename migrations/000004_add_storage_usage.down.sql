@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `ow_storage_usage`;
+
+ALTER TABLE `ow_files`
+DROP COLUMN `derivative_size`;
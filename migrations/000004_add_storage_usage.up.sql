@@ -0,0 +1,15 @@
+-- Track storage used by previews and other derivatives per file
+ALTER TABLE `ow_files`
+ADD COLUMN `derivative_size` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Derivative size in bytes' AFTER `size`;
+
+-- Storage usage counters per user and per group (rebuilt by cmd/quota)
+CREATE TABLE IF NOT EXISTS `ow_storage_usage` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `scope_type` varchar(16) NOT NULL COMMENT 'Scope (user or group)',
+  `scope_id` int(11) NOT NULL COMMENT 'User ID or group ID',
+  `used_bytes` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Used bytes (originals + derivatives)',
+  `file_count` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Number of files',
+  `updated_at` datetime NOT NULL COMMENT 'Last update time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_scope` (`scope_type`, `scope_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Storage usage accounting';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `ow_blobs`;
+
+ALTER TABLE `ow_files`
+DROP KEY `idx_content_hash`,
+DROP COLUMN `content_hash`;
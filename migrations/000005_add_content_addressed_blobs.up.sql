@@ -0,0 +1,18 @@
+-- SHA-256 of the stored content, set for files in the content-addressed storage layout
+ALTER TABLE `ow_files`
+ADD COLUMN `content_hash` char(64) NOT NULL DEFAULT '' COMMENT 'SHA-256 of content' AFTER `name`,
+ADD KEY `idx_content_hash` (`content_hash`);
+
+-- Reference counts of content-addressed objects shared between files
+CREATE TABLE IF NOT EXISTS `ow_blobs` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `hash` char(64) NOT NULL COMMENT 'SHA-256 of content',
+  `path` varchar(255) NOT NULL COMMENT 'Object path in storage',
+  `size` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Size in bytes',
+  `ref_count` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Number of referencing files',
+  `created_at` datetime NOT NULL COMMENT 'Creation time',
+  `updated_at` datetime NOT NULL COMMENT 'Last update time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_path` (`path`),
+  KEY `idx_hash` (`hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Content-addressed storage objects';
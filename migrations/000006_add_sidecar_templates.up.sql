@@ -0,0 +1,13 @@
+-- Mapping templates for metadata sidecars delivered with media files
+CREATE TABLE IF NOT EXISTS `ow_sidecar_templates` (
+  `id` int(11) NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `name` varchar(64) NOT NULL COMMENT 'Template name',
+  `format` varchar(16) NOT NULL COMMENT 'Sidecar format: xml, json, csv',
+  `description` varchar(255) NOT NULL DEFAULT '' COMMENT 'Description',
+  `mappings` text NOT NULL COMMENT 'JSON array of field mappings',
+  `enabled` tinyint(1) NOT NULL DEFAULT '1' COMMENT 'Enabled',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  `updated` int(11) NOT NULL COMMENT 'Last update time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Sidecar metadata mapping templates';
@@ -0,0 +1,16 @@
+-- Upload rules per extension; category_id 0 applies to all categories, other rows override
+-- the rule for the same extension in that category and its subcategories.
+-- The API seeds the default rules on startup when the table is empty.
+CREATE TABLE IF NOT EXISTS `ow_file_type_rules` (
+  `id` int(11) NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `category_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Category override, 0 = all categories',
+  `ext` varchar(16) NOT NULL COMMENT 'Extension including dot',
+  `mime_types` varchar(255) NOT NULL DEFAULT '' COMMENT 'Comma-separated accepted MIME types',
+  `type` int(11) NOT NULL COMMENT 'File type: 1=video, 2=audio, 3=image, 4=rich',
+  `max_size` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Maximum size in bytes, 0 = unlimited',
+  `enabled` tinyint(1) NOT NULL DEFAULT '1' COMMENT 'Enabled',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  `updated` int(11) NOT NULL COMMENT 'Last update time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_category_ext` (`category_id`, `ext`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='File type and extension upload rules';
@@ -0,0 +1,2 @@
+ALTER TABLE `ow_files`
+DROP COLUMN `mime_type`;
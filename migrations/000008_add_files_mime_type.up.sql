@@ -0,0 +1,3 @@
+-- MIME type detected from the file content at upload; empty for files uploaded before detection
+ALTER TABLE `ow_files`
+ADD COLUMN `mime_type` varchar(128) NOT NULL DEFAULT '' COMMENT 'Detected MIME type' AFTER `ext`;
@@ -0,0 +1,5 @@
+ALTER TABLE `ow_files`
+DROP KEY `idx_scan_status`,
+DROP COLUMN `scanned_at`,
+DROP COLUMN `scan_result`,
+DROP COLUMN `scan_status`;
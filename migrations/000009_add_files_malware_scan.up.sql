@@ -0,0 +1,6 @@
+-- Malware scan results; files uploaded before scanning are recorded as skipped
+ALTER TABLE `ow_files`
+ADD COLUMN `scan_status` varchar(16) NOT NULL DEFAULT 'skipped' COMMENT 'Scan status (pending, clean, infected, skipped)' AFTER `putout_at`,
+ADD COLUMN `scan_result` varchar(255) NOT NULL DEFAULT '' COMMENT 'Detected signature or scanner error' AFTER `scan_status`,
+ADD COLUMN `scanned_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Scan time' AFTER `scan_result`,
+ADD KEY `idx_scan_status` (`scan_status`);
@@ -0,0 +1,7 @@
+ALTER TABLE `ow_files`
+DROP KEY `idx_duplicate_of`,
+DROP KEY `idx_name`,
+DROP COLUMN `duplicate_of`;
+
+ALTER TABLE `ow_category`
+DROP COLUMN `duplicate_policy`;
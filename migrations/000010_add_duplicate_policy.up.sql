@@ -0,0 +1,10 @@
+-- Per-category duplicate upload policy; '' inherits from the parent category
+ALTER TABLE `ow_category`
+ADD COLUMN `duplicate_policy` varchar(16) NOT NULL DEFAULT '' COMMENT 'Duplicate upload policy (reject, warn, allow)' AFTER `enabled`;
+
+-- Links duplicates accepted under the warn policy to their canonical file, and indexes the
+-- content MD5 used by the duplicate check and report
+ALTER TABLE `ow_files`
+ADD COLUMN `duplicate_of` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'Canonical file ID for linked duplicates' AFTER `name`,
+ADD KEY `idx_name` (`name`),
+ADD KEY `idx_duplicate_of` (`duplicate_of`);
@@ -0,0 +1,15 @@
+-- Perceptual hashes computed by the worker for near-duplicate detection. Similar files are
+-- found with BIT_COUNT(phash ^ ?) <= max distance.
+CREATE TABLE IF NOT EXISTS `ow_file_fingerprints` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `kind` varchar(16) NOT NULL COMMENT 'image or video',
+  `phash` bigint(20) unsigned NOT NULL COMMENT 'DCT perceptual hash; for video the majority of the frame hashes',
+  `dhash` bigint(20) unsigned NOT NULL COMMENT 'Difference hash',
+  `frame_hashes` text NOT NULL COMMENT 'Comma-separated hex pHashes of sampled video frames',
+  `frames` int(11) NOT NULL DEFAULT '1' COMMENT 'Number of hashed frames',
+  `created` int(11) NOT NULL COMMENT 'Computation time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_file_id` (`file_id`),
+  KEY `idx_kind` (`kind`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Perceptual hashes of images and videos';
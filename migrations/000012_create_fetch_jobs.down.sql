@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `ow_fetch_jobs`;
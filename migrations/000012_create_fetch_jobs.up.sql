@@ -0,0 +1,24 @@
+-- Server-side fetches of remote files (POST /api/v1/files/fetch), processed by the workers
+CREATE TABLE IF NOT EXISTS `ow_fetch_jobs` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `source_url` varchar(1024) NOT NULL COMMENT 'http, https or ftp URL',
+  `checksum` varchar(160) NOT NULL DEFAULT '' COMMENT 'Expected digest, algorithm:hex',
+  `category_id` int(11) NOT NULL COMMENT 'Category ID',
+  `title` varchar(255) NOT NULL DEFAULT '' COMMENT 'Title, empty = source filename',
+  `type` int(11) NOT NULL DEFAULT '0' COMMENT 'File type, 0 = detect',
+  `level` int(11) NOT NULL DEFAULT '0' COMMENT 'Access level',
+  `groups` varchar(255) NOT NULL DEFAULT '' COMMENT 'Groups',
+  `catalog_info` text NOT NULL COMMENT 'JSON catalog metadata',
+  `username` varchar(64) NOT NULL COMMENT 'Requester',
+  `status` varchar(16) NOT NULL COMMENT 'queued, running, completed, failed',
+  `file_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'Created file',
+  `existing_file_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'Existing file for duplicates',
+  `bytes` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Bytes received',
+  `error` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Failure reason',
+  `created` int(11) NOT NULL COMMENT 'Submission time',
+  `started_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Start time',
+  `finished_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Completion time',
+  PRIMARY KEY (`id`),
+  KEY `idx_username` (`username`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Server-side URL fetch jobs';
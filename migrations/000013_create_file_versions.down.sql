@@ -0,0 +1,5 @@
+ALTER TABLE `ow_files`
+DROP COLUMN `version_size`,
+DROP COLUMN `version`;
+
+DROP TABLE IF EXISTS `ow_file_versions`;
@@ -0,0 +1,25 @@
+-- Revisions of a file's content. ow_files keeps describing the current version, so downloads and
+-- previews serve it by default; rows are written from the first revision on.
+CREATE TABLE IF NOT EXISTS `ow_file_versions` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `version` int(11) NOT NULL COMMENT 'Version number, from 1',
+  `name` varchar(255) NOT NULL COMMENT 'Content MD5',
+  `content_hash` char(64) NOT NULL DEFAULT '' COMMENT 'SHA-256, content-addressed layout only',
+  `ext` varchar(16) NOT NULL COMMENT 'Extension',
+  `mime_type` varchar(128) NOT NULL DEFAULT '' COMMENT 'Detected MIME type',
+  `size` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Size in bytes',
+  `path` varchar(255) NOT NULL COMMENT 'Storage path',
+  `scan_status` varchar(16) NOT NULL DEFAULT 'skipped' COMMENT 'Malware scan status',
+  `scan_result` varchar(255) NOT NULL DEFAULT '' COMMENT 'Detected signature or scanner error',
+  `restored_from` int(11) NOT NULL DEFAULT '0' COMMENT 'Restored version, 0 = new content',
+  `comment` varchar(255) NOT NULL DEFAULT '' COMMENT 'Revision note',
+  `username` varchar(64) NOT NULL COMMENT 'Uploaded or restored by',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_file_version` (`file_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='File versions';
+
+ALTER TABLE `ow_files`
+ADD COLUMN `version` int(11) NOT NULL DEFAULT '1' COMMENT 'Current version' AFTER `derivative_size`,
+ADD COLUMN `version_size` bigint(20) NOT NULL DEFAULT '0' COMMENT 'Bytes held by earlier versions' AFTER `version`;
@@ -0,0 +1,4 @@
+ALTER TABLE `ow_category`
+DROP COLUMN `workflow_id`;
+
+DROP TABLE IF EXISTS `ow_workflows`;
@@ -0,0 +1,19 @@
+-- Workflow definitions: the states a file moves through and the transitions allowed between them,
+-- with the permission and metadata each transition requires. The default workflow is seeded by
+-- the API server on start.
+CREATE TABLE IF NOT EXISTS `ow_workflows` (
+  `id` int(11) NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `name` varchar(64) NOT NULL COMMENT 'Name',
+  `description` varchar(255) NOT NULL DEFAULT '' COMMENT 'Description',
+  `states` text NOT NULL COMMENT 'States (JSON)',
+  `transitions` text NOT NULL COMMENT 'Transitions (JSON)',
+  `is_default` tinyint(1) NOT NULL DEFAULT '0' COMMENT 'Used by categories without a workflow',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  `updated` int(11) NOT NULL COMMENT 'Update time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Workflow definitions';
+
+-- Per-category workflow; 0 inherits from the parent category
+ALTER TABLE `ow_category`
+ADD COLUMN `workflow_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Workflow ID, 0 = inherit' AFTER `duplicate_policy`;
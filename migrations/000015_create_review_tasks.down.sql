@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `ow_review_votes`;
+DROP TABLE IF EXISTS `ow_review_assignees`;
+DROP TABLE IF EXISTS `ow_review_tasks`;
+
+ALTER TABLE `ow_workflows`
+DROP COLUMN `review_stages`;
@@ -0,0 +1,46 @@
+-- Review stages of a workflow: pending files must be approved by each stage in turn before they
+-- are published
+ALTER TABLE `ow_workflows`
+ADD COLUMN `review_stages` text NOT NULL COMMENT 'Review stages (JSON)' AFTER `transitions`;
+
+-- One task per review stage of a pending file; reviewers and quorum are copied from the workflow
+CREATE TABLE IF NOT EXISTS `ow_review_tasks` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `workflow_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Workflow ID, 0 = built-in workflow',
+  `stage` int(11) NOT NULL COMMENT 'Index of the review stage',
+  `stage_name` varchar(64) NOT NULL COMMENT 'Review stage name',
+  `quorum` int(11) NOT NULL COMMENT 'Approvals required',
+  `approvals` int(11) NOT NULL DEFAULT '0' COMMENT 'Approvals received',
+  `status` varchar(16) NOT NULL COMMENT 'open, approved, rejected or cancelled',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  `closed` int(11) NOT NULL DEFAULT '0' COMMENT 'Completion time',
+  PRIMARY KEY (`id`),
+  KEY `idx_file_id` (`file_id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Review tasks';
+
+CREATE TABLE IF NOT EXISTS `ow_review_assignees` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `task_id` bigint(20) unsigned NOT NULL COMMENT 'Review task ID',
+  `user_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Reviewer user ID',
+  `group_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Reviewer group ID',
+  PRIMARY KEY (`id`),
+  KEY `idx_task_id` (`task_id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_group_id` (`group_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Review task assignees';
+
+CREATE TABLE IF NOT EXISTS `ow_review_votes` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `task_id` bigint(20) unsigned NOT NULL COMMENT 'Review task ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `user_id` int(11) NOT NULL COMMENT 'Reviewer user ID',
+  `username` varchar(64) NOT NULL COMMENT 'Reviewer',
+  `decision` varchar(16) NOT NULL COMMENT 'approve or reject',
+  `comment` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Comment',
+  `created` int(11) NOT NULL COMMENT 'Vote time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_task_user` (`task_id`, `user_id`),
+  KEY `idx_file_id` (`file_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Review votes';
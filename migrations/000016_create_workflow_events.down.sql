@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `ow_workflow_events`;
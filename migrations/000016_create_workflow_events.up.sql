@@ -0,0 +1,16 @@
+-- Workflow history: one row per status change of a file, written in the same transaction as the
+-- status update. Rows are kept when the file is deleted.
+CREATE TABLE IF NOT EXISTS `ow_workflow_events` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `from_status` int(11) NOT NULL COMMENT 'Previous status',
+  `to_status` int(11) NOT NULL COMMENT 'New status',
+  `transition` varchar(64) NOT NULL DEFAULT '' COMMENT 'Workflow transition name',
+  `username` varchar(64) NOT NULL COMMENT 'Actor',
+  `comment` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Reason or comment',
+  `created` int(11) NOT NULL COMMENT 'Event time',
+  PRIMARY KEY (`id`),
+  KEY `idx_file_id` (`file_id`),
+  KEY `idx_username` (`username`),
+  KEY `idx_created` (`created`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Workflow history';
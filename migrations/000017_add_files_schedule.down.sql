@@ -0,0 +1,5 @@
+ALTER TABLE `ow_files`
+DROP KEY `idx_unpublish_at`,
+DROP KEY `idx_publish_at`,
+DROP COLUMN `unpublish_at`,
+DROP COLUMN `publish_at`;
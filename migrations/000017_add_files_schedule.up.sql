@@ -0,0 +1,7 @@
+-- Publication window: files are hidden from search and preview outside it, and the scheduler
+-- publishes and withdraws them through the workflow
+ALTER TABLE `ow_files`
+ADD COLUMN `publish_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Embargo end and scheduled publication, 0 = none' AFTER `putout_at`,
+ADD COLUMN `unpublish_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Scheduled withdrawal, 0 = none' AFTER `publish_at`,
+ADD KEY `idx_publish_at` (`publish_at`),
+ADD KEY `idx_unpublish_at` (`unpublish_at`);
@@ -0,0 +1,7 @@
+ALTER TABLE `ow_category`
+DROP COLUMN `trash_retention_days`;
+
+ALTER TABLE `ow_files`
+DROP KEY `idx_deleted_at`,
+DROP COLUMN `deleted_by`,
+DROP COLUMN `deleted_at`;
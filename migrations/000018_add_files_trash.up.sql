@@ -0,0 +1,13 @@
+-- Trash bin: deleted files keep their row and objects until restored or purged. The purger removes
+-- them once the category's retention has passed.
+ALTER TABLE `ow_files`
+ADD COLUMN `deleted_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Moved to the trash at, 0 = not deleted' AFTER `unpublish_at`,
+ADD COLUMN `deleted_by` varchar(64) NOT NULL DEFAULT '' COMMENT 'Deleted by' AFTER `deleted_at`,
+ADD KEY `idx_deleted_at` (`deleted_at`);
+
+-- Files deleted before the trash existed start their retention now
+UPDATE `ow_files` SET `deleted_at` = UNIX_TIMESTAMP() WHERE `status` = 4;
+
+-- Per-category trash retention in days; 0 inherits from the parent, -1 keeps deleted files forever
+ALTER TABLE `ow_category`
+ADD COLUMN `trash_retention_days` int(11) NOT NULL DEFAULT '0' COMMENT 'Trash retention in days, 0 = inherit, -1 = forever' AFTER `workflow_id`;
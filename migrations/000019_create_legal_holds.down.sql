@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `ow_legal_hold_events`;
+DROP TABLE IF EXISTS `ow_legal_holds`;
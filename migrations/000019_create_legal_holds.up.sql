@@ -0,0 +1,35 @@
+-- Legal holds: files, or whole category subtrees, that must not be deleted or altered while
+-- litigation is pending. Holds are released, never deleted.
+CREATE TABLE IF NOT EXISTS `ow_legal_holds` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'Held file, 0 for a category hold',
+  `category_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Held category and its subcategories, 0 for a file hold',
+  `case_reference` varchar(128) NOT NULL COMMENT 'Case reference',
+  `reason` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Reason',
+  `created_by` varchar(64) NOT NULL COMMENT 'Applied by',
+  `created` int(11) NOT NULL COMMENT 'Applied at',
+  `released_by` varchar(64) NOT NULL DEFAULT '' COMMENT 'Released by',
+  `released_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Released at, 0 = active',
+  `release_reason` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Release reason',
+  PRIMARY KEY (`id`),
+  KEY `idx_file_id` (`file_id`),
+  KEY `idx_category_id` (`category_id`),
+  KEY `idx_case_reference` (`case_reference`),
+  KEY `idx_released_at` (`released_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Legal holds';
+
+-- Legal hold log: holds applied and released, and every operation a hold blocked
+CREATE TABLE IF NOT EXISTS `ow_legal_hold_events` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `hold_id` bigint(20) unsigned NOT NULL COMMENT 'Legal hold ID',
+  `action` varchar(16) NOT NULL COMMENT 'apply, release or blocked',
+  `file_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'File of a blocked operation',
+  `username` varchar(64) NOT NULL COMMENT 'Actor',
+  `detail` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Reason or blocked operation',
+  `created` int(11) NOT NULL COMMENT 'Event time',
+  PRIMARY KEY (`id`),
+  KEY `idx_hold_id` (`hold_id`),
+  KEY `idx_file_id` (`file_id`),
+  KEY `idx_username` (`username`),
+  KEY `idx_created` (`created`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Legal hold log';
@@ -0,0 +1,31 @@
+-- Bulk file operations (POST /api/v1/files/bulk), processed by the workers. The files are resolved
+-- on submission and get one result row each.
+CREATE TABLE IF NOT EXISTS `ow_bulk_jobs` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `action` varchar(16) NOT NULL COMMENT 'submit, publish, reject, move, access, catalog',
+  `params` text NOT NULL COMMENT 'Action parameters (JSON)',
+  `user_id` int(11) NOT NULL COMMENT 'Requester ID',
+  `username` varchar(64) NOT NULL COMMENT 'Requester',
+  `status` varchar(16) NOT NULL COMMENT 'queued, running, completed, failed',
+  `total` int(11) NOT NULL DEFAULT '0' COMMENT 'Files',
+  `succeeded` int(11) NOT NULL DEFAULT '0' COMMENT 'Files changed',
+  `failed` int(11) NOT NULL DEFAULT '0' COMMENT 'Files not changed',
+  `error` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Failure reason',
+  `created` int(11) NOT NULL COMMENT 'Submission time',
+  `started_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Start time',
+  `finished_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Completion time',
+  PRIMARY KEY (`id`),
+  KEY `idx_username` (`username`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Bulk file operations';
+
+CREATE TABLE IF NOT EXISTS `ow_bulk_job_items` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `job_id` bigint(20) unsigned NOT NULL COMMENT 'Bulk job ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `status` varchar(16) NOT NULL COMMENT 'pending, succeeded, failed',
+  `error` varchar(1024) NOT NULL DEFAULT '' COMMENT 'Failure reason',
+  `processed` int(11) NOT NULL DEFAULT '0' COMMENT 'Processing time',
+  PRIMARY KEY (`id`),
+  KEY `idx_job_status` (`job_id`, `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Bulk file operation results';
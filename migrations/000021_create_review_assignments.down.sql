@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `ow_notifications`;
+DROP TABLE IF EXISTS `ow_review_assignments`;
+
+ALTER TABLE `ow_category`
+DROP COLUMN `review_group_id`,
+DROP COLUMN `review_sla_hours`;
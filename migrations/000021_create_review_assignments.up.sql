@@ -0,0 +1,39 @@
+-- Per-category review SLA and review group; 0 inherits from the parent, -1 disables them
+ALTER TABLE `ow_category`
+ADD COLUMN `review_sla_hours` int(11) NOT NULL DEFAULT '0' COMMENT 'Review SLA in hours, 0 = inherit, -1 = none' AFTER `trash_retention_days`,
+ADD COLUMN `review_group_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Group whose members review in turn, 0 = inherit, -1 = none' AFTER `review_sla_hours`;
+
+-- The reviewer responsible for a pending file; one open assignment per file
+CREATE TABLE IF NOT EXISTS `ow_review_assignments` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `category_id` int(11) NOT NULL COMMENT 'Category of the file when assigned',
+  `reviewer_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Reviewer user ID, 0 = unassigned',
+  `reviewer` varchar(64) NOT NULL DEFAULT '' COMMENT 'Reviewer',
+  `group_id` int(11) NOT NULL DEFAULT '0' COMMENT 'Review group of a round-robin assignment',
+  `assigned_by` varchar(64) NOT NULL COMMENT 'Assigned by',
+  `assigned` int(11) NOT NULL COMMENT 'Assignment time',
+  `submitted` int(11) NOT NULL COMMENT 'Time the file entered pending review',
+  `due_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Due time, 0 = no SLA',
+  `escalated_at` int(11) NOT NULL DEFAULT '0' COMMENT 'SLA breach escalation time',
+  `status` varchar(16) NOT NULL COMMENT 'open, done or reassigned',
+  `closed` int(11) NOT NULL DEFAULT '0' COMMENT 'Completion time',
+  PRIMARY KEY (`id`),
+  KEY `idx_file_id` (`file_id`),
+  KEY `idx_category_id` (`category_id`),
+  KEY `idx_reviewer_id` (`reviewer_id`),
+  KEY `idx_group_id` (`group_id`),
+  KEY `idx_status_due_at` (`status`, `due_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Review assignments';
+
+CREATE TABLE IF NOT EXISTS `ow_notifications` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `user_id` int(11) NOT NULL COMMENT 'Recipient user ID',
+  `kind` varchar(32) NOT NULL COMMENT 'review_assigned or review_escalated',
+  `file_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'File ID',
+  `message` varchar(1024) NOT NULL COMMENT 'Message',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  `read_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Read time, 0 = unread',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`, `read_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User notifications';
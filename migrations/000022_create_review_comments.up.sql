@@ -0,0 +1,24 @@
+-- Review comments on files, optionally at a timecode range and a region of the frame. Replies
+-- belong to a top-level comment; unresolved top-level comments block publishing.
+CREATE TABLE IF NOT EXISTS `ow_review_comments` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `file_id` bigint(20) unsigned NOT NULL COMMENT 'File ID',
+  `parent_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'Top-level comment of a reply, 0 = top-level',
+  `user_id` int(11) NOT NULL COMMENT 'Author user ID',
+  `username` varchar(64) NOT NULL COMMENT 'Author',
+  `body` text NOT NULL COMMENT 'Comment',
+  `start_ms` int(11) DEFAULT NULL COMMENT 'Timecode in milliseconds',
+  `end_ms` int(11) DEFAULT NULL COMMENT 'End of the timecode range in milliseconds',
+  `region_x` double DEFAULT NULL COMMENT 'Region left edge, 0-1 of the frame width',
+  `region_y` double DEFAULT NULL COMMENT 'Region top edge, 0-1 of the frame height',
+  `region_w` double DEFAULT NULL COMMENT 'Region width, 0-1 of the frame width',
+  `region_h` double DEFAULT NULL COMMENT 'Region height, 0-1 of the frame height',
+  `resolved` tinyint(1) NOT NULL DEFAULT '0' COMMENT 'Thread resolved',
+  `resolved_by` varchar(64) NOT NULL DEFAULT '' COMMENT 'Resolved by',
+  `resolved_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Resolution time',
+  `created` int(11) NOT NULL COMMENT 'Creation time',
+  `updated` int(11) NOT NULL COMMENT 'Last edit time',
+  PRIMARY KEY (`id`),
+  KEY `idx_file_id` (`file_id`, `parent_id`, `resolved`),
+  KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Review comments and annotations';
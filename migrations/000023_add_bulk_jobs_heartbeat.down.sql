@@ -0,0 +1,3 @@
+ALTER TABLE `ow_bulk_jobs`
+DROP KEY `idx_status_heartbeat`,
+DROP COLUMN `heartbeat_at`;
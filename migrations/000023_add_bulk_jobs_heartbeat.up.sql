@@ -0,0 +1,5 @@
+-- Running bulk jobs refresh their heartbeat with every file result. The workers requeue running
+-- jobs whose heartbeat has gone stale, so a job survives the crash of the worker running it.
+ALTER TABLE `ow_bulk_jobs`
+ADD COLUMN `heartbeat_at` int(11) NOT NULL DEFAULT '0' COMMENT 'Last file result of a running job' AFTER `finished_at`,
+ADD KEY `idx_status_heartbeat` (`status`, `heartbeat_at`);